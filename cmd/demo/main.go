@@ -9,6 +9,7 @@ import (
 	"render-engine/math"
 	"render-engine/renderer"
 	"render-engine/scene"
+	"render-engine/scene/environment"
 )
 
 // collBox is an axis-aligned rectangle in XZ used for player collision.
@@ -51,16 +52,11 @@ func resolvePlayerCollision(pos math.Vec3, boxes []collBox) math.Vec3 {
 	return pos
 }
 
-// CameraController handles keyboard/mouse input with gravity and ground collision.
+// CameraController walks the camera with keyboard/mouse/gamepad input: a
+// planar scene.FlyController supplies smoothed look and accelerated movement,
+// and this adds gravity, jumping and ground/wall collision on top.
 type CameraController struct {
-	moveSpeed      float32
-	lookSpeed      float32
-	lastMouseX     float64
-	lastMouseY     float64
-	firstMouse     bool
-	rightMouseDown bool
-	yaw            float32
-	pitch          float32
+	fly *scene.FlyController
 
 	// Physics
 	velocityY      float32 // vertical velocity (m/s)
@@ -78,12 +74,10 @@ const (
 )
 
 func NewCameraController() *CameraController {
+	fly := scene.NewFlyController()
+	fly.Planar = true
 	return &CameraController{
-		moveSpeed:  6.0,
-		lookSpeed:  0.003,
-		firstMouse: true,
-		yaw:        -90.0,
-		pitch:      0.0,
+		fly:        fly,
 		eyeHeight:  1.7,
 		onGround:   true,
 	}
@@ -95,56 +89,36 @@ func (cc *CameraController) Update(window *core.Window, camera *scene.Camera, de
 		deltaTime = 0.05
 	}
 
-	// Mouse look (right mouse drag)
-	cc.rightMouseDown = window.IsMouseButtonPressed(1)
-	if cc.rightMouseDown {
-		mouseX, mouseY := window.GetCursorPos()
-		if cc.firstMouse {
-			cc.lastMouseX = mouseX
-			cc.lastMouseY = mouseY
-			cc.firstMouse = false
-		}
-		cc.yaw   += float32(mouseX-cc.lastMouseX) * cc.lookSpeed
-		cc.pitch += float32(cc.lastMouseY-mouseY) * cc.lookSpeed
-		if cc.pitch > 88.0  { cc.pitch = 88.0  }
-		if cc.pitch < -88.0 { cc.pitch = -88.0 }
-		cc.lastMouseX = mouseX
-		cc.lastMouseY = mouseY
-	} else {
-		cc.firstMouse = true
-	}
+	var in scene.FlyInput
 
-	// Compute view vectors
-	yawRad   := cc.yaw   * stdmath.Pi / 180.0
-	pitchRad := cc.pitch * stdmath.Pi / 180.0
-
-	forward := math.Vec3{
-		X: float32(stdmath.Cos(float64(yawRad)) * stdmath.Cos(float64(pitchRad))),
-		Y: float32(stdmath.Sin(float64(pitchRad))),
-		Z: float32(stdmath.Sin(float64(yawRad)) * stdmath.Cos(float64(pitchRad))),
-	}.Normalize()
-
-	// Horizontal move direction (ignore pitch so strafing stays level)
-	moveForward := math.Vec3{
-		X: float32(stdmath.Cos(float64(yawRad))),
-		Y: 0,
-		Z: float32(stdmath.Sin(float64(yawRad))),
-	}.Normalize()
-	right := math.Vec3{
-		X: float32(stdmath.Cos(float64(yawRad - stdmath.Pi/2))),
-		Y: 0,
-		Z: float32(stdmath.Sin(float64(yawRad - stdmath.Pi/2))),
-	}.Normalize()
+	// Mouse look: always while the cursor is captured, otherwise on right drag.
+	// The delta is read every frame so releasing the button never leaves a
+	// stale position behind to jump from.
+	dx, dy := window.GetCursorDelta()
+	if window.IsMouseButtonPressed(1) || window.CursorMode() == core.CursorDisabled {
+		in.LookX, in.LookY = float32(dx), float32(dy)
+	}
 
 	// Horizontal movement (WASD)
-	hMove := math.Vec3{}
-	if window.IsKeyPressed(core.KeyW) { hMove = hMove.Add(moveForward.Mul(cc.moveSpeed * deltaTime)) }
-	if window.IsKeyPressed(core.KeyS) { hMove = hMove.Add(moveForward.Mul(-cc.moveSpeed * deltaTime)) }
-	if window.IsKeyPressed(core.KeyD) { hMove = hMove.Add(right.Mul(cc.moveSpeed * deltaTime)) }
-	if window.IsKeyPressed(core.KeyA) { hMove = hMove.Add(right.Mul(-cc.moveSpeed * deltaTime)) }
+	if window.IsKeyPressed(core.KeyW) { in.Move.Z += 1 }
+	if window.IsKeyPressed(core.KeyS) { in.Move.Z -= 1 }
+	if window.IsKeyPressed(core.KeyD) { in.Move.X += 1 }
+	if window.IsKeyPressed(core.KeyA) { in.Move.X -= 1 }
+
+	// Gamepad 0: left stick moves, right stick looks, A jumps
+	pad, padOK := window.GetGamepadState(0)
+	if padOK {
+		// Stick +Y is down/back, so forward is -LeftY
+		in.Move.Z -= pad.LeftY
+		in.Move.X += pad.LeftX
+		in.TurnX, in.TurnY = pad.RightX, pad.RightY
+	}
+
+	// Look and horizontal movement
+	cc.fly.Update(camera, in, deltaTime)
 
 	// Jump (Space — debounced so it fires once per press)
-	spaceDown := window.IsKeyPressed(core.KeySpace)
+	spaceDown := window.IsKeyPressed(core.KeySpace) || pad.Pressed(core.GamepadA)
 	if spaceDown && !cc.jumpKeyWasDown && cc.onGround {
 		cc.velocityY = jumpSpeed
 		cc.onGround  = false
@@ -157,7 +131,7 @@ func (cc *CameraController) Update(window *core.Window, camera *scene.Camera, de
 	}
 
 	// Vertical position
-	newPos := camera.Position.Add(hMove)
+	newPos := camera.Position
 	newPos.Y += cc.velocityY * deltaTime
 
 	// Ground collision (eye is at eyeHeight above Y=0 floor)
@@ -172,9 +146,7 @@ func (cc *CameraController) Update(window *core.Window, camera *scene.Camera, de
 	newPos = resolvePlayerCollision(newPos, cc.CollBoxes)
 
 	camera.SetPosition(newPos)
-	up := forward.Cross(right).Normalize()
-	if up.Y < 0 { up.Y = -up.Y } // keep up-vector pointing up
-	camera.LookAt(newPos.Add(forward), up)
+	camera.LookAt(newPos.Add(cc.fly.Forward()), math.Vec3Up)
 }
 
 func main() {
@@ -217,6 +189,12 @@ func main() {
 		} else {
 			fmt.Println("Bloom enabled (bright-pass + 4x Gaussian blur)")
 		}
+		// Multisample the HDR scene target; resolved before post-processing
+		if n, err := renderEngine.SetMSAA(4); err != nil {
+			fmt.Printf("MSAA init failed (continuing without it): %v\n", err)
+		} else if n > 1 {
+			fmt.Printf("MSAA enabled (%dx)\n", n)
+		}
 	}
 
 	// Enable SSAO (screen-space ambient occlusion)
@@ -226,6 +204,9 @@ func main() {
 		fmt.Println("SSAO enabled (64-sample hemisphere, 5x5 blur)")
 	}
 
+	// Sharper textures on the ground at grazing angles; set before anything uploads
+	renderEngine.SetTextureAnisotropy(8)
+
 	// Enable procedural gradient skybox
 	if err := renderEngine.EnableSkybox(); err != nil {
 		fmt.Printf("Skybox init failed (continuing without it): %v\n", err)
@@ -233,6 +214,11 @@ func main() {
 		fmt.Println("Skybox enabled (procedural gradient: zenith/horizon/ground)")
 	}
 
+	// Infinite ground grid: 2-unit cells, major lines every 20 units
+	if err := renderEngine.EnableGroundGrid(2, core.Color{R: 0.35, G: 0.35, B: 0.35, A: 1}); err != nil {
+		fmt.Printf("Ground grid init failed (continuing without it): %v\n", err)
+	}
+
 	// IBL — must be called after EnableSkybox; SetSkyboxColors will sync colours
 	renderEngine.EnableIBL()
 	fmt.Println("IBL enabled (sky-gradient irradiance for PBR + Phong ambient)")
@@ -278,6 +264,7 @@ func main() {
 
 	matLamp := scene.NewPBRMaterial("LampGlow", core.Color{R: 1.0, G: 0.85, B: 0.45, A: 1}, 0.0, 0.5)
 	matLamp.EmissiveColor = core.Color{R: 3.0, G: 2.0, B: 0.6, A: 1} // bright emissive → bloom
+	matLamp.BloomScale    = 0.5                                      // glow even with a high bloom threshold
 
 	// PBR materials toggled by P key
 	pbrMaterials := []*scene.Material{matMarble, matWater, matMetal, matLamp}
@@ -300,11 +287,6 @@ func main() {
 	groundNode.Mesh = groundMesh
 	s.AddNode(groundNode)
 
-	gridMesh := scene.CreateGrid(80, 40)
-	gridNode := scene.NewNode("Grid")
-	gridNode.Mesh = gridMesh
-	s.AddNode(gridNode)
-
 	// ── Buildings ─────────────────────────────────────────────────────────────
 	// NW — tall stone tower
 	addBox("Bldg_NW", math.Vec3{X: -15, Y: 4.5, Z: -15}, 9, 9, 9, matStone)
@@ -400,6 +382,7 @@ func main() {
 		{X: -5.5, Y: 0, Z: 5.5},
 		{X: 5.5, Y: 0, Z: 5.5},
 	}
+	var lampLights []*scene.Light // switched by the day/night cycle
 	for i, lp := range lampPos {
 		pole := scene.CreateCylinder(0.09, 4.8, 8)
 		pole.Material = matMetal
@@ -415,13 +398,15 @@ func main() {
 		cn.SetPosition(math.Vec3{X: lp.X, Y: 4.9, Z: lp.Z})
 		s.AddNode(cn)
 
-		s.AddLight(&scene.Light{
+		lampLight := &scene.Light{
 			Type:      scene.LightTypePoint,
 			Position:  math.Vec3{X: lp.X, Y: 4.7, Z: lp.Z},
 			Color:     core.Color{R: 1.0, G: 0.78, B: 0.35, A: 1},
 			Intensity: 3.0,
 			Range:     14.0,
-		})
+		}
+		s.AddLight(lampLight)
+		lampLights = append(lampLights, lampLight)
 	}
 
 	// ── Lights ────────────────────────────────────────────────────────────────
@@ -437,14 +422,13 @@ func main() {
 	// ── Particle emitters ──────────────────────────────────────────────────
 	// Torch fires at the fountain base (4 corners)
 	fireEmitter := scene.NewParticleEmitter(300)
-	fireEmitter.Position = math.Vec3{X: 3.5, Y: 0.1, Z: 3.5}
+	fireEmitter.SoftFadeDistance = 0.3
 
 	smokeEmitter := scene.NewSmokeEmitter(80)
-	smokeEmitter.Position = math.Vec3{X: 3.5, Y: 0.7, Z: 3.5}
+	smokeEmitter.SoftFadeDistance = 0.5
 
 	// Water spray at top of fountain pillar
 	magicEmitter := scene.NewParticleEmitter(200)
-	magicEmitter.Position   = math.Vec3{X: 0, Y: 3.4, Z: 0}
 	magicEmitter.StartColor = core.Color{R: 0.55, G: 0.80, B: 1.0, A: 0.9}
 	magicEmitter.EndColor   = core.Color{R: 0.30, G: 0.60, B: 0.90, A: 0.0}
 	magicEmitter.Gravity    = math.Vec3{Y: -4.5}
@@ -456,6 +440,22 @@ func main() {
 	magicEmitter.MinSize = 0.08
 	magicEmitter.MaxSize = 0.20
 
+	// Attached to nodes, the emitters follow them, are simulated by s.Update
+	// and drawn by Present. The smoke rises from a child 0.6 above the flame.
+	torchNode := scene.NewNode("Torch")
+	torchNode.SetPosition(math.Vec3{X: 3.5, Y: 0.1, Z: 3.5})
+	torchNode.AddEmitter(fireEmitter)
+	smokeNode := scene.NewNode("TorchSmoke")
+	smokeNode.SetPosition(math.Vec3{X: 0, Y: 0.6, Z: 0})
+	smokeNode.AddEmitter(smokeEmitter)
+	torchNode.AddChild(smokeNode)
+	s.AddNode(torchNode)
+
+	sprayNode := scene.NewNode("FountainSpray")
+	sprayNode.SetPosition(math.Vec3{X: 0, Y: 3.4, Z: 0})
+	sprayNode.AddEmitter(magicEmitter)
+	s.AddNode(sprayNode)
+
 	// Instanced cube mesh (shared geometry, 400 instances)
 	instancedCubeMesh := scene.CreateCube(0.5)
 	instancedCubeMat := scene.NewMaterial("InstanceCube", core.Color{R: 0.3, G: 0.8, B: 0.4, A: 1})
@@ -488,8 +488,13 @@ func main() {
 	renderEngine.SetScene(s)
 
 	// Day/night cycle — starts at noon (t=0), 120s per full day
-	dayNight := NewDayNight()
+	// Street lamps glow in at dusk and fade out after sunrise
+	dayNight := environment.NewDayNightCycle()
+	for _, l := range lampLights {
+		dayNight.AddLamp(l)
+	}
 	dayNight.Apply(renderEngine, s, sunLight) // apply initial sky before first frame
+	s.AddAnimator(dayNight)
 
 	// Initialize camera controller and HUD
 	camController := NewCameraController()
@@ -512,16 +517,23 @@ func main() {
 	fmt.Println("  A / D           - Strafe left / right")
 	fmt.Println("  Space           - Jump")
 	fmt.Println("  Right Mouse Drag - Look around")
+	fmt.Println("  C               - Capture mouse for FPS look (Esc releases)")
+	fmt.Println("  Scroll Wheel    - Zoom (field of view)")
+	fmt.Println("  Gamepad         - Left stick move, right stick look, A jump")
 	fmt.Println("")
 	fmt.Println("VIEW TOGGLES:")
 	fmt.Println("  Z              - Toggle wireframe mode")
+	fmt.Println("  V              - Toggle wireframe overlay on shaded geometry")
 	fmt.Println("  X              - Toggle AABB debug boxes (green wireframe)")
+	fmt.Println("  M              - Toggle vertex normal lines (yellow)")
+	fmt.Println("  L              - Toggle light gizmos (range spheres, spot cones, sun arrow)")
 	fmt.Println("  I              - Toggle instanced cube grid (400 cubes, 1 draw call)")
 	fmt.Println("  O              - Toggle SSAO (screen-space ambient occlusion)")
 	fmt.Println("  P              - Toggle PBR (Cook-Torrance GGX) vs Phong on bottom row")
 	fmt.Println("  E              - Toggle particle emitters (fire / smoke / magic)")
 	fmt.Println("  N              - Pause / resume day/night cycle")
 	fmt.Println("  , / .          - Slow down / speed up day/night cycle")
+	fmt.Println("  K              - Toggle sky: gradient / atmospheric scattering")
 
 	fmt.Println("  [ / ]          - Decrease / increase HDR exposure")
 	fmt.Println("  B              - Toggle bloom on/off")
@@ -533,27 +545,21 @@ func main() {
 	fmt.Println("")
 	fmt.Println("SCENE:")
 	fmt.Println("  F5             - Save scene to scene.json")
+	fmt.Println("  F6             - Reload shaders (from $RENDER_ENGINE_SHADER_DIR if set)")
+	fmt.Println("  F7             - Cycle material debug view (normals / UV / roughness / metallic / AO)")
 	fmt.Println("  F9             - Load scene from scene.json")
+	fmt.Println("  F11            - Toggle fullscreen")
+	fmt.Println("  F12            - Save screenshot (PNG)")
+	fmt.Println("  Shift+F12      - Save HDR scene colour (Radiance .hdr)")
 	fmt.Println("")
-	fmt.Println("EXIT: ESC")
+	fmt.Println("EXIT: ESC (press twice while the mouse is captured)")
 	fmt.Println("===========================================")
 	fmt.Println("")
 
-	// Enable frustum culling now that AABBs are visualizable for verification
-	renderEngine.FrustumCulling = true
-
-	// Debounce state for toggle keys
-	wireframeKeyWasDown  := false
-	saveKeyWasDown       := false
-	loadKeyWasDown       := false
-	bloomKeyWasDown      := false
-	aabbKeyWasDown       := false
-	instancedKeyWasDown  := false
-	ssaoKeyWasDown       := false
-	pbrKeyWasDown        := false
-	emitterKeyWasDown   := false
-	dnKeyWasDown        := false
-	const scenePath      = "scene.json"
+	const scenePath = "scene.json"
+
+	// Scroll-wheel zoom limits (radians)
+	const minFOV, maxFOV = float32(stdmath.Pi / 12), float32(stdmath.Pi / 2)
 
 	// PBR toggle — starts enabled (bottom 3 shapes already have UsePBR=true)
 	pbrOn := true
@@ -561,6 +567,9 @@ func main() {
 	// Particle emitter toggle
 	emittersOn := true
 
+	// Sky mode toggle — starts on the gradient driven by the day/night palette
+	atmosphereSky := false
+
 	// Instanced rendering toggle
 	instancedOn  := false
 	instanceTime := float32(0)
@@ -577,30 +586,143 @@ func main() {
 	bloomStrength := float32(0.6)
 	bloomOn       := true
 
-	for !window.ShouldClose() {
-		window.PollEvents()
+	// Screenshot requested by F12 (Shift+F12: HDR); taken after Present so the frame is complete
+	shotRequested := false
+	hdrShot       := false
 
-		if window.IsKeyPressed(core.KeyEscape) {
-			break
+	// Escape releases a captured cursor first and only quits once released
+	quitRequested := false
+
+	// One-shot toggles run from the key callback (once per press, no debouncing);
+	// held keys such as movement and exposure are still polled in the loop.
+	window.SetKeyCallback(func(key core.Key, action core.Action) {
+		if action != core.ActionPress {
+			return
 		}
+		switch key {
+		case core.KeyEscape:
+			if window.CursorMode() == core.CursorDisabled {
+				window.SetCursorMode(core.CursorNormal)
+			} else {
+				quitRequested = true
+			}
 
-		// Toggle wireframe on Z key press (debounced)
-		zDown := window.IsKeyPressed(core.KeyZ)
-		if zDown && !wireframeKeyWasDown {
+		case core.KeyC: // capture the cursor for FPS-style look
+			window.SetCursorMode(core.CursorDisabled)
+
+		case core.KeyZ:
 			renderEngine.SetWireframe(!renderEngine.IsWireframe())
-		}
-		wireframeKeyWasDown = zDown
 
-		// Save scene: F5
-		f5Down := window.IsKeyPressed(core.KeyF5)
-		if f5Down && !saveKeyWasDown {
+		case core.KeyV:
+			renderEngine.SetWireframeOverlay(!renderEngine.IsWireframeOverlay())
+
+		case core.KeyX:
+			renderEngine.DrawAABBs = !renderEngine.DrawAABBs
+			fmt.Printf("[AABB] %s\n", map[bool]string{true: "ON", false: "OFF"}[renderEngine.DrawAABBs])
+
+		case core.KeyM:
+			renderEngine.DrawNormals = !renderEngine.DrawNormals
+			fmt.Printf("[Normals] %s\n", map[bool]string{true: "ON", false: "OFF"}[renderEngine.DrawNormals])
+
+		case core.KeyL:
+			renderEngine.DrawLightGizmos = !renderEngine.DrawLightGizmos
+			fmt.Printf("[Light gizmos] %s\n", map[bool]string{true: "ON", false: "OFF"}[renderEngine.DrawLightGizmos])
+
+		case core.KeyB:
+			bloomOn = !bloomOn
+			if bloomOn {
+				renderEngine.SetBloomStrength(bloomStrength)
+			} else {
+				renderEngine.SetBloomStrength(0)
+			}
+			fmt.Printf("[Bloom] %s\n", map[bool]string{true: "ON", false: "OFF"}[bloomOn])
+
+		case core.KeyI: // instanced cube grid (20×20 = 400 cubes, 1 draw call)
+			instancedOn = !instancedOn
+			fmt.Printf("[Instanced] %s (%d cubes, 1 draw call)\n", map[bool]string{true: "ON", false: "OFF"}[instancedOn], instCols*instRows)
+
+		case core.KeyO:
+			ssaoOn = !ssaoOn
+			if ssaoOn {
+				renderEngine.SetSSAOStrength(ssaoStrength)
+			} else {
+				renderEngine.SetSSAOStrength(0)
+			}
+			fmt.Printf("[SSAO] %s\n", map[bool]string{true: "ON", false: "OFF"}[ssaoOn])
+
+		case core.KeyP: // PBR on the bottom row of shapes
+			pbrOn = !pbrOn
+			for _, m := range pbrMaterials {
+				m.UsePBR = pbrOn
+			}
+			fmt.Printf("[PBR] %s\n", map[bool]string{true: "ON", false: "OFF (Phong fallback)"}[pbrOn])
+
+		case core.KeyE:
+			emittersOn = !emittersOn
+			fireEmitter.Active  = emittersOn
+			smokeEmitter.Active = emittersOn
+			magicEmitter.Active = emittersOn
+			fmt.Printf("[Particles] %s\n", map[bool]string{true: "ON", false: "OFF"}[emittersOn])
+
+		case core.KeyN:
+			dayNight.Active = !dayNight.Active
+			fmt.Printf("[DayNight] %s\n", map[bool]string{true: "RUNNING", false: "PAUSED"}[dayNight.Active])
+
+		case core.KeyK:
+			atmosphereSky = !atmosphereSky
+			if atmosphereSky {
+				renderEngine.SetSkyMode(renderer.SkyAtmosphere)
+			} else {
+				renderEngine.SetSkyMode(renderer.SkyGradient)
+			}
+			fmt.Printf("[Sky] %s\n", map[bool]string{true: "ATMOSPHERE", false: "GRADIENT"}[atmosphereSky])
+
+		case core.KeyF5:
 			if err := scene.SaveScene(s, scenePath); err != nil {
 				fmt.Printf("[Save] Error: %v\n", err)
 			} else {
 				fmt.Printf("[Save] Scene saved to %q\n", scenePath)
 			}
+
+		case core.KeyF6:
+			if err := renderEngine.ReloadShaders(); err != nil {
+				fmt.Printf("[Shaders] %v\n", err)
+			} else {
+				fmt.Println("[Shaders] Reloaded")
+			}
+
+		case core.KeyF7:
+			view := (renderEngine.DebugView() + 1) % (renderer.DebugAO + 1)
+			renderEngine.SetDebugView(view)
+			fmt.Printf("[DebugView] %s\n", []string{"OFF", "NORMALS", "UV", "ROUGHNESS", "METALLIC", "AO"}[view])
+
+		case core.KeyF9: // restores node transforms but not meshes
+			sd, err := scene.LoadScene(scenePath)
+			if err != nil {
+				fmt.Printf("[Load] Error: %v\n", err)
+			} else {
+				sd.ApplyToScene(s)
+				fmt.Printf("[Load] Scene loaded from %q (%d nodes)\n", scenePath, len(sd.Nodes))
+			}
+
+		case core.KeyF11:
+			renderEngine.SetFullscreen(!window.IsFullscreen())
+
+		case core.KeyF12:
+			shotRequested = true
+			hdrShot       = window.IsKeyPressed(core.KeyLeftShift)
+			if !hdrShot {
+				renderEngine.RequestCapture()
+			}
+		}
+	})
+
+	for !window.ShouldClose() {
+		window.PollEvents()
+
+		if quitRequested {
+			break
 		}
-		saveKeyWasDown = f5Down
 
 		// Exposure: [ to decrease, ] to increase
 		if window.IsKeyPressed(core.KeyLeftBracket) {
@@ -618,27 +740,6 @@ func main() {
 			renderEngine.SetExposure(exposure)
 		}
 
-		// X key — toggle AABB wireframe debug draw
-		xDown := window.IsKeyPressed(core.KeyX)
-		if xDown && !aabbKeyWasDown {
-			renderEngine.DrawAABBs = !renderEngine.DrawAABBs
-			fmt.Printf("[AABB] %s\n", map[bool]string{true: "ON", false: "OFF"}[renderEngine.DrawAABBs])
-		}
-		aabbKeyWasDown = xDown
-
-		// B key — toggle bloom on/off
-		bDown := window.IsKeyPressed(core.KeyB)
-		if bDown && !bloomKeyWasDown {
-			bloomOn = !bloomOn
-			if bloomOn {
-				renderEngine.SetBloomStrength(bloomStrength)
-			} else {
-				renderEngine.SetBloomStrength(0)
-			}
-			fmt.Printf("[Bloom] %s\n", map[bool]string{true: "ON", false: "OFF"}[bloomOn])
-		}
-		bloomKeyWasDown = bDown
-
 		// Bloom strength: - (decrease) / = (increase)
 		if bloomOn {
 			if window.IsKeyPressed(core.KeyMinus) {
@@ -657,72 +758,6 @@ func main() {
 			}
 		}
 
-		// Load scene: F9 (restores node transforms but not meshes)
-		f9Down := window.IsKeyPressed(core.KeyF9)
-		if f9Down && !loadKeyWasDown {
-			sd, err := scene.LoadScene(scenePath)
-			if err != nil {
-				fmt.Printf("[Load] Error: %v\n", err)
-			} else {
-				sd.ApplyToScene(s)
-				fmt.Printf("[Load] Scene loaded from %q (%d nodes)\n", scenePath, len(sd.Nodes))
-			}
-		}
-		loadKeyWasDown = f9Down
-
-		// I key — toggle instanced cube grid (20×20 = 400 cubes, 1 draw call)
-		iDown := window.IsKeyPressed(core.KeyI)
-		if iDown && !instancedKeyWasDown {
-			instancedOn = !instancedOn
-			fmt.Printf("[Instanced] %s (%d cubes, 1 draw call)\n",
-				map[bool]string{true: "ON", false: "OFF"}[instancedOn],
-				instCols*instRows)
-		}
-		instancedKeyWasDown = iDown
-
-		// O key — toggle SSAO on/off
-		oDown := window.IsKeyPressed(core.KeyO)
-		if oDown && !ssaoKeyWasDown {
-			ssaoOn = !ssaoOn
-			if ssaoOn {
-				renderEngine.SetSSAOStrength(ssaoStrength)
-			} else {
-				renderEngine.SetSSAOStrength(0)
-			}
-			fmt.Printf("[SSAO] %s\n", map[bool]string{true: "ON", false: "OFF"}[ssaoOn])
-		}
-		ssaoKeyWasDown = oDown
-
-		// P key — toggle PBR on the bottom row of shapes
-		pDown := window.IsKeyPressed(core.KeyP)
-		if pDown && !pbrKeyWasDown {
-			pbrOn = !pbrOn
-			for _, m := range pbrMaterials {
-				m.UsePBR = pbrOn
-			}
-			fmt.Printf("[PBR] %s\n", map[bool]string{true: "ON", false: "OFF (Phong fallback)"}[pbrOn])
-		}
-		pbrKeyWasDown = pDown
-
-		// E key — toggle particle emitters
-		eDown := window.IsKeyPressed(core.KeyE)
-		if eDown && !emitterKeyWasDown {
-			emittersOn = !emittersOn
-			fireEmitter.Active  = emittersOn
-			smokeEmitter.Active = emittersOn
-			magicEmitter.Active = emittersOn
-			fmt.Printf("[Particles] %s\n", map[bool]string{true: "ON", false: "OFF"}[emittersOn])
-		}
-		emitterKeyWasDown = eDown
-
-		// N key — pause/resume day/night cycle
-		nDown := window.IsKeyPressed(core.KeyN)
-		if nDown && !dnKeyWasDown {
-			dayNight.Active = !dayNight.Active
-			fmt.Printf("[DayNight] %s\n", map[bool]string{true: "RUNNING", false: "PAUSED"}[dayNight.Active])
-		}
-		dnKeyWasDown = nDown
-
 		// Comma/Period — slow down / speed up the cycle (larger Speed = slower)
 		if window.IsKeyPressed(core.KeyComma) {
 			dayNight.Speed += 20.0 * deltaTime
@@ -733,8 +768,17 @@ func main() {
 			if dayNight.Speed < 10 { dayNight.Speed = 10 }
 		}
 
-		// Advance cycle and push sky/light state to the renderer
-		dayNight.Update(deltaTime)
+		// Scroll wheel — zoom by narrowing / widening the field of view
+		if _, scrollY := window.GetScrollOffset(); scrollY != 0 {
+			fov := camera.FOV - float32(scrollY)*0.05
+			if fov < minFOV { fov = minFOV }
+			if fov > maxFOV { fov = maxFOV }
+			camera.SetFOV(fov)
+		}
+
+		// Advance the day/night cycle and particle emitters, then push the
+		// sky/light state to the renderer
+		s.Update(deltaTime)
 		dayNight.Apply(renderEngine, s, sunLight)
 
 		// Update camera with controller
@@ -743,11 +787,6 @@ func main() {
 		// Advance instance animation timer
 		instanceTime += deltaTime
 
-		// Simulate particles every frame
-		fireEmitter.Update(deltaTime)
-		smokeEmitter.Update(deltaTime)
-		magicEmitter.Update(deltaTime)
-
 		if err := renderEngine.Render(); err != nil {
 			width, height := window.GetFramebufferSize()
 			if width > 0 && height > 0 {
@@ -772,16 +811,13 @@ func main() {
 			renderEngine.DrawMeshInstanced(instancedCubeMesh, instanceModels)
 		}
 
-		// Particle systems — rendered into HDR FBO (benefits from bloom + tone map)
-		renderEngine.DrawParticles(fireEmitter)
-		renderEngine.DrawParticles(smokeEmitter)
-		renderEngine.DrawParticles(magicEmitter)
-
 		// ── Build on-screen HUD (queued, flushed in Present after HDR blit) ──
 		objects, verts, tris, culled := renderEngine.DrawStats()
 		wireStr := ""
 		if renderEngine.IsWireframe() {
 			wireStr = " [WIRE]"
+		} else if renderEngine.IsWireframeOverlay() {
+			wireStr = " [WIRE+SHADED]"
 		}
 		cullingStr := map[bool]string{true: "on", false: "off"}[renderEngine.FrustumCulling]
 
@@ -789,9 +825,12 @@ func main() {
 		groundStr := map[bool]string{true: "grnd", false: "air"}[camController.onGround]
 		debugOverlay.AddLine("FPS: %d   Pos: %.1f  %.1f  %.1f   Yaw: %.0f  Pitch: %.0f  %s%s",
 			displayFPS, camera.Position.X, camera.Position.Y, camera.Position.Z,
-			camController.yaw, camController.pitch, groundStr, wireStr)
+			camController.fly.Yaw, camController.fly.Pitch, groundStr, wireStr)
 		debugOverlay.AddLine("Draw: obj=%d  verts=%d  tris=%d  culled=%d  (culling %s)",
 			objects, verts, tris, culled, cullingStr)
+		gpu := renderEngine.PassTimings()
+		debugOverlay.AddLine("GPU ms: shadow=%.2f  scene=%.2f  particles=%.2f  ssao=%.2f  bloom=%.2f  tonemap=%.2f",
+			gpu["shadow"], gpu["scene"], gpu["particles"], gpu["ssao"], gpu["bloom"], gpu["tonemap"])
 		bloomStatus := map[bool]string{true: fmt.Sprintf("ON  str=%.2f  (- / =)", bloomStrength), false: "OFF"}[bloomOn]
 		debugOverlay.AddLine("Exposure: %.2f ([ ])   Bloom: %s (B)   SSAO: %s (O)",
 			exposure, bloomStatus, map[bool]string{true: fmt.Sprintf("ON  str=%.2f", ssaoStrength), false: "OFF"}[ssaoOn])
@@ -807,14 +846,29 @@ func main() {
 		}
 		dnStatus := map[bool]string{true: "running", false: "PAUSED"}[dayNight.Active]
 		debugOverlay.AddLine("Day/Night: %s  Speed: %.0fs/cycle  (N=pause  ,/.=speed)",
-			dayNight.TimeOfDayStr()+" "+dnStatus, dayNight.Speed)
-		debugOverlay.AddLine("Z=wire  X=AABB  B=bloom  O=ssao  P=pbr  I=inst  E=particles  F5/F9=save/load  N=day/night")
+			dayNight.Clock()+" "+dnStatus, dayNight.Speed)
+		debugOverlay.AddLine("Z=wire  V=overlay  X=AABB  M=normals  L=lights  B=bloom  O=ssao  P=pbr  I=inst  E=particles  F5/F9=save/load  N=day/night")
 
 		renderEngine.DrawText(debugOverlay.GetText(), 10, 10, 2, core.ColorWhite)
 
 		// Resolve HDR FBO → screen, flush text overlay, swap buffers
 		renderEngine.Present()
 
+		// F12 — screenshot of the frame just presented
+		if shotRequested {
+			shotRequested = false
+			ext, save := "png", renderEngine.Screenshot
+			if hdrShot {
+				ext, save = "hdr", renderEngine.CaptureHDR
+			}
+			shotPath := fmt.Sprintf("screenshot_%s.%s", time.Now().Format("20060102_150405"), ext)
+			if err := save(shotPath); err != nil {
+				fmt.Printf("[Screenshot] Error: %v\n", err)
+			} else {
+				fmt.Printf("[Screenshot] Saved %q\n", shotPath)
+			}
+		}
+
 		frameCount++
 		fpsCounter++
 		now := time.Now()
@@ -0,0 +1,158 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ColorFromHex parses "#RGB", "#RGBA", "#RRGGBB" or "#RRGGBBAA" (the leading
+// '#' is optional). Channels are taken as-is — hex colours are normally
+// authored in sRGB, so call ToLinear before handing them to a material.
+func ColorFromHex(hex string) (Color, error) {
+	h := strings.TrimPrefix(strings.TrimSpace(hex), "#")
+	switch len(h) {
+	case 3, 4: // short form: each digit is doubled
+		var b strings.Builder
+		for _, c := range h {
+			b.WriteRune(c)
+			b.WriteRune(c)
+		}
+		h = b.String()
+	case 6, 8:
+	default:
+		return Color{}, fmt.Errorf("color %q: expected 3, 4, 6 or 8 hex digits", hex)
+	}
+	if len(h) == 6 {
+		h += "ff"
+	}
+	v, err := strconv.ParseUint(h, 16, 32)
+	if err != nil {
+		return Color{}, fmt.Errorf("color %q: %w", hex, err)
+	}
+	return Color{
+		R: float32(v>>24&0xff) / 255,
+		G: float32(v>>16&0xff) / 255,
+		B: float32(v>>8&0xff) / 255,
+		A: float32(v&0xff) / 255,
+	}, nil
+}
+
+// Hex formats c as "#RRGGBB", or "#RRGGBBAA" when it is not fully opaque.
+// Channels are clamped to [0, 1].
+func (c Color) Hex() string {
+	byteOf := func(v float32) uint8 {
+		return uint8(math.Round(float64(clamp01(v)) * 255))
+	}
+	if byteOf(c.A) == 255 {
+		return fmt.Sprintf("#%02X%02X%02X", byteOf(c.R), byteOf(c.G), byteOf(c.B))
+	}
+	return fmt.Sprintf("#%02X%02X%02X%02X", byteOf(c.R), byteOf(c.G), byteOf(c.B), byteOf(c.A))
+}
+
+// ColorFromHSV returns an opaque colour from hue h in degrees (any value;
+// wrapped to [0, 360)), saturation s and value v in [0, 1].
+func ColorFromHSV(h, s, v float32) Color {
+	h = float32(math.Mod(float64(h), 360))
+	if h < 0 {
+		h += 360
+	}
+	s, v = clamp01(s), clamp01(v)
+
+	chroma := v * s
+	sector := h / 60
+	x := chroma * (1 - float32(math.Abs(math.Mod(float64(sector), 2)-1)))
+	m := v - chroma
+
+	var r, g, b float32
+	switch int(sector) {
+	case 0:
+		r, g, b = chroma, x, 0
+	case 1:
+		r, g, b = x, chroma, 0
+	case 2:
+		r, g, b = 0, chroma, x
+	case 3:
+		r, g, b = 0, x, chroma
+	case 4:
+		r, g, b = x, 0, chroma
+	default:
+		r, g, b = chroma, 0, x
+	}
+	return Color{R: r + m, G: g + m, B: b + m, A: 1}
+}
+
+// ToHSV returns hue in degrees [0, 360), saturation and value. Hue is 0 for
+// greys, where it is undefined.
+func (c Color) ToHSV() (h, s, v float32) {
+	max := float32(math.Max(float64(c.R), math.Max(float64(c.G), float64(c.B))))
+	min := float32(math.Min(float64(c.R), math.Min(float64(c.G), float64(c.B))))
+	delta := max - min
+
+	v = max
+	if max > 0 {
+		s = delta / max
+	}
+	if delta == 0 {
+		return 0, s, v
+	}
+	switch max {
+	case c.R:
+		h = 60 * float32(math.Mod(float64((c.G-c.B)/delta), 6))
+	case c.G:
+		h = 60 * ((c.B-c.R)/delta + 2)
+	default:
+		h = 60 * ((c.R-c.G)/delta + 4)
+	}
+	if h < 0 {
+		h += 360
+	}
+	return h, s, v
+}
+
+// ToLinear converts sRGB-encoded channels to linear light using the exact
+// piecewise sRGB transfer function. Alpha is unchanged. Shaders light in
+// linear space, so authored (sRGB) colours should be converted with this.
+func (c Color) ToLinear() Color {
+	return Color{R: srgbToLinear(c.R), G: srgbToLinear(c.G), B: srgbToLinear(c.B), A: c.A}
+}
+
+// ToSRGB is the inverse of ToLinear.
+func (c Color) ToSRGB() Color {
+	return Color{R: linearToSRGB(c.R), G: linearToSRGB(c.G), B: linearToSRGB(c.B), A: c.A}
+}
+
+// Lerp linearly interpolates every channel (including alpha) from c to o.
+func (c Color) Lerp(o Color, t float32) Color {
+	return Color{
+		R: c.R + (o.R-c.R)*t,
+		G: c.G + (o.G-c.G)*t,
+		B: c.B + (o.B-c.B)*t,
+		A: c.A + (o.A-c.A)*t,
+	}
+}
+
+func srgbToLinear(v float32) float32 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return float32(math.Pow((float64(v)+0.055)/1.055, 2.4))
+}
+
+func linearToSRGB(v float32) float32 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return float32(1.055*math.Pow(float64(v), 1/2.4) - 0.055)
+}
+
+func clamp01(v float32) float32 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
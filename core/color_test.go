@@ -0,0 +1,58 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func approxColor(a, b float32) bool { return math.Abs(float64(a-b)) < 1e-3 }
+
+func TestColorHexRoundTrip(t *testing.T) {
+	for _, hex := range []string{"#FF8800", "#000000", "#12345678", "#FFFFFF"} {
+		c, err := ColorFromHex(hex)
+		if err != nil {
+			t.Fatalf("ColorFromHex(%q): %v", hex, err)
+		}
+		if got := c.Hex(); got != hex {
+			t.Errorf("round trip: %q -> %+v -> %q", hex, c, got)
+		}
+	}
+
+	c, err := ColorFromHex("f80")
+	if err != nil || c.Hex() != "#FF8800" {
+		t.Errorf("short form f80: expected #FF8800, got %q (err %v)", c.Hex(), err)
+	}
+
+	for _, bad := range []string{"", "#12", "#GGGGGG", "#1234567"} {
+		if _, err := ColorFromHex(bad); err == nil {
+			t.Errorf("ColorFromHex(%q): expected error", bad)
+		}
+	}
+}
+
+func TestColorHSVRoundTrip(t *testing.T) {
+	orange, _ := ColorFromHex("#FF8800")
+	h, s, v := orange.ToHSV()
+	back := ColorFromHSV(h, s, v)
+	if !approxColor(back.R, orange.R) || !approxColor(back.G, orange.G) || !approxColor(back.B, orange.B) {
+		t.Errorf("HSV round trip: %+v -> (%v, %v, %v) -> %+v", orange, h, s, v, back)
+	}
+
+	if c := ColorFromHSV(240, 1, 1); c != ColorBlue {
+		t.Errorf("HSV(240, 1, 1): expected blue, got %+v", c)
+	}
+}
+
+func TestColorSRGBLinear(t *testing.T) {
+	// Mid-grey 0.5 sRGB is ~0.214 linear; pure red keeps 1.0 and 0.0
+	grey := Color{R: 0.5, G: 0.5, B: 0.5, A: 0.5}.ToLinear()
+	if !approxColor(grey.R, 0.2140) || grey.A != 0.5 {
+		t.Errorf("0.5 sRGB -> linear: expected ~0.214 (alpha kept), got %+v", grey)
+	}
+	if red := ColorRed.ToLinear(); red != ColorRed {
+		t.Errorf("red sRGB -> linear: expected unchanged, got %+v", red)
+	}
+	if back := grey.ToSRGB(); !approxColor(back.R, 0.5) {
+		t.Errorf("linear -> sRGB: expected 0.5, got %v", back.R)
+	}
+}
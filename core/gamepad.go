@@ -0,0 +1,114 @@
+package core
+
+import (
+	"math"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// DefaultGamepadDeadZone is the stick/trigger dead zone a new Window starts with.
+const DefaultGamepadDeadZone = 0.15
+
+// MaxGamepads is the number of gamepad slots GLFW tracks (indices 0–15).
+const MaxGamepads = int(glfw.JoystickLast) + 1
+
+// GamepadButton indexes GamepadState.Buttons (Xbox-style layout, via GLFW's
+// SDL_GameControllerDB mappings).
+type GamepadButton int
+
+const (
+	GamepadA           = GamepadButton(glfw.ButtonA)
+	GamepadB           = GamepadButton(glfw.ButtonB)
+	GamepadX           = GamepadButton(glfw.ButtonX)
+	GamepadY           = GamepadButton(glfw.ButtonY)
+	GamepadLeftBumper  = GamepadButton(glfw.ButtonLeftBumper)
+	GamepadRightBumper = GamepadButton(glfw.ButtonRightBumper)
+	GamepadBack        = GamepadButton(glfw.ButtonBack)
+	GamepadStart       = GamepadButton(glfw.ButtonStart)
+	GamepadGuide       = GamepadButton(glfw.ButtonGuide)
+	GamepadLeftThumb   = GamepadButton(glfw.ButtonLeftThumb)
+	GamepadRightThumb  = GamepadButton(glfw.ButtonRightThumb)
+	GamepadDpadUp      = GamepadButton(glfw.ButtonDpadUp)
+	GamepadDpadRight   = GamepadButton(glfw.ButtonDpadRight)
+	GamepadDpadDown    = GamepadButton(glfw.ButtonDpadDown)
+	GamepadDpadLeft    = GamepadButton(glfw.ButtonDpadLeft)
+
+	GamepadButtonCount = int(glfw.ButtonLast) + 1
+)
+
+// GamepadState is a dead-zone-filtered snapshot of a gamepad.
+// Sticks are in [-1, 1] with +X right and +Y down (GLFW convention);
+// triggers are in [0, 1], 0 = released.
+type GamepadState struct {
+	LeftX, LeftY   float32
+	RightX, RightY float32
+	LeftTrigger    float32
+	RightTrigger   float32
+	Buttons        [GamepadButtonCount]bool
+}
+
+// Pressed reports whether button b is held.
+func (s GamepadState) Pressed(b GamepadButton) bool {
+	return int(b) >= 0 && int(b) < GamepadButtonCount && s.Buttons[b]
+}
+
+// IsGamepadConnected reports whether a joystick with a gamepad mapping is
+// present at index (0 to MaxGamepads-1).
+func (w *Window) IsGamepadConnected(index int) bool {
+	if index < 0 || index >= MaxGamepads {
+		return false
+	}
+	joy := glfw.Joystick(index)
+	return joy.Present() && joy.IsGamepad()
+}
+
+// GetGamepadState returns the state of the gamepad at index with
+// w.GamepadDeadZone applied. ok is false when no mapped gamepad is connected.
+func (w *Window) GetGamepadState(index int) (state GamepadState, ok bool) {
+	if !w.IsGamepadConnected(index) {
+		return GamepadState{}, false
+	}
+	raw := glfw.Joystick(index).GetGamepadState()
+	if raw == nil {
+		return GamepadState{}, false
+	}
+
+	dz := w.GamepadDeadZone
+	state.LeftX, state.LeftY = applyStickDeadZone(raw.Axes[glfw.AxisLeftX], raw.Axes[glfw.AxisLeftY], dz)
+	state.RightX, state.RightY = applyStickDeadZone(raw.Axes[glfw.AxisRightX], raw.Axes[glfw.AxisRightY], dz)
+	// GLFW reports triggers as -1 (released) to 1 (fully pressed)
+	state.LeftTrigger = applyTriggerDeadZone((raw.Axes[glfw.AxisLeftTrigger]+1)*0.5, dz)
+	state.RightTrigger = applyTriggerDeadZone((raw.Axes[glfw.AxisRightTrigger]+1)*0.5, dz)
+	for i := range state.Buttons {
+		state.Buttons[i] = raw.Buttons[i] == glfw.Press
+	}
+	return state, true
+}
+
+// applyStickDeadZone applies a radial dead zone to a stick: deflections
+// shorter than dz become zero and the rest is rescaled so output still ramps
+// smoothly from 0 at the dead-zone edge to 1 at full deflection.
+func applyStickDeadZone(x, y, dz float32) (float32, float32) {
+	mag := float32(math.Sqrt(float64(x*x + y*y)))
+	if mag <= dz || dz >= 1 {
+		return 0, 0
+	}
+	scaled := (mag - dz) / (1 - dz)
+	if scaled > 1 {
+		scaled = 1
+	}
+	return x / mag * scaled, y / mag * scaled
+}
+
+// applyTriggerDeadZone is the one-axis version of applyStickDeadZone for
+// triggers in [0, 1].
+func applyTriggerDeadZone(v, dz float32) float32 {
+	if v <= dz || dz >= 1 {
+		return 0
+	}
+	v = (v - dz) / (1 - dz)
+	if v > 1 {
+		v = 1
+	}
+	return v
+}
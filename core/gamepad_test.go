@@ -0,0 +1,39 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func TestApplyStickDeadZone(t *testing.T) {
+	const dz = 0.2
+	approx := func(a, b float32) bool { return math.Abs(float64(a-b)) < 1e-5 }
+
+	// Small deflections, including diagonals, are swallowed
+	for _, in := range [][2]float32{{0.1, 0}, {0, -0.15}, {0.1, 0.1}, {0.2, 0}} {
+		if x, y := applyStickDeadZone(in[0], in[1], dz); x != 0 || y != 0 {
+			t.Errorf("input %v inside dead zone: expected (0, 0), got (%v, %v)", in, x, y)
+		}
+	}
+
+	// Just past the edge ramps from zero; full deflection stays full
+	if x, _ := applyStickDeadZone(0.6, 0, dz); !approx(x, 0.5) {
+		t.Errorf("input 0.6: expected 0.5 after rescale, got %v", x)
+	}
+	if _, y := applyStickDeadZone(0, -1, dz); !approx(y, -1) {
+		t.Errorf("input -1: expected -1, got %v", y)
+	}
+
+	// Direction is preserved
+	x, y := applyStickDeadZone(0.6, 0.6, dz)
+	if !approx(x, y) || x <= 0 {
+		t.Errorf("diagonal: expected equal positive components, got (%v, %v)", x, y)
+	}
+
+	if v := applyTriggerDeadZone(0.1, dz); v != 0 {
+		t.Errorf("trigger 0.1 inside dead zone: expected 0, got %v", v)
+	}
+	if v := applyTriggerDeadZone(1, dz); !approx(v, 1) {
+		t.Errorf("trigger fully pressed: expected 1, got %v", v)
+	}
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// MonitorInfo describes a connected monitor and its current video mode.
+type MonitorInfo struct {
+	Name        string
+	Width       int
+	Height      int
+	RefreshRate int
+	Primary     bool
+}
+
+// Monitors lists the connected monitors in GLFW order; the index is what
+// WindowConfig.Monitor and Window.Monitor refer to. GLFW must be initialised
+// (i.e. a Window created) first.
+func Monitors() []MonitorInfo {
+	primary := glfw.GetPrimaryMonitor()
+	var infos []MonitorInfo
+	for _, m := range glfw.GetMonitors() {
+		info := MonitorInfo{Name: m.GetName(), Primary: m == primary}
+		if mode := m.GetVideoMode(); mode != nil {
+			info.Width, info.Height, info.RefreshRate = mode.Width, mode.Height, mode.RefreshRate
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// monitorAt returns monitor index, falling back to the primary monitor when
+// the index is out of range (e.g. a monitor was unplugged). It returns nil
+// when no monitor is connected at all (headless sessions).
+func monitorAt(index int) *glfw.Monitor {
+	monitors := glfw.GetMonitors()
+	if index >= 0 && index < len(monitors) {
+		return monitors[index]
+	}
+	return glfw.GetPrimaryMonitor()
+}
+
+// IsFullscreen reports whether the window currently covers a monitor.
+func (w *Window) IsFullscreen() bool {
+	return w.Handle.GetMonitor() != nil
+}
+
+// SetFullscreen switches between windowed mode and fullscreen on w.Monitor,
+// using that monitor's current video mode so the desktop resolution is kept
+// (no mode switch on most platforms). The windowed position and size are
+// saved on the way in and restored on the way out. The framebuffer size
+// changes, so callers must resize their render targets afterwards
+// (renderer.RenderEngine.SetFullscreen does this). Entering fullscreen does
+// nothing when no monitor or video mode is available.
+func (w *Window) SetFullscreen(enabled bool) {
+	if enabled == w.IsFullscreen() {
+		return
+	}
+	if enabled {
+		monitor := monitorAt(w.Monitor)
+		if monitor == nil {
+			return
+		}
+		mode := monitor.GetVideoMode()
+		if mode == nil {
+			return
+		}
+		w.windowedX, w.windowedY = w.Handle.GetPos()
+		w.windowedW, w.windowedH = w.Handle.GetSize()
+		w.Handle.SetMonitor(monitor, 0, 0, mode.Width, mode.Height, mode.RefreshRate)
+	} else {
+		w.Handle.SetMonitor(nil, w.windowedX, w.windowedY, w.windowedW, w.windowedH, 0)
+	}
+	// Some platforms reset the swap interval when the window is recreated
+	glfw.SwapInterval(w.swapInterval)
+}
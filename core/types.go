@@ -24,6 +24,12 @@ type Vertex struct {
 	Color     Color
 	Tangent   math.Vec3
 	Bitangent math.Vec3
+	UV2       math.Vec2 // second UV set, used for lightmaps
+
+	// Skinning: up to four joint indices (into the mesh's Skeleton) and their
+	// weights, which sum to 1 for skinned vertices and are all 0 otherwise.
+	Joints  [4]uint16
+	Weights [4]float32
 }
 
 type MeshData struct {
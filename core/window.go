@@ -2,6 +2,7 @@ package core
 
 import (
 	"fmt"
+	"os"
 	"runtime"
 
 	"github.com/go-gl/glfw/v3.3/glfw"
@@ -12,10 +13,63 @@ func init() {
 }
 
 type Window struct {
-	Handle *glfw.Window
-	Width  int
-	Height int
-	Title  string
+	Handle      *glfw.Window
+	Width       int
+	Height      int
+	Title       string
+	PresentMode PresentMode // mode actually in effect; see SetPresentMode
+	Headless    bool        // created by NewOffscreenContext; never shown or swapped
+
+	// Stick/trigger deflection below which GetGamepadState reports zero (0–1)
+	GamepadDeadZone float32
+
+	// Monitor index (see Monitors) used by SetFullscreen
+	Monitor int
+
+	// Swap interval last passed to glfwSwapInterval (SetSwapInterval)
+	swapInterval int
+
+	// Windowed placement saved by SetFullscreen, restored on leaving fullscreen
+	windowedX, windowedY int
+	windowedW, windowedH int
+
+	// Cursor mode and the position GetCursorDelta last measured from
+	cursorMode   CursorMode
+	lastCursorX  float64
+	lastCursorY  float64
+	cursorPrimed bool
+
+	// Event callbacks and the scroll accumulator, fed by GLFW during PollEvents
+	onScroll ScrollCallback
+	onKey    KeyCallback
+	scrollX  float64
+	scrollY  float64
+}
+
+// PresentMode selects how SwapBuffers synchronises with the display refresh.
+// The names follow the Vulkan present modes; OpenGL only exposes them through
+// the swap interval, so some modes fall back (see SetPresentMode).
+type PresentMode int
+
+const (
+	PresentFIFO        PresentMode = iota // wait for vblank (VSync on), always supported
+	PresentFIFORelaxed                    // VSync, but tear instead of waiting when a frame is late
+	PresentMailbox                        // not available in OpenGL, falls back to FIFO
+	PresentImmediate                      // no sync, lowest latency, may tear
+)
+
+func (m PresentMode) String() string {
+	switch m {
+	case PresentFIFO:
+		return "FIFO"
+	case PresentFIFORelaxed:
+		return "FIFO_RELAXED"
+	case PresentMailbox:
+		return "MAILBOX"
+	case PresentImmediate:
+		return "IMMEDIATE"
+	}
+	return fmt.Sprintf("PresentMode(%d)", int(m))
 }
 
 type WindowConfig struct {
@@ -24,7 +78,9 @@ type WindowConfig struct {
 	Title      string
 	Resizable  bool
 	VSync      bool
-	Fullscreen bool
+	Fullscreen bool // start fullscreen at Monitor's current video mode
+	Monitor    int  // index into Monitors(); out of range means the primary monitor
+	Hidden     bool // create the window without showing it
 }
 
 func DefaultWindowConfig() WindowConfig {
@@ -47,39 +103,100 @@ func NewWindow(config WindowConfig) (*Window, error) {
 	glfw.WindowHint(glfw.ContextVersionMinor, 1)
 	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
 	glfw.WindowHint(glfw.Resizable, boolToInt(config.Resizable))
+	glfw.WindowHint(glfw.Visible, boolToInt(!config.Hidden))
 
 	monitor := (*glfw.Monitor)(nil)
+	width, height := config.Width, config.Height
 	if config.Fullscreen {
-		monitor = glfw.GetPrimaryMonitor()
+		monitor = monitorAt(config.Monitor)
+		if monitor == nil {
+			return nil, fmt.Errorf("fullscreen window: no monitor connected")
+		}
+		mode := monitor.GetVideoMode()
+		if mode == nil {
+			return nil, fmt.Errorf("fullscreen window: monitor %q has no video mode", monitor.GetName())
+		}
+		width, height = mode.Width, mode.Height
+		glfw.WindowHint(glfw.RefreshRate, mode.RefreshRate)
 	}
 
-	handle, err := glfw.CreateWindow(config.Width, config.Height, config.Title, monitor, nil)
+	handle, err := glfw.CreateWindow(width, height, config.Title, monitor, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create window: %w", err)
 	}
 
 	handle.MakeContextCurrent()
-	if config.VSync {
-		glfw.SwapInterval(1)
-	} else {
-		glfw.SwapInterval(0)
-	}
 
 	window := &Window{
-		Handle: handle,
-		Width:  config.Width,
-		Height: config.Height,
-		Title:  config.Title,
+		Handle:          handle,
+		Width:           width,
+		Height:          height,
+		Title:           config.Title,
+		GamepadDeadZone: DefaultGamepadDeadZone,
+		Monitor:         config.Monitor,
+		windowedW:       config.Width,
+		windowedH:       config.Height,
+	}
+	if config.Fullscreen {
+		// Leaving fullscreen centres the configured windowed size on the monitor
+		mx, my := monitor.GetPos()
+		window.windowedX = mx + (width-config.Width)/2
+		window.windowedY = my + (height-config.Height)/2
+	}
+	if config.VSync {
+		window.SetPresentMode(PresentFIFO)
+	} else {
+		window.SetPresentMode(PresentImmediate)
 	}
 
 	handle.SetSizeCallback(func(w *glfw.Window, width, height int) {
 		window.Width = width
 		window.Height = height
 	})
+	handle.SetScrollCallback(func(w *glfw.Window, xoff, yoff float64) {
+		window.scrollX += xoff
+		window.scrollY += yoff
+		if window.onScroll != nil {
+			window.onScroll(xoff, yoff)
+		}
+	})
+	handle.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if window.onKey != nil {
+			window.onKey(Key(key), Action(action))
+		}
+	})
 
 	return window, nil
 }
 
+// NewOffscreenContext creates a hidden width×height window whose OpenGL 4.1
+// core context is made current, for rendering without anything appearing on
+// screen (CI visual regression tests, thumbnail generation). The result can be
+// passed to renderer.NewRenderEngine; Present then skips the buffer swap and
+// keeps a copy of every frame for CaptureFrame, without RequestCapture.
+//
+// Platform requirements: GLFW has no true headless mode, so a windowing system
+// must still be reachable. On Linux that means an X server (Xvfb works, e.g.
+// `xvfb-run go test ./...`) via DISPLAY, or a Wayland compositor via
+// WAYLAND_DISPLAY; on Windows and macOS the process needs a desktop session.
+// The driver must also support OpenGL 4.1 core (Mesa llvmpipe is sufficient).
+func NewOffscreenContext(width, height int) (*Window, error) {
+	if runtime.GOOS == "linux" && os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		return nil, fmt.Errorf("offscreen context: no DISPLAY or WAYLAND_DISPLAY set")
+	}
+	window, err := NewWindow(WindowConfig{
+		Width:  width,
+		Height: height,
+		Title:  "offscreen",
+		Hidden: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("offscreen context: %w", err)
+	}
+	window.Headless = true
+	return window, nil
+}
+
 func (w *Window) ShouldClose() bool {
 	return w.Handle.ShouldClose()
 }
@@ -118,13 +235,154 @@ func (w *Window) GetCursorPos() (float64, float64) {
 	return w.Handle.GetCursorPos()
 }
 
+// CursorMode controls cursor visibility and confinement.
+type CursorMode int
+
+const (
+	CursorNormal   CursorMode = iota // visible, moves freely
+	CursorHidden                     // invisible over the window, still moves freely
+	CursorDisabled                   // invisible and locked; use GetCursorDelta for FPS-style look
+)
+
+// SetCursorMode changes the cursor mode. CursorDisabled also turns on raw
+// (unaccelerated) mouse motion where supported. The GetCursorDelta baseline
+// is reset so the switch itself never produces a jump.
+//
+// A captured cursor can't leave the window, so always give the user a way
+// out: the usual pattern is to release it with Escape (switch back to
+// CursorNormal) and only treat Escape as "quit" while already released.
+func (w *Window) SetCursorMode(mode CursorMode) {
+	switch mode {
+	case CursorHidden:
+		w.Handle.SetInputMode(glfw.CursorMode, glfw.CursorHidden)
+	case CursorDisabled:
+		w.Handle.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+	default:
+		mode = CursorNormal
+		w.Handle.SetInputMode(glfw.CursorMode, glfw.CursorNormal)
+	}
+	if glfw.RawMouseMotionSupported() {
+		w.Handle.SetInputMode(glfw.RawMouseMotion, boolToInt(mode == CursorDisabled))
+	}
+	w.cursorMode = mode
+	w.cursorPrimed = false
+}
+
+// CursorMode returns the mode last set with SetCursorMode.
+func (w *Window) CursorMode() CursorMode {
+	return w.cursorMode
+}
+
+// GetCursorDelta returns how far the cursor moved since the previous call
+// (+Y down). The first call after creation or a SetCursorMode returns zero.
+// Unbounded when the cursor is CursorDisabled; elsewhere it stops at the
+// window edges.
+func (w *Window) GetCursorDelta() (dx, dy float64) {
+	x, y := w.Handle.GetCursorPos()
+	if w.cursorPrimed {
+		dx, dy = x-w.lastCursorX, y-w.lastCursorY
+	}
+	w.lastCursorX, w.lastCursorY = x, y
+	w.cursorPrimed = true
+	return dx, dy
+}
+
+// SetPresentMode sets the swap interval for the requested present mode and
+// returns the mode actually applied. FIFO_RELAXED needs the
+// *_EXT_swap_control_tear extension and falls back to FIFO without it;
+// MAILBOX has no OpenGL equivalent and always falls back to FIFO.
+// The window's context must be current.
+func (w *Window) SetPresentMode(mode PresentMode) PresentMode {
+	switch mode {
+	case PresentImmediate:
+		w.SetSwapInterval(0)
+	case PresentFIFORelaxed:
+		w.SetSwapInterval(-1)
+	default:
+		w.SetSwapInterval(1)
+	}
+	return w.PresentMode
+}
+
+// SetSwapInterval sets how many display refreshes SwapBuffers waits for:
+// 0 = no vsync, 1 = vsync, 2 = half the refresh rate, and so on. Negative
+// values are adaptive (late frames swap immediately and tear) where the
+// *_EXT_swap_control_tear extension exists, and plain vsync otherwise.
+// PresentMode is updated to match. The window's context must be current.
+func (w *Window) SetSwapInterval(n int) {
+	switch {
+	case n == 0:
+		w.PresentMode = PresentImmediate
+	case n < 0 && swapControlTear():
+		w.PresentMode = PresentFIFORelaxed
+	case n < 0:
+		n = 1
+		fallthrough
+	default:
+		w.PresentMode = PresentFIFO
+	}
+	glfw.SwapInterval(n)
+	w.swapInterval = n
+}
+
+// SwapInterval returns the swap interval in effect (see SetSwapInterval).
+func (w *Window) SwapInterval() int {
+	return w.swapInterval
+}
+
+// swapControlTear reports whether negative (adaptive) swap intervals are
+// supported.
+func swapControlTear() bool {
+	return glfw.ExtensionSupported("WGL_EXT_swap_control_tear") ||
+		glfw.ExtensionSupported("GLX_EXT_swap_control_tear")
+}
+
+// SetVSync is shorthand for SetPresentMode(PresentFIFO) / SetPresentMode(PresentImmediate).
+func (w *Window) SetVSync(enabled bool) {
+	if enabled {
+		w.SetPresentMode(PresentFIFO)
+	} else {
+		w.SetPresentMode(PresentImmediate)
+	}
+}
+
 // ScrollCallback is the type for scroll event handlers
 type ScrollCallback func(xoff, yoff float64)
 
+// SetScrollCallback registers cb for scroll events (nil to remove). It runs
+// inside PollEvents; GetScrollOffset keeps accumulating either way.
 func (w *Window) SetScrollCallback(cb ScrollCallback) {
-	w.Handle.SetScrollCallback(func(win *glfw.Window, xoff, yoff float64) {
-		cb(xoff, yoff)
-	})
+	w.onScroll = cb
+}
+
+// GetScrollOffset returns the scroll distance accumulated since the previous
+// call and resets it.  yoff > 0 means scrolling up / away from the user.
+func (w *Window) GetScrollOffset() (xoff, yoff float64) {
+	xoff, yoff = w.scrollX, w.scrollY
+	w.scrollX, w.scrollY = 0, 0
+	return xoff, yoff
+}
+
+// Key is a keyboard key code, one of the Key* constants.
+type Key = int
+
+// Action is the key state change reported to a KeyCallback.
+type Action int
+
+const (
+	ActionRelease Action = Action(glfw.Release)
+	ActionPress   Action = Action(glfw.Press)
+	ActionRepeat  Action = Action(glfw.Repeat) // key held down long enough to auto-repeat
+)
+
+// KeyCallback is the type for key event handlers
+type KeyCallback func(key Key, action Action)
+
+// SetKeyCallback registers cb for key events (nil to remove). It runs inside
+// PollEvents and sees every press exactly once, so toggles need no manual
+// debouncing.  IsKeyPressed keeps working for held-key polling.
+func (w *Window) SetKeyCallback(cb KeyCallback) {
+	w.onKey = cb
 }
 
 func boolToInt(b bool) int {
@@ -22,8 +22,24 @@ type Editor struct {
 	// Camera
 	OrbitCamera *scene.OrbitCamera
 
+	// Transform gizmo height on screen, in pixels
+	GizmoPixels float32
+
 	// Status info
 	StatusText string
+
+	// Handle being dragged (axis == AxisNone when idle)
+	drag gizmoDrag
+}
+
+// gizmoDrag is an in-progress gizmo drag: the gizmo and ray at the press,
+// and the selected nodes' transforms before it.
+type gizmoDrag struct {
+	axis  Axis
+	gizmo Gizmo
+	start Ray
+	nodes []*scene.Node
+	orig  []core.Transform
 }
 
 // NewEditor initializes a new editor instance
@@ -40,6 +56,7 @@ func NewEditor(window *core.Window, s *scene.Scene) *Editor {
 		Scene:       s,
 		Window:      window,
 		OrbitCamera: camera,
+		GizmoPixels: 100,
 		StatusText:  "Ready",
 	}
 }
@@ -162,14 +179,17 @@ func (e *Editor) handleMouseSelection() {
 		return
 	}
 
+	ray := ScreenToRay(
+		float32(e.Input.MouseX), float32(e.Input.MouseY),
+		float32(e.Window.Width), float32(e.Window.Height),
+		&e.OrbitCamera.Camera,
+	)
+	if e.handleGizmoDrag(ray) {
+		return
+	}
+
 	// Left click select
 	if e.Input.IsMousePressed(MouseLeft) {
-		ray := ScreenToRay(
-			float32(e.Input.MouseX), float32(e.Input.MouseY),
-			float32(e.Window.Width), float32(e.Window.Height),
-			&e.OrbitCamera.Camera,
-		)
-
 		hit := RaycastScene(ray, e.Scene)
 		if hit.Hit && hit.Node != nil {
 			if e.Input.ShiftDown {
@@ -185,6 +205,103 @@ func (e *Editor) handleMouseSelection() {
 	}
 }
 
+// Gizmo returns the active tool's transform gizmo at the selection centre,
+// sized to GizmoPixels on screen; false when there is nothing to manipulate.
+// Draw it with the matching scene.Create*Gizmo mesh.
+func (e *Editor) Gizmo() (Gizmo, bool) {
+	if e.Mode != ModeObject || e.ActiveTool == ToolSelect || len(e.Selection.Objects) == 0 {
+		return Gizmo{}, false
+	}
+	center := e.Selection.GetSelectionCenter()
+	size := e.GizmoPixels * e.OrbitCamera.PixelWorldSize(center, float32(e.Window.Height))
+	if size <= 0 {
+		return Gizmo{}, false
+	}
+	return Gizmo{Tool: e.ActiveTool, Position: center, Size: size}, true
+}
+
+// handleGizmoDrag starts a drag when a gizmo handle is clicked, applies it
+// while the button is held and records it in the history on release.
+// Returns true while the mouse is busy with the gizmo.
+func (e *Editor) handleGizmoDrag(ray Ray) bool {
+	switch {
+	case e.drag.axis != AxisNone && e.Input.IsMouseDown(MouseLeft):
+		e.applyDrag(ray)
+		return true
+	case e.drag.axis != AxisNone:
+		e.endDrag()
+		return true
+	case e.Input.IsMousePressed(MouseLeft):
+		g, ok := e.Gizmo()
+		if !ok {
+			return false
+		}
+		axis, ok := GizmoHitTest(g, ray)
+		if !ok {
+			return false
+		}
+		e.drag = gizmoDrag{axis: axis, gizmo: g, start: ray}
+		for _, node := range e.Selection.Objects {
+			e.drag.nodes = append(e.drag.nodes, node)
+			e.drag.orig = append(e.drag.orig, node.Transform)
+		}
+		return true
+	}
+	return false
+}
+
+// applyDrag sets the dragged nodes' transforms from their originals and the
+// pointer's motion since the press. Scaling stretches the node's own axis
+// matching the handle, which is the world axis for unrotated nodes.
+func (e *Editor) applyDrag(ray Ray) {
+	d := e.drag
+	dir := d.axis.Vector()
+	dist := DragAxisDistance(d.gizmo, d.axis, d.start, ray)
+	turn := math.QuaternionFromAxisAngle(dir, DragRotationAngle(d.gizmo, d.axis, d.start, ray))
+	factor := max32(1+dist/(scene.GizmoAxisLength*d.gizmo.Size), 0.01)
+
+	for i, node := range d.nodes {
+		t := d.orig[i]
+		switch d.gizmo.Tool {
+		case ToolTranslate:
+			node.SetPosition(t.Position.Add(dir.Mul(dist)))
+		case ToolRotate:
+			node.SetRotation(turn.Mul(t.Rotation).Normalize())
+		case ToolScale:
+			s := t.Scale
+			switch d.axis {
+			case AxisX:
+				s.X *= factor
+			case AxisY:
+				s.Y *= factor
+			case AxisZ:
+				s.Z *= factor
+			}
+			node.SetScale(s)
+		}
+	}
+}
+
+// endDrag turns the finished drag into one undoable command per node.
+func (e *Editor) endDrag() {
+	d := e.drag
+	e.drag = gizmoDrag{}
+	for i, node := range d.nodes {
+		final := node.Transform
+		node.Transform = d.orig[i]
+		node.MarkWorldMatrixDirty()
+		switch d.gizmo.Tool {
+		case ToolTranslate:
+			e.History.Do(NewMoveCommand(node, final.Position))
+		case ToolRotate:
+			e.History.Do(NewRotateCommand(node, final.Rotation))
+		case ToolScale:
+			e.History.Do(NewScaleCommand(node, final.Scale))
+		}
+	}
+	e.StatusText = fmt.Sprintf("Transformed %d object(s)", len(d.nodes))
+}
+
 func (e *Editor) deleteSelected() {
 	for _, node := range e.Selection.Objects {
 		cmd := NewDeleteNodeCommand(e.Scene, node)
@@ -0,0 +1,161 @@
+package editor
+
+import (
+	stdmath "math"
+
+	"render-engine/math"
+	"render-engine/scene"
+)
+
+// Axis identifies a transform gizmo handle.
+type Axis int
+
+const (
+	AxisNone Axis = iota
+	AxisX
+	AxisY
+	AxisZ
+)
+
+// Vector returns the world direction of a (zero for AxisNone).
+func (a Axis) Vector() math.Vec3 {
+	switch a {
+	case AxisX:
+		return math.Vec3{X: 1, Y: 0, Z: 0}
+	case AxisY:
+		return math.Vec3{X: 0, Y: 1, Z: 0}
+	case AxisZ:
+		return math.Vec3{X: 0, Y: 0, Z: 1}
+	}
+	return math.Vec3Zero
+}
+
+// Gizmo is a transform gizmo placed in the world: the scene.Create*Gizmo mesh
+// for Tool, drawn at Position scaled by Size (world units per gizmo unit —
+// pixels × Camera.PixelWorldSize keeps it a constant size on screen).
+type Gizmo struct {
+	Tool     TransformTool
+	Position math.Vec3
+	Size     float32
+}
+
+// gizmoPickRadius is how far from a handle, in gizmo units, a ray still
+// grabs it: wider than the drawn shafts and rings so they are easy to hit.
+const gizmoPickRadius = 0.08
+
+// GizmoHitTest returns the handle of g under ray, the one nearest the ray
+// origin when several are. Translate and scale handles are picked along
+// their shafts, rotate handles on their rings; ToolSelect has no handles.
+func GizmoHitTest(g Gizmo, ray Ray) (axis Axis, ok bool) {
+	best := float32(stdmath.MaxFloat32)
+	for _, a := range []Axis{AxisX, AxisY, AxisZ} {
+		var t float32
+		var hit bool
+		switch g.Tool {
+		case ToolTranslate, ToolScale:
+			t, hit = g.hitShaft(ray, a)
+		case ToolRotate:
+			t, hit = g.hitRing(ray, a)
+		}
+		if hit && t < best {
+			best, axis, ok = t, a, true
+		}
+	}
+	return axis, ok
+}
+
+// hitShaft tests ray against the capsule of radius gizmoPickRadius around
+// a's handle, from the gizmo centre to the handle's tip, returning the ray
+// distance of the closest approach.
+func (g Gizmo) hitShaft(ray Ray, a Axis) (float32, bool) {
+	s, ok := axisParam(ray, g.Position, a.Vector())
+	if !ok {
+		return 0, false // looking straight down the axis: the handle is a dot
+	}
+	s = clamp32(s, 0, scene.GizmoAxisLength*g.Size)
+	p := g.Position.Add(a.Vector().Mul(s))
+
+	t := p.Sub(ray.Origin).Dot(ray.Direction) / ray.Direction.Dot(ray.Direction)
+	if t < 0 {
+		return 0, false
+	}
+	q := ray.Origin.Add(ray.Direction.Mul(t))
+	return t, q.Sub(p).Length() <= gizmoPickRadius*g.Size
+}
+
+// hitRing intersects ray with the plane of a's ring and tests the hit point
+// against the ring radius.
+func (g Gizmo) hitRing(ray Ray, a Axis) (float32, bool) {
+	p, t, ok := planeHit(ray, g.Position, a.Vector())
+	if !ok {
+		return 0, false
+	}
+	r := p.Sub(g.Position).Length()
+	return t, stdmath.Abs(float64(r-scene.GizmoRingRadius*g.Size)) <= float64(gizmoPickRadius*g.Size)
+}
+
+// DragAxisDistance returns how far, in world units, the pointer moved along
+// axis between the rays from and to: the mouse delta projected onto the
+// axis line through g.Position. Moving a node by axis.Vector() × the
+// distance keeps the grabbed point under the cursor; for a scale drag,
+// 1 + distance / (scene.GizmoAxisLength × g.Size) is the factor. Returns 0
+// when either ray runs parallel to the axis.
+func DragAxisDistance(g Gizmo, axis Axis, from, to Ray) float32 {
+	s0, ok0 := axisParam(from, g.Position, axis.Vector())
+	s1, ok1 := axisParam(to, g.Position, axis.Vector())
+	if !ok0 || !ok1 {
+		return 0
+	}
+	return s1 - s0
+}
+
+// DragRotationAngle returns the angle in radians the pointer turned about
+// axis between the rays from and to, measured where they cross the ring's
+// plane: positive is counter-clockwise looking down the axis towards the
+// gizmo (right-handed), as math.QuaternionFromAxisAngle expects. Returns 0
+// when either ray misses the plane.
+func DragRotationAngle(g Gizmo, axis Axis, from, to Ray) float32 {
+	n := axis.Vector()
+	p0, _, ok0 := planeHit(from, g.Position, n)
+	p1, _, ok1 := planeHit(to, g.Position, n)
+	if !ok0 || !ok1 {
+		return 0
+	}
+	u, v := p0.Sub(g.Position), p1.Sub(g.Position)
+	return float32(stdmath.Atan2(float64(n.Dot(u.Cross(v))), float64(u.Dot(v))))
+}
+
+// axisParam returns the parameter along the line origin + dir·s of its point
+// closest to ray's line; false when the two are parallel.
+func axisParam(ray Ray, origin, dir math.Vec3) (float32, bool) {
+	w := ray.Origin.Sub(origin)
+	a := ray.Direction.Dot(ray.Direction)
+	b := ray.Direction.Dot(dir)
+	c := dir.Dot(dir)
+	d := ray.Direction.Dot(w)
+	e := dir.Dot(w)
+	den := a*c - b*b
+	if den < 1e-6*a*c {
+		return 0, false
+	}
+	return (a*e - b*d) / den, true
+}
+
+// planeHit intersects ray with the plane through center with normal n,
+// returning the point and ray distance; false for rays parallel to the plane
+// or pointing away from it.
+func planeHit(ray Ray, center, n math.Vec3) (math.Vec3, float32, bool) {
+	den := ray.Direction.Dot(n)
+	if stdmath.Abs(float64(den)) < 1e-4 {
+		return math.Vec3{}, 0, false
+	}
+	t := center.Sub(ray.Origin).Dot(n) / den
+	if t < 0 {
+		return math.Vec3{}, 0, false
+	}
+	return ray.Origin.Add(ray.Direction.Mul(t)), t, true
+}
+
+func clamp32(v, lo, hi float32) float32 {
+	return max32(lo, min32(v, hi))
+}
@@ -0,0 +1,63 @@
+package editor
+
+import (
+	stdmath "math"
+	"testing"
+
+	"render-engine/math"
+)
+
+// rayDown returns a ray from (x, y, 5) looking down -Z.
+func rayDown(x, y float32) Ray {
+	return Ray{Origin: math.Vec3{X: x, Y: y, Z: 5}, Direction: math.Vec3{X: 0, Y: 0, Z: -1}}
+}
+
+func TestGizmoHitTest(t *testing.T) {
+	move := Gizmo{Tool: ToolTranslate, Position: math.Vec3Zero, Size: 1}
+	for _, c := range []struct {
+		name string
+		ray  Ray
+		axis Axis
+		ok   bool
+	}{
+		{"x shaft", rayDown(0.6, 0.02), AxisX, true},
+		{"y shaft", rayDown(-0.03, 0.5), AxisY, true},
+		{"between shafts", rayDown(0.5, 0.5), AxisNone, false},
+		{"past the tip", rayDown(1.5, 0), AxisNone, false},
+	} {
+		if axis, ok := GizmoHitTest(move, c.ray); axis != c.axis || ok != c.ok {
+			t.Errorf("translate, %s: expected %v %v, got %v %v", c.name, c.axis, c.ok, axis, ok)
+		}
+	}
+
+	// Scaled up and moved, the handles follow
+	big := Gizmo{Tool: ToolScale, Position: math.Vec3{X: 10, Y: 0, Z: 0}, Size: 3}
+	if axis, ok := GizmoHitTest(big, rayDown(10, 2.5)); !ok || axis != AxisY {
+		t.Errorf("scale: expected the Y handle, got %v %v", axis, ok)
+	}
+
+	// Looking down Z, only the Z ring faces the camera
+	rot := Gizmo{Tool: ToolRotate, Position: math.Vec3Zero, Size: 2}
+	if axis, ok := GizmoHitTest(rot, rayDown(0, 2)); !ok || axis != AxisZ {
+		t.Errorf("rotate: expected the Z ring, got %v %v", axis, ok)
+	}
+	if axis, ok := GizmoHitTest(rot, rayDown(0, 1)); ok {
+		t.Errorf("rotate: inside the ring should miss, got %v", axis)
+	}
+	if axis, ok := GizmoHitTest(Gizmo{Tool: ToolSelect, Size: 1}, rayDown(0.6, 0)); ok {
+		t.Errorf("select tool has no handles, got %v", axis)
+	}
+}
+
+func TestGizmoDrag(t *testing.T) {
+	g := Gizmo{Tool: ToolTranslate, Position: math.Vec3Zero, Size: 1}
+	if d := DragAxisDistance(g, AxisX, rayDown(0.5, 0.1), rayDown(0.8, 0.3)); stdmath.Abs(float64(d-0.3)) > 1e-5 {
+		t.Errorf("drag along X: expected 0.3, got %v", d)
+	}
+	if d := DragAxisDistance(g, AxisZ, rayDown(0, 0), rayDown(0, 1)); d != 0 {
+		t.Errorf("drag along the view axis: expected 0, got %v", d)
+	}
+	if a := DragRotationAngle(g, AxisZ, rayDown(1, 0), rayDown(0, 1)); stdmath.Abs(float64(a)-stdmath.Pi/2) > 1e-5 {
+		t.Errorf("quarter turn about Z: expected π/2, got %v", a)
+	}
+}
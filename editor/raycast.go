@@ -32,33 +32,11 @@ type HitResult struct {
 
 // ScreenToRay converts a screen-space mouse position to a world-space ray
 func ScreenToRay(mouseX, mouseY float32, screenWidth, screenHeight float32, camera *scene.Camera) Ray {
-	// Convert to normalized device coordinates (-1 to 1)
-	ndcX := (2.0*mouseX)/screenWidth - 1.0
-	ndcY := 1.0 - (2.0*mouseY)/screenHeight // flip Y
-
-	// Create clip-space coordinates
-	clipNear := math.Vec4{X: ndcX, Y: ndcY, Z: 0.0, W: 1.0}
-
-	// Inverse projection and view matrices
-	invProj := camera.GetProjectionMatrix().Inverse()
-	invView := camera.GetViewMatrix().Inverse()
-
-	// Transform to view space
-	viewNear := invProj.MulVec(clipNear)
-	viewNear = viewNear.Div(viewNear.W)
-
-	// Transform to world space
-	worldNear := invView.MulVec(math.Vec4{X: viewNear.X, Y: viewNear.Y, Z: viewNear.Z, W: 1.0})
-
-	direction := math.Vec3{
-		X: worldNear.X - camera.Position.X,
-		Y: worldNear.Y - camera.Position.Y,
-		Z: worldNear.Z - camera.Position.Z,
-	}.Normalize()
-
+	// Any point on the pixel's view ray gives the direction from the eye
+	p := camera.ScreenToWorld(mouseX, mouseY, 1, screenWidth, screenHeight)
 	return Ray{
 		Origin:    camera.Position,
-		Direction: direction,
+		Direction: p.Sub(camera.Position).Normalize(),
 	}
 }
 
@@ -6,4 +6,4 @@ require github.com/go-gl/glfw/v3.3/glfw v0.0.0-20250301202403-da16c1255728
 
 require github.com/go-gl/gl v0.0.0-20231021071112-07e5d0ea2e71
 
-require github.com/qmuntal/gltf v0.28.0 // indirect
+require github.com/qmuntal/gltf v0.28.0
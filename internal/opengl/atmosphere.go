@@ -0,0 +1,160 @@
+package opengl
+
+import (
+	stdmath "math"
+
+	"render-engine/core"
+	"render-engine/math"
+)
+
+// ── Preetham analytic sky ─────────────────────────────────────────────────────
+//
+// "A Practical Analytic Model for Daylight" (Preetham, Shirley, Smits 1999).
+// The sky colour in direction θ (from zenith), γ (angle to the sun) is the
+// zenith value scaled by the Perez distribution F(θ, γ) / F(0, θs), evaluated
+// separately for luminance Y and chromaticity x, y. The same model is
+// implemented in skyFragSrc; this Go copy feeds IBL, which needs the sky
+// colour on the CPU.
+
+// skyLuminanceScale maps the model's kcd/m² luminance to HDR scene units:
+// a clear noon zenith lands around 0.7 (blue), the sunlit horizon near 1.
+const skyLuminanceScale = 0.05
+
+// perezCoeffs are the Perez A–E coefficients for one channel.
+type perezCoeffs [5]float64
+
+func perezY(t float64) perezCoeffs {
+	return perezCoeffs{0.1787*t - 1.4630, -0.3554*t + 0.4275, -0.0227*t + 5.3251, 0.1206*t - 2.5771, -0.0670*t + 0.3703}
+}
+
+func perezX(t float64) perezCoeffs {
+	return perezCoeffs{-0.0193*t - 0.2592, -0.0665*t + 0.0008, -0.0004*t + 0.2125, -0.0641*t - 0.8989, -0.0033*t + 0.0452}
+}
+
+func perezYChroma(t float64) perezCoeffs {
+	return perezCoeffs{-0.0167*t - 0.2608, -0.0950*t + 0.0092, -0.0079*t + 0.2102, -0.0441*t - 1.6537, -0.0109*t + 0.0529}
+}
+
+// perez evaluates F(θ, γ) given cos θ, γ and cos γ.
+func (c perezCoeffs) perez(cosTheta, gamma, cosGamma float64) float64 {
+	return (1 + c[0]*stdmath.Exp(c[1]/cosTheta)) *
+		(1 + c[2]*stdmath.Exp(c[3]*gamma) + c[4]*cosGamma*cosGamma)
+}
+
+// preethamSky returns the linear RGB sky radiance in direction dir for a sun
+// in direction sunDir (both toward the sky, unit length) and the given
+// turbidity (2 = very clear, 10 = hazy). The sun is clamped to the horizon
+// and dir to just above it; callers handle night and the ground.
+func preethamSky(dir, sunDir math.Vec3, turbidity float32) math.Vec3 {
+	t := float64(turbidity)
+	s := math.Vec3{X: sunDir.X, Y: float32(stdmath.Max(float64(sunDir.Y), 0)) + 1e-4, Z: sunDir.Z}.Normalize()
+	thetaS := stdmath.Acos(stdmath.Min(float64(s.Y), 1))
+
+	cosTheta := stdmath.Max(float64(dir.Y), 0.01)
+	cosGamma := stdmath.Max(-1, stdmath.Min(1, float64(dir.Dot(s))))
+	gamma := stdmath.Acos(cosGamma)
+
+	// Zenith luminance and chromaticity
+	chi := (4.0/9.0 - t/120.0) * (stdmath.Pi - 2*thetaS)
+	yz := (4.0453*t-4.9710)*stdmath.Tan(chi) - 0.2155*t + 2.4192
+	t2, t3 := thetaS*thetaS, thetaS*thetaS*thetaS
+	xz := t*t*(0.00166*t3-0.00375*t2+0.00209*thetaS) +
+		t*(-0.02903*t3+0.06377*t2-0.03202*thetaS+0.00394) +
+		(0.11693*t3 - 0.21196*t2 + 0.06052*thetaS + 0.25886)
+	cz := t*t*(0.00275*t3-0.00610*t2+0.00317*thetaS) +
+		t*(-0.04214*t3+0.08970*t2-0.04153*thetaS+0.00516) +
+		(0.15346*t3 - 0.26756*t2 + 0.06670*thetaS + 0.26688)
+
+	eval := func(c perezCoeffs, zenith float64) float64 {
+		return zenith * c.perez(cosTheta, gamma, cosGamma) / c.perez(1, thetaS, stdmath.Cos(thetaS))
+	}
+	Y := eval(perezY(t), yz) * skyLuminanceScale
+	x := eval(perezX(t), xz)
+	y := eval(perezYChroma(t), cz)
+
+	// xyY → XYZ → linear sRGB
+	X := x / y * Y
+	Z := (1 - x - y) / y * Y
+	rgb := math.Vec3{
+		X: float32(3.2406*X - 1.5372*Y - 0.4986*Z),
+		Y: float32(-0.9689*X + 1.8758*Y + 0.0415*Z),
+		Z: float32(0.0557*X - 0.2040*Y + 1.0570*Z),
+	}
+	return math.Vec3{
+		X: float32(stdmath.Max(float64(rgb.X), 0)),
+		Y: float32(stdmath.Max(float64(rgb.Y), 0)),
+		Z: float32(stdmath.Max(float64(rgb.Z), 0)),
+	}
+}
+
+// skyDayFactor is 1 while the sun is up and fades to 0 once it is more than
+// ~6° below the horizon, matching the shader.
+func skyDayFactor(sunDir math.Vec3) float32 {
+	return smoothstep(-0.1, 0.05, sunDir.Normalize().Y)
+}
+
+func smoothstep(edge0, edge1, x float32) float32 {
+	t := clampf((x-edge0)/(edge1-edge0), 0, 1)
+	return t * t * (3 - 2*t)
+}
+
+func clampf(v, lo, hi float32) float32 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// SampleAtmosphere returns the atmosphere-mode sky colour in direction dir
+// (unit length) as the shader draws it: Preetham above the horizon, the
+// horizon tinted by GroundAlbedo below it, blended into the gradient colours
+// as the sun sets so the gradient serves as the night sky.
+func (sb *Skybox) SampleAtmosphere(dir math.Vec3) core.Color {
+	sun := sb.SunDirection.Normalize()
+	flat := dir
+	if flat.Y < 0 {
+		flat.Y = 0
+	}
+	sky := preethamSky(flat.Normalize(), sun, sb.Turbidity)
+	if dir.Y < 0 {
+		g := clampf(-dir.Y*3, 0, 1)
+		sky = sky.Lerp(math.Vec3{
+			X: sky.X * sb.GroundAlbedo.R,
+			Y: sky.Y * sb.GroundAlbedo.G,
+			Z: sky.Z * sb.GroundAlbedo.B,
+		}, g)
+	}
+	c := core.Color{R: sky.X, G: sky.Y, B: sky.Z, A: 1}
+	return sb.sampleGradient(dir).Lerp(c, skyDayFactor(sun))
+}
+
+// sampleGradient mirrors the gradient branch of skyFragSrc.
+func (sb *Skybox) sampleGradient(dir math.Vec3) core.Color {
+	t := dir.Y
+	if t >= 0 {
+		return sb.HorizonColor.Lerp(sb.ZenithColor, float32(stdmath.Pow(float64(t), 0.4)))
+	}
+	return sb.HorizonColor.Lerp(sb.GroundColor, clampf(-t*3, 0, 1))
+}
+
+// AtmosphereIBL returns zenith, horizon and ground colours sampled from the
+// atmosphere sky, for the main shader's hemisphere IBL. The horizon colour is
+// averaged around the compass (slightly above the horizon) so the sun's
+// azimuth does not bias it.
+func (sb *Skybox) AtmosphereIBL() (zenith, horizon, ground core.Color) {
+	zenith = sb.SampleAtmosphere(math.Vec3Up)
+	const n = 8
+	var sum core.Color
+	for i := 0; i < n; i++ {
+		a := float64(i) * 2 * stdmath.Pi / n
+		d := math.Vec3{X: float32(stdmath.Cos(a)), Y: 0.1, Z: float32(stdmath.Sin(a))}.Normalize()
+		c := sb.SampleAtmosphere(d)
+		sum = core.Color{R: sum.R + c.R/n, G: sum.G + c.G/n, B: sum.B + c.B/n, A: 1}
+	}
+	horizon = sum
+	ground = sb.SampleAtmosphere(math.Vec3{X: 0, Y: -1, Z: 0})
+	return zenith, horizon, ground
+}
@@ -0,0 +1,23 @@
+package opengl
+
+import (
+	"testing"
+
+	"render-engine/math"
+)
+
+func TestPreethamSky(t *testing.T) {
+	sun := math.Vec3{X: 0, Y: 1, Z: 0}
+	zenith := preethamSky(math.Vec3Up, sun, 3)
+	if zenith.Z <= zenith.X {
+		t.Errorf("noon zenith should be blue, got %+v", zenith)
+	}
+
+	// With a low sun the sky near the sun outshines the opposite side
+	low := math.Vec3{X: 1, Y: 0.1, Z: 0}.Normalize()
+	toward := preethamSky(math.Vec3{X: 1, Y: 0.2, Z: 0}.Normalize(), low, 3)
+	away := preethamSky(math.Vec3{X: -1, Y: 0.2, Z: 0}.Normalize(), low, 3)
+	if toward.Length() <= away.Length() {
+		t.Errorf("sky toward the sun (%+v) should be brighter than away (%+v)", toward, away)
+	}
+}
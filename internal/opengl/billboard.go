@@ -0,0 +1,113 @@
+package opengl
+
+import (
+	"fmt"
+	"unsafe"
+
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+
+	"render-engine/core"
+	"render-engine/math"
+	"render-engine/scene"
+)
+
+// billboardAxes returns the world-space right and up directions of a
+// camera-facing quad for the given view matrix ([col][row] layout). With a
+// zero lockAxis the quad is parallel to the screen: right and up are rows 0
+// and 1 of the view matrix. Otherwise up is lockAxis and the quad only turns
+// about it (upright trees, cylindrical impostors): right is perpendicular to
+// both it and the view direction, falling back to the screen's right when
+// the camera looks along the axis.
+func billboardAxes(view math.Mat4, lockAxis math.Vec3) (right, up math.Vec3) {
+	right = math.Vec3{X: view[0][0], Y: view[1][0], Z: view[2][0]}
+	up = math.Vec3{X: view[0][1], Y: view[1][1], Z: view[2][1]}
+	if lockAxis.LengthSqr() == 0 {
+		return right, up
+	}
+	up = lockAxis.Normalize()
+	back := math.Vec3{X: view[0][2], Y: view[1][2], Z: view[2][2]} // towards the camera
+	if r := up.Cross(back); r.LengthSqr() > 1e-8 {
+		right = r.Normalize()
+	}
+	return right, up
+}
+
+// billboardCorners returns the top-left, top-right, bottom-right and
+// bottom-left corners of a size.X × size.Y quad centred on center.
+func billboardCorners(view math.Mat4, center math.Vec3, size math.Vec2, lockAxis math.Vec3) [4]math.Vec3 {
+	right, up := billboardAxes(view, lockAxis)
+	r := right.Mul(size.X * 0.5)
+	u := up.Mul(size.Y * 0.5)
+	return [4]math.Vec3{
+		center.Sub(r).Add(u),
+		center.Add(r).Add(u),
+		center.Add(r).Sub(u),
+		center.Sub(r).Sub(u),
+	}
+}
+
+// whiteTexture returns a 1×1 white texture, created on first use.
+func (pr *ParticleRenderer) whiteTexture() uint32 {
+	if pr.white == nil {
+		white := scene.NewSolidTexture("billboard_white", 255, 255, 255, 255)
+		if err := UploadTexture(white); err != nil {
+			return 0
+		}
+		pr.white = white
+	}
+	return pr.white.GLID
+}
+
+// DrawBillboard draws a camera-facing quad size world units across at
+// center, textured with tex (0 = plain colour) and tinted by color, unlit and
+// alpha-blended. A non-zero lockAxis keeps the quad's up along that axis.
+// Billboards are depth-tested but don't write depth, like particles; they
+// share the particle shader and buffers. Call after BeginFrame and before
+// BlitPostProcess.
+func (r *Renderer) DrawBillboard(tex uint32, center math.Vec3, size math.Vec2, color core.Color, lockAxis math.Vec3, view, proj math.Mat4) {
+	texturesRebound()
+	if r.particleRenderer == nil {
+		pr, err := newParticleRenderer()
+		if err != nil {
+			fmt.Printf("particle renderer init: %v\n", err)
+			return
+		}
+		r.particleRenderer = pr
+	}
+	pr := r.particleRenderer
+
+	// Image row 0 (v = 0) goes at the top
+	c := billboardCorners(view, center, size, lockAxis)
+	uv := [4][2]float32{{0, 0}, {1, 0}, {1, 1}, {0, 1}}
+	buf := make([]float32, 0, 6*9)
+	for _, i := range [6]int{0, 1, 2, 0, 2, 3} {
+		buf = append(buf, c[i].X, c[i].Y, c[i].Z, uv[i][0], uv[i][1], color.R, color.G, color.B, color.A)
+	}
+	pr.upload(buf, 6)
+
+	r.setBloomSourceWrites(false)
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	gl.DepthMask(false)
+
+	vp := view.Mul(proj)
+	gl.UseProgram(pr.prog)
+	gl.UniformMatrix4fv(pr.vpLoc, 1, false, (*float32)(unsafe.Pointer(&vp[0][0])))
+	gl.Uniform1f(pr.softFadeLoc, 0)
+	if tex == 0 {
+		// Untextured billboards are solid, not the particles' soft circle
+		tex = pr.whiteTexture()
+	}
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, tex)
+	gl.Uniform1i(pr.hasParticleTexLoc, 1)
+
+	gl.BindVertexArray(pr.vao)
+	gl.DrawArrays(gl.TRIANGLES, 0, 6)
+	gl.BindVertexArray(0)
+
+	gl.Uniform1i(pr.hasParticleTexLoc, 0)
+	gl.DepthMask(true)
+	gl.Disable(gl.BLEND)
+	r.setBloomSourceWrites(true)
+}
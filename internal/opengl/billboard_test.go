@@ -0,0 +1,40 @@
+package opengl
+
+import (
+	"testing"
+
+	"render-engine/math"
+)
+
+func TestBillboardCorners(t *testing.T) {
+	// Camera at the origin turned 90° to look down -X: its right is -Z, its
+	// up +Y and its back +X (view[component][axis], see billboardAxes)
+	view := math.Mat4Identity()
+	view[0][0], view[2][0] = 0, -1 // right
+	view[0][2], view[2][2] = 1, 0  // back
+
+	center := math.Vec3{X: -5, Y: 1, Z: 0}
+	size := math.Vec2{X: 2, Y: 4}
+	got := billboardCorners(view, center, size, math.Vec3{})
+	want := [4]math.Vec3{
+		{X: -5, Y: 3, Z: 1},   // top-left
+		{X: -5, Y: 3, Z: -1},  // top-right
+		{X: -5, Y: -1, Z: -1}, // bottom-right
+		{X: -5, Y: -1, Z: 1},  // bottom-left
+	}
+	if got != want {
+		t.Errorf("screen-aligned corners:\n got %v\nwant %v", got, want)
+	}
+
+	// Tilt the camera to look down on the quad: a Y-locked quad keeps
+	// vertical edges while the screen-aligned one leans with the camera
+	pitch := math.Mat4RotationX(-0.6)
+	tilted := view.Mul(pitch)
+	locked := billboardCorners(tilted, center, size, math.Vec3Up)
+	if top, bottom := locked[0], locked[3]; top.X != bottom.X || top.Z != bottom.Z || top.Y-bottom.Y != 4 {
+		t.Errorf("locked quad not upright: top-left %v, bottom-left %v", top, bottom)
+	}
+	if free := billboardCorners(tilted, center, size, math.Vec3{}); free[0].X == free[3].X {
+		t.Errorf("screen-aligned quad did not tilt: %v", free)
+	}
+}
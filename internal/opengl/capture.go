@@ -0,0 +1,103 @@
+package opengl
+
+import (
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// frameCopy is a single-sample RGBA8 framebuffer holding the last presented
+// frame. The default framebuffer's contents are undefined once swapped, so
+// Present copies the back buffer here first and captures read it back.
+type frameCopy struct {
+	fbo, rbo      uint32
+	width, height int32
+	valid         bool // holds the last presented frame
+}
+
+func (c *frameCopy) destroy() {
+	if c.fbo != 0 {
+		gl.DeleteFramebuffers(1, &c.fbo)
+	}
+	if c.rbo != 0 {
+		gl.DeleteRenderbuffers(1, &c.rbo)
+	}
+	*c = frameCopy{}
+}
+
+// CopyFramebuffer copies the full viewport of the default back buffer into
+// the frame copy that ReadFramebuffer returns. Call it after the last draw of
+// the frame and before SwapBuffers. The copy is (re)allocated on first use and
+// whenever the viewport size changes.
+func (r *Renderer) CopyFramebuffer() {
+	c := &r.frameCopy
+	w, h := r.viewportW, r.viewportH
+	if w <= 0 || h <= 0 {
+		c.valid = false
+		return
+	}
+	if c.fbo == 0 || c.width != w || c.height != h {
+		c.destroy()
+		gl.GenRenderbuffers(1, &c.rbo)
+		gl.BindRenderbuffer(gl.RENDERBUFFER, c.rbo)
+		gl.RenderbufferStorage(gl.RENDERBUFFER, gl.RGBA8, w, h)
+		gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+		gl.GenFramebuffers(1, &c.fbo)
+		gl.BindFramebuffer(gl.FRAMEBUFFER, c.fbo)
+		gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.RENDERBUFFER, c.rbo)
+		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+		c.width, c.height = w, h
+	}
+
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+	gl.ReadBuffer(gl.BACK)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, c.fbo)
+	gl.BlitFramebuffer(0, 0, w, h, 0, 0, w, h, gl.COLOR_BUFFER_BIT, gl.NEAREST)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	c.valid = true
+}
+
+// DiscardFramebufferCopy marks the frame copy stale, for a frame presented
+// without CopyFramebuffer, so ReadFramebuffer does not return an older one.
+// The storage is kept for the next copy.
+func (r *Renderer) DiscardFramebufferCopy() {
+	r.frameCopy.valid = false
+}
+
+// ReadFramebuffer returns the frame last copied by CopyFramebuffer as RGBA8.
+// Rows are returned bottom-up, in GL's native order. Returns nil when no frame
+// has been copied for the last presented frame.
+func (r *Renderer) ReadFramebuffer() (pixels []byte, width, height int) {
+	c := &r.frameCopy
+	if !c.valid {
+		return nil, 0, 0
+	}
+	width, height = int(c.width), int(c.height)
+	pixels = make([]byte, width*height*4)
+
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, c.fbo)
+	gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.ReadPixels(0, 0, int32(width), int32(height), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pixels))
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+	gl.ReadBuffer(gl.BACK)
+	return pixels, width, height
+}
+
+// ReadHDRColor reads the post-process FBO's colour attachment, the lit scene
+// before tone mapping and bloom, as linear float RGB. Rows are bottom-up like
+// ReadFramebuffer. Returns nil when post-processing is disabled.
+func (r *Renderer) ReadHDRColor() (rgb []float32, width, height int) {
+	pp := r.postProcess
+	if pp == nil || pp.Width <= 0 || pp.Height <= 0 {
+		return nil, 0, 0
+	}
+	width, height = int(pp.Width), int(pp.Height)
+	rgb = make([]float32, width*height*3)
+
+	texturesRebound()
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, pp.ColorTex)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 4)
+	gl.GetTexImage(gl.TEXTURE_2D, 0, gl.RGB, gl.FLOAT, gl.Ptr(rgb))
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return rgb, width, height
+}
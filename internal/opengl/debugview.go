@@ -0,0 +1,38 @@
+package opengl
+
+// DebugView replaces the lit colour of every mesh draw with one of its
+// material inputs, for diagnosing bad normals, UVs and texture channels.
+type DebugView int
+
+const (
+	DebugViewOff   DebugView = iota // normal shading (default)
+	DebugNormals                    // world-space normal after normal mapping, as N × 0.5 + 0.5
+	DebugUV                         // first UV set, fract(u) and fract(v) as red and green
+	DebugRoughness                  // PBR roughness: the texture's G channel, else Material.Roughness
+	DebugMetallic                   // PBR metallic: the texture's B channel, else Material.Metallic
+	DebugAO                         // ambient occlusion: the material's occlusion map times SSAO (white with neither)
+)
+
+// postOutput returns the composite shader's debugOutput mode for v: debug
+// values are shown as written, without tone mapping, and the AO view takes
+// its occlusion from the SSAO pass.
+func (v DebugView) postOutput() int32 {
+	switch v {
+	case DebugViewOff:
+		return 0
+	case DebugAO:
+		return 2
+	}
+	return 1
+}
+
+// SetDebugView selects the material debug view (DebugViewOff to restore
+// normal shading). It applies from the next BeginFrame.
+func (r *Renderer) SetDebugView(v DebugView) {
+	r.debugView = v
+}
+
+// DebugView returns the active material debug view.
+func (r *Renderer) DebugView() DebugView {
+	return r.debugView
+}
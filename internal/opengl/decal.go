@@ -0,0 +1,241 @@
+package opengl
+
+import (
+	"fmt"
+	"unsafe"
+
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+
+	"render-engine/math"
+)
+
+// DecalPass projects textures onto the opaque scene after the main pass,
+// without touching the meshes underneath (screen-space decals):
+//
+//   - Each decal is a box. Its projector matrix maps the unit cube [-1, 1]³
+//     to the box in world space, with local +Z along the surface normal and
+//     local XY spanning the texture.
+//   - The box's back faces are rasterised with depth testing off, so every
+//     pixel whose surface may lie inside the box runs the fragment shader,
+//     even with the camera inside it.
+//   - The fragment shader rebuilds the surface's world position from
+//     PostProcessFBO.DepthTex and the inverse view-projection, moves it into
+//     box space with the inverse projector and discards it outside the box;
+//     local XY becomes the texture coordinate.
+//
+// Decals are written multiplicatively into the HDR colour target: the lit
+// colour is scaled by the decal's colour where its alpha is set. They pick up
+// the surface's lighting and shadows for free but cannot brighten it.
+type DecalPass struct {
+	// fbo holds PostProcessFBO.ColorTex only: the depth texture the shader
+	// samples must not be attached while it is read
+	fbo  uint32
+	vao  uint32
+	vbo  uint32
+	prog uint32
+
+	mvpLoc          int32
+	invViewProjLoc  int32
+	worldToDecalLoc int32
+	normalLoc       int32
+	reverseZLoc     int32
+	viewportLoc     int32
+}
+
+// DecalDraw is one decal for Renderer.DrawDecals.
+type DecalDraw struct {
+	Projector math.Mat4 // unit cube [-1, 1]³ → world; +Z along Normal
+	Normal    math.Vec3 // unit surface normal the decal faces
+	Texture   uint32    // RGBA GL texture; alpha masks the decal
+}
+
+// ── Shaders ───────────────────────────────────────────────────────────────────
+
+const decalVertSrc = `
+#version 410 core
+layout(location = 0) in vec3 inPosition;
+
+uniform mat4 mvp;
+
+void main() {
+    gl_Position = mvp * vec4(inPosition, 1.0);
+}
+` + "\x00"
+
+// decalFragSrc — reconstructs the surface under the pixel and maps it into
+// the decal box. The surface normal comes from the screen-space derivatives
+// of that position (computed before any discard, while all pixels of the quad
+// are still running). Surfaces facing away from the decal get nothing, so a
+// decal on one side of a wall never shows on the other; as a surface turns
+// edge-on to the projection the texture would stretch by 1 / cos θ, so it
+// fades out between cos θ = 0.5 and 0.25 (60°–75°) instead.
+const decalFragSrc = `
+#version 410 core
+out vec4 outColor;
+
+uniform sampler2D depthTex; // unit 0
+uniform sampler2D decalTex; // unit 1
+uniform mat4 invViewProj;
+uniform mat4 worldToDecal;
+uniform vec3 decalNormal;
+uniform bool reverseZ;
+uniform vec2 viewportSize;
+
+void main() {
+    vec2  uv = gl_FragCoord.xy / viewportSize;
+    float d  = texture(depthTex, uv).r;
+    vec4  ndc   = vec4(uv * 2.0 - 1.0, reverseZ ? d : d * 2.0 - 1.0, 1.0);
+    vec4  world = invViewProj * ndc;
+    vec3  P = world.xyz / world.w;
+    vec3  N = normalize(cross(dFdx(P), dFdy(P))); // faces the camera
+
+    if (reverseZ ? d <= 0.0001 : d >= 0.9999) discard; // sky
+    vec3 local = (worldToDecal * vec4(P, 1.0)).xyz;
+    if (any(greaterThan(abs(local), vec3(1.0)))) discard;
+
+    float facing = dot(N, decalNormal);
+    if (facing <= 0.25) discard;
+
+    // Image row 0 (v = 0) goes at the top of the box
+    vec4 c = texture(decalTex, vec2(local.x, -local.y) * 0.5 + 0.5);
+    float a = c.a * smoothstep(0.25, 0.5, facing)
+                  * (1.0 - smoothstep(0.8, 1.0, abs(local.z))); // soft box ends
+    // Blend: dst × (rgb·a) + dst × (1 − a) = dst × mix(1, rgb, a)
+    outColor = vec4(c.rgb * a, a);
+}
+` + "\x00"
+
+// ── Constructor ───────────────────────────────────────────────────────────────
+
+// NewDecalPass compiles the decal shader and uploads the box geometry.
+func NewDecalPass() (*DecalPass, error) {
+	prog, err := newProgram(decalVertSrc, decalFragSrc)
+	if err != nil {
+		return nil, fmt.Errorf("decal shader: %w", err)
+	}
+
+	d := &DecalPass{
+		prog:            prog,
+		mvpLoc:          gl.GetUniformLocation(prog, gl.Str("mvp\x00")),
+		invViewProjLoc:  gl.GetUniformLocation(prog, gl.Str("invViewProj\x00")),
+		worldToDecalLoc: gl.GetUniformLocation(prog, gl.Str("worldToDecal\x00")),
+		normalLoc:       gl.GetUniformLocation(prog, gl.Str("decalNormal\x00")),
+		reverseZLoc:     gl.GetUniformLocation(prog, gl.Str("reverseZ\x00")),
+		viewportLoc:     gl.GetUniformLocation(prog, gl.Str("viewportSize\x00")),
+	}
+	gl.UseProgram(prog)
+	gl.Uniform1i(gl.GetUniformLocation(prog, gl.Str("depthTex\x00")), 0)
+	gl.Uniform1i(gl.GetUniformLocation(prog, gl.Str("decalTex\x00")), 1)
+
+	// Same [-1, 1] cube as the skybox
+	gl.GenVertexArrays(1, &d.vao)
+	gl.GenBuffers(1, &d.vbo)
+	gl.BindVertexArray(d.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, d.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(skyboxVerts)*4, gl.Ptr(skyboxVerts), gl.STATIC_DRAW)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 12, gl.PtrOffset(0))
+	gl.BindVertexArray(0)
+
+	gl.GenFramebuffers(1, &d.fbo)
+	return d, nil
+}
+
+// Destroy frees all GPU resources.
+func (d *DecalPass) Destroy() {
+	gl.DeleteFramebuffers(1, &d.fbo)
+	gl.DeleteVertexArrays(1, &d.vao)
+	gl.DeleteBuffers(1, &d.vbo)
+	gl.DeleteProgram(d.prog)
+}
+
+// draw projects decals onto pp's colour using its depth. The colour texture
+// is attached on every call, so the pass follows PostProcessFBO resizes.
+// Units 0 and 1 are left holding the depth and the last decal texture.
+func (d *DecalPass) draw(pp *PostProcessFBO, view, proj math.Mat4, reverseZ bool, decals []DecalDraw) {
+	pp.resolve()
+	gl.BindFramebuffer(gl.FRAMEBUFFER, d.fbo)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, pp.ColorTex, 0)
+	gl.Viewport(0, 0, pp.Width, pp.Height)
+
+	gl.Disable(gl.DEPTH_TEST)
+	gl.DepthMask(false)
+	gl.Enable(gl.CULL_FACE)
+	gl.CullFace(gl.FRONT)
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.DST_COLOR, gl.ONE_MINUS_SRC_ALPHA)
+
+	viewProj := view.Mul(proj)
+	invViewProj := viewProj.Inverse()
+	gl.UseProgram(d.prog)
+	gl.UniformMatrix4fv(d.invViewProjLoc, 1, false, (*float32)(unsafe.Pointer(&invViewProj[0][0])))
+	if reverseZ {
+		gl.Uniform1i(d.reverseZLoc, 1)
+	} else {
+		gl.Uniform1i(d.reverseZLoc, 0)
+	}
+	gl.Uniform2f(d.viewportLoc, float32(pp.Width), float32(pp.Height))
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, pp.DepthTex)
+
+	gl.BindVertexArray(d.vao)
+	gl.ActiveTexture(gl.TEXTURE1)
+	for _, dc := range decals {
+		mvp := dc.Projector.Mul(viewProj)
+		worldToDecal := dc.Projector.Inverse()
+		gl.UniformMatrix4fv(d.mvpLoc, 1, false, (*float32)(unsafe.Pointer(&mvp[0][0])))
+		gl.UniformMatrix4fv(d.worldToDecalLoc, 1, false, (*float32)(unsafe.Pointer(&worldToDecal[0][0])))
+		gl.Uniform3f(d.normalLoc, dc.Normal.X, dc.Normal.Y, dc.Normal.Z)
+		gl.BindTexture(gl.TEXTURE_2D, dc.Texture)
+		gl.DrawArrays(gl.TRIANGLES, 0, 36)
+	}
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindVertexArray(0)
+
+	gl.Disable(gl.BLEND)
+	gl.CullFace(gl.BACK)
+	gl.Disable(gl.CULL_FACE)
+	gl.DepthMask(true)
+	gl.Enable(gl.DEPTH_TEST)
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, pp.FBO)
+}
+
+// ── Renderer integration ──────────────────────────────────────────────────────
+
+// EnableDecals compiles the decal pass. EnablePostProcess must be called
+// first: decals read the HDR depth texture.
+func (r *Renderer) EnableDecals() error {
+	if r.postProcess == nil {
+		return fmt.Errorf("EnableDecals: EnablePostProcess must be called first")
+	}
+	if r.decals != nil {
+		return nil
+	}
+	d, err := NewDecalPass()
+	if err != nil {
+		return err
+	}
+	r.decals = d
+	return nil
+}
+
+// DrawDecals projects decals onto the scene drawn so far. Call it after the
+// opaque geometry of the main pass; the HDR FBO is bound again afterwards.
+// No-op without EnableDecals, without post-processing or while an off-screen
+// target is bound.
+func (r *Renderer) DrawDecals(view, proj math.Mat4, decals []DecalDraw) {
+	texturesRebound()
+	if r.decals == nil || r.postProcess == nil || r.renderTarget != nil || len(decals) == 0 {
+		return
+	}
+	r.timer.begin("decals")
+	r.decals.draw(r.postProcess, view, proj, r.reverseZ, decals)
+	// Unit 1 is the main shader's shadow map
+	if r.shadowMap != nil {
+		gl.ActiveTexture(gl.TEXTURE1)
+		gl.BindTexture(gl.TEXTURE_2D, r.shadowMap.DepthTex)
+		gl.ActiveTexture(gl.TEXTURE0)
+	}
+	r.timer.begin("scene")
+}
@@ -0,0 +1,92 @@
+package opengl
+
+import (
+	"fmt"
+	"strings"
+
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+
+	"render-engine/math"
+)
+
+// ── Reverse-Z ─────────────────────────────────────────────────────────────────
+
+// clipControlSupported reports whether glClipControl can be called: it is core
+// in GL 4.5 and otherwise needs GL_ARB_clip_control. The 4.1 bindings leave
+// the function pointer nil when the driver does not expose it.
+func clipControlSupported() bool {
+	var major, minor int32
+	gl.GetIntegerv(gl.MAJOR_VERSION, &major)
+	gl.GetIntegerv(gl.MINOR_VERSION, &minor)
+	if major > 4 || (major == 4 && minor >= 5) {
+		return true
+	}
+	return hasExtension("GL_ARB_clip_control")
+}
+
+// hasExtension reports whether the current context exposes the named GL
+// extension.
+func hasExtension(name string) bool {
+	var n int32
+	gl.GetIntegerv(gl.NUM_EXTENSIONS, &n)
+	for i := int32(0); i < n; i++ {
+		if strings.TrimSpace(gl.GoStr(gl.GetStringi(gl.EXTENSIONS, uint32(i)))) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// applyDepthConvention sets clip-space depth range, depth test and clear
+// value for either the standard ([-1, 1], LESS, clear 1) or the reverse-Z
+// ([0, 1], GREATER, clear 0) convention.
+func applyDepthConvention(reverseZ bool) {
+	if reverseZ {
+		gl.ClipControl(gl.LOWER_LEFT, gl.ZERO_TO_ONE)
+		gl.DepthFunc(gl.GREATER)
+		gl.ClearDepth(0)
+		return
+	}
+	gl.ClipControl(gl.LOWER_LEFT, gl.NEGATIVE_ONE_TO_ONE)
+	gl.DepthFunc(gl.LESS)
+	gl.ClearDepth(1)
+}
+
+// depthUnproject returns the matrix that maps (x, y, d*2-1, 1) — the NDC the
+// post-process shaders rebuild from a depth-texture sample d — back through
+// m. With reverse-Z the NDC depth is d itself, so a remap z' → z'*0.5+0.5 is
+// folded in front of the inverse and the shaders stay unchanged.
+func depthUnproject(m math.Mat4, reverseZ bool) math.Mat4 {
+	inv := m.Inverse()
+	if !reverseZ {
+		return inv
+	}
+	remap := math.Mat4Identity()
+	remap[2][2] = 0.5
+	remap[3][2] = 0.5
+	return remap.Mul(inv)
+}
+
+// SetReverseZ switches the depth buffer between the standard convention and
+// reverse-Z (near = 1, far = 0 with a GREATER test), which keeps float depth
+// precise out to the far plane. The camera must use a matching projection
+// (scene.Camera.SetReverseZ). Requires glClipControl (GL 4.5 or
+// GL_ARB_clip_control); returns an error and leaves the mode unchanged
+// otherwise.
+func (r *Renderer) SetReverseZ(enabled bool) error {
+	if enabled == r.reverseZ {
+		return nil
+	}
+	if enabled && !clipControlSupported() {
+		return fmt.Errorf("glClipControl not supported (needs GL 4.5 or GL_ARB_clip_control)")
+	}
+	r.reverseZ = enabled
+	applyDepthConvention(enabled)
+	if r.ssao != nil {
+		r.ssao.ReverseZ = enabled
+	}
+	return nil
+}
+
+// ReverseZ reports whether the reverse-Z depth convention is active.
+func (r *Renderer) ReverseZ() bool { return r.reverseZ }
@@ -0,0 +1,362 @@
+package opengl
+
+import (
+	"fmt"
+	stdmath "math"
+
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+
+	"render-engine/scene"
+)
+
+// Environment holds an equirectangular HDR panorama and the image-based
+// lighting maps convolved from it once at creation (split-sum IBL, Karis 2013):
+//
+//   - IrradianceCube: cosine-weighted hemisphere integral per normal
+//     direction (diffuse IBL), small because irradiance is very low frequency.
+//   - PrefilterCube:  GGX-prefiltered radiance per reflection direction, one
+//     mip level per roughness step from 0 (mip 0) to 1 (last mip).
+//
+// Together with the environment-independent BRDF LUT (see newBRDFLUT) these
+// give the specular term prefiltered(R, roughness) × (F·A + B).
+type Environment struct {
+	EnvTex         uint32 // source panorama (the scene.Texture's GLID; not owned)
+	IrradianceCube uint32
+	PrefilterCube  uint32
+}
+
+const (
+	envIrradianceSize = 32
+	envPrefilterSize  = 128
+	brdfLUTSize       = 512
+
+	// EnvPrefilterMips is the number of roughness levels in PrefilterCube.
+	EnvPrefilterMips = 5
+)
+
+// equirectGLSL maps between directions and equirectangular UVs. Row 0 of a
+// panorama (the top of the image, uploaded first at v = 0) looks straight
+// up; u = 0.5 faces +X. Shared by the skybox and convolution shaders.
+const equirectGLSL = `
+vec2 dirToEquirect(vec3 d) {
+    return vec2(atan(d.z, d.x) / 6.28318531 + 0.5,
+                0.5 - asin(clamp(d.y, -1.0, 1.0)) / 3.14159265);
+}
+
+vec3 equirectToDir(vec2 uv) {
+    float phi = (uv.x - 0.5) * 6.28318531;
+    float el  = (0.5 - uv.y) * 3.14159265;
+    return vec3(cos(el) * cos(phi), sin(el), cos(el) * sin(phi));
+}
+`
+
+// cubeFaceGLSL returns the direction through a cubemap texel while rendering
+// face (0..5 = +X, -X, +Y, -Y, +Z, -Z) with a fullscreen pass, following the
+// face orientation table of the GL spec (§8.13).
+const cubeFaceGLSL = `
+uniform int face;
+
+vec3 cubeFaceDir(vec2 uv) {
+    vec2 st = uv * 2.0 - 1.0;
+    if (face == 0) return normalize(vec3( 1.0, -st.y, -st.x));
+    if (face == 1) return normalize(vec3(-1.0, -st.y,  st.x));
+    if (face == 2) return normalize(vec3( st.x,  1.0,  st.y));
+    if (face == 3) return normalize(vec3( st.x, -1.0, -st.y));
+    if (face == 4) return normalize(vec3( st.x, -st.y,  1.0));
+    return normalize(vec3(-st.x, -st.y, -1.0));
+}
+`
+
+// envIrradianceFragSrc — brute-force hemisphere integral on a fixed
+// (θ, φ) grid around each output texel's normal.
+const envIrradianceFragSrc = `
+#version 410 core
+in  vec2 fragUV;
+out vec4 outColor;
+
+uniform sampler2D envTex;
+uniform float     envLod; // source mip matching the sample spacing
+` + equirectGLSL + cubeFaceGLSL + `
+void main() {
+    vec3 N  = cubeFaceDir(fragUV);
+    vec3 up = abs(N.y) < 0.999 ? vec3(0.0, 1.0, 0.0) : vec3(1.0, 0.0, 0.0);
+    vec3 T  = normalize(cross(up, N));
+    vec3 B  = cross(N, T);
+
+    const float PI    = 3.14159265;
+    const float delta = 0.05;
+    vec3  sum   = vec3(0.0);
+    float count = 0.0;
+    for (float phi = 0.0; phi < 2.0 * PI; phi += delta) {
+        for (float theta = 0.0; theta < 0.5 * PI; theta += delta) {
+            vec3 d = sin(theta) * (cos(phi) * T + sin(phi) * B) + cos(theta) * N;
+            sum   += textureLod(envTex, dirToEquirect(d), envLod).rgb * cos(theta) * sin(theta);
+            count += 1.0;
+        }
+    }
+    outColor = vec4(PI * sum / count, 1.0);
+}
+` + "\x00"
+
+// envPrefilterFragSrc — GGX importance-sampled prefilter (Karis 2013) with
+// the N = V = R assumption. Each sample reads a source mip sized to the
+// sample's solid angle, which removes the bright-pixel fireflies a fixed
+// mip would leave at medium roughness.
+const envPrefilterFragSrc = `
+#version 410 core
+in  vec2 fragUV;
+out vec4 outColor;
+
+uniform sampler2D envTex;
+uniform float     roughness;
+uniform float     envTexelSolidAngle; // 4π / (width × height) of the source
+` + equirectGLSL + cubeFaceGLSL + `
+const float PI = 3.14159265;
+const uint  SAMPLE_COUNT = 256u;
+
+vec2 hammersley(uint i) {
+    return vec2(float(i) / float(SAMPLE_COUNT), float(bitfieldReverse(i)) * 2.3283064365386963e-10);
+}
+
+vec3 importanceSampleGGX(vec2 xi, vec3 N, float a) {
+    float phi      = 2.0 * PI * xi.x;
+    float cosTheta = sqrt((1.0 - xi.y) / (1.0 + (a * a - 1.0) * xi.y));
+    float sinTheta = sqrt(1.0 - cosTheta * cosTheta);
+    vec3  h  = vec3(cos(phi) * sinTheta, sin(phi) * sinTheta, cosTheta);
+    vec3  up = abs(N.y) < 0.999 ? vec3(0.0, 1.0, 0.0) : vec3(1.0, 0.0, 0.0);
+    vec3  T  = normalize(cross(up, N));
+    vec3  B  = cross(N, T);
+    return normalize(T * h.x + B * h.y + N * h.z);
+}
+
+void main() {
+    vec3  N = cubeFaceDir(fragUV);
+    float a = roughness * roughness;
+
+    vec3  sum    = vec3(0.0);
+    float weight = 0.0;
+    for (uint i = 0u; i < SAMPLE_COUNT; i++) {
+        vec3  H   = importanceSampleGGX(hammersley(i), N, a);
+        vec3  L   = normalize(2.0 * dot(N, H) * H - N);
+        float NdL = dot(N, L);
+        if (NdL <= 0.0) continue;
+
+        float NdH = max(dot(N, H), 0.0);
+        float a2  = a * a;
+        float dn  = NdH * NdH * (a2 - 1.0) + 1.0;
+        float D   = a2 / (PI * dn * dn);
+        float pdf = D * 0.25 + 0.0001; // D·NdH / (4·HdV) with N = V
+        float saSample = 1.0 / (float(SAMPLE_COUNT) * pdf + 0.0001);
+        float lod = roughness == 0.0 ? 0.0 : 0.5 * log2(saSample / envTexelSolidAngle);
+
+        sum    += textureLod(envTex, dirToEquirect(L), lod).rgb * NdL;
+        weight += NdL;
+    }
+    outColor = vec4(sum / max(weight, 0.0001), 1.0);
+}
+` + "\x00"
+
+// brdfLUTFragSrc — the environment-independent half of the split sum: for
+// (N·V, roughness) it integrates the GGX BRDF over a white environment and
+// stores the scale A and bias B applied to F0, so specular IBL becomes
+// prefiltered × (F0·A + B).
+const brdfLUTFragSrc = `
+#version 410 core
+in  vec2 fragUV;
+out vec2 outColor;
+
+const float PI = 3.14159265;
+const uint  SAMPLE_COUNT = 512u;
+
+vec2 hammersley(uint i) {
+    return vec2(float(i) / float(SAMPLE_COUNT), float(bitfieldReverse(i)) * 2.3283064365386963e-10);
+}
+
+// Smith-Schlick G1 with the IBL remapping k = a / 2
+float geometrySchlick(float NdX, float a) {
+    float k = a * 0.5;
+    return NdX / (NdX * (1.0 - k) + k);
+}
+
+void main() {
+    float NdV       = max(fragUV.x, 0.001);
+    float roughness = fragUV.y;
+    float a         = roughness * roughness;
+    vec3  V = vec3(sqrt(1.0 - NdV * NdV), 0.0, NdV); // N = +Z
+
+    float A = 0.0;
+    float B = 0.0;
+    for (uint i = 0u; i < SAMPLE_COUNT; i++) {
+        vec2  xi       = hammersley(i);
+        float phi      = 2.0 * PI * xi.x;
+        float cosTheta = sqrt((1.0 - xi.y) / (1.0 + (a * a - 1.0) * xi.y));
+        float sinTheta = sqrt(1.0 - cosTheta * cosTheta);
+        vec3  H = vec3(cos(phi) * sinTheta, sin(phi) * sinTheta, cosTheta);
+        vec3  L = normalize(2.0 * dot(V, H) * H - V);
+
+        float NdL = max(L.z, 0.0);
+        float NdH = max(H.z, 0.0);
+        float VdH = max(dot(V, H), 0.0);
+        if (NdL <= 0.0) continue;
+
+        float G     = geometrySchlick(NdV, a) * geometrySchlick(NdL, a);
+        float G_vis = G * VdH / (NdH * NdV);
+        float Fc    = pow(1.0 - VdH, 5.0);
+        A += (1.0 - Fc) * G_vis;
+        B += Fc * G_vis;
+    }
+    outColor = vec2(A, B) / float(SAMPLE_COUNT);
+}
+` + "\x00"
+
+// NewEnvironment convolves an uploaded HDR panorama (UploadTexture with
+// tex.HDR set) into the irradiance and prefiltered cubemaps. It runs once on
+// the GPU, leaves the default framebuffer bound and does not restore the
+// viewport; the caller resets it.
+func NewEnvironment(tex *scene.Texture) (*Environment, error) {
+	if tex == nil || tex.GLID == 0 {
+		return nil, fmt.Errorf("environment map must be uploaded first")
+	}
+	if len(tex.HDR) == 0 {
+		return nil, fmt.Errorf("environment map %q is not an HDR texture", tex.Name)
+	}
+
+	irrProg, err := newProgram(ppVertSrc, envIrradianceFragSrc)
+	if err != nil {
+		return nil, fmt.Errorf("irradiance shader: %w", err)
+	}
+	defer gl.DeleteProgram(irrProg)
+	preProg, err := newProgram(ppVertSrc, envPrefilterFragSrc)
+	if err != nil {
+		return nil, fmt.Errorf("prefilter shader: %w", err)
+	}
+	defer gl.DeleteProgram(preProg)
+
+	env := &Environment{EnvTex: tex.GLID}
+	env.IrradianceCube = allocEnvCubemap(envIrradianceSize, 1)
+	env.PrefilterCube = allocEnvCubemap(envPrefilterSize, EnvPrefilterMips)
+
+	var fbo, vao uint32
+	gl.GenFramebuffers(1, &fbo)
+	gl.GenVertexArrays(1, &vao)
+	defer gl.DeleteFramebuffers(1, &fbo)
+	defer gl.DeleteVertexArrays(1, &vao)
+
+	// Filter across face edges when the main shader samples the cubes
+	gl.Enable(gl.TEXTURE_CUBE_MAP_SEAMLESS)
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, fbo)
+	gl.BindVertexArray(vao)
+	gl.Disable(gl.DEPTH_TEST)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, tex.GLID)
+
+	// Irradiance: the 0.05 rad grid spacing spans 0.05·W/2π source texels
+	gl.UseProgram(irrProg)
+	gl.Uniform1i(gl.GetUniformLocation(irrProg, gl.Str("envTex\x00")), 0)
+	lod := stdmath.Max(0, stdmath.Log2(0.05*float64(tex.Width)/(2*stdmath.Pi)))
+	gl.Uniform1f(gl.GetUniformLocation(irrProg, gl.Str("envLod\x00")), float32(lod))
+	drawCubeFaces(irrProg, env.IrradianceCube, 0, envIrradianceSize)
+
+	// Prefilter: one roughness per mip
+	gl.UseProgram(preProg)
+	gl.Uniform1i(gl.GetUniformLocation(preProg, gl.Str("envTex\x00")), 0)
+	gl.Uniform1f(gl.GetUniformLocation(preProg, gl.Str("envTexelSolidAngle\x00")),
+		float32(4*stdmath.Pi/float64(tex.Width*tex.Height)))
+	roughLoc := gl.GetUniformLocation(preProg, gl.Str("roughness\x00"))
+	for mip := int32(0); mip < EnvPrefilterMips; mip++ {
+		gl.Uniform1f(roughLoc, float32(mip)/float32(EnvPrefilterMips-1))
+		drawCubeFaces(preProg, env.PrefilterCube, mip, envPrefilterSize>>mip)
+	}
+
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	gl.BindVertexArray(0)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.Enable(gl.DEPTH_TEST)
+	return env, nil
+}
+
+// drawCubeFaces runs prog once per face of cube's mip level, which is size
+// texels square. The program's face uniform selects the direction table.
+func drawCubeFaces(prog, cube uint32, mip, size int32) {
+	faceLoc := gl.GetUniformLocation(prog, gl.Str("face\x00"))
+	gl.Viewport(0, 0, size, size)
+	for face := int32(0); face < 6; face++ {
+		gl.Uniform1i(faceLoc, face)
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0,
+			gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(face), cube, mip)
+		gl.DrawArrays(gl.TRIANGLES, 0, 3)
+	}
+}
+
+// allocEnvCubemap creates an RGBA16F cubemap with mips levels.
+func allocEnvCubemap(size int32, mips int32) uint32 {
+	var tex uint32
+	gl.GenTextures(1, &tex)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, tex)
+	for i := int32(0); i < mips; i++ {
+		for face := uint32(0); face < 6; face++ {
+			gl.TexImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+face, i, gl.RGBA16F, size>>i, size>>i, 0, gl.RGBA, gl.FLOAT, nil)
+		}
+	}
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAX_LEVEL, mips-1)
+	if mips > 1 {
+		gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+	} else {
+		gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	}
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, 0)
+	return tex
+}
+
+// newBRDFLUT renders the split-sum BRDF table (RG16F, N·V along u,
+// roughness along v). It depends on nothing but the BRDF, so the renderer
+// builds it once and shares it between environments. Like NewEnvironment it
+// leaves the default framebuffer bound and the viewport changed.
+func newBRDFLUT() (uint32, error) {
+	prog, err := newProgram(ppVertSrc, brdfLUTFragSrc)
+	if err != nil {
+		return 0, fmt.Errorf("brdf lut shader: %w", err)
+	}
+	defer gl.DeleteProgram(prog)
+
+	var lut uint32
+	gl.GenTextures(1, &lut)
+	gl.BindTexture(gl.TEXTURE_2D, lut)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RG16F, brdfLUTSize, brdfLUTSize, 0, gl.RG, gl.FLOAT, nil)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	var fbo, vao uint32
+	gl.GenFramebuffers(1, &fbo)
+	gl.GenVertexArrays(1, &vao)
+	defer gl.DeleteFramebuffers(1, &fbo)
+	defer gl.DeleteVertexArrays(1, &vao)
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, fbo)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, lut, 0)
+	gl.Viewport(0, 0, brdfLUTSize, brdfLUTSize)
+	gl.Disable(gl.DEPTH_TEST)
+	gl.UseProgram(prog)
+	gl.BindVertexArray(vao)
+	gl.DrawArrays(gl.TRIANGLES, 0, 3)
+
+	gl.BindVertexArray(0)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.Enable(gl.DEPTH_TEST)
+	return lut, nil
+}
+
+// Destroy frees the convolved cubemaps. The source panorama belongs to its
+// scene.Texture and is left alone.
+func (e *Environment) Destroy() {
+	gl.DeleteTextures(1, &e.IrradianceCube)
+	gl.DeleteTextures(1, &e.PrefilterCube)
+}
@@ -0,0 +1,428 @@
+package opengl
+
+import (
+	"fmt"
+	stdmath "math"
+	"unsafe"
+
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+
+	"render-engine/math"
+	"render-engine/scene"
+)
+
+// GPU particles (ParticleEmitter.GPUSimulation) never leave video memory.
+//
+// Each emitter owns a fixed pool of Capacity() particle slots, stored twice
+// (ping-pong): buffers[0] and buffers[1] each hold one gpuParticle per slot.
+// A frame's simulation step reads every slot from buffers[cur] as vertex
+// attributes, runs the simulation vertex shader once per slot (a POINTS draw
+// with rasterisation discarded) and captures the shader's outputs with
+// transform feedback into buffers[1-cur]; cur then flips. A buffer can't be
+// read and captured into by the same draw, hence the pair.
+//
+// The feedback varyings are outPosition, outVelocity and outLife, captured
+// interleaved (GL_INTERLEAVED_ATTRIBS) so the written layout is exactly
+// gpuParticle — the next step and the billboard draw read it back with the
+// same attribute pointers. outLife is (remaining, total) seconds; a slot with
+// remaining <= 0 is dead and drawn as nothing.
+//
+// Spawning walks the pool as a ring: each step the CPU passes the first slot
+// and the number of new particles, and the shader respawns the slots in that
+// range with a hashed random lifetime, speed and direction. With the pool
+// full, the oldest particles are recycled first.
+//
+// The billboards are drawn instanced straight from buffers[cur]: six
+// vertices per instance, corners chosen by gl_VertexID, colour and size
+// derived from the life ratio, and the same fragment shader as CPU particles.
+
+// gpuParticle is one slot's layout in the ping-pong buffers (32 bytes).
+type gpuParticle struct {
+	Position [3]float32
+	Velocity [3]float32
+	Life     [2]float32 // remaining, total seconds
+}
+
+const gpuParticleStride = int32(unsafe.Sizeof(gpuParticle{}))
+
+// ── Shaders ───────────────────────────────────────────────────────────────────
+
+// particleSimVertSrc — one invocation per slot; outputs are captured, never
+// rasterised.
+const particleSimVertSrc = `
+#version 410 core
+layout(location = 0) in vec3 inPosition;
+layout(location = 1) in vec3 inVelocity;
+layout(location = 2) in vec2 inLife;
+
+out vec3 outPosition;
+out vec3 outVelocity;
+out vec2 outLife;
+
+uniform float dt;
+uniform vec3  gravity;
+uniform int   poolSize;
+uniform int   spawnFirst; // first slot to respawn
+uniform int   spawnCount; // slots to respawn, wrapping at poolSize
+uniform uint  seed;       // changes every step
+uniform vec3  emitterPos;
+uniform vec3  emitterDir; // unit
+uniform float cosSpread;  // cos of the cone half-angle
+uniform vec2  lifeRange;
+uniform vec2  speedRange;
+
+uint pcg(uint v) {
+    uint state = v * 747796405u + 2891336453u;
+    uint word  = ((state >> ((state >> 28u) + 4u)) ^ state) * 277803737u;
+    return (word >> 22u) ^ word;
+}
+
+float rand(inout uint s) {
+    s = pcg(s);
+    return float(s) * (1.0 / 4294967295.0);
+}
+
+// Uniform direction in the cone around emitterDir (as scene.randomInCone)
+vec3 coneDirection(inout uint s) {
+    float phi      = rand(s) * 6.28318530718;
+    float cosTheta = mix(cosSpread, 1.0, rand(s));
+    float sinTheta = sqrt(max(1.0 - cosTheta * cosTheta, 0.0));
+    vec3  up       = abs(emitterDir.y) > 0.99 ? vec3(1.0, 0.0, 0.0) : vec3(0.0, 1.0, 0.0);
+    vec3  right    = normalize(cross(emitterDir, up));
+    up = cross(right, emitterDir);
+    return normalize(emitterDir * cosTheta + (right * cos(phi) + up * sin(phi)) * sinTheta);
+}
+
+void main() {
+    if ((gl_VertexID - spawnFirst + poolSize) % poolSize < spawnCount) {
+        uint  s     = pcg(uint(gl_VertexID) ^ pcg(seed));
+        float life  = mix(lifeRange.x, lifeRange.y, rand(s));
+        float speed = mix(speedRange.x, speedRange.y, rand(s));
+        outPosition = emitterPos;
+        outVelocity = coneDirection(s) * speed;
+        outLife     = vec2(life, life);
+        return;
+    }
+
+    float life = inLife.x - dt;
+    if (life <= 0.0) {
+        outPosition = inPosition;
+        outVelocity = inVelocity;
+        outLife     = vec2(0.0, inLife.y);
+        return;
+    }
+    vec3 v = inVelocity + gravity * dt;
+    outPosition = inPosition + v * dt;
+    outVelocity = v;
+    outLife     = vec2(life, inLife.y);
+}
+` + "\x00"
+
+// particleSimFragSrc — required to link; never runs (rasteriser discard).
+const particleSimFragSrc = `
+#version 410 core
+out vec4 outColor;
+void main() { outColor = vec4(0.0); }
+` + "\x00"
+
+// gpuParticleVertSrc — expands each particle instance into a camera-facing
+// quad; feeds particleFragSrc.
+const gpuParticleVertSrc = `
+#version 410 core
+layout(location = 0) in vec3 inPosition; // per instance
+layout(location = 2) in vec2 inLife;     // per instance
+
+uniform mat4 vp;
+uniform vec3 camRight;
+uniform vec3 camUp;
+uniform vec4 startColor;
+uniform vec4 endColor;
+uniform vec2 sizeRange; // min, max half-size
+
+out vec2  fragUV;
+out vec4  fragColor;
+out float fragViewDist;
+
+const vec2 corners[6] = vec2[6](
+    vec2(0.0, 1.0), vec2(1.0, 1.0), vec2(1.0, 0.0),
+    vec2(0.0, 1.0), vec2(1.0, 0.0), vec2(0.0, 0.0));
+
+void main() {
+    vec2 uv = corners[gl_VertexID];
+    fragUV = uv;
+    if (inLife.x <= 0.0) {
+        // Dead slot: a degenerate triangle rasterises nothing
+        gl_Position  = vec4(0.0);
+        fragColor    = vec4(0.0);
+        fragViewDist = 0.0;
+        return;
+    }
+    float t    = 1.0 - inLife.x / inLife.y; // 0 = just born, 1 = about to die
+    float size = mix(sizeRange.y, sizeRange.x, t);
+    vec2  c    = (uv * 2.0 - 1.0) * size;
+    vec3  p    = inPosition + camRight * c.x + camUp * c.y;
+    gl_Position  = vp * vec4(p, 1.0);
+    fragColor    = mix(startColor, endColor, t);
+    fragViewDist = gl_Position.w;
+}
+` + "\x00"
+
+// ── Programs ──────────────────────────────────────────────────────────────────
+
+// gpuParticlePass holds the simulation and draw programs shared by every
+// GPU emitter.
+type gpuParticlePass struct {
+	simProg  uint32
+	drawProg uint32
+
+	dtLoc         int32
+	gravityLoc    int32
+	poolSizeLoc   int32
+	spawnFirstLoc int32
+	spawnCountLoc int32
+	seedLoc       int32
+	emitterPosLoc int32
+	emitterDirLoc int32
+	cosSpreadLoc  int32
+	lifeRangeLoc  int32
+	speedRangeLoc int32
+
+	vpLoc         int32
+	camRightLoc   int32
+	camUpLoc      int32
+	startColorLoc int32
+	endColorLoc   int32
+	sizeRangeLoc  int32
+	softFadeLoc   int32
+	invProjLoc    int32
+}
+
+func newGPUParticlePass() (*gpuParticlePass, error) {
+	simProg, err := newFeedbackProgram(particleSimVertSrc, particleSimFragSrc,
+		"outPosition", "outVelocity", "outLife")
+	if err != nil {
+		return nil, fmt.Errorf("particle simulation shader: %w", err)
+	}
+	drawProg, err := newProgram(gpuParticleVertSrc, particleFragSrc)
+	if err != nil {
+		gl.DeleteProgram(simProg)
+		return nil, fmt.Errorf("gpu particle shader: %w", err)
+	}
+	loc := func(prog uint32, name string) int32 {
+		return gl.GetUniformLocation(prog, gl.Str(name+"\x00"))
+	}
+	p := &gpuParticlePass{
+		simProg:       simProg,
+		drawProg:      drawProg,
+		dtLoc:         loc(simProg, "dt"),
+		gravityLoc:    loc(simProg, "gravity"),
+		poolSizeLoc:   loc(simProg, "poolSize"),
+		spawnFirstLoc: loc(simProg, "spawnFirst"),
+		spawnCountLoc: loc(simProg, "spawnCount"),
+		seedLoc:       loc(simProg, "seed"),
+		emitterPosLoc: loc(simProg, "emitterPos"),
+		emitterDirLoc: loc(simProg, "emitterDir"),
+		cosSpreadLoc:  loc(simProg, "cosSpread"),
+		lifeRangeLoc:  loc(simProg, "lifeRange"),
+		speedRangeLoc: loc(simProg, "speedRange"),
+		vpLoc:         loc(drawProg, "vp"),
+		camRightLoc:   loc(drawProg, "camRight"),
+		camUpLoc:      loc(drawProg, "camUp"),
+		startColorLoc: loc(drawProg, "startColor"),
+		endColorLoc:   loc(drawProg, "endColor"),
+		sizeRangeLoc:  loc(drawProg, "sizeRange"),
+		softFadeLoc:   loc(drawProg, "softFade"),
+		invProjLoc:    loc(drawProg, "invProj"),
+	}
+	gl.UseProgram(drawProg)
+	gl.Uniform1i(loc(drawProg, "particleTex"), 0)
+	gl.Uniform1i(loc(drawProg, "sceneDepth"), 1)
+	gl.Uniform1i(loc(drawProg, "hasParticleTex"), 0)
+	return p, nil
+}
+
+func (p *gpuParticlePass) destroy() {
+	gl.DeleteProgram(p.simProg)
+	gl.DeleteProgram(p.drawProg)
+}
+
+// newFeedbackProgram links a program whose vertex outputs named in varyings
+// are captured, interleaved, by transform feedback. The varyings must be
+// declared before linking, so newProgram can't be used.
+func newFeedbackProgram(vertSrc, fragSrc string, varyings ...string) (uint32, error) {
+	vert, err := compileShader(vertSrc, gl.VERTEX_SHADER)
+	if err != nil {
+		return 0, fmt.Errorf("vertex: %w", err)
+	}
+	frag, err := compileShader(fragSrc, gl.FRAGMENT_SHADER)
+	if err != nil {
+		return 0, fmt.Errorf("fragment: %w", err)
+	}
+
+	prog := gl.CreateProgram()
+	gl.AttachShader(prog, vert)
+	gl.AttachShader(prog, frag)
+	names := make([]string, len(varyings))
+	for i, v := range varyings {
+		names[i] = v + "\x00"
+	}
+	cnames, free := gl.Strs(names...)
+	gl.TransformFeedbackVaryings(prog, int32(len(names)), cnames, gl.INTERLEAVED_ATTRIBS)
+	free()
+	if err := linkProgram(prog); err != nil {
+		return 0, err
+	}
+
+	gl.DeleteShader(vert)
+	gl.DeleteShader(frag)
+	return prog, nil
+}
+
+// ── Per-emitter buffers ───────────────────────────────────────────────────────
+
+// gpuParticleSystem is one emitter's ping-pong particle state.
+type gpuParticleSystem struct {
+	buffers  [2]uint32 // gpuParticle × capacity each
+	simVAO   [2]uint32 // reads buffers[i] per vertex
+	drawVAO  [2]uint32 // reads buffers[i] per instance
+	feedback [2]uint32 // transform feedback objects capturing into buffers[i]
+	cur      int       // buffer holding the latest state
+	capacity int
+	head     int    // next slot to respawn
+	step     uint32 // simulation step counter, seeds the spawn hash
+}
+
+func newGPUParticleSystem(capacity int) *gpuParticleSystem {
+	ps := &gpuParticleSystem{capacity: capacity}
+	// Zeroed slots have no life left: the pool starts empty
+	zero := make([]gpuParticle, capacity)
+	gl.GenBuffers(2, &ps.buffers[0])
+	gl.GenVertexArrays(2, &ps.simVAO[0])
+	gl.GenVertexArrays(2, &ps.drawVAO[0])
+	gl.GenTransformFeedbacks(2, &ps.feedback[0])
+	for i := 0; i < 2; i++ {
+		gl.BindBuffer(gl.ARRAY_BUFFER, ps.buffers[i])
+		gl.BufferData(gl.ARRAY_BUFFER, capacity*int(gpuParticleStride), gl.Ptr(zero), gl.DYNAMIC_COPY)
+
+		for _, vao := range []uint32{ps.simVAO[i], ps.drawVAO[i]} {
+			gl.BindVertexArray(vao)
+			gl.EnableVertexAttribArray(0)
+			gl.VertexAttribPointer(0, 3, gl.FLOAT, false, gpuParticleStride, gl.PtrOffset(0))  // position
+			gl.EnableVertexAttribArray(1)
+			gl.VertexAttribPointer(1, 3, gl.FLOAT, false, gpuParticleStride, gl.PtrOffset(12)) // velocity
+			gl.EnableVertexAttribArray(2)
+			gl.VertexAttribPointer(2, 2, gl.FLOAT, false, gpuParticleStride, gl.PtrOffset(24)) // life
+			if vao == ps.drawVAO[i] {
+				gl.VertexAttribDivisor(0, 1)
+				gl.VertexAttribDivisor(1, 1)
+				gl.VertexAttribDivisor(2, 1)
+			}
+		}
+
+		gl.BindTransformFeedback(gl.TRANSFORM_FEEDBACK, ps.feedback[i])
+		gl.BindBufferBase(gl.TRANSFORM_FEEDBACK_BUFFER, 0, ps.buffers[i])
+	}
+	gl.BindTransformFeedback(gl.TRANSFORM_FEEDBACK, 0)
+	gl.BindVertexArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	return ps
+}
+
+func (ps *gpuParticleSystem) destroy() {
+	gl.DeleteTransformFeedbacks(2, &ps.feedback[0])
+	gl.DeleteVertexArrays(2, &ps.drawVAO[0])
+	gl.DeleteVertexArrays(2, &ps.simVAO[0])
+	gl.DeleteBuffers(2, &ps.buffers[0])
+}
+
+// simulate advances the particles by dt and spawns spawn new ones, capturing
+// buffers[cur] into the other buffer, which becomes current.
+func (ps *gpuParticleSystem) simulate(p *gpuParticlePass, e *scene.ParticleEmitter, dt float32, spawn int) {
+	spawn = min(spawn, ps.capacity)
+	dir := e.Direction.Normalize()
+	ps.step++
+
+	gl.UseProgram(p.simProg)
+	gl.Uniform1f(p.dtLoc, dt)
+	gl.Uniform3f(p.gravityLoc, e.Gravity.X, e.Gravity.Y, e.Gravity.Z)
+	gl.Uniform1i(p.poolSizeLoc, int32(ps.capacity))
+	gl.Uniform1i(p.spawnFirstLoc, int32(ps.head))
+	gl.Uniform1i(p.spawnCountLoc, int32(spawn))
+	gl.Uniform1ui(p.seedLoc, ps.step)
+	gl.Uniform3f(p.emitterPosLoc, e.Position.X, e.Position.Y, e.Position.Z)
+	gl.Uniform3f(p.emitterDirLoc, dir.X, dir.Y, dir.Z)
+	gl.Uniform1f(p.cosSpreadLoc, float32(stdmath.Cos(float64(e.Spread))))
+	gl.Uniform2f(p.lifeRangeLoc, e.MinLife, e.MaxLife)
+	gl.Uniform2f(p.speedRangeLoc, e.MinSpeed, e.MaxSpeed)
+
+	next := 1 - ps.cur
+	gl.Enable(gl.RASTERIZER_DISCARD)
+	gl.BindVertexArray(ps.simVAO[ps.cur])
+	gl.BindTransformFeedback(gl.TRANSFORM_FEEDBACK, ps.feedback[next])
+	gl.BeginTransformFeedback(gl.POINTS)
+	gl.DrawArrays(gl.POINTS, 0, int32(ps.capacity))
+	gl.EndTransformFeedback()
+	gl.BindTransformFeedback(gl.TRANSFORM_FEEDBACK, 0)
+	gl.BindVertexArray(0)
+	gl.Disable(gl.RASTERIZER_DISCARD)
+
+	ps.cur = next
+	ps.head = (ps.head + spawn) % ps.capacity
+}
+
+// drawGPU advances a GPUSimulation emitter by the time Update accumulated
+// and draws its billboards from the simulation buffer. Arguments are as for
+// draw. Builds the shared programs and the emitter's buffers on first use.
+func (pr *ParticleRenderer) drawGPU(emitter *scene.ParticleEmitter, view, proj math.Mat4, sceneDepth uint32, reverseZ bool) error {
+	if emitter.Capacity() <= 0 {
+		return nil
+	}
+	if pr.gpu == nil {
+		p, err := newGPUParticlePass()
+		if err != nil {
+			return err
+		}
+		pr.gpu = p
+	}
+	ps := pr.gpuSystems[emitter]
+	if ps == nil {
+		if pr.gpuSystems == nil {
+			pr.gpuSystems = make(map[*scene.ParticleEmitter]*gpuParticleSystem)
+		}
+		ps = newGPUParticleSystem(emitter.Capacity())
+		pr.gpuSystems[emitter] = ps
+	}
+
+	if dt, spawn := emitter.TakeGPUStep(); dt > 0 || spawn > 0 {
+		ps.simulate(pr.gpu, emitter, dt, spawn)
+	}
+
+	p := pr.gpu
+	camRight, camUp := billboardAxes(view, math.Vec3{})
+	vp := view.Mul(proj)
+	sc, ec := emitter.StartColor, emitter.EndColor
+
+	beginParticleBlend(emitter.BlendMode)
+	gl.UseProgram(p.drawProg)
+	gl.UniformMatrix4fv(p.vpLoc, 1, false, (*float32)(unsafe.Pointer(&vp[0][0])))
+	gl.Uniform3f(p.camRightLoc, camRight.X, camRight.Y, camRight.Z)
+	gl.Uniform3f(p.camUpLoc, camUp.X, camUp.Y, camUp.Z)
+	gl.Uniform4f(p.startColorLoc, sc.R, sc.G, sc.B, sc.A)
+	gl.Uniform4f(p.endColorLoc, ec.R, ec.G, ec.B, ec.A)
+	gl.Uniform2f(p.sizeRangeLoc, emitter.MinSize, emitter.MaxSize)
+	setSoftFade(p.softFadeLoc, p.invProjLoc, emitter.SoftFadeDistance, sceneDepth, proj, reverseZ)
+
+	gl.BindVertexArray(ps.drawVAO[ps.cur])
+	gl.DrawArraysInstanced(gl.TRIANGLES, 0, 6, int32(ps.capacity))
+	gl.BindVertexArray(0)
+	endParticleBlend()
+	return nil
+}
+
+// release frees emitter's GPU particle buffers, if it has any.
+func (pr *ParticleRenderer) release(emitter *scene.ParticleEmitter) {
+	if ps := pr.gpuSystems[emitter]; ps != nil {
+		ps.destroy()
+		delete(pr.gpuSystems, emitter)
+	}
+}
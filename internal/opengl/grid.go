@@ -0,0 +1,222 @@
+package opengl
+
+import (
+	"fmt"
+	"unsafe"
+
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+
+	"render-engine/core"
+	"render-engine/math"
+)
+
+// GroundGrid draws an infinite editor grid on the world Y = 0 plane.
+// A fullscreen triangle is unprojected into one camera ray per pixel; the
+// fragment shader intersects it with the plane and evaluates the grid lines
+// analytically, so the grid never runs out and never needs rebuilding.
+// Lines are anti-aliased with screen-space derivatives and fade with distance.
+type GroundGrid struct {
+	vao  uint32
+	prog uint32
+
+	invViewProjLoc int32
+	viewProjLoc    int32
+	cameraPosLoc   int32
+	spacingLoc     int32
+	majorEveryLoc  int32
+	minorColorLoc  int32
+	majorColorLoc  int32
+	fadeLoc        int32
+	reverseZLoc    int32
+
+	// Spacing is the world-space size of one minor cell.
+	Spacing float32
+	// MajorEvery is the number of minor cells between major lines (default 10).
+	MajorEvery int
+	// MinorColor and MajorColor are the line colours; alpha scales opacity.
+	MinorColor core.Color
+	MajorColor core.Color
+	// FadeDistance is the distance from the camera at which the grid has
+	// faded out completely (default 100). Keep it inside the far plane.
+	FadeDistance float32
+}
+
+// ── Shaders ───────────────────────────────────────────────────────────────────
+
+// gridVertSrc — fullscreen triangle; each corner is unprojected at the near
+// and far clip planes so the interpolated pair defines the pixel's view ray.
+const gridVertSrc = `
+#version 410 core
+uniform mat4 invViewProj;
+uniform bool reverseZ;
+
+out vec3 nearPoint;
+out vec3 farPoint;
+
+vec3 unproject(vec2 xy, float z) {
+    vec4 p = invViewProj * vec4(xy, z, 1.0);
+    return p.xyz / p.w;
+}
+
+void main() {
+    const vec2 pos[3] = vec2[3](
+        vec2(-1.0, -1.0),
+        vec2( 3.0, -1.0),
+        vec2(-1.0,  3.0)
+    );
+    vec2 xy   = pos[gl_VertexID];
+    nearPoint = unproject(xy, reverseZ ? 1.0 : -1.0);
+    farPoint  = unproject(xy, reverseZ ? 0.0 : 1.0);
+    gl_Position = vec4(xy, 0.0, 1.0);
+}
+` + "\x00"
+
+// gridFragSrc — ray/plane intersection, grid lines and depth output.
+const gridFragSrc = `
+#version 410 core
+in vec3 nearPoint;
+in vec3 farPoint;
+
+out vec4 outColor;
+
+uniform mat4  viewProj;
+uniform vec3  cameraPos;
+uniform float spacing;
+uniform float majorEvery;
+uniform vec4  minorColor;
+uniform vec4  majorColor;
+uniform float fadeDistance;
+uniform bool  reverseZ;
+
+// Coverage of the lines at integer coord values, about one pixel wide.
+// Cells narrower than a couple of pixels fade out instead of aliasing.
+float lineCoverage(vec2 coord) {
+    vec2  fw = fwidth(coord);
+    vec2  g  = abs(fract(coord - 0.5) - 0.5) / fw;
+    float lod = 1.0 - smoothstep(0.3, 0.6, max(fw.x, fw.y));
+    return (1.0 - min(min(g.x, g.y), 1.0)) * lod;
+}
+
+void main() {
+    vec3  dir = farPoint - nearPoint;
+    float t   = -nearPoint.y / dir.y;
+    if (dir.y == 0.0 || t <= 0.0 || t > 1.0) discard; // plane behind camera or past far
+
+    vec3 p     = nearPoint + t * dir;
+    vec2 coord = p.xz / spacing;
+
+    float minor = lineCoverage(coord);
+    float major = lineCoverage(coord / majorEvery);
+    vec4  color = vec4(minorColor.rgb, minorColor.a * minor);
+    color = mix(color, majorColor, major);
+
+    // World axes: X axis (z = 0) red, Z axis (x = 0) blue
+    vec2  fw    = fwidth(p.xz);
+    float axisX = 1.0 - min(abs(p.z) / fw.y, 1.0);
+    float axisZ = 1.0 - min(abs(p.x) / fw.x, 1.0);
+    color = mix(color, vec4(0.8, 0.15, 0.15, 1.0), axisX);
+    color = mix(color, vec4(0.15, 0.35, 0.9, 1.0), axisZ);
+
+    color.a *= 1.0 - smoothstep(fadeDistance * 0.5, fadeDistance, length(p - cameraPos));
+    if (color.a <= 0.001) discard;
+
+    // Depth of the hit, nudged slightly toward the camera so the grid wins
+    // against coplanar ground geometry
+    vec4  clip = viewProj * vec4(mix(cameraPos, p, 0.999), 1.0);
+    float z    = clip.z / clip.w;
+    gl_FragDepth = reverseZ ? z : z * 0.5 + 0.5;
+    outColor = color;
+}
+` + "\x00"
+
+// ── Constructor ───────────────────────────────────────────────────────────────
+
+// NewGroundGrid compiles the grid shader. The grid starts with 1-unit cells,
+// a major line every 10 cells and a 100-unit fade.
+func NewGroundGrid() (*GroundGrid, error) {
+	prog, err := newProgram(gridVertSrc, gridFragSrc)
+	if err != nil {
+		return nil, fmt.Errorf("ground grid shader: %w", err)
+	}
+
+	g := &GroundGrid{
+		prog:           prog,
+		invViewProjLoc: gl.GetUniformLocation(prog, gl.Str("invViewProj\x00")),
+		viewProjLoc:    gl.GetUniformLocation(prog, gl.Str("viewProj\x00")),
+		cameraPosLoc:   gl.GetUniformLocation(prog, gl.Str("cameraPos\x00")),
+		spacingLoc:     gl.GetUniformLocation(prog, gl.Str("spacing\x00")),
+		majorEveryLoc:  gl.GetUniformLocation(prog, gl.Str("majorEvery\x00")),
+		minorColorLoc:  gl.GetUniformLocation(prog, gl.Str("minorColor\x00")),
+		majorColorLoc:  gl.GetUniformLocation(prog, gl.Str("majorColor\x00")),
+		fadeLoc:        gl.GetUniformLocation(prog, gl.Str("fadeDistance\x00")),
+		reverseZLoc:    gl.GetUniformLocation(prog, gl.Str("reverseZ\x00")),
+
+		Spacing:      1,
+		MajorEvery:   10,
+		MinorColor:   core.Color{R: 0.35, G: 0.35, B: 0.35, A: 1},
+		MajorColor:   core.Color{R: 0.55, G: 0.55, B: 0.55, A: 1},
+		FadeDistance: 100,
+	}
+
+	// Fullscreen-triangle VAO (no vertex data, uses gl_VertexID)
+	gl.GenVertexArrays(1, &g.vao)
+
+	return g, nil
+}
+
+// SetColor sets the minor line colour and derives a brighter major colour.
+func (g *GroundGrid) SetColor(color core.Color) {
+	g.MinorColor = color
+	g.MajorColor = color.Lerp(core.ColorWhite, 0.3)
+	g.MajorColor.A = color.A
+}
+
+// ── Draw ──────────────────────────────────────────────────────────────────────
+
+// Draw renders the grid over the current depth buffer. It depth-tests against
+// the scene but does not write depth, so draw it after opaque geometry.
+// reverseZ must match the renderer's depth convention.
+func (g *GroundGrid) Draw(view, proj math.Mat4, camPos math.Vec3, reverseZ bool) {
+	spacing := g.Spacing
+	if spacing <= 0 {
+		spacing = 1
+	}
+	majorEvery := g.MajorEvery
+	if majorEvery < 1 {
+		majorEvery = 1
+	}
+	viewProj := view.Mul(proj)
+	invViewProj := viewProj.Inverse()
+
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	gl.DepthMask(false)
+
+	gl.UseProgram(g.prog)
+	gl.UniformMatrix4fv(g.invViewProjLoc, 1, false, (*float32)(unsafe.Pointer(&invViewProj[0][0])))
+	gl.UniformMatrix4fv(g.viewProjLoc, 1, false, (*float32)(unsafe.Pointer(&viewProj[0][0])))
+	gl.Uniform3f(g.cameraPosLoc, camPos.X, camPos.Y, camPos.Z)
+	gl.Uniform1f(g.spacingLoc, spacing)
+	gl.Uniform1f(g.majorEveryLoc, float32(majorEvery))
+	gl.Uniform4f(g.minorColorLoc, g.MinorColor.R, g.MinorColor.G, g.MinorColor.B, g.MinorColor.A)
+	gl.Uniform4f(g.majorColorLoc, g.MajorColor.R, g.MajorColor.G, g.MajorColor.B, g.MajorColor.A)
+	gl.Uniform1f(g.fadeLoc, g.FadeDistance)
+	if reverseZ {
+		gl.Uniform1i(g.reverseZLoc, 1)
+	} else {
+		gl.Uniform1i(g.reverseZLoc, 0)
+	}
+
+	gl.BindVertexArray(g.vao)
+	gl.DrawArrays(gl.TRIANGLES, 0, 3)
+	gl.BindVertexArray(0)
+
+	gl.DepthMask(true)
+	gl.Disable(gl.BLEND)
+}
+
+// Destroy frees the grid's GPU resources.
+func (g *GroundGrid) Destroy() {
+	gl.DeleteVertexArrays(1, &g.vao)
+	gl.DeleteProgram(g.prog)
+}
@@ -0,0 +1,82 @@
+package opengl
+
+import (
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+
+	"render-engine/math"
+	"render-engine/scene"
+)
+
+// Instance material variants let one instanced draw mix a few materials: the
+// variant table (albedo + opacity, metallic + roughness for up to
+// scene.MaxInstanceMaterials entries) is uploaded as uniform arrays and each
+// instance carries an index into it in a second per-instance buffer, read at
+// attribute location 15. Everything else (textures, Phong/PBR, emissive)
+// comes from the draw's material, so variants are cheap tints rather than
+// full materials.
+
+// packInstanceMaterials flattens set into the shader's variant tables. Entries
+// past scene.MaxInstanceMaterials are dropped; nil entries and unused slots
+// get the default material.
+func packInstanceMaterials(set []*scene.Material) (albedo [scene.MaxInstanceMaterials][4]float32, metalRough [scene.MaxInstanceMaterials][2]float32) {
+	def := scene.DefaultMaterial()
+	for i := range albedo {
+		m := def
+		if i < len(set) && set[i] != nil {
+			m = set[i]
+		}
+		albedo[i] = [4]float32{m.Albedo.R, m.Albedo.G, m.Albedo.B, 1}
+		if m.Transparent {
+			albedo[i][3] = m.Albedo.A
+		}
+		metalRough[i] = [2]float32{m.Metallic, m.Roughness}
+	}
+	return albedo, metalRough
+}
+
+// instanceVariantIndices returns count per-instance indices into a variant
+// table of setLen entries: indices[i], or 0 when it is missing or out of
+// range.
+func instanceVariantIndices(indices []uint32, setLen, count int) []uint32 {
+	limit := uint32(min(setLen, scene.MaxInstanceMaterials))
+	out := make([]uint32, count)
+	for i := range out {
+		if i < len(indices) && indices[i] < limit {
+			out[i] = indices[i]
+		}
+	}
+	return out
+}
+
+// uploadVariantVBO uploads the per-instance variant indices to the mesh's
+// variant VBO, creating it and wiring location 15 into the VAO on first call.
+// The attribute array is left disabled: drawInstanced enables it around the
+// draws that use it.
+func (r *Renderer) uploadVariantVBO(gpu *GPUMesh, indices []uint32) {
+	if gpu.VariantVBO == 0 {
+		gl.GenBuffers(1, &gpu.VariantVBO)
+		gl.BindVertexArray(gpu.VAO)
+		gl.BindBuffer(gl.ARRAY_BUFFER, gpu.VariantVBO)
+		gl.VertexAttribIPointer(15, 1, gl.UNSIGNED_INT, 4, gl.PtrOffset(0))
+		gl.VertexAttribDivisor(15, 1)
+		gl.BindVertexArray(0)
+	}
+
+	byteSize := len(indices) * 4
+	gl.BindBuffer(gl.ARRAY_BUFFER, gpu.VariantVBO)
+	if len(indices) > gpu.VariantCap {
+		gl.BufferData(gl.ARRAY_BUFFER, byteSize, gl.Ptr(indices), gl.DYNAMIC_DRAW)
+		gpu.VariantCap = len(indices)
+	} else {
+		gl.BufferSubData(gl.ARRAY_BUFFER, 0, byteSize, gl.Ptr(indices))
+	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+}
+
+// DrawMeshInstancedVariants is DrawMeshInstancedWithMaterial with per-instance
+// material variants: instance i takes its albedo, opacity, metallic and
+// roughness from set[variants[i]] (see scene.Node.InstanceMaterialSet) and
+// the rest from mat. Still one draw call. An empty set draws plain instances.
+func (r *Renderer) DrawMeshInstancedVariants(mesh *scene.Mesh, mat *scene.Material, view, proj math.Mat4, models []math.Mat4, set []*scene.Material, variants []uint32) {
+	r.drawInstanced(mesh, mat, view, proj, models, set, variants)
+}
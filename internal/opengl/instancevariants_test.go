@@ -0,0 +1,36 @@
+package opengl
+
+import (
+	"testing"
+
+	"render-engine/core"
+	"render-engine/scene"
+)
+
+func TestPackInstanceMaterials(t *testing.T) {
+	red := &scene.Material{Albedo: core.Color{R: 1, A: 1}, Metallic: 1, Roughness: 0.2}
+	blue := &scene.Material{Albedo: core.Color{B: 1, A: 0.5}, Roughness: 0.9}
+	albedo, metalRough := packInstanceMaterials([]*scene.Material{red, blue})
+
+	// Three instances: red, blue and an out-of-range index falling back to 0
+	indices := instanceVariantIndices([]uint32{0, 1, 9}, 2, 3)
+	want := [][4]float32{{1, 0, 0, 1}, {0, 0, 1, 1}, {1, 0, 0, 1}}
+	for i, idx := range indices {
+		if albedo[idx] != want[i] {
+			t.Errorf("instance %d: expected albedo %v, got %v (index %d)", i, want[i], albedo[idx], idx)
+		}
+	}
+	if metalRough[1] != [2]float32{0, 0.9} {
+		t.Errorf("blue metallic/roughness: expected [0 0.9], got %v", metalRough[1])
+	}
+	// Alpha is opacity only for transparent materials, like Material.Albedo
+	blue.Transparent = true
+	if albedo, _ = packInstanceMaterials([]*scene.Material{red, blue}); albedo[1][3] != 0.5 {
+		t.Errorf("transparent blue: expected opacity 0.5, got %g", albedo[1][3])
+	}
+
+	// Indices past the table are clamped even when the set is larger
+	if got := instanceVariantIndices([]uint32{8, 7}, 12, 2); got[0] != 0 || got[1] != 7 {
+		t.Errorf("expected [0 7] with an 8-entry table, got %v", got)
+	}
+}
@@ -0,0 +1,83 @@
+package opengl
+
+import (
+	"render-engine/core"
+	"render-engine/scene"
+)
+
+// materialState is everything applyMaterial uploads for a material: its
+// shader parameters and the GL textures bound for it (0 = none). Two draws
+// with equal states need only one upload.
+type materialState struct {
+	albedo, specular, emissive core.Color
+	shininess, metallic        float32
+	roughness, bloomScale      float32
+	pbr, unlit, flat           bool
+	vertexColor, transparent   bool
+
+	// albedo, normal, metallic-roughness, emissive, lightmap, specular,
+	// opacity, occlusion
+	textures [8]uint32
+}
+
+func materialStateOf(mat *scene.Material) materialState {
+	glid := func(tex *scene.Texture) uint32 {
+		if tex == nil {
+			return 0
+		}
+		return tex.GLID
+	}
+	return materialState{
+		albedo:      mat.Albedo,
+		specular:    mat.Specular,
+		emissive:    mat.EmissiveColor,
+		shininess:   mat.Shininess,
+		metallic:    mat.Metallic,
+		roughness:   mat.Roughness,
+		bloomScale:  mat.BloomScale,
+		pbr:         mat.UsePBR,
+		unlit:       mat.Unlit,
+		flat:        mat.FlatShading,
+		vertexColor: mat.UseVertexColor,
+		transparent: mat.Transparent,
+		textures: [8]uint32{
+			glid(mat.AlbedoTexture),
+			glid(mat.NormalTexture),
+			glid(mat.MetallicRoughnessTexture),
+			glid(mat.EmissiveTexture),
+			glid(mat.LightmapTexture),
+			glid(mat.SpecularTexture),
+			glid(mat.OpacityTexture),
+			glid(mat.OcclusionTexture),
+		},
+	}
+}
+
+// textureEpoch changes whenever code other than applyMaterial may have
+// changed texture bindings (uploads, deletes, and every pass that samples
+// its own textures), so the next applyMaterial binds its textures again.
+var textureEpoch uint64
+
+func texturesRebound() { textureEpoch++ }
+
+// materialChanged reports whether mat needs uploading: it differs from the
+// last material applied to the main program, or the texture bindings have
+// been disturbed since. It records mat as the bound material.
+func (r *Renderer) materialChanged(mat *scene.Material) bool {
+	st := materialStateOf(mat)
+	if r.materialBound && st == r.boundMaterial && r.boundTexEpoch == textureEpoch {
+		return false
+	}
+	r.boundMaterial = st
+	r.boundTexEpoch = textureEpoch
+	r.materialBound = true
+	r.materialBinds++
+	return true
+}
+
+// MaterialBinds returns how many material uploads applyMaterial made since
+// the last BeginFrame; draws sharing the previous draw's material skip
+// theirs.
+func (r *Renderer) MaterialBinds() int {
+	return r.materialBinds
+}
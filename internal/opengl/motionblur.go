@@ -0,0 +1,441 @@
+package opengl
+
+import (
+	"fmt"
+	"unsafe"
+
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+
+	"render-engine/math"
+	"render-engine/scene"
+)
+
+// MotionBlur implements per-object motion blur in two steps:
+//
+//  1. Velocity pass: scene geometry is drawn again into VelocityTex (RG16F)
+//     with both its current and its previous-frame MVP. The fragment shader
+//     writes the screen-space motion of the surface since the last frame in
+//     UV units.
+//  2. Blur pass: the HDR colour is averaged along each pixel's velocity into
+//     blurTex and copied back over PostProcessFBO.ColorTex, so bloom and tone
+//     mapping see the blurred image.
+//
+// Pixels no geometry covers (sky) keep zero velocity and stay sharp.
+type MotionBlur struct {
+	// velFBO/VelocityTex — per-pixel motion, depth-tested against velDepth
+	velFBO      uint32
+	VelocityTex uint32
+	velDepth    uint32 // DEPTH_COMPONENT32F renderbuffer (float for reverse-Z)
+
+	// blurFBO/blurTex — blurred HDR colour (RGBA16F)
+	blurFBO uint32
+	blurTex uint32
+
+	width, height int32
+
+	// Velocity pass shader
+	velProg             uint32
+	velMVPLoc           int32
+	velPrevMVPLoc       int32
+	velInstancedLoc     int32
+	velPrevFromWorldLoc int32
+
+	// Blur pass shader
+	blurProg       uint32
+	blurSamplesLoc int32
+	blurStrLoc     int32
+	blurMaxLenLoc  int32
+
+	// Fullscreen triangle VAO (no VBO needed)
+	quadVAO uint32
+
+	// Samples is the number of colour taps along the velocity per pixel
+	// (clamped to [2, MaxMotionBlurSamples]).
+	Samples int
+	// Strength scales the blur length: 1 smears across the full motion since
+	// the previous frame, 0.5 matches a half-frame (180°) shutter.
+	Strength float32
+	// MaxLength caps the blur length as a fraction of the screen (default
+	// 0.05), so a sudden jump does not smear the whole image.
+	MaxLength float32
+}
+
+// MaxMotionBlurSamples caps MotionBlur.Samples.
+const MaxMotionBlurSamples = 32
+
+// motionBlurSamples clamps a requested tap count to [2, MaxMotionBlurSamples].
+func motionBlurSamples(n int) int {
+	if n < 2 {
+		return 2
+	}
+	if n > MaxMotionBlurSamples {
+		return MaxMotionBlurSamples
+	}
+	return n
+}
+
+// ── Shaders ───────────────────────────────────────────────────────────────────
+
+// velocityVertSrc — current and previous clip positions per vertex. Instanced
+// draws read the current MVP and the instance world matrix from the same
+// attribute slots as the main shader; prevFromWorld carries the instance from
+// this frame's world space to the previous frame's clip space.
+const velocityVertSrc = `
+#version 410 core
+layout(location = 0)  in vec3 inPosition;
+layout(location = 6)  in vec4 instMVP0;
+layout(location = 7)  in vec4 instMVP1;
+layout(location = 8)  in vec4 instMVP2;
+layout(location = 9)  in vec4 instMVP3;
+layout(location = 10) in vec4 instModel0;
+layout(location = 11) in vec4 instModel1;
+layout(location = 12) in vec4 instModel2;
+layout(location = 13) in vec4 instModel3;
+
+uniform mat4 mvp;
+uniform mat4 prevMVP;
+uniform bool instanced;
+uniform mat4 prevFromWorld;
+
+out vec4 curClip;
+out vec4 prevClip;
+
+void main() {
+    vec4 p = vec4(inPosition, 1.0);
+    if (instanced) {
+        mat4 iMVP   = mat4(instMVP0,   instMVP1,   instMVP2,   instMVP3);
+        mat4 iModel = mat4(instModel0, instModel1, instModel2, instModel3);
+        curClip  = iMVP * p;
+        prevClip = prevFromWorld * (iModel * p);
+    } else {
+        curClip  = mvp * p;
+        prevClip = prevMVP * p;
+    }
+    gl_Position = curClip;
+}
+` + "\x00"
+
+// velocityFragSrc — screen-space motion since the previous frame in UV units
+// (half the NDC difference). Surfaces that were behind the camera last frame
+// have no meaningful previous position and get zero.
+const velocityFragSrc = `
+#version 410 core
+in  vec4 curClip;
+in  vec4 prevClip;
+out vec2 outVelocity;
+
+void main() {
+    if (prevClip.w <= 0.0) { outVelocity = vec2(0.0); return; }
+    vec2 cur  = curClip.xy / curClip.w;
+    vec2 prev = prevClip.xy / prevClip.w;
+    outVelocity = (cur - prev) * 0.5;
+}
+` + "\x00"
+
+// motionBlurFragSrc — averages samples taps spread evenly along the scaled
+// velocity, centred on the pixel. Motion under half a pixel is left alone.
+const motionBlurFragSrc = `
+#version 410 core
+in  vec2 fragUV;
+out vec4 outColor;
+
+uniform sampler2D hdrBuffer;   // unit 0
+uniform sampler2D velocityTex; // unit 1
+uniform int   samples;
+uniform float strength;
+uniform float maxLength;
+
+void main() {
+    vec3 color = texture(hdrBuffer, fragUV).rgb;
+    vec2 v     = texture(velocityTex, fragUV).rg * strength;
+    float len  = length(v);
+    if (len > maxLength) {
+        v *= maxLength / len;
+    }
+    vec2 px = v * vec2(textureSize(hdrBuffer, 0));
+    if (dot(px, px) < 0.25) {
+        outColor = vec4(color, 1.0);
+        return;
+    }
+
+    vec3 sum = vec3(0.0);
+    for (int i = 0; i < samples; i++) {
+        float t = float(i) / float(samples - 1) - 0.5;
+        sum += texture(hdrBuffer, fragUV + v * t).rgb;
+    }
+    outColor = vec4(sum / float(samples), 1.0);
+}
+` + "\x00"
+
+// ── Constructor ───────────────────────────────────────────────────────────────
+
+// NewMotionBlur compiles the velocity and blur shaders and allocates the
+// width × height targets.
+func NewMotionBlur(width, height int) (*MotionBlur, error) {
+	vp, err := newProgram(velocityVertSrc, velocityFragSrc)
+	if err != nil {
+		return nil, fmt.Errorf("velocity shader: %w", err)
+	}
+	bp, err := newProgram(ppVertSrc, motionBlurFragSrc)
+	if err != nil {
+		gl.DeleteProgram(vp)
+		return nil, fmt.Errorf("motion blur shader: %w", err)
+	}
+
+	m := &MotionBlur{
+		velProg:             vp,
+		velMVPLoc:           gl.GetUniformLocation(vp, gl.Str("mvp\x00")),
+		velPrevMVPLoc:       gl.GetUniformLocation(vp, gl.Str("prevMVP\x00")),
+		velInstancedLoc:     gl.GetUniformLocation(vp, gl.Str("instanced\x00")),
+		velPrevFromWorldLoc: gl.GetUniformLocation(vp, gl.Str("prevFromWorld\x00")),
+
+		blurProg:       bp,
+		blurSamplesLoc: gl.GetUniformLocation(bp, gl.Str("samples\x00")),
+		blurStrLoc:     gl.GetUniformLocation(bp, gl.Str("strength\x00")),
+		blurMaxLenLoc:  gl.GetUniformLocation(bp, gl.Str("maxLength\x00")),
+
+		Samples:   8,
+		Strength:  1,
+		MaxLength: 0.05,
+	}
+	gl.UseProgram(bp)
+	gl.Uniform1i(gl.GetUniformLocation(bp, gl.Str("hdrBuffer\x00")), 0)
+	gl.Uniform1i(gl.GetUniformLocation(bp, gl.Str("velocityTex\x00")), 1)
+
+	gl.GenVertexArrays(1, &m.quadVAO)
+
+	m.allocFBOs(width, height)
+	return m, nil
+}
+
+// ── Target lifecycle ──────────────────────────────────────────────────────────
+
+func (m *MotionBlur) allocFBOs(width, height int) {
+	m.width, m.height = int32(width), int32(height)
+
+	// Velocity: RG16F colour + its own depth so only the nearest surface's
+	// motion survives
+	gl.GenTextures(1, &m.VelocityTex)
+	gl.BindTexture(gl.TEXTURE_2D, m.VelocityTex)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RG16F,
+		m.width, m.height, 0, gl.RG, gl.HALF_FLOAT, nil)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	gl.GenRenderbuffers(1, &m.velDepth)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, m.velDepth)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT32F, m.width, m.height)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+
+	gl.GenFramebuffers(1, &m.velFBO)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, m.velFBO)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0,
+		gl.TEXTURE_2D, m.VelocityTex, 0)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT,
+		gl.RENDERBUFFER, m.velDepth)
+	if s := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); s != gl.FRAMEBUFFER_COMPLETE {
+		fmt.Printf("WARNING: velocity FBO incomplete (0x%X)\n", s)
+	}
+
+	// Blurred colour, same format as the HDR colour target it is copied into
+	gl.GenTextures(1, &m.blurTex)
+	gl.BindTexture(gl.TEXTURE_2D, m.blurTex)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA16F,
+		m.width, m.height, 0, gl.RGBA, gl.HALF_FLOAT, nil)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	gl.GenFramebuffers(1, &m.blurFBO)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, m.blurFBO)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0,
+		gl.TEXTURE_2D, m.blurTex, 0)
+	if s := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); s != gl.FRAMEBUFFER_COMPLETE {
+		fmt.Printf("WARNING: motion blur FBO incomplete (0x%X)\n", s)
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+}
+
+func (m *MotionBlur) freeFBOs() {
+	for _, fbo := range []*uint32{&m.velFBO, &m.blurFBO} {
+		if *fbo != 0 {
+			gl.DeleteFramebuffers(1, fbo)
+			*fbo = 0
+		}
+	}
+	for _, tex := range []*uint32{&m.VelocityTex, &m.blurTex} {
+		if *tex != 0 {
+			gl.DeleteTextures(1, tex)
+			*tex = 0
+		}
+	}
+	if m.velDepth != 0 {
+		gl.DeleteRenderbuffers(1, &m.velDepth)
+		m.velDepth = 0
+	}
+}
+
+// Resize recreates the velocity and blur targets at the new size.
+func (m *MotionBlur) Resize(width, height int) {
+	m.freeFBOs()
+	m.allocFBOs(width, height)
+}
+
+// Destroy frees all GPU resources.
+func (m *MotionBlur) Destroy() {
+	m.freeFBOs()
+	for _, prog := range []*uint32{&m.velProg, &m.blurProg} {
+		if *prog != 0 {
+			gl.DeleteProgram(*prog)
+			*prog = 0
+		}
+	}
+	if m.quadVAO != 0 {
+		gl.DeleteVertexArrays(1, &m.quadVAO)
+		m.quadVAO = 0
+	}
+}
+
+// ── Blur pass ─────────────────────────────────────────────────────────────────
+
+// apply blurs pp.ColorTex along VelocityTex and copies the result back into
+// it. Only colour attachment 0 of the HDR FBO is written.
+func (m *MotionBlur) apply(pp *PostProcessFBO) {
+	gl.Disable(gl.DEPTH_TEST)
+	gl.BindVertexArray(m.quadVAO)
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, m.blurFBO)
+	gl.Viewport(0, 0, m.width, m.height)
+	gl.UseProgram(m.blurProg)
+	gl.Uniform1i(m.blurSamplesLoc, int32(motionBlurSamples(m.Samples)))
+	gl.Uniform1f(m.blurStrLoc, m.Strength)
+	gl.Uniform1f(m.blurMaxLenLoc, m.MaxLength)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, pp.ColorTex)
+	gl.ActiveTexture(gl.TEXTURE1)
+	gl.BindTexture(gl.TEXTURE_2D, m.VelocityTex)
+	gl.DrawArrays(gl.TRIANGLES, 0, 3)
+	gl.ActiveTexture(gl.TEXTURE0)
+
+	// Copy back: the HDR FBO draws to both attachments, so narrow it to
+	// the colour target for the blit and restore it afterwards
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, m.blurFBO)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, pp.FBO)
+	gl.DrawBuffer(gl.COLOR_ATTACHMENT0)
+	gl.BlitFramebuffer(0, 0, m.width, m.height, 0, 0, pp.Width, pp.Height,
+		gl.COLOR_BUFFER_BIT, gl.NEAREST)
+	drawBufs := [2]uint32{gl.COLOR_ATTACHMENT0, gl.COLOR_ATTACHMENT1}
+	gl.DrawBuffers(2, &drawBufs[0])
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	gl.BindVertexArray(0)
+	gl.Enable(gl.DEPTH_TEST)
+}
+
+// ── Renderer integration ──────────────────────────────────────────────────────
+
+// EnableMotionBlur creates the motion blur pipeline (or retunes it) with
+// samples taps per pixel and the given strength. EnablePostProcess must be
+// called first.
+func (r *Renderer) EnableMotionBlur(samples int, strength float32) error {
+	if r.postProcess == nil {
+		return fmt.Errorf("EnableMotionBlur: EnablePostProcess must be called first")
+	}
+	if r.motionBlur == nil {
+		m, err := NewMotionBlur(int(r.postProcess.Width), int(r.postProcess.Height))
+		if err != nil {
+			return err
+		}
+		r.motionBlur = m
+	}
+	r.motionBlur.Samples = samples
+	r.motionBlur.Strength = strength
+	return nil
+}
+
+// DisableMotionBlur frees the motion blur pipeline.
+func (r *Renderer) DisableMotionBlur() {
+	if r.motionBlur != nil {
+		r.motionBlur.Destroy()
+		r.motionBlur = nil
+	}
+}
+
+// HasMotionBlur reports whether motion blur is active.
+func (r *Renderer) HasMotionBlur() bool { return r.motionBlur != nil }
+
+// BeginVelocityPass binds and clears the velocity target. Returns false (and
+// does nothing) when motion blur is off or an off-screen target is bound, in
+// which case the velocity draws must be skipped.
+func (r *Renderer) BeginVelocityPass() bool {
+	if r.motionBlur == nil || r.renderTarget != nil {
+		return false
+	}
+	r.timer.begin("velocity")
+	m := r.motionBlur
+	gl.BindFramebuffer(gl.FRAMEBUFFER, m.velFBO)
+	gl.Viewport(0, 0, m.width, m.height)
+	gl.ClearColor(0, 0, 0, 0)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	gl.UseProgram(m.velProg)
+	gl.Uniform1i(m.velInstancedLoc, 0)
+	return true
+}
+
+// DrawMeshVelocity draws mesh into the velocity target with this frame's and
+// the previous frame's MVP.
+func (r *Renderer) DrawMeshVelocity(mesh *scene.Mesh, mvp, prevMVP math.Mat4) {
+	gpu := r.ensureUploaded(mesh)
+	if gpu == nil {
+		return
+	}
+	m := r.motionBlur
+	gl.UniformMatrix4fv(m.velMVPLoc, 1, false, (*float32)(unsafe.Pointer(&mvp[0][0])))
+	gl.UniformMatrix4fv(m.velPrevMVPLoc, 1, false, (*float32)(unsafe.Pointer(&prevMVP[0][0])))
+	gl.BindVertexArray(gpu.VAO)
+	if gpu.HasIndices {
+		gl.DrawElements(gl.TRIANGLES, gpu.IndexCount, gl.UNSIGNED_INT, nil)
+	} else {
+		gl.DrawArrays(gl.TRIANGLES, 0, int32(len(mesh.Vertices)))
+	}
+	gl.BindVertexArray(0)
+}
+
+// DrawMeshVelocityInstanced draws mesh once per world matrix in models into
+// the velocity target. prevFromWorld maps this frame's world space to the
+// previous frame's clip space for every instance.
+func (r *Renderer) DrawMeshVelocityInstanced(mesh *scene.Mesh, viewProj, prevFromWorld math.Mat4, models []math.Mat4) {
+	if len(models) == 0 {
+		return
+	}
+	gpu := r.ensureUploaded(mesh)
+	if gpu == nil {
+		return
+	}
+	r.uploadInstanceVBO(gpu, instanceBuffer(models, viewProj), len(models))
+
+	m := r.motionBlur
+	gl.Uniform1i(m.velInstancedLoc, 1)
+	gl.UniformMatrix4fv(m.velPrevFromWorldLoc, 1, false, (*float32)(unsafe.Pointer(&prevFromWorld[0][0])))
+	gl.BindVertexArray(gpu.VAO)
+	if gpu.HasIndices {
+		gl.DrawElementsInstanced(gl.TRIANGLES, gpu.IndexCount, gl.UNSIGNED_INT, nil, int32(len(models)))
+	} else {
+		gl.DrawArraysInstanced(gl.TRIANGLES, 0, int32(len(mesh.Vertices)), int32(len(models)))
+	}
+	gl.BindVertexArray(0)
+	gl.Uniform1i(m.velInstancedLoc, 0)
+}
+
+// EndVelocityPass rebinds the HDR FBO so later scene draws (gizmos, debug
+// overlays, particles) land in the frame again.
+func (r *Renderer) EndVelocityPass() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, r.postProcess.sceneFBO())
+	gl.Viewport(0, 0, r.postProcess.Width, r.postProcess.Height)
+	r.timer.begin("scene")
+}
@@ -0,0 +1,151 @@
+package opengl
+
+import (
+	"fmt"
+
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// ── MSAA ──────────────────────────────────────────────────────────────────────
+//
+// With multisampling on, the HDR FBO gets a multisampled twin: msFBO, whose
+// colour, bloom-source and depth-stencil attachments are renderbuffers with
+// the same formats as ColorTex, BloomSrcTex and DepthTex. BeginFrame binds it
+// (sceneFBO), the scene geometry is rasterised with several coverage samples
+// per pixel, and resolve blits the three attachments into the single-sample
+// textures the rest of the pipeline samples.
+//
+// The resolve happens once per frame, at the first pass that needs the
+// single-sample textures: decals and soft particles (scene depth) or
+// BlitPostProcess (SSAO, motion blur, bloom, tone mapping). Draws after it
+// go straight to the single-sample FBO, so decals, particles and anything
+// drawn after them are not multisampled. Depth and stencil resolve by
+// taking one sample per pixel, which is all the depth-based passes need.
+
+// maxMSAASamples returns the largest sample count the driver supports for
+// the HDR attachments. GL 4.1 guarantees every non-integer colour format and
+// every depth format MAX_SAMPLES samples, so one query covers RGBA16F and
+// DEPTH32F_STENCIL8 alike.
+func maxMSAASamples() int32 {
+	var n int32
+	gl.GetIntegerv(gl.MAX_SAMPLES, &n)
+	return n
+}
+
+// msaaSamples clamps a requested sample count to [1, limit]; 1 disables MSAA.
+func msaaSamples(requested int, limit int32) int32 {
+	if requested <= 1 || limit <= 1 {
+		return 1
+	}
+	return min(int32(requested), limit)
+}
+
+// allocMS creates the multisampled attachments at the HDR FBO's size.
+func (pp *PostProcessFBO) allocMS() {
+	storage := func(rb *uint32, format uint32) {
+		gl.GenRenderbuffers(1, rb)
+		gl.BindRenderbuffer(gl.RENDERBUFFER, *rb)
+		gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, pp.Samples, format, pp.Width, pp.Height)
+	}
+	storage(&pp.msColor, gl.RGBA16F)
+	storage(&pp.msBloom, gl.RGBA16F)
+	storage(&pp.msDepth, gl.DEPTH32F_STENCIL8)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+
+	gl.GenFramebuffers(1, &pp.msFBO)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, pp.msFBO)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.RENDERBUFFER, pp.msColor)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT1, gl.RENDERBUFFER, pp.msBloom)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.RENDERBUFFER, pp.msDepth)
+	drawBufs := [2]uint32{gl.COLOR_ATTACHMENT0, gl.COLOR_ATTACHMENT1}
+	gl.DrawBuffers(2, &drawBufs[0])
+	if s := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); s != gl.FRAMEBUFFER_COMPLETE {
+		fmt.Printf("WARNING: MSAA FBO incomplete (0x%X)\n", s)
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+}
+
+func (pp *PostProcessFBO) freeMS() {
+	if pp.msFBO != 0 {
+		gl.DeleteFramebuffers(1, &pp.msFBO)
+		pp.msFBO = 0
+	}
+	for _, rb := range []*uint32{&pp.msColor, &pp.msBloom, &pp.msDepth} {
+		if *rb != 0 {
+			gl.DeleteRenderbuffers(1, rb)
+			*rb = 0
+		}
+	}
+}
+
+// sceneFBO returns the framebuffer scene draws go to: the multisampled one
+// until this frame's resolve, the HDR FBO otherwise.
+func (pp *PostProcessFBO) sceneFBO() uint32 {
+	if pp.msFBO != 0 && !pp.resolved {
+		return pp.msFBO
+	}
+	return pp.FBO
+}
+
+// resolve blits the multisampled attachments into ColorTex, BloomSrcTex and
+// DepthTex, once per frame, and leaves the HDR FBO bound for drawing. A
+// no-op without MSAA or when already resolved.
+func (pp *PostProcessFBO) resolve() {
+	if pp.msFBO == 0 || pp.resolved {
+		return
+	}
+	pp.resolved = true
+
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, pp.msFBO)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, pp.FBO)
+	for _, att := range []uint32{gl.COLOR_ATTACHMENT0, gl.COLOR_ATTACHMENT1} {
+		gl.ReadBuffer(att)
+		gl.DrawBuffer(att)
+		gl.BlitFramebuffer(0, 0, pp.Width, pp.Height, 0, 0, pp.Width, pp.Height,
+			gl.COLOR_BUFFER_BIT, gl.NEAREST)
+	}
+	gl.BlitFramebuffer(0, 0, pp.Width, pp.Height, 0, 0, pp.Width, pp.Height,
+		gl.DEPTH_BUFFER_BIT|gl.STENCIL_BUFFER_BIT, gl.NEAREST)
+
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, pp.msFBO)
+	gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, pp.FBO)
+	drawBufs := [2]uint32{gl.COLOR_ATTACHMENT0, gl.COLOR_ATTACHMENT1}
+	gl.DrawBuffers(2, &drawBufs[0])
+	gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+	gl.Viewport(0, 0, pp.Width, pp.Height)
+}
+
+// setSamples switches the HDR FBO's multisampling, reallocating the
+// multisampled attachments.
+func (pp *PostProcessFBO) setSamples(samples int32) {
+	if samples == pp.Samples {
+		return
+	}
+	pp.freeMS()
+	pp.Samples = samples
+	if samples > 1 {
+		pp.allocMS()
+	}
+}
+
+// SetMSAA multisamples the scene with the given number of samples per pixel
+// (1 = off; typically 4 or 8), clamped to what the driver supports. The
+// samples are resolved into the HDR FBO before post-processing, so MSAA
+// requires EnablePostProcess. Returns the sample count in use.
+func (r *Renderer) SetMSAA(samples int) (int, error) {
+	if r.postProcess == nil {
+		return 1, fmt.Errorf("SetMSAA: EnablePostProcess must be called first")
+	}
+	n := msaaSamples(samples, maxMSAASamples())
+	r.postProcess.setSamples(n)
+	return int(n), nil
+}
+
+// MSAASamples returns the scene's samples per pixel (1 when MSAA is off).
+func (r *Renderer) MSAASamples() int {
+	if r.postProcess == nil || r.postProcess.Samples < 1 {
+		return 1
+	}
+	return int(r.postProcess.Samples)
+}
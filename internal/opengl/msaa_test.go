@@ -0,0 +1,70 @@
+package opengl
+
+import (
+	"testing"
+
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+)
+
+func TestMSAASamples(t *testing.T) {
+	cases := []struct {
+		requested int
+		limit     int32
+		want      int32
+	}{
+		{0, 8, 1},
+		{1, 8, 1},
+		{4, 8, 4},
+		{16, 8, 8},
+		{4, 1, 1}, // driver without multisampling
+	}
+	for _, c := range cases {
+		if got := msaaSamples(c.requested, c.limit); got != c.want {
+			t.Errorf("msaaSamples(%d, %d): expected %d, got %d", c.requested, c.limit, c.want, got)
+		}
+	}
+}
+
+func TestMSAAResolve(t *testing.T) {
+	r, cleanup := newTestRenderer(t, 32, 32)
+	defer cleanup()
+	if _, err := r.SetMSAA(4); err == nil {
+		t.Fatal("SetMSAA without post-processing: expected an error")
+	}
+	if err := r.EnablePostProcess(32, 32); err != nil {
+		t.Fatalf("EnablePostProcess: %v", err)
+	}
+	n, err := r.SetMSAA(4)
+	if err != nil {
+		t.Fatalf("SetMSAA: %v", err)
+	}
+	if n < 2 {
+		t.Skipf("driver has no multisampling")
+	}
+	pp := r.postProcess
+
+	var samples int32
+	gl.BindRenderbuffer(gl.RENDERBUFFER, pp.msColor)
+	gl.GetRenderbufferParameteriv(gl.RENDERBUFFER, gl.RENDERBUFFER_SAMPLES, &samples)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+	if samples < int32(n) {
+		t.Errorf("colour renderbuffer: expected at least %d samples, got %d", n, samples)
+	}
+
+	// Clear the multisampled target and resolve: the colour lands in ColorTex
+	pp.resolved = false
+	gl.BindFramebuffer(gl.FRAMEBUFFER, pp.sceneFBO())
+	gl.ClearColor(0.25, 0.5, 2, 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	pp.resolve()
+	if pp.sceneFBO() != pp.FBO {
+		t.Error("after resolve, scene draws should go to the HDR FBO")
+	}
+	px := make([]float32, 32*32*4)
+	gl.BindTexture(gl.TEXTURE_2D, pp.ColorTex)
+	gl.GetTexImage(gl.TEXTURE_2D, 0, gl.RGBA, gl.FLOAT, gl.Ptr(px))
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	if px[0] != 0.25 || px[1] != 0.5 || px[2] != 2 {
+		t.Errorf("resolved colour: expected (0.25, 0.5, 2), got (%g, %g, %g)", px[0], px[1], px[2])
+	}
+}
@@ -0,0 +1,149 @@
+package opengl
+
+import (
+	"fmt"
+	"unsafe"
+
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+
+	"render-engine/core"
+	"render-engine/math"
+	"render-engine/scene"
+)
+
+// Selection outlines use the classic two-pass stencil technique:
+//
+//  1. Mask: the selected mesh is drawn normally between BeginOutlineMask and
+//     EndOutlineMask, which sets the outline stencil bit under every fragment
+//     it rasterises, hidden ones included, so the mask is its full silhouette.
+//  2. Outline: DrawOutline draws the mesh again with every vertex pushed out
+//     along its normal by a fixed number of pixels, in a flat colour, only
+//     where the bit is clear. What survives is a band around the
+//     silhouette. Depth testing is off, so the band shows through occluders.
+//
+// The outline ignores the mesh's material. Meshes with split normals (hard
+// edges such as a cube's) open small gaps at the corners, where the faces
+// move apart.
+
+// outlineVertSrc — extrudes along the normal in screen space so the band is
+// thickness pixels wide at any distance.
+const outlineVertSrc = `
+#version 410 core
+layout(location = 0) in vec3 inPosition;
+layout(location = 1) in vec3 inNormal;
+
+uniform mat4  mvp;
+uniform float thickness;    // pixels
+uniform vec2  viewportSize;
+
+void main() {
+    vec4 clip = mvp * vec4(inPosition, 1.0);
+    vec2 n    = (mvp * vec4(inNormal, 0.0)).xy * viewportSize; // pixel space
+    if (dot(n, n) > 1e-12) {
+        clip.xy += normalize(n) * thickness * 2.0 / viewportSize * clip.w;
+    }
+    gl_Position = clip;
+}
+` + "\x00"
+
+const outlineFragSrc = `
+#version 410 core
+out vec4 outColor;
+
+uniform vec3 outlineColor;
+
+void main() {
+    outColor = vec4(outlineColor, 1.0);
+}
+` + "\x00"
+
+// outlinePass holds the outline shader.
+type outlinePass struct {
+	prog         uint32
+	mvpLoc       int32
+	thicknessLoc int32
+	viewportLoc  int32
+	colorLoc     int32
+}
+
+func newOutlinePass() (*outlinePass, error) {
+	prog, err := newProgram(outlineVertSrc, outlineFragSrc)
+	if err != nil {
+		return nil, fmt.Errorf("outline shader: %w", err)
+	}
+	return &outlinePass{
+		prog:         prog,
+		mvpLoc:       gl.GetUniformLocation(prog, gl.Str("mvp\x00")),
+		thicknessLoc: gl.GetUniformLocation(prog, gl.Str("thickness\x00")),
+		viewportLoc:  gl.GetUniformLocation(prog, gl.Str("viewportSize\x00")),
+		colorLoc:     gl.GetUniformLocation(prog, gl.Str("outlineColor\x00")),
+	}, nil
+}
+
+func (o *outlinePass) destroy() {
+	gl.DeleteProgram(o.prog)
+}
+
+// BeginOutlineMask makes the following draws set the outline stencil bit
+// wherever they rasterise, whether or not they pass the depth test.
+func (r *Renderer) BeginOutlineMask() {
+	gl.Enable(gl.STENCIL_TEST)
+	gl.StencilMask(outlineStencilBit)
+	gl.StencilFunc(gl.ALWAYS, outlineStencilBit, outlineStencilBit)
+	gl.StencilOp(gl.KEEP, gl.REPLACE, gl.REPLACE)
+}
+
+// EndOutlineMask stops stencil writes.
+func (r *Renderer) EndOutlineMask() {
+	r.resetStencil()
+}
+
+// DrawOutline draws mesh as a color band thickness pixels wide around the
+// stencil mask left by BeginOutlineMask. Call it once all opaque geometry is
+// drawn. Only triangle meshes are outlined, and nothing is drawn while an
+// off-screen target (which has no stencil) is bound. Lazily creates the
+// outline shader on first call.
+func (r *Renderer) DrawOutline(mesh *scene.Mesh, mvp math.Mat4, color core.Color, thickness float32) {
+	if mesh == nil || mesh.DrawMode != scene.DrawTriangles || thickness <= 0 || r.renderTarget != nil {
+		return
+	}
+	if r.outline == nil {
+		o, err := newOutlinePass()
+		if err != nil {
+			fmt.Printf("outline init: %v\n", err)
+			return
+		}
+		r.outline = o
+	}
+	gpu := r.ensureUploaded(mesh)
+	if gpu == nil {
+		return
+	}
+
+	var vp [4]int32
+	gl.GetIntegerv(gl.VIEWPORT, &vp[0])
+
+	r.setBloomSourceWrites(false)
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Enable(gl.STENCIL_TEST)
+	gl.StencilMask(0x00)
+	gl.StencilFunc(gl.NOTEQUAL, outlineStencilBit, outlineStencilBit)
+
+	o := r.outline
+	gl.UseProgram(o.prog)
+	gl.UniformMatrix4fv(o.mvpLoc, 1, false, (*float32)(unsafe.Pointer(&mvp[0][0])))
+	gl.Uniform1f(o.thicknessLoc, thickness)
+	gl.Uniform2f(o.viewportLoc, float32(vp[2]), float32(vp[3]))
+	gl.Uniform3f(o.colorLoc, color.R, color.G, color.B)
+	gl.BindVertexArray(gpu.VAO)
+	if gpu.HasIndices {
+		gl.DrawElements(gl.TRIANGLES, gpu.IndexCount, gl.UNSIGNED_INT, nil)
+	} else {
+		gl.DrawArrays(gl.TRIANGLES, 0, int32(len(mesh.Vertices)))
+	}
+	gl.BindVertexArray(0)
+
+	r.resetStencil()
+	gl.Enable(gl.DEPTH_TEST)
+	r.setBloomSourceWrites(true)
+}
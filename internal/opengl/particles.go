@@ -23,26 +23,43 @@ uniform mat4 vp;
 
 out vec2  fragUV;
 out vec4  fragColor;
+out float fragViewDist; // distance along the view axis (clip w)
 
 void main() {
-    gl_Position = vp * vec4(inPos, 1.0);
-    fragUV      = inUV;
-    fragColor   = inColor;
+    gl_Position  = vp * vec4(inPos, 1.0);
+    fragUV       = inUV;
+    fragColor    = inColor;
+    fragViewDist = gl_Position.w;
 }
 ` + "\x00"
 
 // Procedural soft-circle fragment shader (no texture required).
 // UV (0,1)² mapped so centre=0.5; alpha rolls off quadratically at the edge.
+// Soft particles: alpha also fades to 0 as the billboard approaches the scene
+// surface behind it, over softFade view-space units.
 const particleFragSrc = `
 #version 410 core
-in vec2 fragUV;
-in vec4 fragColor;
+in vec2  fragUV;
+in vec4  fragColor;
+in float fragViewDist;
 
 out vec4 outColor;
 
 uniform sampler2D particleTex;
 uniform bool      hasParticleTex;
 
+uniform sampler2D sceneDepth; // unit 1 — HDR FBO depth [0,1]
+uniform float     softFade;   // 0 = hard intersections
+uniform mat4      invProj;
+
+// View-axis distance of the opaque scene at this pixel.
+float sceneViewDist() {
+    vec2  uv = gl_FragCoord.xy / vec2(textureSize(sceneDepth, 0));
+    float d  = texture(sceneDepth, uv).r * 2.0 - 1.0;
+    vec4  vp = invProj * vec4(0.0, 0.0, d, 1.0);
+    return -vp.z / vp.w;
+}
+
 void main() {
     vec4 col = fragColor;
     if (hasParticleTex) {
@@ -52,6 +69,9 @@ void main() {
         float d = length(fragUV - vec2(0.5)) * 2.0;
         col.a  *= clamp(1.0 - d * d, 0.0, 1.0);
     }
+    if (softFade > 0.0) {
+        col.a *= clamp((sceneViewDist() - fragViewDist) / softFade, 0.0, 1.0);
+    }
     outColor = col;
 }
 ` + "\x00"
@@ -67,7 +87,20 @@ type ParticleRenderer struct {
 	vpLoc         int32
 	hasParticleTexLoc int32
 	particleTexLoc    int32
+	sceneDepthLoc     int32
+	softFadeLoc       int32
+	invProjLoc        int32
 	vboCap        int // current VBO capacity in vertices
+	vboAllocs     int // glBufferData calls on vbo, for tests
+	verts         []float32 // CPU quad buffer, reused across draws
+
+	// GPUSimulation emitters: shared programs (built on first use) and one
+	// buffer pair per emitter
+	gpu        *gpuParticlePass
+	gpuSystems map[*scene.ParticleEmitter]*gpuParticleSystem
+
+	// 1×1 white texture for untextured billboards (DrawBillboard)
+	white *scene.Texture
 }
 
 // newParticleRenderer compiles the particle shader and creates the dynamic VAO/VBO.
@@ -100,33 +133,43 @@ func newParticleRenderer() (*ParticleRenderer, error) {
 		vpLoc:             gl.GetUniformLocation(prog, gl.Str("vp\x00")),
 		hasParticleTexLoc: gl.GetUniformLocation(prog, gl.Str("hasParticleTex\x00")),
 		particleTexLoc:    gl.GetUniformLocation(prog, gl.Str("particleTex\x00")),
+		sceneDepthLoc:     gl.GetUniformLocation(prog, gl.Str("sceneDepth\x00")),
+		softFadeLoc:       gl.GetUniformLocation(prog, gl.Str("softFade\x00")),
+		invProjLoc:        gl.GetUniformLocation(prog, gl.Str("invProj\x00")),
 	}
 	gl.UseProgram(prog)
 	gl.Uniform1i(pr.particleTexLoc, 0)
+	gl.Uniform1i(pr.sceneDepthLoc, 1)
 	gl.Uniform1i(pr.hasParticleTexLoc, 0)
 	return pr, nil
 }
 
 // draw renders all live particles in the emitter as camera-facing billboards.
+// sceneDepth is the depth texture of the target being drawn into, used for
+// soft-particle fading; 0 disables the fade (e.g. no HDR FBO). reverseZ must
+// match the depth convention it was rendered with. fade scales every
+// particle's alpha (distance fade; 1 = as simulated).
 //
 // Camera right and up are extracted from the view matrix ([col][row] layout):
 //
 //	right = row 0 of view = (view[0][0], view[1][0], view[2][0])
 //	up    = row 1 of view = (view[0][1], view[1][1], view[2][1])
-func (pr *ParticleRenderer) draw(emitter *scene.ParticleEmitter, view, proj math.Mat4) {
+func (pr *ParticleRenderer) draw(emitter *scene.ParticleEmitter, view, proj math.Mat4, sceneDepth uint32, reverseZ bool, fade float32) {
 	n := len(emitter.Particles)
 	if n == 0 {
 		return
 	}
 
 	// Camera axes from view matrix rows
-	camRight := math.Vec3{X: view[0][0], Y: view[1][0], Z: view[2][0]}
-	camUp    := math.Vec3{X: view[0][1], Y: view[1][1], Z: view[2][1]}
+	camRight, camUp := billboardAxes(view, math.Vec3{})
 
 	// Build CPU-side quad buffer: 6 vertices (2 triangles) per particle.
 	const vertsPerParticle = 6
 	const floatsPerVert    = 9
-	buf := make([]float32, n*vertsPerParticle*floatsPerVert)
+	if need := n * vertsPerParticle * floatsPerVert; cap(pr.verts) < need {
+		pr.verts = make([]float32, need)
+	}
+	buf := pr.verts[:n*vertsPerParticle*floatsPerVert]
 	out := 0
 
 	addVert := func(p math.Vec3, u, v float32, c [4]float32) {
@@ -139,7 +182,7 @@ func (pr *ParticleRenderer) draw(emitter *scene.ParticleEmitter, view, proj math
 	for i := range emitter.Particles {
 		p  := &emitter.Particles[i]
 		s  := p.Size
-		c  := [4]float32{p.Color.R, p.Color.G, p.Color.B, p.Color.A}
+		c  := [4]float32{p.Color.R, p.Color.G, p.Color.B, p.Color.A * fade}
 		r  := camRight.Mul(s)
 		u  := camUp.Mul(s)
 
@@ -159,21 +202,58 @@ func (pr *ParticleRenderer) draw(emitter *scene.ParticleEmitter, view, proj math
 		addVert(bl, 0, 0, c)
 	}
 
-	// Upload to GPU (grow VBO only when needed)
+	vertCount := n * vertsPerParticle
+	pr.upload(buf, vertCount)
+
+	beginParticleBlend(emitter.BlendMode)
+
+	vp := view.Mul(proj)
+	gl.UseProgram(pr.prog)
+	gl.UniformMatrix4fv(pr.vpLoc, 1, false, (*float32)(unsafe.Pointer(&vp[0][0])))
+	gl.Uniform1i(pr.hasParticleTexLoc, 0) // procedural soft-circle
+	setSoftFade(pr.softFadeLoc, pr.invProjLoc, emitter.SoftFadeDistance, sceneDepth, proj, reverseZ)
+
+	gl.BindVertexArray(pr.vao)
+	gl.DrawArrays(gl.TRIANGLES, 0, int32(vertCount))
+	gl.BindVertexArray(0)
+
+	endParticleBlend()
+}
+
+// particleVBOCapacity returns the capacity in vertices the particle VBO
+// must be reallocated to for need vertices, or 0 when cap already holds
+// them. It grows by half again at a time, so an emitter filling up a
+// particle per frame reallocates a handful of times, not every frame.
+func particleVBOCapacity(cap, need int) int {
+	if need <= cap {
+		return 0
+	}
+	if grown := cap + cap/2; grown > need {
+		return grown
+	}
+	return need
+}
+
+// upload copies vertCount vertices of buf into the VBO, reallocating it
+// only when they don't fit.
+func (pr *ParticleRenderer) upload(buf []float32, vertCount int) {
 	gl.BindBuffer(gl.ARRAY_BUFFER, pr.vbo)
 	byteSize := len(buf) * 4
-	vertCount := n * vertsPerParticle
-	if vertCount > pr.vboCap {
-		gl.BufferData(gl.ARRAY_BUFFER, byteSize, gl.Ptr(buf), gl.DYNAMIC_DRAW)
-		pr.vboCap = vertCount
-	} else {
-		gl.BufferSubData(gl.ARRAY_BUFFER, 0, byteSize, gl.Ptr(buf))
+	if grown := particleVBOCapacity(pr.vboCap, vertCount); grown > 0 {
+		gl.BufferData(gl.ARRAY_BUFFER, grown*byteSize/vertCount, nil, gl.DYNAMIC_DRAW)
+		pr.vboCap = grown
+		pr.vboAllocs++
 	}
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, byteSize, gl.Ptr(buf))
 	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+}
 
+// beginParticleBlend sets the blend and depth state shared by the CPU and GPU
+// particle paths.
+func beginParticleBlend(mode scene.BlendMode) {
 	// Blending: additive (fire/glow) or standard alpha (smoke)
 	gl.Enable(gl.BLEND)
-	switch emitter.BlendMode {
+	switch mode {
 	case scene.BlendAdditive:
 		gl.BlendFunc(gl.SRC_ALPHA, gl.ONE)
 	default:
@@ -182,22 +262,42 @@ func (pr *ParticleRenderer) draw(emitter *scene.ParticleEmitter, view, proj math
 
 	// Depth: read (test against scene) but do NOT write (particles don't occlude)
 	gl.DepthMask(false)
+}
 
-	vp := view.Mul(proj)
-	gl.UseProgram(pr.prog)
-	gl.UniformMatrix4fv(pr.vpLoc, 1, false, (*float32)(unsafe.Pointer(&vp[0][0])))
-	gl.Uniform1i(pr.hasParticleTexLoc, 0) // procedural soft-circle
-
-	gl.BindVertexArray(pr.vao)
-	gl.DrawArrays(gl.TRIANGLES, 0, int32(vertCount))
-	gl.BindVertexArray(0)
-
-	// Restore render state
+// endParticleBlend restores the state changed by beginParticleBlend.
+func endParticleBlend() {
 	gl.DepthMask(true)
 	gl.Disable(gl.BLEND)
 }
 
+// setSoftFade points the bound particle program's soft-fade uniforms at
+// sceneDepth, or turns the fade off when there is no depth or no distance.
+func setSoftFade(softFadeLoc, invProjLoc int32, distance float32, sceneDepth uint32, proj math.Mat4, reverseZ bool) {
+	// Soft particles read the depth buffer they are depth-tested against.
+	// Depth writes are off, so sampling it is not a feedback loop.
+	if sceneDepth != 0 && distance > 0 {
+		invProj := depthUnproject(proj, reverseZ)
+		gl.ActiveTexture(gl.TEXTURE1)
+		gl.BindTexture(gl.TEXTURE_2D, sceneDepth)
+		gl.ActiveTexture(gl.TEXTURE0)
+		gl.UniformMatrix4fv(invProjLoc, 1, false, (*float32)(unsafe.Pointer(&invProj[0][0])))
+		gl.Uniform1f(softFadeLoc, distance)
+	} else {
+		gl.Uniform1f(softFadeLoc, 0)
+	}
+}
+
 func (pr *ParticleRenderer) destroy() {
+	for e, ps := range pr.gpuSystems {
+		ps.destroy()
+		delete(pr.gpuSystems, e)
+	}
+	if pr.gpu != nil {
+		pr.gpu.destroy()
+	}
+	if pr.white != nil {
+		DeleteTexture(pr.white)
+	}
 	gl.DeleteVertexArrays(1, &pr.vao)
 	gl.DeleteBuffers(1, &pr.vbo)
 	gl.DeleteProgram(pr.prog)
@@ -0,0 +1,53 @@
+package opengl
+
+import (
+	"testing"
+
+	"render-engine/math"
+	"render-engine/scene"
+)
+
+func TestParticleVBOCapacity(t *testing.T) {
+	cases := []struct{ cap, need, want int }{
+		{0, 6, 6},
+		{6, 6, 0},    // fits: no reallocation
+		{600, 12, 0}, // shrinking never reallocates
+		{600, 606, 900},
+		{600, 1200, 1200},
+	}
+	for _, c := range cases {
+		if got := particleVBOCapacity(c.cap, c.need); got != c.want {
+			t.Errorf("particleVBOCapacity(%d, %d): expected %d, got %d", c.cap, c.need, c.want, got)
+		}
+	}
+}
+
+func TestParticleVBOReallocatesOnlyToGrow(t *testing.T) {
+	_, cleanup := newTestRenderer(t, 32, 32)
+	defer cleanup()
+
+	pr, err := newParticleRenderer()
+	if err != nil {
+		t.Skipf("no GL context available: %v", err)
+	}
+	defer pr.destroy()
+
+	emitter := scene.NewParticleEmitter(1000)
+	view, proj := math.Mat4Identity(), math.Mat4Identity()
+	draw := func(n int) {
+		emitter.Particles = make([]scene.Particle, n)
+		pr.draw(emitter, view, proj, 0, false, 1)
+	}
+
+	draw(100)
+	draw(100)
+	draw(40)
+	if pr.vboAllocs != 1 || pr.vboCap != 600 {
+		t.Errorf("expected one allocation of 600 vertices, got %d allocations, capacity %d", pr.vboAllocs, pr.vboCap)
+	}
+	draw(120) // grows by half: room for 150 particles
+	draw(150)
+	if pr.vboAllocs != 2 || pr.vboCap != 900 {
+		t.Errorf("expected a second allocation of 900 vertices, got %d allocations, capacity %d", pr.vboAllocs, pr.vboCap)
+	}
+}
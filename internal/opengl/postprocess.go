@@ -2,19 +2,37 @@ package opengl
 
 import (
 	"fmt"
+	stdmath "math"
+	"time"
+	"unsafe"
 
 	gl "github.com/go-gl/gl/v4.1-core/gl"
+
+	"render-engine/math"
 )
 
 // PostProcessFBO is an HDR off-screen render target with tone mapping and
 // optional bloom (bright-pass → separable Gaussian blur → additive composite).
 type PostProcessFBO struct {
 	// Main HDR FBO (scene renders into this)
-	FBO      uint32 // framebuffer object
-	ColorTex uint32 // RGBA16F colour attachment
-	DepthTex uint32 // DEPTH_COMPONENT32F depth texture (sampleable for SSAO)
-	Width    int32
-	Height   int32
+	//   COLOR_ATTACHMENT0 = ColorTex: lit HDR scene colour
+	//   COLOR_ATTACHMENT1 = BloomSrcTex: per-material emissive × BloomScale,
+	//                       added to the bright-pass output so it blooms
+	//                       regardless of BloomThreshold
+	FBO         uint32 // framebuffer object
+	ColorTex    uint32 // RGBA16F colour attachment
+	BloomSrcTex uint32 // RGBA16F bloom-only emissive attachment
+	DepthTex    uint32 // DEPTH32F_STENCIL8 depth/stencil texture (depth sampleable for SSAO)
+	Width       int32
+	Height      int32
+
+	// Multisampled twin of FBO (see msaa.go); Samples ≤ 1 = no MSAA
+	Samples  int32
+	msFBO    uint32
+	msColor  uint32 // RGBA16F renderbuffer
+	msBloom  uint32 // RGBA16F renderbuffer
+	msDepth  uint32 // DEPTH32F_STENCIL8 renderbuffer
+	resolved bool   // msFBO already resolved into the textures this frame
 
 	// Tone-map + bloom composite shader
 	prog        uint32
@@ -27,12 +45,50 @@ type PostProcessFBO struct {
 	aoTexLoc    int32
 	hasAOLoc    int32
 	aoStrLoc    int32
+	// Depth-aware AO upsample (depth on unit 3)
+	aoDepthLoc    int32
+	aoUpsampleLoc int32
+	aoFalloffLoc  int32
+	aoInvProjLoc  int32
+	// Lens effects
+	chromaLoc int32
+	lensK1Loc int32
+	lensK2Loc int32
+	// Film grain
+	grainLoc int32
+	timeLoc  int32
+	// Output dithering
+	ditherLoc int32
+	// Debug view output (see debugOutput)
+	debugOutLoc int32
 
 	quadVAO uint32 // empty VAO for the fullscreen triangle
 
 	// Tone-mapping
 	Exposure float32
 
+	// ChromaticAberration shifts the red and blue channels apart radially,
+	// by this fraction of each pixel's distance from the screen centre
+	// (0 = off). LensK1/LensK2 are the radial distortion coefficients of
+	// r' = r(1 + K1 r² + K2 r⁴): positive values give barrel distortion,
+	// negative pincushion (0 = off).
+	ChromaticAberration float32
+	LensK1              float32
+	LensK2              float32
+
+	// FilmGrain is the peak-to-peak amplitude of the animated grain added
+	// after tone mapping, in display units (0 = off; 0.02–0.05 is subtle).
+	FilmGrain float32
+	start     time.Time // grain animation clock
+
+	// Dither adds a ±0.5/255 ordered (4×4 Bayer) dither before the 8-bit
+	// write, which hides banding in smooth gradients (default true).
+	Dither bool
+
+	// debugOut replaces tone mapping while a material debug view is active:
+	// 0 = off, 1 = the HDR colour as is, 2 = the HDR colour × the SSAO term.
+	debugOut int32
+
 	// Bloom ping-pong FBOs (created by EnableBloom)
 	bloomFBO        [2]uint32
 	bloomTex        [2]uint32
@@ -44,10 +100,25 @@ type PostProcessFBO struct {
 	blurTexLoc      int32
 	blurDirLoc      int32
 
+	// Bloom mip chain (used instead of the ping-pong blur when bloomMips > 0)
+	bloomMipFBO  []uint32
+	bloomMipTex  []uint32
+	bloomMipSize [][2]int32
+	bloomMips    int
+	downProg     uint32 // 4-tap bilinear downsample shader
+	downTexLoc   int32
+	downTexelLoc int32
+	upProg       uint32 // 3×3 tent upsample shader
+	upTexLoc     int32
+	upTexelLoc   int32
+
 	BloomEnabled   bool
 	BloomThreshold float32 // luminance cut-off (1.0 = only pixels brighter than white)
 	BloomStrength  float32 // additive bloom multiplier
 	BloomPasses    int     // number of H+V blur pairs (more = softer, more expensive)
+
+	// GPU pass timer shared with the Renderer (nil = not timed)
+	timer *gpuTimer
 }
 
 // ── Shaders ───────────────────────────────────────────────────────────────────
@@ -67,7 +138,9 @@ void main() {
 }
 ` + "\x00"
 
-// ppFragSrc — exposure, Reinhard tone mapping, gamma 2.2, optional bloom add, optional SSAO.
+// ppFragSrc — exposure, Reinhard tone mapping, gamma 2.2, optional bloom add,
+// optional SSAO, optional lens distortion and chromatic aberration, optional
+// film grain and output dithering.
 const ppFragSrc = `
 #version 410 core
 in  vec2 fragUV;
@@ -81,24 +154,134 @@ uniform float     bloomStrength;
 uniform bool      hasBloom;
 uniform bool      hasAO;
 uniform float     aoStrength;
+uniform sampler2D depthTex;   // unit 3 (AO upsample only)
+uniform bool      aoUpsample; // aoTex is lower resolution than hdrBuffer
+uniform float     aoFalloff;  // depth edge-weight falloff, as in the SSAO blur
+uniform mat4      invProj;
+uniform float     chromaticAberration; // radial R/B offset (0 = off)
+uniform float     lensK1;              // radial distortion coefficients (0 = off)
+uniform float     lensK2;
+uniform float     filmGrain;           // grain amplitude after tone mapping (0 = off)
+uniform float     time;                // seconds, animates the grain
+uniform bool      dither;              // ordered dither before the 8-bit write
+uniform int       debugOutput;         // 1 = HDR as is, 2 = HDR × AO (debug views)
+
+float viewZ(vec2 uv) {
+    float d = texture(depthTex, uv).r * 2.0 - 1.0;
+    vec4 vp = invProj * vec4(0.0, 0.0, d, 1.0);
+    return vp.z / vp.w;
+}
 
-void main() {
-    vec3 hdr = texture(hdrBuffer, fragUV).rgb;
+// Bilateral upsample: bilinear weights over the 4 nearest low-res AO texels,
+// each scaled down by its depth difference from this pixel so occlusion
+// from a foreground edge does not bleed onto the background (or back).
+float upsampleAO(vec2 at) {
+    vec2  size = vec2(textureSize(aoTex, 0));
+    vec2  p    = at * size - 0.5;
+    vec2  b    = floor(p);
+    vec2  f    = p - b;
+    float zc   = viewZ(at);
+    float sum   = 0.0;
+    float total = 0.0;
+    for (int j = 0; j <= 1; j++) {
+        for (int i = 0; i <= 1; i++) {
+            vec2  uv = (b + vec2(i, j) + 0.5) / size;
+            float wb = (i == 0 ? 1.0 - f.x : f.x) * (j == 0 ? 1.0 - f.y : f.y);
+            float wd = exp(-aoFalloff * abs(viewZ(uv) - zc) / max(abs(zc), 0.0001));
+            float w  = wb * wd + 1e-5; // all taps rejected: fall back to bilinear
+            sum   += texture(aoTex, uv).r * w;
+            total += w;
+        }
+    }
+    return sum / total;
+}
+
+// hash12 — "hash without sine" (Dave Hoskins): a uniform value in [0, 1)
+// per input, stable across GPUs.
+float hash12(vec2 p) {
+    vec3 p3 = fract(vec3(p.xyx) * 0.1031);
+    p3 += dot(p3, p3.yzx + 33.33);
+    return fract((p3.x + p3.y) * p3.z);
+}
+
+// bayer4 — 4×4 ordered-dither threshold for pixel p, in (0, 1).
+float bayer4(vec2 p) {
+    const float m[16] = float[16](
+         0.0,  8.0,  2.0, 10.0,
+        12.0,  4.0, 14.0,  6.0,
+         3.0, 11.0,  1.0,  9.0,
+        15.0,  7.0, 13.0,  5.0);
+    ivec2 i = ivec2(mod(p, 4.0));
+    return (m[i.y * 4 + i.x] + 0.5) / 16.0;
+}
+
+// Linear scene colour at uv: HDR + bloom, darkened by AO.
+vec3 sceneColor(vec2 uv) {
+    vec3 hdr = texture(hdrBuffer, uv).rgb;
 
     if (hasBloom) {
-        hdr += texture(bloomTex, fragUV).rgb * bloomStrength;
+        hdr += texture(bloomTex, uv).rgb * bloomStrength;
     }
 
     // Apply SSAO occlusion (modulates HDR before tone-mapping so it stays in linear space)
     if (hasAO) {
-        float ao = texture(aoTex, fragUV).r;
+        float ao = aoUpsample ? upsampleAO(uv) : texture(aoTex, uv).r;
         hdr *= mix(1.0, ao, aoStrength);
     }
+    return hdr;
+}
+
+void main() {
+    // Debug views: the main shader wrote the value to show; no bloom, tone
+    // mapping or effects, and SSAO at full strength for the AO view
+    if (debugOutput != 0) {
+        vec3 v = texture(hdrBuffer, fragUV).rgb;
+        if (debugOutput == 2 && hasAO) {
+            v *= aoUpsample ? upsampleAO(fragUV) : texture(aoTex, fragUV).r;
+        }
+        outColor = vec4(v, 1.0);
+        return;
+    }
+
+    // Lens distortion warps where the pixel samples the scene; barrel
+    // distortion reaches past the image edge, which stays black
+    vec2 uv = fragUV;
+    if (lensK1 != 0.0 || lensK2 != 0.0) {
+        vec2  c  = fragUV - 0.5;
+        float r2 = dot(c, c);
+        uv = 0.5 + c * (1.0 + lensK1 * r2 + lensK2 * r2 * r2);
+        if (any(lessThan(uv, vec2(0.0))) || any(greaterThan(uv, vec2(1.0)))) {
+            outColor = vec4(0.0, 0.0, 0.0, 1.0);
+            return;
+        }
+    }
+
+    // Chromatic aberration: red and blue sampled apart along the radius
+    vec3 hdr;
+    if (chromaticAberration != 0.0) {
+        vec2 d = (uv - 0.5) * chromaticAberration;
+        hdr = vec3(sceneColor(uv + d).r, sceneColor(uv).g, sceneColor(uv - d).b);
+    } else {
+        hdr = sceneColor(uv);
+    }
 
     // Exposure → Reinhard → gamma 2.2
     vec3 mapped = vec3(1.0) - exp(-hdr * exposure);
     mapped = pow(mapped, vec3(1.0 / 2.2));
 
+    // Film grain: zero-mean monochrome noise, re-rolled every frame, so the
+    // average brightness is unchanged while gradients are dithered
+    if (filmGrain > 0.0) {
+        float n = hash12(gl_FragCoord.xy + fract(time) * 1000.0) - 0.5;
+        mapped += n * filmGrain;
+    }
+
+    // Ordered dither of ±0.5 LSB: a pixel between two 8-bit levels rounds up
+    // with probability equal to its fraction, so gradients stop banding
+    if (dither) {
+        mapped += (bayer4(gl_FragCoord.xy) - 0.5) / 255.0;
+    }
+
     outColor = vec4(mapped, 1.0);
 }
 ` + "\x00"
@@ -110,12 +293,13 @@ in  vec2 fragUV;
 out vec4 outColor;
 
 uniform sampler2D hdrBuffer;
+uniform sampler2D bloomSrc;  // per-material bloom emissive, bypasses the threshold
 uniform float     threshold;
 
 void main() {
     vec3  color = texture(hdrBuffer, fragUV).rgb;
     float luma  = dot(color, vec3(0.2126, 0.7152, 0.0722));
-    outColor = vec4(color * step(threshold, luma), 1.0);
+    outColor = vec4(color * step(threshold, luma) + texture(bloomSrc, fragUV).rgb, 1.0);
 }
 ` + "\x00"
 
@@ -139,10 +323,74 @@ void main() {
 }
 ` + "\x00"
 
+// ppDownFragSrc — 4 bilinear taps at the diagonal texel corners, averaging a
+// 4×4 source footprint into each destination texel of a half-size target.
+// texel = 1 / source size.
+const ppDownFragSrc = `
+#version 410 core
+in  vec2 fragUV;
+out vec4 outColor;
+
+uniform sampler2D srcTex;
+uniform vec2      texel;
+
+void main() {
+    vec3 c = texture(srcTex, fragUV + texel * vec2(-1.0, -1.0)).rgb
+           + texture(srcTex, fragUV + texel * vec2( 1.0, -1.0)).rgb
+           + texture(srcTex, fragUV + texel * vec2(-1.0,  1.0)).rgb
+           + texture(srcTex, fragUV + texel * vec2( 1.0,  1.0)).rgb;
+    outColor = vec4(c * 0.25, 1.0);
+}
+` + "\x00"
+
+// ppUpFragSrc — 3×3 tent filter over a smaller mip, blended additively onto
+// the next larger one.  texel = 1 / source (smaller mip) size.
+const ppUpFragSrc = `
+#version 410 core
+in  vec2 fragUV;
+out vec4 outColor;
+
+uniform sampler2D srcTex;
+uniform vec2      texel;
+
+void main() {
+    vec3 c = vec3(0.0);
+    for (int x = -1; x <= 1; x++) {
+        for (int y = -1; y <= 1; y++) {
+            float w = (x == 0 ? 2.0 : 1.0) * (y == 0 ? 2.0 : 1.0);
+            c += texture(srcTex, fragUV + vec2(x, y) * texel).rgb * w;
+        }
+    }
+    outColor = vec4(c / 16.0, 1.0);
+}
+` + "\x00"
+
+// MaxBloomMips caps the bloom mip chain length.
+const MaxBloomMips = 8
+
+// bloomMipSizes returns the dimensions of each level of a count-long bloom
+// mip chain for a width × height HDR buffer: ½, ¼, ⅛… of the buffer, never
+// below 1×1.
+func bloomMipSizes(width, height int32, count int) [][2]int32 {
+	sizes := make([][2]int32, count)
+	w, h := width, height
+	for i := range sizes {
+		w, h = w/2, h/2
+		if w < 1 {
+			w = 1
+		}
+		if h < 1 {
+			h = 1
+		}
+		sizes[i] = [2]int32{w, h}
+	}
+	return sizes
+}
+
 // ── Constructor ───────────────────────────────────────────────────────────────
 
 func NewPostProcessFBO(width, height int) (*PostProcessFBO, error) {
-	pp := &PostProcessFBO{Exposure: 1.0}
+	pp := &PostProcessFBO{Exposure: 1.0, Dither: true, start: time.Now()}
 
 	prog, err := newProgram(ppVertSrc, ppFragSrc)
 	if err != nil {
@@ -157,11 +405,23 @@ func NewPostProcessFBO(width, height int) (*PostProcessFBO, error) {
 	pp.aoTexLoc    = gl.GetUniformLocation(prog, gl.Str("aoTex\x00"))
 	pp.hasAOLoc    = gl.GetUniformLocation(prog, gl.Str("hasAO\x00"))
 	pp.aoStrLoc    = gl.GetUniformLocation(prog, gl.Str("aoStrength\x00"))
+	pp.aoDepthLoc    = gl.GetUniformLocation(prog, gl.Str("depthTex\x00"))
+	pp.aoUpsampleLoc = gl.GetUniformLocation(prog, gl.Str("aoUpsample\x00"))
+	pp.aoFalloffLoc  = gl.GetUniformLocation(prog, gl.Str("aoFalloff\x00"))
+	pp.aoInvProjLoc  = gl.GetUniformLocation(prog, gl.Str("invProj\x00"))
+	pp.chromaLoc     = gl.GetUniformLocation(prog, gl.Str("chromaticAberration\x00"))
+	pp.lensK1Loc     = gl.GetUniformLocation(prog, gl.Str("lensK1\x00"))
+	pp.lensK2Loc     = gl.GetUniformLocation(prog, gl.Str("lensK2\x00"))
+	pp.grainLoc      = gl.GetUniformLocation(prog, gl.Str("filmGrain\x00"))
+	pp.timeLoc       = gl.GetUniformLocation(prog, gl.Str("time\x00"))
+	pp.ditherLoc     = gl.GetUniformLocation(prog, gl.Str("dither\x00"))
+	pp.debugOutLoc   = gl.GetUniformLocation(prog, gl.Str("debugOutput\x00"))
 
 	gl.UseProgram(prog)
 	gl.Uniform1i(pp.hdrLoc, 0)
 	gl.Uniform1i(pp.bloomTexLoc, 1)
 	gl.Uniform1i(pp.aoTexLoc, 2)
+	gl.Uniform1i(pp.aoDepthLoc, 3)
 
 	gl.GenVertexArrays(1, &pp.quadVAO)
 
@@ -169,6 +429,13 @@ func NewPostProcessFBO(width, height int) (*PostProcessFBO, error) {
 	return pp, nil
 }
 
+// SetBloomSourceWrites enables or disables writes to the bloom-only attachment.
+// Passes whose shaders only write location 0 (skybox, particles) must disable
+// it, otherwise the attachment's contents under them are undefined.
+func (pp *PostProcessFBO) SetBloomSourceWrites(enabled bool) {
+	gl.ColorMaski(1, enabled, enabled, enabled, enabled)
+}
+
 // ── Bloom ─────────────────────────────────────────────────────────────────────
 
 // EnableBloom compiles the bright-pass and blur shaders, and creates the
@@ -187,6 +454,7 @@ func (pp *PostProcessFBO) EnableBloom() error {
 	pp.brightThreshLoc = gl.GetUniformLocation(bp, gl.Str("threshold\x00"))
 	gl.UseProgram(bp)
 	gl.Uniform1i(gl.GetUniformLocation(bp, gl.Str("hdrBuffer\x00")), 0)
+	gl.Uniform1i(gl.GetUniformLocation(bp, gl.Str("bloomSrc\x00")), 1)
 
 	// Blur shader
 	blp, err := newProgram(ppVertSrc, ppBlurFragSrc)
@@ -201,6 +469,33 @@ func (pp *PostProcessFBO) EnableBloom() error {
 	gl.UseProgram(blp)
 	gl.Uniform1i(pp.blurTexLoc, 0)
 
+	// Mip-chain shaders (the chain itself is allocated by SetBloomMipCount)
+	dp, err := newProgram(ppVertSrc, ppDownFragSrc)
+	if err != nil {
+		gl.DeleteProgram(bp)
+		gl.DeleteProgram(blp)
+		pp.brightProg, pp.blurProg = 0, 0
+		return fmt.Errorf("bloom downsample shader: %w", err)
+	}
+	up, err := newProgram(ppVertSrc, ppUpFragSrc)
+	if err != nil {
+		gl.DeleteProgram(bp)
+		gl.DeleteProgram(blp)
+		gl.DeleteProgram(dp)
+		pp.brightProg, pp.blurProg = 0, 0
+		return fmt.Errorf("bloom upsample shader: %w", err)
+	}
+	pp.downProg     = dp
+	pp.downTexLoc   = gl.GetUniformLocation(dp, gl.Str("srcTex\x00"))
+	pp.downTexelLoc = gl.GetUniformLocation(dp, gl.Str("texel\x00"))
+	pp.upProg       = up
+	pp.upTexLoc     = gl.GetUniformLocation(up, gl.Str("srcTex\x00"))
+	pp.upTexelLoc   = gl.GetUniformLocation(up, gl.Str("texel\x00"))
+	gl.UseProgram(dp)
+	gl.Uniform1i(pp.downTexLoc, 0)
+	gl.UseProgram(up)
+	gl.Uniform1i(pp.upTexLoc, 0)
+
 	// Half-resolution bloom FBOs
 	pp.bloomW = pp.Width / 2
 	if pp.bloomW < 1 {
@@ -241,6 +536,95 @@ func (pp *PostProcessFBO) allocBloomFBOs() {
 	}
 }
 
+// SetBloomMipCount switches bloom to a mip chain of n levels (½, ¼, ⅛… of
+// the screen, capped at MaxBloomMips): the bright pass fills the top level,
+// each level is downsampled into the next, and the levels are then tent-
+// upsampled and added back up the chain.  The result is a wide, smooth glow
+// made of several radii instead of the single radius of the ping-pong blur.
+// n = 0 returns to the ping-pong blur (BloomPasses).
+func (pp *PostProcessFBO) SetBloomMipCount(n int) {
+	if n < 0 {
+		n = 0
+	}
+	if n > MaxBloomMips {
+		n = MaxBloomMips
+	}
+	pp.freeBloomMips()
+	pp.bloomMips = n
+	pp.allocBloomMips()
+}
+
+// BloomMipCount returns the mip chain length (0 = ping-pong blur).
+func (pp *PostProcessFBO) BloomMipCount() int { return pp.bloomMips }
+
+// allocBloomMips creates the mip chain textures and FBOs for the current
+// HDR size.  Mip levels filter linearly: the down/up shaders rely on it.
+func (pp *PostProcessFBO) allocBloomMips() {
+	if pp.bloomMips == 0 {
+		return
+	}
+	pp.bloomMipSize = bloomMipSizes(pp.Width, pp.Height, pp.bloomMips)
+	pp.bloomMipTex  = make([]uint32, pp.bloomMips)
+	pp.bloomMipFBO  = make([]uint32, pp.bloomMips)
+	for i, size := range pp.bloomMipSize {
+		gl.GenTextures(1, &pp.bloomMipTex[i])
+		gl.BindTexture(gl.TEXTURE_2D, pp.bloomMipTex[i])
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA16F,
+			size[0], size[1], 0, gl.RGBA, gl.HALF_FLOAT, nil)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+		gl.BindTexture(gl.TEXTURE_2D, 0)
+
+		gl.GenFramebuffers(1, &pp.bloomMipFBO[i])
+		gl.BindFramebuffer(gl.FRAMEBUFFER, pp.bloomMipFBO[i])
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0,
+			gl.TEXTURE_2D, pp.bloomMipTex[i], 0)
+		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	}
+}
+
+// freeBloomMips deletes the mip chain textures and FBOs.
+func (pp *PostProcessFBO) freeBloomMips() {
+	for i := range pp.bloomMipFBO {
+		gl.DeleteFramebuffers(1, &pp.bloomMipFBO[i])
+		gl.DeleteTextures(1, &pp.bloomMipTex[i])
+	}
+	pp.bloomMipFBO  = nil
+	pp.bloomMipTex  = nil
+	pp.bloomMipSize = nil
+}
+
+// runBloomMips downsamples bloomMipTex[0] (already holding the bright pass)
+// through the chain, then upsamples additively back to level 0.
+func (pp *PostProcessFBO) runBloomMips() {
+	gl.ActiveTexture(gl.TEXTURE0)
+
+	gl.UseProgram(pp.downProg)
+	for i := 1; i < pp.bloomMips; i++ {
+		src := pp.bloomMipSize[i-1]
+		gl.BindFramebuffer(gl.FRAMEBUFFER, pp.bloomMipFBO[i])
+		gl.Viewport(0, 0, pp.bloomMipSize[i][0], pp.bloomMipSize[i][1])
+		gl.Uniform2f(pp.downTexelLoc, 1.0/float32(src[0]), 1.0/float32(src[1]))
+		gl.BindTexture(gl.TEXTURE_2D, pp.bloomMipTex[i-1])
+		gl.DrawArrays(gl.TRIANGLES, 0, 3)
+	}
+
+	gl.UseProgram(pp.upProg)
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.ONE, gl.ONE)
+	for i := pp.bloomMips - 1; i > 0; i-- {
+		src := pp.bloomMipSize[i]
+		gl.BindFramebuffer(gl.FRAMEBUFFER, pp.bloomMipFBO[i-1])
+		gl.Viewport(0, 0, pp.bloomMipSize[i-1][0], pp.bloomMipSize[i-1][1])
+		gl.Uniform2f(pp.upTexelLoc, 1.0/float32(src[0]), 1.0/float32(src[1]))
+		gl.BindTexture(gl.TEXTURE_2D, pp.bloomMipTex[i])
+		gl.DrawArrays(gl.TRIANGLES, 0, 3)
+	}
+	gl.Disable(gl.BLEND)
+}
+
 // freeBloomFBOs deletes the bloom ping-pong textures and FBOs.
 func (pp *PostProcessFBO) freeBloomFBOs() {
 	for i := 0; i < 2; i++ {
@@ -271,11 +655,23 @@ func (pp *PostProcessFBO) allocFBO(width, height int) {
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
 	gl.BindTexture(gl.TEXTURE_2D, 0)
 
-	// Depth as a sampleable texture (required by SSAO pass)
+	// Bloom-only emissive target (written by the main shader's outBloom)
+	gl.GenTextures(1, &pp.BloomSrcTex)
+	gl.BindTexture(gl.TEXTURE_2D, pp.BloomSrcTex)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA16F,
+		int32(width), int32(height), 0, gl.RGBA, gl.HALF_FLOAT, nil)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	// Depth as a sampleable texture (required by SSAO pass), with the
+	// stencil for outlines and masks; samplers read the depth part
 	gl.GenTextures(1, &pp.DepthTex)
 	gl.BindTexture(gl.TEXTURE_2D, pp.DepthTex)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.DEPTH_COMPONENT32F,
-		int32(width), int32(height), 0, gl.DEPTH_COMPONENT, gl.FLOAT, nil)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.DEPTH32F_STENCIL8,
+		int32(width), int32(height), 0, gl.DEPTH_STENCIL, gl.FLOAT_32_UNSIGNED_INT_24_8_REV, nil)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
@@ -286,15 +682,24 @@ func (pp *PostProcessFBO) allocFBO(width, height int) {
 	gl.BindFramebuffer(gl.FRAMEBUFFER, pp.FBO)
 	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0,
 		gl.TEXTURE_2D, pp.ColorTex, 0)
-	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT,
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT1,
+		gl.TEXTURE_2D, pp.BloomSrcTex, 0)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT,
 		gl.TEXTURE_2D, pp.DepthTex, 0)
+	drawBufs := [2]uint32{gl.COLOR_ATTACHMENT0, gl.COLOR_ATTACHMENT1}
+	gl.DrawBuffers(2, &drawBufs[0])
 	if s := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); s != gl.FRAMEBUFFER_COMPLETE {
 		fmt.Printf("WARNING: HDR FBO incomplete (0x%X)\n", s)
 	}
 	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	if pp.Samples > 1 {
+		pp.allocMS()
+	}
 }
 
 func (pp *PostProcessFBO) freeFBO() {
+	pp.freeMS()
 	if pp.FBO != 0 {
 		gl.DeleteFramebuffers(1, &pp.FBO)
 		pp.FBO = 0
@@ -303,6 +708,10 @@ func (pp *PostProcessFBO) freeFBO() {
 		gl.DeleteTextures(1, &pp.ColorTex)
 		pp.ColorTex = 0
 	}
+	if pp.BloomSrcTex != 0 {
+		gl.DeleteTextures(1, &pp.BloomSrcTex)
+		pp.BloomSrcTex = 0
+	}
 	if pp.DepthTex != 0 {
 		gl.DeleteTextures(1, &pp.DepthTex)
 		pp.DepthTex = 0
@@ -327,12 +736,21 @@ func (pp *PostProcessFBO) Resize(width, height int) {
 		}
 		pp.allocBloomFBOs()
 	}
+	pp.freeBloomMips()
+	pp.allocBloomMips()
 }
 
 // Destroy frees all GPU resources owned by this object.
 func (pp *PostProcessFBO) Destroy() {
 	pp.freeFBO()
 	pp.freeBloomFBOs()
+	pp.freeBloomMips()
+	for _, prog := range []*uint32{&pp.downProg, &pp.upProg} {
+		if *prog != 0 {
+			gl.DeleteProgram(*prog)
+			*prog = 0
+		}
+	}
 	if pp.brightProg != 0 {
 		gl.DeleteProgram(pp.brightProg)
 		pp.brightProg = 0
@@ -353,44 +771,109 @@ func (pp *PostProcessFBO) Destroy() {
 
 // ── Blit ──────────────────────────────────────────────────────────────────────
 
+// aoComposite is the SSAO input to Blit.  tex == 0 disables AO.
+type aoComposite struct {
+	tex      uint32    // blurred AO (SSAO.BlurTex)
+	strength float32   // blend factor [0,1]
+	upsample bool      // tex is below HDR resolution: depth-aware upsample
+	falloff  float32   // upsample edge-weight falloff (SSAO.DepthFalloff)
+	invProj  math.Mat4 // inverse camera projection, to linearize depth
+}
+
+// bindAO binds the AO inputs of the composite shader (prog must be in use).
+func (pp *PostProcessFBO) bindAO(ao aoComposite) {
+	if ao.tex == 0 {
+		gl.Uniform1i(pp.hasAOLoc, 0)
+		return
+	}
+	gl.ActiveTexture(gl.TEXTURE2)
+	gl.BindTexture(gl.TEXTURE_2D, ao.tex)
+	gl.Uniform1i(pp.hasAOLoc, 1)
+	gl.Uniform1f(pp.aoStrLoc, ao.strength)
+	if ao.upsample {
+		gl.ActiveTexture(gl.TEXTURE3)
+		gl.BindTexture(gl.TEXTURE_2D, pp.DepthTex)
+		gl.Uniform1i(pp.aoUpsampleLoc, 1)
+		gl.Uniform1f(pp.aoFalloffLoc, ao.falloff)
+		gl.UniformMatrix4fv(pp.aoInvProjLoc, 1, false,
+			(*float32)(unsafe.Pointer(&ao.invProj[0][0])))
+	} else {
+		gl.Uniform1i(pp.aoUpsampleLoc, 0)
+	}
+}
+
+// bindEffects sets the lens-effect, film grain, dither and debug output
+// uniforms of the composite shader (prog must be in use).
+func (pp *PostProcessFBO) bindEffects() {
+	gl.Uniform1f(pp.chromaLoc, pp.ChromaticAberration)
+	gl.Uniform1f(pp.lensK1Loc, pp.LensK1)
+	gl.Uniform1f(pp.lensK2Loc, pp.LensK2)
+	gl.Uniform1f(pp.grainLoc, pp.FilmGrain)
+	// Wrapped to an hour so float32 keeps sub-frame resolution
+	gl.Uniform1f(pp.timeLoc, float32(stdmath.Mod(time.Since(pp.start).Seconds(), 3600)))
+	if pp.Dither {
+		gl.Uniform1i(pp.ditherLoc, 1)
+	} else {
+		gl.Uniform1i(pp.ditherLoc, 0)
+	}
+	gl.Uniform1i(pp.debugOutLoc, pp.debugOut)
+}
+
 // Blit resolves the HDR FBO to the currently bound framebuffer (FBO 0).
-// When bloom is enabled it runs: bright-pass → ping-pong blur → composite.
-// aoTex = SSAO blur texture (0 = disabled), aoStrength = blend factor [0,1].
-func (pp *PostProcessFBO) Blit(aoTex uint32, aoStrength float32) {
+// When bloom is enabled it runs: bright-pass → ping-pong blur (or the mip
+// chain) → composite.
+func (pp *PostProcessFBO) Blit(ao aoComposite) {
 	gl.Disable(gl.DEPTH_TEST)
 	gl.BindVertexArray(pp.quadVAO)
 
 	if pp.BloomEnabled && pp.brightProg != 0 {
-		// ── Step 1: bright-pass → bloomFBO[0] ─────────────────────────────
-		gl.BindFramebuffer(gl.FRAMEBUFFER, pp.bloomFBO[0])
-		gl.Viewport(0, 0, pp.bloomW, pp.bloomH)
+		// ── Step 1: bright-pass → bloomFBO[0] (or the top mip) ────────────
+		pp.timer.begin("bloom")
+		useMips := pp.bloomMips > 0
+		if useMips {
+			gl.BindFramebuffer(gl.FRAMEBUFFER, pp.bloomMipFBO[0])
+			gl.Viewport(0, 0, pp.bloomMipSize[0][0], pp.bloomMipSize[0][1])
+		} else {
+			gl.BindFramebuffer(gl.FRAMEBUFFER, pp.bloomFBO[0])
+			gl.Viewport(0, 0, pp.bloomW, pp.bloomH)
+		}
 		gl.UseProgram(pp.brightProg)
 		gl.Uniform1f(pp.brightThreshLoc, pp.BloomThreshold)
 		gl.ActiveTexture(gl.TEXTURE0)
 		gl.BindTexture(gl.TEXTURE_2D, pp.ColorTex)
+		gl.ActiveTexture(gl.TEXTURE1)
+		gl.BindTexture(gl.TEXTURE_2D, pp.BloomSrcTex)
 		gl.DrawArrays(gl.TRIANGLES, 0, 3)
+		gl.ActiveTexture(gl.TEXTURE0)
 
-		// ── Step 2: ping-pong Gaussian blur ───────────────────────────────
-		// Trace: bright-pass is in bloomTex[0].
+		// ── Step 2: mip chain, or ping-pong Gaussian blur ─────────────────
+		// Ping-pong trace: bright-pass is in bloomTex[0].
 		// Each pair does H (src→dst) then V (dst→src), so after BloomPasses
 		// pairs the result always ends up back in bloomTex[0].
-		src, dst := 0, 1
-		gl.UseProgram(pp.blurProg)
-		for i := 0; i < pp.BloomPasses*2; i++ {
-			gl.BindFramebuffer(gl.FRAMEBUFFER, pp.bloomFBO[dst])
-			if i%2 == 0 { // horizontal
-				gl.Uniform2f(pp.blurDirLoc, 1.0/float32(pp.bloomW), 0)
-			} else { // vertical
-				gl.Uniform2f(pp.blurDirLoc, 0, 1.0/float32(pp.bloomH))
+		bloomResult := pp.bloomTex[0]
+		if useMips {
+			pp.runBloomMips()
+			bloomResult = pp.bloomMipTex[0]
+		} else {
+			src, dst := 0, 1
+			gl.UseProgram(pp.blurProg)
+			for i := 0; i < pp.BloomPasses*2; i++ {
+				gl.BindFramebuffer(gl.FRAMEBUFFER, pp.bloomFBO[dst])
+				if i%2 == 0 { // horizontal
+					gl.Uniform2f(pp.blurDirLoc, 1.0/float32(pp.bloomW), 0)
+				} else { // vertical
+					gl.Uniform2f(pp.blurDirLoc, 0, 1.0/float32(pp.bloomH))
+				}
+				gl.BindTexture(gl.TEXTURE_2D, pp.bloomTex[src])
+				gl.DrawArrays(gl.TRIANGLES, 0, 3)
+				src, dst = dst, src
 			}
-			gl.BindTexture(gl.TEXTURE_2D, pp.bloomTex[src])
-			gl.DrawArrays(gl.TRIANGLES, 0, 3)
-			src, dst = dst, src
+			// After an even number of total iterations the result is in bloomTex[0].
+			// (each pair restores src=0; BloomPasses pairs = BloomPasses*2 iters)
 		}
-		// After an even number of total iterations the result is in bloomTex[0].
-		// (each pair restores src=0; BloomPasses pairs = BloomPasses*2 iters)
 
 		// ── Step 3: composite → default FBO ───────────────────────────────
+		pp.timer.begin("tonemap")
 		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
 		gl.Viewport(0, 0, pp.Width, pp.Height)
 		gl.UseProgram(pp.prog)
@@ -400,19 +883,14 @@ func (pp *PostProcessFBO) Blit(aoTex uint32, aoStrength float32) {
 		gl.ActiveTexture(gl.TEXTURE0)
 		gl.BindTexture(gl.TEXTURE_2D, pp.ColorTex)
 		gl.ActiveTexture(gl.TEXTURE1)
-		gl.BindTexture(gl.TEXTURE_2D, pp.bloomTex[0])
-		if aoTex != 0 {
-			gl.ActiveTexture(gl.TEXTURE2)
-			gl.BindTexture(gl.TEXTURE_2D, aoTex)
-			gl.Uniform1i(pp.hasAOLoc, 1)
-			gl.Uniform1f(pp.aoStrLoc, aoStrength)
-		} else {
-			gl.Uniform1i(pp.hasAOLoc, 0)
-		}
+		gl.BindTexture(gl.TEXTURE_2D, bloomResult)
+		pp.bindAO(ao)
+		pp.bindEffects()
 		gl.DrawArrays(gl.TRIANGLES, 0, 3)
 
 	} else {
 		// ── No bloom: just tone-map ────────────────────────────────────────
+		pp.timer.begin("tonemap")
 		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
 		gl.Viewport(0, 0, pp.Width, pp.Height)
 		gl.UseProgram(pp.prog)
@@ -420,16 +898,11 @@ func (pp *PostProcessFBO) Blit(aoTex uint32, aoStrength float32) {
 		gl.Uniform1i(pp.hasBloomLoc, 0)
 		gl.ActiveTexture(gl.TEXTURE0)
 		gl.BindTexture(gl.TEXTURE_2D, pp.ColorTex)
-		if aoTex != 0 {
-			gl.ActiveTexture(gl.TEXTURE2)
-			gl.BindTexture(gl.TEXTURE_2D, aoTex)
-			gl.Uniform1i(pp.hasAOLoc, 1)
-			gl.Uniform1f(pp.aoStrLoc, aoStrength)
-		} else {
-			gl.Uniform1i(pp.hasAOLoc, 0)
-		}
+		pp.bindAO(ao)
+		pp.bindEffects()
 		gl.DrawArrays(gl.TRIANGLES, 0, 3)
 	}
+	pp.timer.end()
 
 	gl.BindVertexArray(0)
 	gl.Enable(gl.DEPTH_TEST)
@@ -0,0 +1,71 @@
+package opengl
+
+import (
+	"testing"
+
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+)
+
+func TestBloomMipSizes(t *testing.T) {
+	sizes := bloomMipSizes(1280, 720, 5)
+	expected := [][2]int32{{640, 360}, {320, 180}, {160, 90}, {80, 45}, {40, 22}}
+	if len(sizes) != len(expected) {
+		t.Fatalf("expected %d levels, got %d", len(expected), len(sizes))
+	}
+	for i, want := range expected {
+		if sizes[i] != want {
+			t.Errorf("mip %d: expected %v, got %v", i, want, sizes[i])
+		}
+	}
+
+	// Deep chains on small buffers bottom out at 1×1
+	tiny := bloomMipSizes(8, 4, 4)
+	if last := tiny[len(tiny)-1]; last != [2]int32{1, 1} {
+		t.Errorf("tiny chain: expected last level 1×1, got %v", last)
+	}
+}
+
+func TestResizeRecreatesTargets(t *testing.T) {
+	r, cleanup := newTestRenderer(t, 64, 48)
+	defer cleanup()
+	r.SetViewport(64, 48)
+	if err := r.EnablePostProcess(64, 48); err != nil {
+		t.Fatalf("EnablePostProcess: %v", err)
+	}
+	if err := r.EnableSSAO(); err != nil {
+		t.Fatalf("EnableSSAO: %v", err)
+	}
+	if err := r.EnableMotionBlur(8, 1); err != nil {
+		t.Fatalf("EnableMotionBlur: %v", err)
+	}
+
+	// What RenderEngine.Resize does; no pass is rebuilt from scratch
+	prog := r.postProcess.prog
+	r.SetViewport(100, 60)
+	r.ResizePostProcess(100, 60)
+
+	var vp [4]int32
+	gl.GetIntegerv(gl.VIEWPORT, &vp[0])
+	if vp[2] != 100 || vp[3] != 60 {
+		t.Errorf("viewport: expected 100x60, got %dx%d", vp[2], vp[3])
+	}
+	texSize := func(tex uint32) (w, h int32) {
+		gl.BindTexture(gl.TEXTURE_2D, tex)
+		gl.GetTexLevelParameteriv(gl.TEXTURE_2D, 0, gl.TEXTURE_WIDTH, &w)
+		gl.GetTexLevelParameteriv(gl.TEXTURE_2D, 0, gl.TEXTURE_HEIGHT, &h)
+		gl.BindTexture(gl.TEXTURE_2D, 0)
+		return w, h
+	}
+	if w, h := texSize(r.postProcess.ColorTex); w != 100 || h != 60 {
+		t.Errorf("HDR colour: expected 100x60, got %dx%d", w, h)
+	}
+	if r.ssao.viewW != 100 || r.ssao.viewH != 60 {
+		t.Errorf("SSAO: expected 100x60 viewport, got %dx%d", r.ssao.viewW, r.ssao.viewH)
+	}
+	if w, h := texSize(r.motionBlur.VelocityTex); w != 100 || h != 60 {
+		t.Errorf("velocity: expected 100x60, got %dx%d", w, h)
+	}
+	if r.postProcess.prog != prog {
+		t.Error("resize recompiled the tone-map program")
+	}
+}
@@ -2,7 +2,6 @@ package opengl
 
 import (
 	"fmt"
-	gomath "math"
 	"strings"
 	"unsafe"
 
@@ -20,8 +19,11 @@ type GPUMesh struct {
 	EBO         uint32
 	IndexCount  int32
 	HasIndices  bool
+	VertexCap   int    // capacity of VBO in vertices (grows on UpdateMeshVertices)
 	InstanceVBO uint32 // per-instance data VBO (0 = not yet allocated)
 	InstanceCap int    // capacity of InstanceVBO in instances
+	VariantVBO  uint32 // per-instance material index VBO (0 = not yet allocated)
+	VariantCap  int    // capacity of VariantVBO in instances
 }
 
 // Renderer is the OpenGL rendering backend.
@@ -41,20 +43,22 @@ type Renderer struct {
 
 	// Lighting uniforms — point lights (up to 8)
 	pointLightCountLoc     int32
-	pointLightPosLoc       [8]int32
-	pointLightColorLoc     [8]int32
-	pointLightIntensityLoc [8]int32
-	pointLightRangeLoc     [8]int32
+	pointLightPosLoc       [maxPointLights]int32
+	pointLightColorLoc     [maxPointLights]int32
+	pointLightIntensityLoc [maxPointLights]int32
+	pointLightRangeLoc     [maxPointLights]int32
+	pointLightFalloffLoc   [maxPointLights]int32
 
 	// Lighting uniforms — spot lights (up to 4)
 	spotLightCountLoc     int32
-	spotLightPosLoc       [4]int32
-	spotLightDirLoc       [4]int32
-	spotLightColorLoc     [4]int32
-	spotLightIntensityLoc [4]int32
-	spotLightRangeLoc     [4]int32
-	spotLightInnerLoc     [4]int32
-	spotLightOuterLoc     [4]int32
+	spotLightPosLoc       [maxSpotLights]int32
+	spotLightDirLoc       [maxSpotLights]int32
+	spotLightColorLoc     [maxSpotLights]int32
+	spotLightIntensityLoc [maxSpotLights]int32
+	spotLightRangeLoc     [maxSpotLights]int32
+	spotLightFalloffLoc   [maxSpotLights]int32
+	spotLightInnerLoc     [maxSpotLights]int32
+	spotLightOuterLoc     [maxSpotLights]int32
 
 	// Camera uniform (for specular)
 	cameraPosLoc int32
@@ -63,12 +67,14 @@ type Renderer struct {
 	matAlbedoLoc    int32
 	matSpecularLoc  int32
 	matShininessLoc int32
+	matOpacityLoc   int32
 
 	// Material uniforms — PBR
 	usePBRLoc      int32
 	matMetallicLoc int32
 	matRoughnessLoc int32
 	matEmissiveLoc  int32
+	matBloomScaleLoc int32
 
 	// Texture uniforms
 	albedoTexLoc   int32
@@ -81,6 +87,18 @@ type Renderer struct {
 	hasMetallicRoughnessTexLoc int32
 	emissiveTexLoc             int32
 	hasEmissiveTexLoc          int32
+	lightmapTexLoc             int32
+	hasLightmapTexLoc          int32
+
+	// Phong specular and opacity maps
+	specularTexLoc    int32
+	hasSpecularTexLoc int32
+	opacityTexLoc     int32
+	hasOpacityTexLoc  int32
+
+	// Baked ambient occlusion map
+	occlusionTexLoc    int32
+	hasOcclusionTexLoc int32
 
 	// Fog
 	fogEnabledLoc int32
@@ -100,22 +118,64 @@ type Renderer struct {
 	iblHorizon   core.Color
 	iblGround    core.Color
 
+	// Environment-map IBL (nil = sky gradient)
+	useEnvMapLoc     int32
+	envMaxLodLoc     int32
+	envIrradianceLoc int32
+	envPrefilterLoc  int32
+	brdfLUTLoc       int32
+	envSource        *scene.Texture // panorama set by SetEnvironmentMap
+	envIBL           bool           // light from envSource instead of the gradient
+	environment      *Environment   // convolved envSource; built on first use
+	brdfLUT          uint32
+
 	// Instancing
-	instancedLoc int32
+	instancedLoc            int32
+	useInstanceMaterialsLoc int32
+	instanceAlbedoLoc       int32
+	instanceMetalRoughLoc   int32
 
 	// Unlit mode
 	unlitLoc int32
 
+	// Face-normal shading
+	flatShadingLoc int32
+
+	// Vertex colour as a base colour factor
+	useVertexColorLoc int32
+
+	// Wireframe overlay
+	wireOverlayLoc int32
+	wireColorLoc   int32
+
+	// Material debug view (SetDebugView)
+	debugViewLoc int32
+	debugView    DebugView
+
 	// Shadow map uniforms (main shader)
-	shadowMapLoc  int32
-	hasShadowsLoc int32
+	shadowMapLoc      int32
+	hasShadowsLoc     int32
+	shadowDepthLoc    int32 // raw-depth view of the shadow map (unit 5)
+	shadowSoftnessLoc int32
+	receiveShadowLoc  int32
+	receiveShadow     bool // value last uploaded to receiveShadowLoc
+
+	// Shadow bias (SetShadowBias, SetShadowNormalOffset)
+	shadowBiasLoc         int32
+	shadowSlopeBiasLoc    int32
+	shadowNormalOffsetLoc int32
+	shadowBias            float32
+	shadowSlopeBias       float32
+	shadowNormalOffset    float32
 
 	// Shadow depth shader
-	shadowProg        uint32
-	shadowLightMVPLoc int32
+	shadowProg         uint32
+	shadowLightMVPLoc  int32
+	shadowInstancedLoc int32
 
 	// Shadow map FBO (nil if shadows not enabled)
-	shadowMap *ShadowMap
+	shadowMap    *ShadowMap
+	shadowLinear bool // comparison filtering (SetShadowFiltering)
 
 	// Stored viewport for restoring after shadow pass
 	viewportW int32
@@ -124,23 +184,61 @@ type Renderer struct {
 	// Post-processing FBO (nil if disabled)
 	postProcess *PostProcessFBO
 
+	// Off-screen target overriding the HDR/default framebuffer (nil = none)
+	renderTarget *RenderTarget
+
 	// SSAO (nil if disabled; requires postProcess)
 	ssao     *SSAO
 	lastProj math.Mat4 // stored each frame for SSAO pass
+	lastView math.Mat4 // stored each frame for SSAO temporal reprojection
+
+	// Motion blur (nil if disabled; requires postProcess)
+	motionBlur *MotionBlur
+
+	// Decal projection (nil if disabled; requires postProcess)
+	decals *DecalPass
+
+	// Selection outline shader (nil until first DrawOutline call)
+	outline *outlinePass
+
+	// Barycentric wireframe shader (nil until first DrawMeshWireframe call)
+	wire *wirePass
+
+	// Reverse-Z depth convention active (see SetReverseZ)
+	reverseZ bool
 
 	// Skybox (nil if disabled)
 	skybox *Skybox
 
+	// Infinite ground grid (nil if disabled)
+	groundGrid *GroundGrid
+
 	// Particle renderer (nil until first DrawParticles call)
 	particleRenderer *ParticleRenderer
 
 	// Text renderer (nil until first DrawText call)
 	textRenderer *TextRenderer
 
+	// Copy of the last presented frame (CopyFramebuffer), read by captures
+	frameCopy frameCopy
+
 	// Render state
-	wireframe bool
+	wireframe   bool
+	wireOverlay bool       // redraw triangles as lines on top of the shaded pass
+	wireColor   core.Color // unlit colour of the overlay lines
+	wireStyle   WireframeStyle
+	wireWidth   float32    // barycentric line width in pixels
+
+	// Per-pass GPU timing (always present; disabled if timer queries are unsupported)
+	timer *gpuTimer
 
 	gpuMeshes map[*scene.Mesh]*GPUMesh
+
+	// Material last uploaded to the main program (see materialChanged)
+	boundMaterial materialState
+	boundTexEpoch uint64
+	materialBound bool
+	materialBinds int // uploads since BeginFrame
 }
 
 // ── Shaders ───────────────────────────────────────────────────────────────────
@@ -167,18 +265,27 @@ layout(location = 11) in vec4 instModel1;
 layout(location = 12) in vec4 instModel2;
 layout(location = 13) in vec4 instModel3;
 
+// Second UV set (lightmap coordinates); zero when the mesh has none
+layout(location = 14) in vec2 inUV2;
+
+// Per-instance material variant index (DrawMeshInstancedVariants only)
+layout(location = 15) in uint instVariant;
+
 uniform mat4 mvp;
 uniform mat4 model;
 uniform mat4 lightViewProj;
 uniform bool instanced;
+uniform float shadowNormalOffset; // world units along the normal for the shadow lookup
 
 out vec4 fragColor;
 out vec3 fragNormal;
 out vec2 fragUV;
+out vec2 fragUV2;
 out vec3 fragWorldPos;
 out vec4 fragLightSpacePos;
 out vec3 fragTangent;
 out vec3 fragBitangent;
+flat out uint fragVariant;
 
 void main() {
     mat4 effectiveMVP;
@@ -191,21 +298,28 @@ void main() {
         effectiveMVP      = iMVP;
         normalMat         = mat3(iModel);
         worldPos          = iModel * vec4(inPosition, 1.0);
-        fragLightSpacePos = lightViewProj * worldPos;
     } else {
         effectiveMVP      = mvp;
         normalMat         = mat3(model);
         worldPos          = model * vec4(inPosition, 1.0);
-        fragLightSpacePos = lightViewProj * worldPos;
     }
+    // Looking the shadow up from slightly above the surface keeps it out of
+    // its own depth texels
+    vec4 shadowPos = worldPos;
+    if (shadowNormalOffset != 0.0) {
+        shadowPos.xyz += normalize(normalMat * inNormal) * shadowNormalOffset;
+    }
+    fragLightSpacePos = lightViewProj * shadowPos;
 
     gl_Position   = effectiveMVP * vec4(inPosition, 1.0);
     fragColor     = inColor;
     fragNormal    = normalMat * inNormal;
     fragUV        = inUV;
+    fragUV2       = inUV2;
     fragWorldPos  = worldPos.xyz;
     fragTangent   = normalMat * inTangent;
     fragBitangent = normalMat * inBitangent;
+    fragVariant   = instanced ? instVariant : 0u;
 }
 ` + "\x00"
 
@@ -217,12 +331,15 @@ const fragSrc = `
 in vec4 fragColor;
 in vec3 fragNormal;
 in vec2 fragUV;
+in vec2 fragUV2;
 in vec3 fragWorldPos;
 in vec4 fragLightSpacePos;
 in vec3 fragTangent;
 in vec3 fragBitangent;
+flat in uint fragVariant;
 
-out vec4 outColor;
+layout(location = 0) out vec4 outColor;
+layout(location = 1) out vec4 outBloom; // HDR FBO only: emissive that always blooms
 
 // Directional light
 uniform vec3  lightDir;
@@ -237,6 +354,7 @@ uniform vec3  pointLightPos[MAX_POINT_LIGHTS];
 uniform vec3  pointLightColor[MAX_POINT_LIGHTS];
 uniform float pointLightIntensity[MAX_POINT_LIGHTS];
 uniform float pointLightRange[MAX_POINT_LIGHTS];
+uniform int   pointLightFalloff[MAX_POINT_LIGHTS];
 
 // Spot lights (up to 4)
 #define MAX_SPOT_LIGHTS 4
@@ -246,6 +364,7 @@ uniform vec3  spotLightDir[MAX_SPOT_LIGHTS];
 uniform vec3  spotLightColor[MAX_SPOT_LIGHTS];
 uniform float spotLightIntensity[MAX_SPOT_LIGHTS];
 uniform float spotLightRange[MAX_SPOT_LIGHTS];
+uniform int   spotLightFalloff[MAX_SPOT_LIGHTS];
 uniform float spotLightInner[MAX_SPOT_LIGHTS];
 uniform float spotLightOuter[MAX_SPOT_LIGHTS];
 
@@ -256,12 +375,21 @@ uniform vec3 cameraPos;
 uniform vec3  matAlbedo;
 uniform vec3  matSpecular;
 uniform float matShininess;
+uniform float matOpacity; // Albedo alpha for transparent materials, else 1
 
 // PBR material
 uniform bool  usePBR;
 uniform float matMetallic;
 uniform float matRoughness;
 uniform vec3  matEmissive;
+uniform float matBloomScale; // emissive multiplier for the bloom-only target
+
+// Instance material variants: when set, fragVariant picks the albedo and
+// opacity (rgb, a) and metallic and roughness (x, y) in place of the above
+#define MAX_INSTANCE_MATERIALS 8
+uniform bool useInstanceMaterials;
+uniform vec4 instanceAlbedo[MAX_INSTANCE_MATERIALS];
+uniform vec2 instanceMetalRough[MAX_INSTANCE_MATERIALS];
 
 // Albedo texture (unit 0)
 uniform sampler2D albedoTex;
@@ -270,6 +398,11 @@ uniform bool      hasTexture;
 // Shadow map (unit 1) — sampler2DShadow enables hardware PCF comparison
 uniform sampler2DShadow shadowMap;
 uniform bool            hasShadows;
+uniform bool            receiveShadow;  // per draw: false = never in shadow
+uniform sampler2D       shadowDepth;    // same texture, no compare: blocker search
+uniform float           shadowSoftness; // penumbra UV per unit of depth; 0 = fixed 3x3 PCF
+uniform float           shadowBias;      // depth bias facing the light
+uniform float           shadowSlopeBias; // extra bias per unit of tan(angle to the light)
 
 // Normal map (unit 2) — tangent-space RGB normal map
 uniform sampler2D normalTex;
@@ -283,9 +416,39 @@ uniform bool      hasMetallicRoughnessTex;
 uniform sampler2D emissiveTex;
 uniform bool      hasEmissiveTex;
 
+// Baked lightmap (unit 6), sampled with the second UV set
+uniform sampler2D lightmapTex;
+uniform bool      hasLightmapTex;
+
+// Phong specular map (unit 10): RGB multiplies matSpecular
+uniform sampler2D specularTex;
+uniform bool      hasSpecularTex;
+
+// Opacity map (unit 11): R multiplies the base colour's alpha
+uniform sampler2D opacityTex;
+uniform bool      hasOpacityTex;
+
+// Baked ambient occlusion (unit 12): R multiplies ambient and IBL light
+uniform sampler2D occlusionTex;
+uniform bool      hasOcclusionTex;
+
 // When true, skip all lighting and output raw base color
 uniform bool unlit;
 
+// When true, light with the triangle's face normal instead of fragNormal
+uniform bool flatShading;
+
+// When false, ignore the vertex colour: the base colour is matAlbedo alone
+uniform bool useVertexColor;
+
+// Wireframe overlay pass: output a flat wire colour, nothing else
+uniform bool wireOverlay;
+uniform vec3 wireColor;
+
+// Material debug view (DebugView): 0 = off, 1 = normals, 2 = UVs,
+// 3 = roughness, 4 = metallic, 5 = ambient occlusion
+uniform int debugView;
+
 // Exponential depth fog
 uniform bool  fogEnabled;
 uniform vec3  fogColor;
@@ -297,17 +460,55 @@ uniform vec3 iblZenith;   // sky colour straight up
 uniform vec3 iblHorizon;  // sky colour at eye level
 uniform vec3 iblGround;   // sky colour below horizon
 
+// Environment-map IBL (replaces the gradient when set): equirectangular
+// irradiance (unit 7) and roughness-mipped prefiltered radiance (unit 8)
+uniform bool        useEnvMap;
+uniform samplerCube envIrradiance;
+uniform samplerCube envPrefiltered;
+uniform sampler2D   brdfLUT;
+uniform float       envMaxLod;
+
+
 // ── Shadow ───────────────────────────────────────────────────────────────────
 
-float calcShadow() {
+// N is the geometric normal: the bias follows the surface's slope to the
+// light, since a texel covers more depth the more the surface is tilted.
+float calcShadow(vec3 N) {
     vec3 p = fragLightSpacePos.xyz / fragLightSpacePos.w;
     p = p * 0.5 + 0.5;
-    if (p.z > 1.0) return 1.0;
-    float shadow = 0.0;
+    // Outside the light's frustum: lit, rather than whatever the map's edge holds
+    if (p.z > 1.0 || any(lessThan(p.xy, vec2(0.0))) || any(greaterThan(p.xy, vec2(1.0)))) return 1.0;
     float ts = 1.0 / 2048.0;
+
+    float NdL      = clamp(dot(N, normalize(-lightDir)), 0.0, 1.0);
+    float tanTheta = sqrt(1.0 - NdL * NdL) / max(NdL, 0.1); // capped near grazing
+    float bias     = shadowBias + shadowSlopeBias * tanTheta;
+
+    // Sun-sized light: penumbra grows with receiver-to-occluder distance.
+    // Average the occluder depth around the fragment, then widen the PCF
+    // kernel in proportion to the depth gap.
+    float spread = 1.0;
+    if (shadowSoftness > 0.0) {
+        float blockerSum = 0.0;
+        float blockers   = 0.0;
+        for (int x = -2; x <= 2; x++) {
+            for (int y = -2; y <= 2; y++) {
+                float d = texture(shadowDepth, p.xy + vec2(float(x), float(y)) * ts * 3.0).r;
+                if (d < p.z - bias) {
+                    blockerSum += d;
+                    blockers   += 1.0;
+                }
+            }
+        }
+        if (blockers == 0.0) return 1.0;
+        float penumbra = (p.z - blockerSum / blockers) * shadowSoftness; // UV width
+        spread = clamp(0.5 * penumbra / ts, 1.0, 8.0);
+    }
+
+    float shadow = 0.0;
     for (int x = -1; x <= 1; x++) {
         for (int y = -1; y <= 1; y++) {
-            shadow += texture(shadowMap, vec3(p.xy + vec2(float(x), float(y)) * ts, p.z - 0.002));
+            shadow += texture(shadowMap, vec3(p.xy + vec2(float(x), float(y)) * ts * spread, p.z - bias));
         }
     }
     return shadow / 9.0;
@@ -317,7 +518,17 @@ float calcShadow() {
 
 vec3 calcSpecular(vec3 N, vec3 L, vec3 V) {
     vec3 H = normalize(L + V);
-    return matSpecular * pow(max(dot(N, H), 0.0), matShininess);
+    vec3 spec = matSpecular;
+    if (hasSpecularTex) {
+        spec *= texture(specularTex, fragUV).rgb;
+    }
+    // An MTL shininess map (map_Ns) arrives inverted into the roughness
+    // channel; un-invert it to scale Ns as the MTL format specifies
+    float shininess = matShininess;
+    if (hasMetallicRoughnessTex) {
+        shininess = max(1.0, shininess * (1.0 - texture(metallicRoughnessTex, fragUV).g));
+    }
+    return spec * pow(max(dot(N, H), 0.0), shininess);
 }
 
 // ── PBR helpers (Cook-Torrance BRDF) ─────────────────────────────────────────
@@ -351,6 +562,19 @@ vec3 FresnelSchlickRoughness(float cosTheta, vec3 F0, float roughness) {
     return F0 + (max(vec3(1.0 - roughness), F0) - F0) * pow(clamp(1.0 - cosTheta, 0.0, 1.0), 5.0);
 }
 
+// Distance attenuation for point/spot lights (scene.Light.Attenuation).
+// falloff 0: smooth window (1 - d²/range²)²; 1: inverse square, with d
+// clamped to 1 cm so surfaces at the light do not blow up.
+float distanceAtten(float dist, float range, int falloff) {
+    if (falloff == 1) {
+        float d = max(dist, 0.01);
+        return 1.0 / (d * d);
+    }
+    range = max(range, 0.001);
+    float a = clamp(1.0 - (dist * dist) / (range * range), 0.0, 1.0);
+    return a * a;
+}
+
 // Sample the procedural sky gradient in direction dir (must be normalised).
 // dir.y > 0 → lerp horizon→zenith; dir.y < 0 → lerp horizon→ground.
 vec3 sampleSkyGradient(vec3 dir) {
@@ -359,6 +583,12 @@ vec3 sampleSkyGradient(vec3 dir) {
     else          return mix(iblHorizon, iblGround,  -y);
 }
 
+// Diffuse irradiance arriving at a surface with normal N.
+vec3 sampleIrradiance(vec3 N) {
+    if (useEnvMap) return texture(envIrradiance, N).rgb;
+    return sampleSkyGradient(N);
+}
+
 // Evaluate one Cook-Torrance lobe. L = unit vector toward light, rad = light radiance.
 vec3 evalPBR(vec3 N, vec3 V, vec3 L, vec3 rad, vec3 albedo, float metallic, float roughness, vec3 F0) {
     float NdL = max(dot(N, L), 0.0);
@@ -380,37 +610,97 @@ vec3 evalPBR(vec3 N, vec3 V, vec3 L, vec3 rad, vec3 albedo, float metallic, floa
 // ── Main ─────────────────────────────────────────────────────────────────────
 
 void main() {
-    // World-space normal — from normal map (TBN) or interpolated vertex normal
+    if (wireOverlay) {
+        outColor = vec4(wireColor, 1.0);
+        outBloom = vec4(0.0);
+        return;
+    }
+
+    // Surface normal: interpolated, or for flat shading the face normal from
+    // the screen-space derivatives of the position (the same across the
+    // triangle), turned to the side the vertex normals face
+    vec3 Nv = normalize(fragNormal);
+    if (flatShading) {
+        vec3 Nf = normalize(cross(dFdx(fragWorldPos), dFdy(fragWorldPos)));
+        Nv = dot(Nf, fragNormal) < 0.0 ? -Nf : Nf;
+    }
+
+    // World-space normal — from normal map (TBN) or the surface normal
     vec3 N;
     if (hasNormalTex) {
         vec3 T  = normalize(fragTangent);
         vec3 B  = normalize(fragBitangent);
-        vec3 Nv = normalize(fragNormal);
         mat3 TBN = mat3(T, B, Nv);
         N = normalize(TBN * (texture(normalTex, fragUV).rgb * 2.0 - 1.0));
     } else {
-        N = normalize(fragNormal);
+        N = Nv;
     }
     vec3 V = normalize(cameraPos - fragWorldPos);
 
-    // Base color: vertex color * material albedo (* texture if present)
-    vec4 baseColor = fragColor * vec4(matAlbedo, 1.0);
+    outBloom = vec4(0.0);
+
+    vec4  materialColor     = vec4(matAlbedo, matOpacity);
+    float materialMetallic  = matMetallic;
+    float materialRoughness = matRoughness;
+    if (useInstanceMaterials) {
+        uint i = min(fragVariant, uint(MAX_INSTANCE_MATERIALS - 1));
+        materialColor     = instanceAlbedo[i];
+        materialMetallic  = instanceMetalRough[i].x;
+        materialRoughness = instanceMetalRough[i].y;
+    }
+    float ao = hasOcclusionTex ? texture(occlusionTex, fragUV).r : 1.0;
+
+    // Debug views output one material input, unlit and unfogged
+    if (debugView != 0) {
+        vec3 v = vec3(ao); // 5: baked occlusion; the post pass multiplies in SSAO
+        if (debugView == 1) {
+            v = N * 0.5 + 0.5;
+        } else if (debugView == 2) {
+            v = vec3(fract(fragUV), 0.0);
+        } else if (debugView == 3 || debugView == 4) {
+            float roughness = materialRoughness;
+            float metallic  = materialMetallic;
+            if (hasMetallicRoughnessTex) {
+                vec4 mr = texture(metallicRoughnessTex, fragUV);
+                roughness = mr.g;
+                metallic  = mr.b;
+            }
+            v = vec3(debugView == 3 ? roughness : metallic);
+        }
+        outColor = vec4(v, 1.0);
+        return;
+    }
+
+    // Base color: vertex color (if used) * material albedo (* texture if present)
+    vec4 baseColor = materialColor;
+    if (useVertexColor) {
+        baseColor *= fragColor;
+    }
     if (hasTexture) {
         baseColor *= texture(albedoTex, fragUV);
     }
+    if (hasOpacityTex) {
+        baseColor.a *= texture(opacityTex, fragUV).r;
+    }
 
-    // Unlit: skip all lighting
+    // Unlit: skip all lighting, but keep emissive (and its bloom) so
+    // glowing signs and screens need no lights
     if (unlit) {
-        outColor = baseColor;
+        vec3 emissive = matEmissive;
+        if (hasEmissiveTex) {
+            emissive *= texture(emissiveTex, fragUV).rgb;
+        }
+        outColor = vec4(baseColor.rgb + emissive, baseColor.a);
+        outBloom = vec4(emissive * matBloomScale, 1.0);
         return;
     }
 
-    float shadowFactor = hasShadows ? calcShadow() : 1.0;
+    float shadowFactor = hasShadows && receiveShadow ? calcShadow(Nv) : 1.0;
 
     // ── PBR path ─────────────────────────────────────────────────────────────
     if (usePBR) {
-        float metallic  = matMetallic;
-        float roughness = clamp(matRoughness, 0.04, 1.0);
+        float metallic  = materialMetallic;
+        float roughness = clamp(materialRoughness, 0.04, 1.0);
         if (hasMetallicRoughnessTex) {
             vec4 mr  = texture(metallicRoughnessTex, fragUV);
             roughness = clamp(mr.g, 0.04, 1.0);
@@ -423,19 +713,27 @@ void main() {
         // Ambient: sky-based IBL or flat fallback
         vec3 color;
         if (useIBL) {
-            // Diffuse irradiance: sky gradient sampled at surface normal direction
-            vec3 irradiance = sampleSkyGradient(N);
+            // Diffuse irradiance: environment or sky gradient at the surface normal
+            vec3 irradiance = sampleIrradiance(N);
             vec3 F_ibl = FresnelSchlickRoughness(max(dot(N, V), 0.0), F0, roughness);
             vec3 kD    = (vec3(1.0) - F_ibl) * (1.0 - metallic);
             vec3 diffuseIBL = irradiance * albedo * kD;
-            // Specular IBL: sky gradient in reflected direction, fades with roughness
+            // Specular IBL: split sum (prefiltered environment at the roughness
+            // mip × BRDF LUT scale/bias), or the sky gradient in the reflected
+            // direction faded with roughness
             vec3 R = reflect(-V, N);
-            vec3 specIrradiance = sampleSkyGradient(R);
-            float specStrength  = (1.0 - roughness * roughness);
-            vec3 specularIBL    = specIrradiance * F_ibl * specStrength;
-            color = diffuseIBL + specularIBL;
+            vec3 specularIBL;
+            if (useEnvMap) {
+                vec3 prefiltered = textureLod(envPrefiltered, R, roughness * envMaxLod).rgb;
+                vec2 envBRDF     = texture(brdfLUT, vec2(max(dot(N, V), 0.0), roughness)).rg;
+                specularIBL = prefiltered * (F_ibl * envBRDF.x + envBRDF.y);
+            } else {
+                float specStrength = (1.0 - roughness * roughness);
+                specularIBL = sampleSkyGradient(R) * F_ibl * specStrength;
+            }
+            color = (diffuseIBL + specularIBL) * ao;
         } else {
-            color = ambientColor * albedo * (1.0 - 0.5 * metallic);
+            color = ambientColor * albedo * (1.0 - 0.5 * metallic) * ao;
         }
 
         // Directional light
@@ -447,9 +745,7 @@ void main() {
         for (int i = 0; i < pointLightCount && i < MAX_POINT_LIGHTS; i++) {
             vec3  toLight = pointLightPos[i] - fragWorldPos;
             float dist    = length(toLight);
-            float range   = max(pointLightRange[i], 0.001);
-            float atten   = clamp(1.0 - (dist*dist)/(range*range), 0.0, 1.0);
-            atten *= atten;
+            float atten   = distanceAtten(dist, pointLightRange[i], pointLightFalloff[i]);
             vec3 ptRad = pointLightColor[i] * pointLightIntensity[i] * atten;
             color += evalPBR(N, V, normalize(toLight), ptRad, albedo, metallic, roughness, F0);
         }
@@ -458,23 +754,26 @@ void main() {
         for (int i = 0; i < spotLightCount && i < MAX_SPOT_LIGHTS; i++) {
             vec3  toLight = spotLightPos[i] - fragWorldPos;
             float dist    = length(toLight);
-            float range   = max(spotLightRange[i], 0.001);
-            float atten   = clamp(1.0 - (dist*dist)/(range*range), 0.0, 1.0);
-            atten *= atten;
+            float atten   = distanceAtten(dist, spotLightRange[i], spotLightFalloff[i]);
             vec3  L     = normalize(toLight);
             float theta = dot(L, normalize(-spotLightDir[i]));
-            float eps   = spotLightInner[i] - spotLightOuter[i];
+            float eps   = max(spotLightInner[i] - spotLightOuter[i], 1e-4); // inner == outer: hard edge
             float cone  = clamp((theta - spotLightOuter[i]) / eps, 0.0, 1.0);
             vec3 spRad = spotLightColor[i] * spotLightIntensity[i] * atten * cone;
             color += evalPBR(N, V, L, spRad, albedo, metallic, roughness, F0);
         }
 
+        if (hasLightmapTex) {
+            color *= texture(lightmapTex, fragUV2).rgb;
+        }
+
         // Emissive
         vec3 emissive = matEmissive;
         if (hasEmissiveTex) {
             emissive *= texture(emissiveTex, fragUV).rgb;
         }
         color += emissive;
+        outBloom = vec4(emissive * matBloomScale, 1.0);
 
         if (fogEnabled) {
             float fogDist = length(fragWorldPos - cameraPos);
@@ -488,9 +787,9 @@ void main() {
     // ── Phong path ───────────────────────────────────────────────────────────
     vec3 color;
     if (useIBL) {
-        color = sampleSkyGradient(N) * baseColor.rgb * 0.35;
+        color = sampleIrradiance(N) * baseColor.rgb * 0.35 * ao;
     } else {
-        color = ambientColor * baseColor.rgb;
+        color = ambientColor * baseColor.rgb * ao;
     }
 
     // Directional light
@@ -505,9 +804,7 @@ void main() {
     for (int i = 0; i < pointLightCount && i < MAX_POINT_LIGHTS; i++) {
         vec3  toLight = pointLightPos[i] - fragWorldPos;
         float dist    = length(toLight);
-        float range   = max(pointLightRange[i], 0.001);
-        float atten   = clamp(1.0 - (dist * dist) / (range * range), 0.0, 1.0);
-        atten *= atten;
+        float atten   = distanceAtten(dist, pointLightRange[i], pointLightFalloff[i]);
         vec3  L_pt = normalize(toLight);
         float NdL2 = max(dot(N, L_pt), 0.0);
         color += pointLightColor[i] * pointLightIntensity[i] * atten * NdL2 * baseColor.rgb;
@@ -520,12 +817,10 @@ void main() {
     for (int i = 0; i < spotLightCount && i < MAX_SPOT_LIGHTS; i++) {
         vec3  toLight = spotLightPos[i] - fragWorldPos;
         float dist    = length(toLight);
-        float range   = max(spotLightRange[i], 0.001);
-        float atten   = clamp(1.0 - (dist * dist) / (range * range), 0.0, 1.0);
-        atten *= atten;
+        float atten   = distanceAtten(dist, spotLightRange[i], spotLightFalloff[i]);
         vec3  L     = normalize(toLight);
         float theta = dot(L, normalize(-spotLightDir[i]));
-        float eps   = spotLightInner[i] - spotLightOuter[i];
+        float eps   = max(spotLightInner[i] - spotLightOuter[i], 1e-4); // inner == outer: hard edge
         float cone  = clamp((theta - spotLightOuter[i]) / eps, 0.0, 1.0);
         float NdL3  = max(dot(N, L), 0.0);
         float contrib = atten * cone * spotLightIntensity[i];
@@ -535,6 +830,18 @@ void main() {
         }
     }
 
+    if (hasLightmapTex) {
+        color *= texture(lightmapTex, fragUV2).rgb;
+    }
+
+    // Emissive
+    vec3 emissive = matEmissive;
+    if (hasEmissiveTex) {
+        emissive *= texture(emissiveTex, fragUV).rgb;
+    }
+    color += emissive;
+    outBloom = vec4(emissive * matBloomScale, 1.0);
+
     if (fogEnabled) {
         float fogDist = length(fragWorldPos - cameraPos);
         float fogF    = clamp(exp(-fogDensity * fogDist), 0.0, 1.0);
@@ -548,9 +855,16 @@ void main() {
 const depthVertSrc = `
 #version 410 core
 layout(location = 0) in vec3 inPosition;
+// Per-instance light MVP (DrawMeshShadowInstanced), same slots as the main shader
+layout(location = 6) in vec4 instMVP0;
+layout(location = 7) in vec4 instMVP1;
+layout(location = 8) in vec4 instMVP2;
+layout(location = 9) in vec4 instMVP3;
 uniform mat4 lightMVP;
+uniform bool instanced;
 void main() {
-    gl_Position = lightMVP * vec4(inPosition, 1.0);
+    mat4 m = instanced ? mat4(instMVP0, instMVP1, instMVP2, instMVP3) : lightMVP;
+    gl_Position = m * vec4(inPosition, 1.0);
 }
 ` + "\x00"
 
@@ -572,7 +886,11 @@ func NewRenderer() (*Renderer, error) {
 	version := gl.GoStr(gl.GetString(gl.VERSION))
 	fmt.Printf("OpenGL version: %s\n", version)
 
-	prog, err := newProgram(vertSrc, fragSrc)
+	vs, fs, err := mainShaderSources()
+	if err != nil {
+		return nil, fmt.Errorf("main shader: %w", err)
+	}
+	prog, err := newProgram(vs, fs)
 	if err != nil {
 		return nil, fmt.Errorf("main shader compile: %w", err)
 	}
@@ -589,104 +907,171 @@ func NewRenderer() (*Renderer, error) {
 		program:    prog,
 		shadowProg: shadowProg,
 
-		mvpLoc:           gl.GetUniformLocation(prog, gl.Str("mvp\x00")),
-		modelLoc:         gl.GetUniformLocation(prog, gl.Str("model\x00")),
-		lightViewProjLoc: gl.GetUniformLocation(prog, gl.Str("lightViewProj\x00")),
-
-		lightDirLoc:       gl.GetUniformLocation(prog, gl.Str("lightDir\x00")),
-		lightColorLoc:     gl.GetUniformLocation(prog, gl.Str("lightColor\x00")),
-		lightIntensityLoc: gl.GetUniformLocation(prog, gl.Str("lightIntensity\x00")),
-		ambientColorLoc:   gl.GetUniformLocation(prog, gl.Str("ambientColor\x00")),
-
-		pointLightCountLoc: gl.GetUniformLocation(prog, gl.Str("pointLightCount\x00")),
-		cameraPosLoc:       gl.GetUniformLocation(prog, gl.Str("cameraPos\x00")),
-
-		matAlbedoLoc:    gl.GetUniformLocation(prog, gl.Str("matAlbedo\x00")),
-		matSpecularLoc:  gl.GetUniformLocation(prog, gl.Str("matSpecular\x00")),
-		matShininessLoc: gl.GetUniformLocation(prog, gl.Str("matShininess\x00")),
-
-		usePBRLoc:       gl.GetUniformLocation(prog, gl.Str("usePBR\x00")),
-		matMetallicLoc:  gl.GetUniformLocation(prog, gl.Str("matMetallic\x00")),
-		matRoughnessLoc: gl.GetUniformLocation(prog, gl.Str("matRoughness\x00")),
-		matEmissiveLoc:  gl.GetUniformLocation(prog, gl.Str("matEmissive\x00")),
-
-		albedoTexLoc:    gl.GetUniformLocation(prog, gl.Str("albedoTex\x00")),
-		hasTextureLoc:   gl.GetUniformLocation(prog, gl.Str("hasTexture\x00")),
-		normalTexLoc:    gl.GetUniformLocation(prog, gl.Str("normalTex\x00")),
-		hasNormalTexLoc: gl.GetUniformLocation(prog, gl.Str("hasNormalTex\x00")),
-
-		metallicRoughnessTexLoc:    gl.GetUniformLocation(prog, gl.Str("metallicRoughnessTex\x00")),
-		hasMetallicRoughnessTexLoc: gl.GetUniformLocation(prog, gl.Str("hasMetallicRoughnessTex\x00")),
-		emissiveTexLoc:             gl.GetUniformLocation(prog, gl.Str("emissiveTex\x00")),
-		hasEmissiveTexLoc:          gl.GetUniformLocation(prog, gl.Str("hasEmissiveTex\x00")),
+		shadowLightMVPLoc:  gl.GetUniformLocation(shadowProg, gl.Str("lightMVP\x00")),
+		shadowInstancedLoc: gl.GetUniformLocation(shadowProg, gl.Str("instanced\x00")),
 
-		instancedLoc: gl.GetUniformLocation(prog, gl.Str("instanced\x00")),
-		unlitLoc:     gl.GetUniformLocation(prog, gl.Str("unlit\x00")),
+		fogDensity: 0.03,
+		fogColor:   core.Color{R: 0.7, G: 0.7, B: 0.75, A: 1},
 
-		useIBLLoc:    gl.GetUniformLocation(prog, gl.Str("useIBL\x00")),
-		iblZenithLoc:  gl.GetUniformLocation(prog, gl.Str("iblZenith\x00")),
-		iblHorizonLoc: gl.GetUniformLocation(prog, gl.Str("iblHorizon\x00")),
-		iblGroundLoc:  gl.GetUniformLocation(prog, gl.Str("iblGround\x00")),
-
-		fogEnabledLoc: gl.GetUniformLocation(prog, gl.Str("fogEnabled\x00")),
-		fogColorLoc:   gl.GetUniformLocation(prog, gl.Str("fogColor\x00")),
-		fogDensityLoc: gl.GetUniformLocation(prog, gl.Str("fogDensity\x00")),
-		fogDensity:    0.03,
-		fogColor:      core.Color{R: 0.7, G: 0.7, B: 0.75, A: 1},
-
-		shadowMapLoc:  gl.GetUniformLocation(prog, gl.Str("shadowMap\x00")),
-		hasShadowsLoc: gl.GetUniformLocation(prog, gl.Str("hasShadows\x00")),
-
-		shadowLightMVPLoc: gl.GetUniformLocation(shadowProg, gl.Str("lightMVP\x00")),
+		shadowBias:      0.001,
+		shadowSlopeBias: 0.001,
+		shadowLinear:    true,
 
+		timer:     newGPUTimer(),
 		gpuMeshes: make(map[*scene.Mesh]*GPUMesh),
+		wireWidth: 1.5,
 	}
+	r.resolveUniforms()
+
+	return r, nil
+}
+
+// resolveUniforms looks up the main program's uniform locations and sets the
+// uniforms that never change per frame (sampler units, a safe lightViewProj).
+// Called for every new main program: at startup and by ReloadShaders.
+func (r *Renderer) resolveUniforms() {
+	r.materialBound = false
+
+	r.mvpLoc           = gl.GetUniformLocation(r.program, gl.Str("mvp\x00"))
+	r.modelLoc         = gl.GetUniformLocation(r.program, gl.Str("model\x00"))
+	r.lightViewProjLoc = gl.GetUniformLocation(r.program, gl.Str("lightViewProj\x00"))
+
+	r.lightDirLoc       = gl.GetUniformLocation(r.program, gl.Str("lightDir\x00"))
+	r.lightColorLoc     = gl.GetUniformLocation(r.program, gl.Str("lightColor\x00"))
+	r.lightIntensityLoc = gl.GetUniformLocation(r.program, gl.Str("lightIntensity\x00"))
+	r.ambientColorLoc   = gl.GetUniformLocation(r.program, gl.Str("ambientColor\x00"))
+
+	r.pointLightCountLoc = gl.GetUniformLocation(r.program, gl.Str("pointLightCount\x00"))
+	r.cameraPosLoc       = gl.GetUniformLocation(r.program, gl.Str("cameraPos\x00"))
+
+	r.matAlbedoLoc    = gl.GetUniformLocation(r.program, gl.Str("matAlbedo\x00"))
+	r.matSpecularLoc  = gl.GetUniformLocation(r.program, gl.Str("matSpecular\x00"))
+	r.matShininessLoc = gl.GetUniformLocation(r.program, gl.Str("matShininess\x00"))
+	r.matOpacityLoc   = gl.GetUniformLocation(r.program, gl.Str("matOpacity\x00"))
+
+	r.usePBRLoc        = gl.GetUniformLocation(r.program, gl.Str("usePBR\x00"))
+	r.matMetallicLoc   = gl.GetUniformLocation(r.program, gl.Str("matMetallic\x00"))
+	r.matRoughnessLoc  = gl.GetUniformLocation(r.program, gl.Str("matRoughness\x00"))
+	r.matEmissiveLoc   = gl.GetUniformLocation(r.program, gl.Str("matEmissive\x00"))
+	r.matBloomScaleLoc = gl.GetUniformLocation(r.program, gl.Str("matBloomScale\x00"))
+
+	r.albedoTexLoc    = gl.GetUniformLocation(r.program, gl.Str("albedoTex\x00"))
+	r.hasTextureLoc   = gl.GetUniformLocation(r.program, gl.Str("hasTexture\x00"))
+	r.normalTexLoc    = gl.GetUniformLocation(r.program, gl.Str("normalTex\x00"))
+	r.hasNormalTexLoc = gl.GetUniformLocation(r.program, gl.Str("hasNormalTex\x00"))
+
+	r.metallicRoughnessTexLoc    = gl.GetUniformLocation(r.program, gl.Str("metallicRoughnessTex\x00"))
+	r.hasMetallicRoughnessTexLoc = gl.GetUniformLocation(r.program, gl.Str("hasMetallicRoughnessTex\x00"))
+	r.emissiveTexLoc             = gl.GetUniformLocation(r.program, gl.Str("emissiveTex\x00"))
+	r.hasEmissiveTexLoc          = gl.GetUniformLocation(r.program, gl.Str("hasEmissiveTex\x00"))
+	r.lightmapTexLoc             = gl.GetUniformLocation(r.program, gl.Str("lightmapTex\x00"))
+	r.hasLightmapTexLoc          = gl.GetUniformLocation(r.program, gl.Str("hasLightmapTex\x00"))
+
+	r.specularTexLoc    = gl.GetUniformLocation(r.program, gl.Str("specularTex\x00"))
+	r.hasSpecularTexLoc = gl.GetUniformLocation(r.program, gl.Str("hasSpecularTex\x00"))
+	r.opacityTexLoc     = gl.GetUniformLocation(r.program, gl.Str("opacityTex\x00"))
+	r.hasOpacityTexLoc  = gl.GetUniformLocation(r.program, gl.Str("hasOpacityTex\x00"))
+
+	r.occlusionTexLoc    = gl.GetUniformLocation(r.program, gl.Str("occlusionTex\x00"))
+	r.hasOcclusionTexLoc = gl.GetUniformLocation(r.program, gl.Str("hasOcclusionTex\x00"))
+
+	r.instancedLoc = gl.GetUniformLocation(r.program, gl.Str("instanced\x00"))
+	r.unlitLoc     = gl.GetUniformLocation(r.program, gl.Str("unlit\x00"))
+
+	r.useInstanceMaterialsLoc = gl.GetUniformLocation(r.program, gl.Str("useInstanceMaterials\x00"))
+	r.instanceAlbedoLoc       = gl.GetUniformLocation(r.program, gl.Str("instanceAlbedo\x00"))
+	r.instanceMetalRoughLoc   = gl.GetUniformLocation(r.program, gl.Str("instanceMetalRough\x00"))
+
+	r.flatShadingLoc    = gl.GetUniformLocation(r.program, gl.Str("flatShading\x00"))
+	r.useVertexColorLoc = gl.GetUniformLocation(r.program, gl.Str("useVertexColor\x00"))
+
+	r.wireOverlayLoc = gl.GetUniformLocation(r.program, gl.Str("wireOverlay\x00"))
+	r.wireColorLoc   = gl.GetUniformLocation(r.program, gl.Str("wireColor\x00"))
+	r.debugViewLoc   = gl.GetUniformLocation(r.program, gl.Str("debugView\x00"))
+
+	r.useIBLLoc     = gl.GetUniformLocation(r.program, gl.Str("useIBL\x00"))
+	r.iblZenithLoc  = gl.GetUniformLocation(r.program, gl.Str("iblZenith\x00"))
+	r.iblHorizonLoc = gl.GetUniformLocation(r.program, gl.Str("iblHorizon\x00"))
+	r.iblGroundLoc  = gl.GetUniformLocation(r.program, gl.Str("iblGround\x00"))
+
+	r.useEnvMapLoc     = gl.GetUniformLocation(r.program, gl.Str("useEnvMap\x00"))
+	r.envMaxLodLoc     = gl.GetUniformLocation(r.program, gl.Str("envMaxLod\x00"))
+	r.envIrradianceLoc = gl.GetUniformLocation(r.program, gl.Str("envIrradiance\x00"))
+	r.envPrefilterLoc  = gl.GetUniformLocation(r.program, gl.Str("envPrefiltered\x00"))
+	r.brdfLUTLoc       = gl.GetUniformLocation(r.program, gl.Str("brdfLUT\x00"))
+
+	r.fogEnabledLoc = gl.GetUniformLocation(r.program, gl.Str("fogEnabled\x00"))
+	r.fogColorLoc   = gl.GetUniformLocation(r.program, gl.Str("fogColor\x00"))
+	r.fogDensityLoc = gl.GetUniformLocation(r.program, gl.Str("fogDensity\x00"))
+
+	r.shadowMapLoc      = gl.GetUniformLocation(r.program, gl.Str("shadowMap\x00"))
+	r.hasShadowsLoc     = gl.GetUniformLocation(r.program, gl.Str("hasShadows\x00"))
+	r.shadowDepthLoc    = gl.GetUniformLocation(r.program, gl.Str("shadowDepth\x00"))
+	r.shadowSoftnessLoc = gl.GetUniformLocation(r.program, gl.Str("shadowSoftness\x00"))
+	r.receiveShadowLoc  = gl.GetUniformLocation(r.program, gl.Str("receiveShadow\x00"))
+
+	r.shadowBiasLoc         = gl.GetUniformLocation(r.program, gl.Str("shadowBias\x00"))
+	r.shadowSlopeBiasLoc    = gl.GetUniformLocation(r.program, gl.Str("shadowSlopeBias\x00"))
+	r.shadowNormalOffsetLoc = gl.GetUniformLocation(r.program, gl.Str("shadowNormalOffset\x00"))
 
 	// Resolve per-element point light uniform locations
 	for i := 0; i < 8; i++ {
-		r.pointLightPosLoc[i] = gl.GetUniformLocation(prog,
+		r.pointLightPosLoc[i] = gl.GetUniformLocation(r.program,
 			gl.Str(fmt.Sprintf("pointLightPos[%d]\x00", i)))
-		r.pointLightColorLoc[i] = gl.GetUniformLocation(prog,
+		r.pointLightColorLoc[i] = gl.GetUniformLocation(r.program,
 			gl.Str(fmt.Sprintf("pointLightColor[%d]\x00", i)))
-		r.pointLightIntensityLoc[i] = gl.GetUniformLocation(prog,
+		r.pointLightIntensityLoc[i] = gl.GetUniformLocation(r.program,
 			gl.Str(fmt.Sprintf("pointLightIntensity[%d]\x00", i)))
-		r.pointLightRangeLoc[i] = gl.GetUniformLocation(prog,
+		r.pointLightRangeLoc[i] = gl.GetUniformLocation(r.program,
 			gl.Str(fmt.Sprintf("pointLightRange[%d]\x00", i)))
+		r.pointLightFalloffLoc[i] = gl.GetUniformLocation(r.program,
+			gl.Str(fmt.Sprintf("pointLightFalloff[%d]\x00", i)))
 	}
 
 	// Spot light locations
-	r.spotLightCountLoc = gl.GetUniformLocation(prog, gl.Str("spotLightCount\x00"))
+	r.spotLightCountLoc = gl.GetUniformLocation(r.program, gl.Str("spotLightCount\x00"))
 	for i := 0; i < 4; i++ {
-		r.spotLightPosLoc[i] = gl.GetUniformLocation(prog,
+		r.spotLightPosLoc[i] = gl.GetUniformLocation(r.program,
 			gl.Str(fmt.Sprintf("spotLightPos[%d]\x00", i)))
-		r.spotLightDirLoc[i] = gl.GetUniformLocation(prog,
+		r.spotLightDirLoc[i] = gl.GetUniformLocation(r.program,
 			gl.Str(fmt.Sprintf("spotLightDir[%d]\x00", i)))
-		r.spotLightColorLoc[i] = gl.GetUniformLocation(prog,
+		r.spotLightColorLoc[i] = gl.GetUniformLocation(r.program,
 			gl.Str(fmt.Sprintf("spotLightColor[%d]\x00", i)))
-		r.spotLightIntensityLoc[i] = gl.GetUniformLocation(prog,
+		r.spotLightIntensityLoc[i] = gl.GetUniformLocation(r.program,
 			gl.Str(fmt.Sprintf("spotLightIntensity[%d]\x00", i)))
-		r.spotLightRangeLoc[i] = gl.GetUniformLocation(prog,
+		r.spotLightRangeLoc[i] = gl.GetUniformLocation(r.program,
 			gl.Str(fmt.Sprintf("spotLightRange[%d]\x00", i)))
-		r.spotLightInnerLoc[i] = gl.GetUniformLocation(prog,
+		r.spotLightFalloffLoc[i] = gl.GetUniformLocation(r.program,
+			gl.Str(fmt.Sprintf("spotLightFalloff[%d]\x00", i)))
+		r.spotLightInnerLoc[i] = gl.GetUniformLocation(r.program,
 			gl.Str(fmt.Sprintf("spotLightInner[%d]\x00", i)))
-		r.spotLightOuterLoc[i] = gl.GetUniformLocation(prog,
+		r.spotLightOuterLoc[i] = gl.GetUniformLocation(r.program,
 			gl.Str(fmt.Sprintf("spotLightOuter[%d]\x00", i)))
 	}
 
-	// Bind texture units: albedo=0, shadowMap=1, normalMap=2, metallicRoughness=3, emissive=4
-	gl.UseProgram(prog)
+	// Bind texture units: albedo=0, shadowMap=1, normalMap=2, metallicRoughness=3, emissive=4,
+	// shadowDepth=5 (shadow map again, through the raw sampler), lightmap=6,
+	// environment irradiance=7, prefiltered environment=8, BRDF LUT=9,
+	// specular map=10, opacity map=11, occlusion map=12
+	gl.UseProgram(r.program)
 	gl.Uniform1i(r.albedoTexLoc, 0)
 	gl.Uniform1i(r.shadowMapLoc, 1)
 	gl.Uniform1i(r.normalTexLoc, 2)
 	gl.Uniform1i(r.metallicRoughnessTexLoc, 3)
 	gl.Uniform1i(r.emissiveTexLoc, 4)
+	gl.Uniform1i(r.shadowDepthLoc, 5)
+	gl.Uniform1i(r.lightmapTexLoc, 6)
+	gl.Uniform1i(r.envIrradianceLoc, 7)
+	gl.Uniform1i(r.envPrefilterLoc, 8)
+	gl.Uniform1i(r.brdfLUTLoc, 9)
+	gl.Uniform1i(r.specularTexLoc, 10)
+	gl.Uniform1i(r.opacityTexLoc, 11)
+	gl.Uniform1i(r.occlusionTexLoc, 12)
 
 	// Initialise lightViewProj to identity so the shadow computation is safe
 	// even when shadows are disabled
 	ident := math.Mat4Identity()
 	gl.UniformMatrix4fv(r.lightViewProjLoc, 1, false, (*float32)(unsafe.Pointer(&ident[0][0])))
-
-	return r, nil
 }
 
 // ── Viewport ──────────────────────────────────────────────────────────────────
@@ -711,6 +1096,9 @@ func (r *Renderer) EnableSkybox() error {
 	if err != nil {
 		return err
 	}
+	if r.environment != nil {
+		sb.EnvMap = r.environment.EnvTex
+	}
 	r.skybox = sb
 	return nil
 }
@@ -726,6 +1114,7 @@ func (r *Renderer) SkyboxRef() *Skybox { return r.skybox }
 // view so the sky appears infinitely far away, then draws before scene geometry.
 // No-op when no skybox has been enabled.
 func (r *Renderer) DrawSkybox(view, proj math.Mat4) {
+	texturesRebound()
 	if r.skybox == nil {
 		return
 	}
@@ -734,7 +1123,40 @@ func (r *Renderer) DrawSkybox(view, proj math.Mat4) {
 	skyView[3][0] = 0
 	skyView[3][1] = 0
 	skyView[3][2] = 0
-	r.skybox.Draw(skyView.Mul(proj))
+	r.setBloomSourceWrites(false)
+	r.skybox.Draw(skyView.Mul(proj), r.reverseZ)
+	r.setBloomSourceWrites(true)
+}
+
+// EnableGroundGrid creates the infinite ground grid, or updates the existing
+// one, with minor cells spacing world units apart drawn in color.
+func (r *Renderer) EnableGroundGrid(spacing float32, color core.Color) error {
+	if r.groundGrid == nil {
+		g, err := NewGroundGrid()
+		if err != nil {
+			return err
+		}
+		r.groundGrid = g
+	}
+	r.groundGrid.Spacing = spacing
+	r.groundGrid.SetColor(color)
+	return nil
+}
+
+// GroundGridRef returns the ground grid so the caller can adjust it.
+// Returns nil when no grid is active.
+func (r *Renderer) GroundGridRef() *GroundGrid { return r.groundGrid }
+
+// DrawGroundGrid renders the ground grid over the opaque scene.
+// No-op when no grid has been enabled.
+func (r *Renderer) DrawGroundGrid(view, proj math.Mat4, camPos math.Vec3) {
+	texturesRebound()
+	if r.groundGrid == nil {
+		return
+	}
+	r.setBloomSourceWrites(false)
+	r.groundGrid.Draw(view, proj, camPos, r.reverseZ)
+	r.setBloomSourceWrites(true)
 }
 
 // ── Post-processing ───────────────────────────────────────────────────────────
@@ -749,6 +1171,7 @@ func (r *Renderer) EnablePostProcess(width, height int) error {
 	if err != nil {
 		return err
 	}
+	pp.timer = r.timer
 	r.postProcess = pp
 	return nil
 }
@@ -766,6 +1189,9 @@ func (r *Renderer) ResizePostProcess(width, height int) {
 	if r.ssao != nil {
 		r.ssao.Resize(width, height)
 	}
+	if r.motionBlur != nil {
+		r.motionBlur.Resize(width, height)
+	}
 }
 
 // EnableSSAO creates the SSAO pipeline.  EnablePostProcess must be called first.
@@ -780,6 +1206,7 @@ func (r *Renderer) EnableSSAO() error {
 	if err != nil {
 		return fmt.Errorf("ssao: %w", err)
 	}
+	s.ReverseZ = r.reverseZ
 	r.ssao = s
 	return nil
 }
@@ -805,6 +1232,50 @@ func (r *Renderer) SetSSAOStrength(v float32) {
 	}
 }
 
+// SetSSAOSamples sets the SSAO kernel size: 16, 32 or 64 (default 64).
+func (r *Renderer) SetSSAOSamples(n int) {
+	if r.ssao != nil {
+		r.ssao.SampleCount = n
+	}
+}
+
+// SetSSAOPower sets the AO contrast exponent, applied as pow(ao, p) (default 1.0).
+func (r *Renderer) SetSSAOPower(p float32) {
+	if r.ssao != nil {
+		r.ssao.Power = p
+	}
+}
+
+// SetSSAOBilateral switches the AO blur between depth-aware (true, default)
+// and a plain box blur (false).
+func (r *Renderer) SetSSAOBilateral(enabled bool) {
+	if r.ssao != nil {
+		r.ssao.Bilateral = enabled
+	}
+}
+
+// SetSSAODepthFalloff sets the bilateral blur's edge-weight falloff (default 50).
+func (r *Renderer) SetSSAODepthFalloff(v float32) {
+	if r.ssao != nil {
+		r.ssao.DepthFalloff = v
+	}
+}
+
+// SetSSAOScale sets the AO resolution relative to the viewport (1.0 = full,
+// 0.5 = half); lower-resolution AO is upsampled depth-aware in the composite.
+func (r *Renderer) SetSSAOScale(scale float32) {
+	if r.ssao != nil {
+		r.ssao.SetScale(scale)
+	}
+}
+
+// SetSSAOTemporal enables or disables temporal AO accumulation.
+func (r *Renderer) SetSSAOTemporal(enabled bool) {
+	if r.ssao != nil {
+		r.ssao.Temporal = enabled
+	}
+}
+
 // SetExposure sets the tone-mapping exposure value (default 1.0).
 func (r *Renderer) SetExposure(exp float32) {
 	if r.postProcess != nil {
@@ -812,6 +1283,37 @@ func (r *Renderer) SetExposure(exp float32) {
 	}
 }
 
+// SetChromaticAberration sets the radial red/blue channel offset of the
+// composite (0 = off).
+func (r *Renderer) SetChromaticAberration(strength float32) {
+	if r.postProcess != nil {
+		r.postProcess.ChromaticAberration = strength
+	}
+}
+
+// SetLensDistortion sets the composite's radial distortion coefficients
+// (0, 0 = off).
+func (r *Renderer) SetLensDistortion(k1, k2 float32) {
+	if r.postProcess != nil {
+		r.postProcess.LensK1 = k1
+		r.postProcess.LensK2 = k2
+	}
+}
+
+// SetFilmGrain sets the amplitude of the animated film grain (0 = off).
+func (r *Renderer) SetFilmGrain(strength float32) {
+	if r.postProcess != nil {
+		r.postProcess.FilmGrain = strength
+	}
+}
+
+// SetDithering enables or disables the composite's output dither.
+func (r *Renderer) SetDithering(enabled bool) {
+	if r.postProcess != nil {
+		r.postProcess.Dither = enabled
+	}
+}
+
 // EnableBloom compiles the bloom shaders and creates the blur FBOs.
 // Requires post-processing to be enabled first.
 func (r *Renderer) EnableBloom() error {
@@ -835,46 +1337,89 @@ func (r *Renderer) SetBloomStrength(s float32) {
 	}
 }
 
-// BlitPostProcess runs the optional SSAO pass then resolves the HDR FBO to
-// the default framebuffer with tone mapping.  A no-op when post-processing is
-// disabled.
+// SetBloomPasses sets the number of H+V blur pairs of the ping-pong bloom
+// (default 4).
+func (r *Renderer) SetBloomPasses(n int) {
+	if r.postProcess != nil {
+		if n < 0 {
+			n = 0
+		}
+		r.postProcess.BloomPasses = n
+	}
+}
+
+// SetBloomMipCount switches bloom to an n-level mip chain (0 = ping-pong blur).
+// Bloom must be enabled first.
+func (r *Renderer) SetBloomMipCount(n int) {
+	if r.postProcess != nil && r.postProcess.BloomEnabled {
+		r.postProcess.SetBloomMipCount(n)
+	}
+}
+
+// BlitPostProcess runs the optional SSAO and motion blur passes then resolves
+// the HDR FBO to the default framebuffer with tone mapping.  A no-op when
+// post-processing is disabled.
 func (r *Renderer) BlitPostProcess() {
+	texturesRebound()
+	// Close the frame's timer queries even when there is nothing to resolve.
+	defer r.timer.endFrame()
 	if r.postProcess == nil {
 		return
 	}
-	// All fullscreen passes (SSAO, bloom, tone-map) draw a single triangle.
-	// gl.PolygonMode LINE would rasterize it as 3 edges and leave the screen
-	// mostly empty, so temporarily force FILL for the entire post-process blit.
-	if r.wireframe {
-		gl.PolygonMode(gl.FRONT_AND_BACK, gl.FILL)
-	}
+	r.postProcess.debugOut = r.debugView.postOutput()
+	r.postProcess.resolve()
 
 	// Run SSAO passes (depth → AO → blur) if enabled
-	var aoTex uint32
-	var aoStr float32
+	var ao aoComposite
 	if r.ssao != nil {
-		r.ssao.RunPasses(r.postProcess.DepthTex, r.lastProj)
-		aoTex = r.ssao.BlurTex
-		aoStr = r.ssao.Strength
+		r.timer.begin("ssao")
+		r.ssao.RunPasses(r.postProcess.DepthTex, r.lastView, r.lastProj)
+		ao = aoComposite{
+			tex:      r.ssao.OutputTex(),
+			strength: r.ssao.Strength,
+			upsample: r.ssao.Scale() < 1,
+			falloff:  r.ssao.DepthFalloff,
+			invProj:  depthUnproject(r.lastProj, r.reverseZ),
+		}
+	}
+	// Blur the HDR colour along the velocity target before bloom sees it
+	if r.motionBlur != nil {
+		r.timer.begin("motionblur")
+		r.motionBlur.apply(r.postProcess)
 	}
 	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
 	gl.Viewport(0, 0, r.viewportW, r.viewportH)
-	r.postProcess.Blit(aoTex, aoStr)
+	r.postProcess.Blit(ao)
+}
 
-	// Restore wireframe so the next frame's geometry draws correctly.
-	if r.wireframe {
-		gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
+// PassTimings returns the GPU time in milliseconds spent in each pass of the
+// previous frame: "shadow", "scene", "decals", "velocity", "ssao",
+// "motionblur", "bloom", "tonemap", "particles".
+// Passes that did not run report 0, as do all passes when the driver lacks
+// timer query support.
+func (r *Renderer) PassTimings() map[string]float64 {
+	return r.timer.snapshot()
+}
+
+// setBloomSourceWrites masks the HDR FBO's bloom-only attachment around
+// passes whose shaders don't write it.  No-op without post-processing.
+func (r *Renderer) setBloomSourceWrites(enabled bool) {
+	if r.postProcess != nil {
+		r.postProcess.SetBloomSourceWrites(enabled)
 	}
 }
 
 // ── Particles ─────────────────────────────────────────────────────────────────
 
-// DrawParticles renders emitter.Particles as camera-facing billboards.
+// DrawParticles renders emitter.Particles as camera-facing billboards, or,
+// for a GPUSimulation emitter, advances its GPU particles and draws those.
 // Must be called after BeginFrame (so the correct FBO is bound) and before
 // BlitPostProcess (so particles are tone-mapped and may catch bloom).
-// Lazily creates the particle renderer on first call.
-func (r *Renderer) DrawParticles(emitter *scene.ParticleEmitter, view, proj math.Mat4) {
-	if emitter == nil || len(emitter.Particles) == 0 {
+// fade scales the alpha of CPU particles (ParticleEmitter.DistanceFade); GPU
+// particles ignore it. Lazily creates the particle renderer on first call.
+func (r *Renderer) DrawParticles(emitter *scene.ParticleEmitter, view, proj math.Mat4, fade float32) {
+	texturesRebound()
+	if emitter == nil || (len(emitter.Particles) == 0 && !emitter.GPUSimulation) {
 		return
 	}
 	if r.particleRenderer == nil {
@@ -885,14 +1430,32 @@ func (r *Renderer) DrawParticles(emitter *scene.ParticleEmitter, view, proj math
 		}
 		r.particleRenderer = pr
 	}
-	// Particle billboards are always solid; wireframe mode would render them
-	// as triangle outlines (invisible soft-circles) so force FILL temporarily.
-	if r.wireframe {
-		gl.PolygonMode(gl.FRONT_AND_BACK, gl.FILL)
+	r.timer.begin("particles")
+	// Soft-particle fade needs a sampleable scene depth: only the HDR FBO has one
+	var sceneDepth uint32
+	if r.renderTarget == nil && r.postProcess != nil {
+		r.postProcess.resolve()
+		sceneDepth = r.postProcess.DepthTex
+	}
+	r.setBloomSourceWrites(false)
+	if emitter.GPUSimulation {
+		if err := r.particleRenderer.drawGPU(emitter, view, proj, sceneDepth, r.reverseZ); err != nil {
+			fmt.Printf("gpu particles: %v\n", err)
+			emitter.GPUSimulation = false
+		}
+	} else {
+		r.particleRenderer.draw(emitter, view, proj, sceneDepth, r.reverseZ, fade)
 	}
-	r.particleRenderer.draw(emitter, view, proj)
-	if r.wireframe {
-		gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
+	r.setBloomSourceWrites(true)
+	r.timer.end()
+}
+
+// ReleaseParticles frees the GPU buffers of a GPUSimulation emitter. They are
+// otherwise kept until Destroy; drawing the emitter again recreates them
+// empty.
+func (r *Renderer) ReleaseParticles(emitter *scene.ParticleEmitter) {
+	if r.particleRenderer != nil {
+		r.particleRenderer.release(emitter)
 	}
 }
 
@@ -907,31 +1470,64 @@ func (r *Renderer) EnableShadows(size int) error {
 	if err != nil {
 		return err
 	}
+	if !r.shadowLinear {
+		texturesRebound()
+		sm.SetFiltering(false)
+	}
 	r.shadowMap = sm
 	return nil
 }
 
+// SetShadowFiltering selects linear (default) or nearest comparison
+// filtering of the shadow map; see ShadowMap.SetFiltering. Applies to the
+// current shadow map and any created later by EnableShadows.
+func (r *Renderer) SetShadowFiltering(linear bool) {
+	r.shadowLinear = linear
+	if r.shadowMap != nil {
+		texturesRebound()
+		r.shadowMap.SetFiltering(linear)
+	}
+}
+
 // HasShadowMap reports whether the shadow FBO has been created.
 func (r *Renderer) HasShadowMap() bool {
 	return r.shadowMap != nil
 }
 
+// SetReceiveShadow sets whether the following mesh draws sample the shadow
+// map; with false they are fully lit by the directional light whatever is
+// in front of them. BeginFrame resets it to true.
+func (r *Renderer) SetReceiveShadow(enabled bool) {
+	if enabled == r.receiveShadow {
+		return
+	}
+	r.receiveShadow = enabled
+	gl.UseProgram(r.program)
+	if enabled {
+		gl.Uniform1i(r.receiveShadowLoc, 1)
+	} else {
+		gl.Uniform1i(r.receiveShadowLoc, 0)
+	}
+}
+
 // BeginShadowPass binds the depth FBO and sets up for the shadow pass.
-// The wireframe polygon mode is temporarily set to fill.
 func (r *Renderer) BeginShadowPass() {
 	if r.shadowMap == nil {
 		return
 	}
-	// Shadow pass always renders filled triangles regardless of wireframe mode
-	gl.PolygonMode(gl.FRONT_AND_BACK, gl.FILL)
+	r.timer.begin("shadow")
 	gl.BindFramebuffer(gl.FRAMEBUFFER, r.shadowMap.FBO)
 	gl.Viewport(0, 0, r.shadowMap.Size, r.shadowMap.Size)
+	// The shadow map keeps standard depth so the PCF comparison is unchanged
+	if r.reverseZ {
+		applyDepthConvention(false)
+	}
 	gl.Clear(gl.DEPTH_BUFFER_BIT)
 	gl.UseProgram(r.shadowProg)
 }
 
 // DrawMeshShadow draws a mesh into the depth buffer using the depth-only shader.
-// Only triangle meshes cast shadows.
+// Only triangle and triangle-strip meshes cast shadows.
 func (r *Renderer) DrawMeshShadow(mesh *scene.Mesh, lightMVP math.Mat4) {
 	if r.shadowMap == nil || r.shadowProg == 0 {
 		return
@@ -942,13 +1538,39 @@ func (r *Renderer) DrawMeshShadow(mesh *scene.Mesh, lightMVP math.Mat4) {
 	}
 	gl.UniformMatrix4fv(r.shadowLightMVPLoc, 1, false,
 		(*float32)(unsafe.Pointer(&lightMVP[0][0])))
+	primitive := glPrimitive(mesh.DrawMode)
+	gl.BindVertexArray(gpu.VAO)
+	if gpu.HasIndices {
+		gl.DrawElements(primitive, gpu.IndexCount, gl.UNSIGNED_INT, nil)
+	} else {
+		gl.DrawArrays(primitive, 0, int32(len(mesh.Vertices)))
+	}
+	gl.BindVertexArray(0)
+}
+
+// DrawMeshShadowInstanced draws mesh into the shadow map once per model
+// matrix in a single instanced call. Only triangle and triangle-strip meshes
+// cast shadows.
+func (r *Renderer) DrawMeshShadowInstanced(mesh *scene.Mesh, lightVP math.Mat4, models []math.Mat4) {
+	if r.shadowMap == nil || r.shadowProg == 0 || len(models) == 0 {
+		return
+	}
+	gpu := r.ensureUploaded(mesh)
+	if gpu == nil {
+		return
+	}
+	r.uploadInstanceVBO(gpu, instanceBuffer(models, lightVP), len(models))
+
+	primitive := glPrimitive(mesh.DrawMode)
+	gl.Uniform1i(r.shadowInstancedLoc, 1)
 	gl.BindVertexArray(gpu.VAO)
 	if gpu.HasIndices {
-		gl.DrawElements(gl.TRIANGLES, gpu.IndexCount, gl.UNSIGNED_INT, nil)
+		gl.DrawElementsInstanced(primitive, gpu.IndexCount, gl.UNSIGNED_INT, nil, int32(len(models)))
 	} else {
-		gl.DrawArrays(gl.TRIANGLES, 0, int32(len(mesh.Vertices)))
+		gl.DrawArraysInstanced(primitive, 0, int32(len(mesh.Vertices)), int32(len(models)))
 	}
 	gl.BindVertexArray(0)
+	gl.Uniform1i(r.shadowInstancedLoc, 0)
 }
 
 // EndShadowPass restores the default framebuffer and viewport.
@@ -956,31 +1578,57 @@ func (r *Renderer) EndShadowPass() {
 	if r.shadowMap == nil {
 		return
 	}
+	r.timer.end()
 	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
 	gl.Viewport(0, 0, r.viewportW, r.viewportH)
-	// Restore wireframe mode if it was active
-	if r.wireframe {
-		gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
+	if r.reverseZ {
+		applyDepthConvention(true)
 	}
 }
 
 // ── BeginFrame ────────────────────────────────────────────────────────────────
 
-// BeginFrame clears the framebuffer and sets per-frame lighting, camera, and
-// shadow uniforms.  lightVP is the light view-projection matrix (used for
-// shadow map lookup); hasShadows should be true when a populated shadow map
-// is available.  proj is stored internally for the SSAO pass.
-func (r *Renderer) BeginFrame(sky core.Color, lights []*scene.Light, ambient core.Color, camPos math.Vec3, lightVP math.Mat4, hasShadows bool, proj math.Mat4) {
-	r.lastProj = proj
-	// Render into the HDR FBO when post-processing is active.
-	if r.postProcess != nil {
-		gl.BindFramebuffer(gl.FRAMEBUFFER, r.postProcess.FBO)
+// BeginFrame clears the framebuffer to clearColor and sets per-frame
+// lighting, camera, and shadow uniforms.  With a skybox only depth and
+// stencil are cleared, since the sky covers every pixel.  lightVP is the
+// light view-projection matrix (used for shadow map lookup); hasShadows
+// should be true when a populated shadow map is available.  view and proj
+// are stored internally for the SSAO pass.
+func (r *Renderer) BeginFrame(clearColor core.Color, lights []*scene.Light, ambient core.Color, camPos math.Vec3, lightVP math.Mat4, hasShadows bool, view, proj math.Mat4) {
+	texturesRebound()
+	r.materialBinds = 0
+	// "scene" stays open until the next timed pass (particles or SSAO/bloom)
+	r.timer.begin("scene")
+	switch {
+	case r.renderTarget != nil:
+		// Off-screen pass: leave lastProj alone so SSAO keeps the main camera's
+		gl.BindFramebuffer(gl.FRAMEBUFFER, r.renderTarget.FBO)
+		gl.Viewport(0, 0, r.renderTarget.Width, r.renderTarget.Height)
+	case r.postProcess != nil:
+		// Render into the HDR FBO when post-processing is active.
+		r.lastProj = proj
+		r.lastView = view
+		r.postProcess.resolved = false
+		gl.BindFramebuffer(gl.FRAMEBUFFER, r.postProcess.sceneFBO())
 		gl.Viewport(0, 0, r.postProcess.Width, r.postProcess.Height)
-	} else {
+	default:
+		r.lastProj = proj
+		r.lastView = view
 		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
 	}
-	gl.ClearColor(sky.R, sky.G, sky.B, sky.A)
-	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	r.resetStencil()
+	clearBits := uint32(gl.DEPTH_BUFFER_BIT | gl.STENCIL_BUFFER_BIT)
+	if r.skybox == nil {
+		// No sky: the background shows through
+		gl.ClearColor(clearColor.R, clearColor.G, clearColor.B, clearColor.A)
+		clearBits |= gl.COLOR_BUFFER_BIT
+	}
+	gl.Clear(clearBits)
+	if r.renderTarget == nil && r.postProcess != nil {
+		// The bloom-only attachment starts black, not sky-coloured
+		zero := [4]float32{}
+		gl.ClearBufferfv(gl.COLOR, 1, &zero[0])
+	}
 
 	gl.UseProgram(r.program)
 
@@ -997,6 +1645,19 @@ func (r *Renderer) BeginFrame(sky core.Color, lights []*scene.Light, ambient cor
 	} else {
 		gl.Uniform1i(r.useIBLLoc, 0)
 	}
+	if r.iblEnabled && r.envIBL && r.environment != nil {
+		gl.Uniform1i(r.useEnvMapLoc, 1)
+		gl.Uniform1f(r.envMaxLodLoc, EnvPrefilterMips-1)
+		gl.ActiveTexture(gl.TEXTURE7)
+		gl.BindTexture(gl.TEXTURE_CUBE_MAP, r.environment.IrradianceCube)
+		gl.ActiveTexture(gl.TEXTURE8)
+		gl.BindTexture(gl.TEXTURE_CUBE_MAP, r.environment.PrefilterCube)
+		gl.ActiveTexture(gl.TEXTURE9)
+		gl.BindTexture(gl.TEXTURE_2D, r.brdfLUT)
+		gl.ActiveTexture(gl.TEXTURE0)
+	} else {
+		gl.Uniform1i(r.useEnvMapLoc, 0)
+	}
 
 	// Fog
 	if r.fogEnabled {
@@ -1007,80 +1668,147 @@ func (r *Renderer) BeginFrame(sky core.Color, lights []*scene.Light, ambient cor
 		gl.Uniform1i(r.fogEnabledLoc, 0)
 	}
 
+	gl.Uniform1i(r.debugViewLoc, int32(r.debugView))
+
 	// Light-space VP matrix for shadow lookup in vertex shader
 	gl.UniformMatrix4fv(r.lightViewProjLoc, 1, false,
 		(*float32)(unsafe.Pointer(&lightVP[0][0])))
 
-	// Shadow map: bind depth texture to unit 1
+	// Shadow map: bind depth texture to unit 1 (compare) and unit 5 (raw)
 	if hasShadows && r.shadowMap != nil {
 		gl.ActiveTexture(gl.TEXTURE1)
 		gl.BindTexture(gl.TEXTURE_2D, r.shadowMap.DepthTex)
+		gl.ActiveTexture(gl.TEXTURE5)
+		gl.BindTexture(gl.TEXTURE_2D, r.shadowMap.DepthTex)
+		gl.BindSampler(5, r.shadowMap.RawSampler)
 		gl.Uniform1i(r.hasShadowsLoc, 1)
 	} else {
 		gl.Uniform1i(r.hasShadowsLoc, 0)
 	}
+	gl.Uniform1i(r.receiveShadowLoc, 1)
+	r.receiveShadow = true
+	gl.Uniform1f(r.shadowBiasLoc, r.shadowBias)
+	gl.Uniform1f(r.shadowSlopeBiasLoc, r.shadowSlopeBias)
+	gl.Uniform1f(r.shadowNormalOffsetLoc, r.shadowNormalOffset)
 
 	// Defaults for directional light
 	dirLight := math.Vec3{X: 0.5, Y: -1, Z: -0.5}.Normalize()
 	dirColor := core.ColorWhite
 	dirIntensity := float32(0.8)
+	dirSoftness := float32(0)
 
-	pointIdx := 0
-	for _, l := range lights {
-		if l == nil {
-			continue
-		}
-		switch l.Type {
-		case scene.LightTypeDirectional:
-			dirLight = l.Direction.Normalize()
-			dirColor = l.Color
-			dirIntensity = l.Intensity
-		case scene.LightTypePoint:
-			if pointIdx < 8 {
-				gl.Uniform3f(r.pointLightPosLoc[pointIdx], l.Position.X, l.Position.Y, l.Position.Z)
-				gl.Uniform3f(r.pointLightColorLoc[pointIdx], l.Color.R, l.Color.G, l.Color.B)
-				gl.Uniform1f(r.pointLightIntensityLoc[pointIdx], l.Intensity)
-				gl.Uniform1f(r.pointLightRangeLoc[pointIdx], l.Range)
-				pointIdx++
-			}
-		}
+	fl := gatherLights(lights)
+	if l := fl.dir; l != nil {
+		dirLight = l.Direction.Normalize()
+		dirColor = l.Color
+		dirIntensity = l.Intensity
+		dirSoftness = l.ShadowSoftness()
+	} else if fl.dirDisabled {
+		dirIntensity = 0 // switched off, not absent: no default light either
 	}
 
-	spotIdx := 0
-	for _, l := range lights {
-		if l == nil || l.Type != scene.LightTypeSpot || spotIdx >= 4 {
-			continue
-		}
+	for pointIdx, l := range fl.points {
+		gl.Uniform3f(r.pointLightPosLoc[pointIdx], l.Position.X, l.Position.Y, l.Position.Z)
+		gl.Uniform3f(r.pointLightColorLoc[pointIdx], l.Color.R, l.Color.G, l.Color.B)
+		gl.Uniform1f(r.pointLightIntensityLoc[pointIdx], l.Intensity)
+		gl.Uniform1f(r.pointLightRangeLoc[pointIdx], l.Range)
+		gl.Uniform1i(r.pointLightFalloffLoc[pointIdx], int32(l.Falloff))
+	}
+
+	for spotIdx, l := range fl.spots {
 		dir := l.Direction.Normalize()
-		outerCos := cosAngleDeg(l.SpotAngle)
-		innerCos := cosAngleDeg(l.SpotAngle * 0.8)
+		innerCos, outerCos := l.SpotCutoffs()
 		gl.Uniform3f(r.spotLightPosLoc[spotIdx], l.Position.X, l.Position.Y, l.Position.Z)
 		gl.Uniform3f(r.spotLightDirLoc[spotIdx], dir.X, dir.Y, dir.Z)
 		gl.Uniform3f(r.spotLightColorLoc[spotIdx], l.Color.R, l.Color.G, l.Color.B)
 		gl.Uniform1f(r.spotLightIntensityLoc[spotIdx], l.Intensity)
 		gl.Uniform1f(r.spotLightRangeLoc[spotIdx], l.Range)
+		gl.Uniform1i(r.spotLightFalloffLoc[spotIdx], int32(l.Falloff))
 		gl.Uniform1f(r.spotLightInnerLoc[spotIdx], innerCos)
 		gl.Uniform1f(r.spotLightOuterLoc[spotIdx], outerCos)
-		spotIdx++
 	}
 
 	gl.Uniform3f(r.lightDirLoc, dirLight.X, dirLight.Y, dirLight.Z)
 	gl.Uniform3f(r.lightColorLoc, dirColor.R, dirColor.G, dirColor.B)
 	gl.Uniform1f(r.lightIntensityLoc, dirIntensity)
-	gl.Uniform1i(r.pointLightCountLoc, int32(pointIdx))
-	gl.Uniform1i(r.spotLightCountLoc, int32(spotIdx))
+	gl.Uniform1f(r.shadowSoftnessLoc, dirSoftness)
+	gl.Uniform1i(r.pointLightCountLoc, int32(len(fl.points)))
+	gl.Uniform1i(r.spotLightCountLoc, int32(len(fl.spots)))
+}
+
+// Light slots in the main shader (MAX_POINT_LIGHTS, MAX_SPOT_LIGHTS)
+const (
+	maxPointLights = 8
+	maxSpotLights  = 4
+)
+
+// frameLights are the lights BeginFrame uploads.
+type frameLights struct {
+	dir         *scene.Light // last enabled directional light, nil if none
+	dirDisabled bool         // dir is nil only because they are all disabled
+	points      []*scene.Light
+	spots       []*scene.Light
+}
+
+// gatherLights picks the lights BeginFrame uploads: the last directional
+// light and the first maxPointLights point and maxSpotLights spot lights, in
+// order. Nil and disabled lights are skipped, so they leave their slot to
+// the next light of the same type.
+func gatherLights(lights []*scene.Light) frameLights {
+	var fl frameLights
+	for _, l := range lights {
+		if l == nil {
+			continue
+		}
+		if l.Disabled {
+			if l.Type == scene.LightTypeDirectional && fl.dir == nil {
+				fl.dirDisabled = true
+			}
+			continue
+		}
+		switch l.Type {
+		case scene.LightTypeDirectional:
+			fl.dir = l
+		case scene.LightTypePoint:
+			if len(fl.points) < maxPointLights {
+				fl.points = append(fl.points, l)
+			}
+		case scene.LightTypeSpot:
+			if len(fl.spots) < maxSpotLights {
+				fl.spots = append(fl.spots, l)
+			}
+		}
+	}
+	return fl
+}
+
+// SetRenderTarget redirects the next BeginFrame (and every draw after it) into
+// rt instead of the HDR FBO / default framebuffer.  Pass nil to restore normal
+// output; the default framebuffer and main viewport are rebound immediately.
+func (r *Renderer) SetRenderTarget(rt *RenderTarget) {
+	r.renderTarget = rt
+	if rt == nil {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+		gl.Viewport(0, 0, r.viewportW, r.viewportH)
+	}
+}
+
+// SetDepthWrite turns depth writes for the following draws on or off (on by
+// default); depth testing is unaffected. Turn it back on before the next
+// BeginFrame, whose depth clear it also masks.
+func (r *Renderer) SetDepthWrite(enabled bool) {
+	gl.DepthMask(enabled)
 }
 
 // ── Wireframe ─────────────────────────────────────────────────────────────────
 
-// SetWireframe toggles wireframe rendering mode.
+// SetWireframe toggles wireframe rendering mode. It applies to mesh draws
+// only (DrawMesh, DrawMeshInstanced and their WithMaterial forms), which
+// switch glPolygonMode to LINE around their own draw call; the GL state is
+// FILL everywhere else, so fullscreen passes, particles, billboards, text
+// and the shadow and velocity passes always draw filled.
 func (r *Renderer) SetWireframe(enabled bool) {
 	r.wireframe = enabled
-	if enabled {
-		gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
-	} else {
-		gl.PolygonMode(gl.FRONT_AND_BACK, gl.FILL)
-	}
 }
 
 // IsWireframe returns whether wireframe mode is active.
@@ -1088,11 +1816,86 @@ func (r *Renderer) IsWireframe() bool {
 	return r.wireframe
 }
 
+// SetWireframeOverlay toggles drawing triangle edges on top of the normally
+// shaded geometry.  Ignored while full wireframe mode is active.
+func (r *Renderer) SetWireframeOverlay(enabled bool) {
+	r.wireOverlay = enabled
+}
+
+// IsWireframeOverlay returns whether the wireframe overlay is active.
+func (r *Renderer) IsWireframeOverlay() bool {
+	return r.wireOverlay
+}
+
+// SetWireframeColor sets the unlit colour of the wireframe overlay lines.
+func (r *Renderer) SetWireframeColor(c core.Color) {
+	r.wireColor = c
+}
+
+// drawGeometry runs a mesh draw call, as lines in wireframe mode.
+func (r *Renderer) drawGeometry(draw func()) {
+	if !r.wireframe {
+		draw()
+		return
+	}
+	gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
+	draw()
+	gl.PolygonMode(gl.FRONT_AND_BACK, gl.FILL)
+}
+
+// drawWireOverlay re-issues draw as an unlit line pass over the shaded
+// triangles just drawn.  A negative polygon offset pulls the lines toward the
+// camera so they win the depth test against their own faces.  Polygon mode,
+// offset state and the overlay uniform are restored before returning.
+func (r *Renderer) drawWireOverlay(primitive uint32, draw func()) {
+	if !r.wireOverlay || r.wireframe || primitive != gl.TRIANGLES {
+		return
+	}
+	gl.Uniform1i(r.wireOverlayLoc, 1)
+	gl.Uniform3f(r.wireColorLoc, r.wireColor.R, r.wireColor.G, r.wireColor.B)
+	gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
+	// Pull the lines toward the camera so they win against their own faces
+	gl.Enable(gl.POLYGON_OFFSET_LINE)
+	if r.reverseZ {
+		gl.PolygonOffset(1, 1)
+	} else {
+		gl.PolygonOffset(-1, -1)
+	}
+
+	draw()
+
+	gl.Disable(gl.POLYGON_OFFSET_LINE)
+	gl.PolygonMode(gl.FRONT_AND_BACK, gl.FILL)
+	gl.Uniform1i(r.wireOverlayLoc, 0)
+}
+
 // ── DrawMesh ──────────────────────────────────────────────────────────────────
 
+// glPrimitive returns the GL primitive type a mesh with the given DrawMode
+// is drawn with.
+func glPrimitive(mode scene.DrawMode) uint32 {
+	switch mode {
+	case scene.DrawLines:
+		return gl.LINES
+	case scene.DrawPoints:
+		return gl.POINTS
+	case scene.DrawLineStrip:
+		return gl.LINE_STRIP
+	case scene.DrawTriangleStrip:
+		return gl.TRIANGLE_STRIP
+	}
+	return gl.TRIANGLES
+}
+
 // DrawMesh draws a mesh with the given MVP and model matrices.
 // Material properties (albedo, specular, shininess, texture) are read from mesh.Material.
 func (r *Renderer) DrawMesh(mesh *scene.Mesh, mvp, model math.Mat4) {
+	r.DrawMeshWithMaterial(mesh, mesh.Material, mvp, model)
+}
+
+// DrawMeshWithMaterial is DrawMesh with mat used in place of mesh.Material
+// (nil = default material).  The mesh's GPU buffers are shared.
+func (r *Renderer) DrawMeshWithMaterial(mesh *scene.Mesh, mat *scene.Material, mvp, model math.Mat4) {
 	gpu := r.ensureUploaded(mesh)
 	if gpu == nil {
 		return
@@ -1104,26 +1907,29 @@ func (r *Renderer) DrawMesh(mesh *scene.Mesh, mvp, model math.Mat4) {
 	gl.UniformMatrix4fv(r.modelLoc, 1, false, (*float32)(unsafe.Pointer(&model[0][0])))
 
 	// Material
-	mat := mesh.Material
 	if mat == nil {
 		mat = scene.DefaultMaterial()
 	}
 	r.applyMaterial(mat)
 
-	// Resolve draw primitive from mesh.DrawMode
-	primitive := uint32(gl.TRIANGLES)
-	switch mesh.DrawMode {
-	case scene.DrawLines:
-		primitive = gl.LINES
-	case scene.DrawPoints:
-		primitive = gl.POINTS
+	primitive := glPrimitive(mesh.DrawMode)
+
+	draw := func() {
+		if gpu.HasIndices {
+			gl.DrawElements(primitive, gpu.IndexCount, gl.UNSIGNED_INT, nil)
+		} else {
+			gl.DrawArrays(primitive, 0, int32(len(mesh.Vertices)))
+		}
 	}
 
 	gl.BindVertexArray(gpu.VAO)
-	if gpu.HasIndices {
-		gl.DrawElements(primitive, gpu.IndexCount, gl.UNSIGNED_INT, nil)
+	r.drawGeometry(draw)
+	if r.wireStyle == WireframeBarycentric {
+		if r.wireOverlay && !r.wireframe && primitive == gl.TRIANGLES {
+			r.DrawMeshWireframe(mesh, mvp, r.wireColor, r.wireWidth)
+		}
 	} else {
-		gl.DrawArrays(primitive, 0, int32(len(mesh.Vertices)))
+		r.drawWireOverlay(primitive, draw)
 	}
 	gl.BindVertexArray(0)
 }
@@ -1131,10 +1937,23 @@ func (r *Renderer) DrawMesh(mesh *scene.Mesh, mvp, model math.Mat4) {
 // ── Instanced rendering ───────────────────────────────────────────────────────
 
 // DrawMeshInstanced renders mesh len(models) times in a single GPU draw call.
-// models contains one world-space transform per instance.
+// models contains one world-space transform per instance; scene nodes built
+// with scene.NewInstancedNode are drawn through this path by RenderEngine.
 // MVPs are computed on the CPU (same convention as DrawMesh) and streamed to
 // the GPU via a dynamic per-instance VBO bound to attrib locations 6-13.
 func (r *Renderer) DrawMeshInstanced(mesh *scene.Mesh, view, proj math.Mat4, models []math.Mat4) {
+	r.DrawMeshInstancedWithMaterial(mesh, mesh.Material, view, proj, models)
+}
+
+// DrawMeshInstancedWithMaterial is DrawMeshInstanced with mat used in place of
+// mesh.Material (nil = default material).
+func (r *Renderer) DrawMeshInstancedWithMaterial(mesh *scene.Mesh, mat *scene.Material, view, proj math.Mat4, models []math.Mat4) {
+	r.drawInstanced(mesh, mat, view, proj, models, nil, nil)
+}
+
+// drawInstanced is the instanced draw behind DrawMeshInstancedWithMaterial and
+// DrawMeshInstancedVariants; a non-empty set turns on per-instance variants.
+func (r *Renderer) drawInstanced(mesh *scene.Mesh, mat *scene.Material, view, proj math.Mat4, models []math.Mat4, set []*scene.Material, variants []uint32) {
 	if len(models) == 0 {
 		return
 	}
@@ -1143,49 +1962,50 @@ func (r *Renderer) DrawMeshInstanced(mesh *scene.Mesh, view, proj math.Mat4, mod
 		return
 	}
 
-	// Build flat instance buffer: 32 float32 per instance (MVP mat4 + Model mat4).
-	// Layout (column-major to match OpenGL expectation):
-	//   [0..15]  MVP   = models[i].Mul(view).Mul(proj)
-	//   [16..31] Model = models[i]
+	// Upload instance data to the per-mesh VBO (lazy create + attrib setup).
 	n := len(models)
-	buf := make([]float32, n*32)
-	for i, m := range models {
-		mvp := m.Mul(view).Mul(proj)
-		base := i * 32
-		for col := 0; col < 4; col++ {
-			for row := 0; row < 4; row++ {
-				buf[base+col*4+row]    = mvp[col][row]
-				buf[base+16+col*4+row] = m[col][row]
-			}
-		}
+	r.uploadInstanceVBO(gpu, instanceBuffer(models, view.Mul(proj)), n)
+	useVariants := len(set) > 0
+	if useVariants {
+		r.uploadVariantVBO(gpu, instanceVariantIndices(variants, len(set), n))
 	}
 
-	// Upload instance data to the per-mesh VBO (lazy create + attrib setup).
-	r.uploadInstanceVBO(gpu, buf, n)
-
 	// Material uniforms — identical to DrawMesh.
 	gl.UseProgram(r.program)
 	gl.Uniform1i(r.instancedLoc, 1)
 
-	mat := mesh.Material
 	if mat == nil {
 		mat = scene.DefaultMaterial()
 	}
 	r.applyMaterial(mat)
+	if useVariants {
+		albedo, metalRough := packInstanceMaterials(set)
+		gl.Uniform4fv(r.instanceAlbedoLoc, scene.MaxInstanceMaterials, &albedo[0][0])
+		gl.Uniform2fv(r.instanceMetalRoughLoc, scene.MaxInstanceMaterials, &metalRough[0][0])
+		gl.Uniform1i(r.useInstanceMaterialsLoc, 1)
+	}
 
-	primitive := uint32(gl.TRIANGLES)
-	switch mesh.DrawMode {
-	case scene.DrawLines:
-		primitive = gl.LINES
-	case scene.DrawPoints:
-		primitive = gl.POINTS
+	primitive := glPrimitive(mesh.DrawMode)
+
+	draw := func() {
+		if gpu.HasIndices {
+			gl.DrawElementsInstanced(primitive, gpu.IndexCount, gl.UNSIGNED_INT, nil, int32(n))
+		} else {
+			gl.DrawArraysInstanced(primitive, 0, int32(len(mesh.Vertices)), int32(n))
+		}
 	}
 
 	gl.BindVertexArray(gpu.VAO)
-	if gpu.HasIndices {
-		gl.DrawElementsInstanced(primitive, gpu.IndexCount, gl.UNSIGNED_INT, nil, int32(n))
-	} else {
-		gl.DrawArraysInstanced(primitive, 0, int32(len(mesh.Vertices)), int32(n))
+	if useVariants {
+		gl.EnableVertexAttribArray(15)
+	}
+	r.drawGeometry(draw)
+	r.drawWireOverlay(primitive, draw)
+	if useVariants {
+		// Other passes share the VAO and draw more instances than the
+		// variant buffer may hold
+		gl.DisableVertexAttribArray(15)
+		gl.Uniform1i(r.useInstanceMaterialsLoc, 0)
 	}
 	gl.BindVertexArray(0)
 
@@ -1196,10 +2016,18 @@ func (r *Renderer) DrawMeshInstanced(mesh *scene.Mesh, view, proj math.Mat4, mod
 // applyMaterial sets all material-related shader uniforms and binds textures.
 // Must be called while r.program is active (UseProgram already called by DrawMesh/DrawMeshInstanced).
 func (r *Renderer) applyMaterial(mat *scene.Material) {
+	if !r.materialChanged(mat) {
+		return
+	}
 	// Phong params (always set so the Phong path has valid values)
 	gl.Uniform3f(r.matAlbedoLoc, mat.Albedo.R, mat.Albedo.G, mat.Albedo.B)
 	gl.Uniform3f(r.matSpecularLoc, mat.Specular.R, mat.Specular.G, mat.Specular.B)
 	gl.Uniform1f(r.matShininessLoc, mat.Shininess)
+	if mat.Transparent {
+		gl.Uniform1f(r.matOpacityLoc, mat.Albedo.A)
+	} else {
+		gl.Uniform1f(r.matOpacityLoc, 1)
+	}
 
 	// PBR params
 	if mat.UsePBR {
@@ -1210,6 +2038,7 @@ func (r *Renderer) applyMaterial(mat *scene.Material) {
 	gl.Uniform1f(r.matMetallicLoc, mat.Metallic)
 	gl.Uniform1f(r.matRoughnessLoc, mat.Roughness)
 	gl.Uniform3f(r.matEmissiveLoc, mat.EmissiveColor.R, mat.EmissiveColor.G, mat.EmissiveColor.B)
+	gl.Uniform1f(r.matBloomScaleLoc, mat.BloomScale)
 
 	// Unlit flag
 	if mat.Unlit {
@@ -1217,6 +2046,16 @@ func (r *Renderer) applyMaterial(mat *scene.Material) {
 	} else {
 		gl.Uniform1i(r.unlitLoc, 0)
 	}
+	if mat.FlatShading {
+		gl.Uniform1i(r.flatShadingLoc, 1)
+	} else {
+		gl.Uniform1i(r.flatShadingLoc, 0)
+	}
+	if mat.UseVertexColor {
+		gl.Uniform1i(r.useVertexColorLoc, 1)
+	} else {
+		gl.Uniform1i(r.useVertexColorLoc, 0)
+	}
 
 	// Albedo texture (unit 0)
 	if tex := mat.AlbedoTexture; tex != nil && tex.GLID != 0 {
@@ -1253,6 +2092,62 @@ func (r *Renderer) applyMaterial(mat *scene.Material) {
 	} else {
 		gl.Uniform1i(r.hasEmissiveTexLoc, 0)
 	}
+
+	// Lightmap (unit 6)
+	if lm := mat.LightmapTexture; lm != nil && lm.GLID != 0 {
+		gl.ActiveTexture(gl.TEXTURE6)
+		gl.BindTexture(gl.TEXTURE_2D, lm.GLID)
+		gl.Uniform1i(r.hasLightmapTexLoc, 1)
+	} else {
+		gl.Uniform1i(r.hasLightmapTexLoc, 0)
+	}
+
+	// Specular map (unit 10)
+	if sp := mat.SpecularTexture; sp != nil && sp.GLID != 0 {
+		gl.ActiveTexture(gl.TEXTURE10)
+		gl.BindTexture(gl.TEXTURE_2D, sp.GLID)
+		gl.Uniform1i(r.hasSpecularTexLoc, 1)
+	} else {
+		gl.Uniform1i(r.hasSpecularTexLoc, 0)
+	}
+
+	// Opacity map (unit 11)
+	if op := mat.OpacityTexture; op != nil && op.GLID != 0 {
+		gl.ActiveTexture(gl.TEXTURE11)
+		gl.BindTexture(gl.TEXTURE_2D, op.GLID)
+		gl.Uniform1i(r.hasOpacityTexLoc, 1)
+	} else {
+		gl.Uniform1i(r.hasOpacityTexLoc, 0)
+	}
+
+	// Occlusion map (unit 12)
+	if ao := mat.OcclusionTexture; ao != nil && ao.GLID != 0 {
+		gl.ActiveTexture(gl.TEXTURE12)
+		gl.BindTexture(gl.TEXTURE_2D, ao.GLID)
+		gl.Uniform1i(r.hasOcclusionTexLoc, 1)
+	} else {
+		gl.Uniform1i(r.hasOcclusionTexLoc, 0)
+	}
+}
+
+// instanceBuffer builds the flat instance buffer: 32 float32 per instance
+// (MVP mat4 + Model mat4). Layout (column-major to match OpenGL expectation):
+//
+//	[0..15]  MVP   = models[i].Mul(viewProj)
+//	[16..31] Model = models[i]
+func instanceBuffer(models []math.Mat4, viewProj math.Mat4) []float32 {
+	buf := make([]float32, len(models)*32)
+	for i, m := range models {
+		mvp := m.Mul(viewProj)
+		base := i * 32
+		for col := 0; col < 4; col++ {
+			for row := 0; row < 4; row++ {
+				buf[base+col*4+row]    = mvp[col][row]
+				buf[base+16+col*4+row] = m[col][row]
+			}
+		}
+	}
+	return buf
 }
 
 // uploadInstanceVBO uploads buf to the per-mesh instance VBO, creating it
@@ -1293,6 +2188,12 @@ func (r *Renderer) uploadInstanceVBO(gpu *GPUMesh, buf []float32, count int) {
 
 // ── Resource management ───────────────────────────────────────────────────────
 
+// UploadMesh creates the GPU buffers for mesh now rather than on its first
+// draw. No-op if it is already uploaded or has no vertices.
+func (r *Renderer) UploadMesh(mesh *scene.Mesh) {
+	r.ensureUploaded(mesh)
+}
+
 // ReleaseMesh frees GPU buffers for the given mesh.
 func (r *Renderer) ReleaseMesh(mesh *scene.Mesh) {
 	if gpu, ok := r.gpuMeshes[mesh]; ok {
@@ -1304,9 +2205,15 @@ func (r *Renderer) ReleaseMesh(mesh *scene.Mesh) {
 		if gpu.InstanceVBO != 0 {
 			gl.DeleteBuffers(1, &gpu.InstanceVBO)
 		}
+		if gpu.VariantVBO != 0 {
+			gl.DeleteBuffers(1, &gpu.VariantVBO)
+		}
 		delete(r.gpuMeshes, mesh)
 		mesh.GPUData = nil
 	}
+	if r.wire != nil {
+		r.wire.release(mesh)
+	}
 }
 
 // Destroy releases all GPU resources.
@@ -1323,18 +2230,41 @@ func (r *Renderer) Destroy() {
 	if r.ssao != nil {
 		r.ssao.Destroy()
 	}
+	if r.motionBlur != nil {
+		r.motionBlur.Destroy()
+	}
+	if r.decals != nil {
+		r.decals.Destroy()
+	}
+	if r.outline != nil {
+		r.outline.destroy()
+	}
+	if r.wire != nil {
+		r.wire.destroy()
+	}
 	if r.postProcess != nil {
 		r.postProcess.Destroy()
 	}
 	if r.skybox != nil {
 		r.skybox.Destroy()
 	}
+	if r.environment != nil {
+		r.environment.Destroy()
+	}
+	if r.brdfLUT != 0 {
+		gl.DeleteTextures(1, &r.brdfLUT)
+	}
+	if r.groundGrid != nil {
+		r.groundGrid.Destroy()
+	}
 	if r.particleRenderer != nil {
 		r.particleRenderer.destroy()
 	}
 	if r.textRenderer != nil {
 		r.textRenderer.destroy()
 	}
+	r.frameCopy.destroy()
+	r.timer.destroy()
 	gl.DeleteProgram(r.program)
 }
 
@@ -1346,11 +2276,85 @@ func (r *Renderer) SetFog(enabled bool, density float32, color core.Color) {
 	r.fogColor   = color
 }
 
+// SetShadowBias sets the depth bias of shadow lookups: constant for surfaces
+// facing the light, plus slope × tan(angle between normal and light), so
+// surfaces steep to the light get more bias (less acne) without lifting
+// the shadows of flat ones (peter-panning). Defaults 0.001 and 0.001, in
+// shadow-map depth units (0–1 across the light's range).
+func (r *Renderer) SetShadowBias(constant, slope float32) {
+	r.shadowBias      = constant
+	r.shadowSlopeBias = slope
+}
+
+// SetShadowNormalOffset moves each shadow lookup offset world units along the
+// surface normal (default 0). A few centimetres removes the remaining acne
+// on curved surfaces without any depth bias.
+func (r *Renderer) SetShadowNormalOffset(offset float32) {
+	r.shadowNormalOffset = offset
+}
+
 // EnableIBL activates sky-based image-based lighting in the PBR and Phong shaders.
 func (r *Renderer) EnableIBL() {
 	r.iblEnabled = true
 }
 
+// SetEnvironmentMap makes tex, an HDR panorama already uploaded with
+// UploadTexture, the skybox image and turns on EnableIBLFromEnvironment so
+// the scene is lit from it. A nil tex returns to the sky gradient.
+func (r *Renderer) SetEnvironmentMap(tex *scene.Texture) error {
+	texturesRebound()
+	if r.environment != nil {
+		r.environment.Destroy()
+		r.environment = nil
+	}
+	r.envSource = tex
+	if r.skybox != nil {
+		r.skybox.EnvMap = 0
+	}
+	if tex == nil {
+		return nil
+	}
+	if err := r.EnableIBLFromEnvironment(true); err != nil {
+		r.envSource = nil
+		return err
+	}
+	if r.skybox != nil {
+		r.skybox.EnvMap = tex.GLID
+	}
+	return nil
+}
+
+// EnableIBLFromEnvironment switches image-based lighting between the
+// environment map and the sky gradient. When enabled, the panorama from
+// SetEnvironmentMap is convolved once on the GPU into a diffuse irradiance
+// cubemap and a mip-chained GGX-prefiltered cubemap; together with a BRDF
+// LUT they give split-sum diffuse and specular IBL. Enabling also turns IBL
+// on. Without an environment map the gradient stays in use until one is set.
+func (r *Renderer) EnableIBLFromEnvironment(enabled bool) error {
+	r.envIBL = enabled
+	if !enabled {
+		return nil
+	}
+	r.iblEnabled = true
+	if r.envSource == nil || r.environment != nil {
+		return nil
+	}
+	defer gl.Viewport(0, 0, r.viewportW, r.viewportH)
+	if r.brdfLUT == 0 {
+		lut, err := newBRDFLUT()
+		if err != nil {
+			return err
+		}
+		r.brdfLUT = lut
+	}
+	env, err := NewEnvironment(r.envSource)
+	if err != nil {
+		return fmt.Errorf("environment map: %w", err)
+	}
+	r.environment = env
+	return nil
+}
+
 // SetIBLColors updates the sky gradient colours used for ambient irradiance.
 func (r *Renderer) SetIBLColors(zenith, horizon, ground core.Color) {
 	r.iblZenith  = zenith
@@ -1362,6 +2366,7 @@ func (r *Renderer) SetIBLColors(zenith, horizon, ground core.Color) {
 // Must be called after BlitPostProcess so text lands on the default framebuffer.
 // Lazily creates the TextRenderer on first call.
 func (r *Renderer) DrawText(text string, x, y, scale float32, color core.Color, screenW, screenH float32) {
+	texturesRebound()
 	if r.textRenderer == nil {
 		tr, err := newTextRenderer()
 		if err != nil {
@@ -1370,19 +2375,15 @@ func (r *Renderer) DrawText(text string, x, y, scale float32, color core.Color,
 		}
 		r.textRenderer = tr
 	}
-	// Text is always solid; wireframe would show triangle outlines instead of glyphs.
-	if r.wireframe {
-		gl.PolygonMode(gl.FRONT_AND_BACK, gl.FILL)
-	}
 	r.textRenderer.draw(text, x, y, scale, color, screenW, screenH)
-	if r.wireframe {
-		gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
-	}
 }
 
 // ── Internal helpers ──────────────────────────────────────────────────────────
 
-// ensureUploaded uploads vertex/index data if not already done.
+// ensureUploaded uploads vertex/index data if not already done. The buffers
+// don't depend on DrawMode: indices are uploaded as-is and read as lists or
+// strips by the primitive the draw call passes (glPrimitive). Strips are one
+// continuous run; there is no primitive restart index.
 func (r *Renderer) ensureUploaded(mesh *scene.Mesh) *GPUMesh {
 	if gpu, ok := r.gpuMeshes[mesh]; ok {
 		return gpu
@@ -1396,6 +2397,7 @@ func (r *Renderer) ensureUploaded(mesh *scene.Mesh) *GPUMesh {
 	gpu := &GPUMesh{
 		IndexCount: int32(len(mesh.Indices)),
 		HasIndices: len(mesh.Indices) > 0,
+		VertexCap:  len(mesh.Vertices),
 	}
 
 	gl.GenVertexArrays(1, &gpu.VAO)
@@ -1406,7 +2408,7 @@ func (r *Renderer) ensureUploaded(mesh *scene.Mesh) *GPUMesh {
 	gl.BufferData(gl.ARRAY_BUFFER,
 		len(mesh.Vertices)*int(stride),
 		gl.Ptr(mesh.Vertices),
-		gl.STATIC_DRAW)
+		vertexUsage(mesh))
 
 	var v core.Vertex
 	posOff       := int(unsafe.Offsetof(v.Position))
@@ -1415,6 +2417,7 @@ func (r *Renderer) ensureUploaded(mesh *scene.Mesh) *GPUMesh {
 	colorOff     := int(unsafe.Offsetof(v.Color))
 	tangentOff   := int(unsafe.Offsetof(v.Tangent))
 	bitangentOff := int(unsafe.Offsetof(v.Bitangent))
+	uv2Off       := int(unsafe.Offsetof(v.UV2))
 
 	gl.EnableVertexAttribArray(0)
 	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, stride, gl.PtrOffset(posOff))
@@ -1434,6 +2437,9 @@ func (r *Renderer) ensureUploaded(mesh *scene.Mesh) *GPUMesh {
 	gl.EnableVertexAttribArray(5)
 	gl.VertexAttribPointer(5, 3, gl.FLOAT, false, stride, gl.PtrOffset(bitangentOff))
 
+	gl.EnableVertexAttribArray(14)
+	gl.VertexAttribPointer(14, 2, gl.FLOAT, false, stride, gl.PtrOffset(uv2Off))
+
 	if gpu.HasIndices {
 		gl.GenBuffers(1, &gpu.EBO)
 		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, gpu.EBO)
@@ -1450,6 +2456,45 @@ func (r *Renderer) ensureUploaded(mesh *scene.Mesh) *GPUMesh {
 	return gpu
 }
 
+// vertexUsage returns the VBO usage hint for mesh: DYNAMIC_DRAW for meshes
+// flagged Dynamic (re-uploaded via UpdateMeshVertices), STATIC_DRAW otherwise.
+func vertexUsage(mesh *scene.Mesh) uint32 {
+	if mesh.Dynamic {
+		return gl.DYNAMIC_DRAW
+	}
+	return gl.STATIC_DRAW
+}
+
+// UpdateMeshVertices re-uploads mesh.Vertices to its VBO.  The existing
+// buffer is overwritten in place with BufferSubData when the vertices still
+// fit; a grown slice reallocates it with BufferData (as DYNAMIC_DRAW, since
+// the mesh is evidently changing).  Meshes not yet on the GPU are uploaded.
+// Indices are not touched.
+func (r *Renderer) UpdateMeshVertices(mesh *scene.Mesh) {
+	if r.wire != nil {
+		r.wire.release(mesh) // stale positions; rebuilt on the next draw
+	}
+	gpu, ok := r.gpuMeshes[mesh]
+	if !ok {
+		r.ensureUploaded(mesh)
+		return
+	}
+	n := len(mesh.Vertices)
+	if n == 0 {
+		return
+	}
+	stride := int(unsafe.Sizeof(core.Vertex{}))
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, gpu.VBO)
+	if n > gpu.VertexCap {
+		gl.BufferData(gl.ARRAY_BUFFER, n*stride, gl.Ptr(mesh.Vertices), gl.DYNAMIC_DRAW)
+		gpu.VertexCap = n
+	} else {
+		gl.BufferSubData(gl.ARRAY_BUFFER, 0, n*stride, gl.Ptr(mesh.Vertices))
+	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+}
+
 // ── Shader helpers ────────────────────────────────────────────────────────────
 
 func newProgram(vertSrc, fragSrc string) (uint32, error) {
@@ -1459,12 +2504,24 @@ func newProgram(vertSrc, fragSrc string) (uint32, error) {
 	}
 	frag, err := compileShader(fragSrc, gl.FRAGMENT_SHADER)
 	if err != nil {
+		gl.DeleteShader(vert)
 		return 0, fmt.Errorf("fragment: %w", err)
 	}
 
 	prog := gl.CreateProgram()
 	gl.AttachShader(prog, vert)
 	gl.AttachShader(prog, frag)
+	gl.DeleteShader(vert)
+	gl.DeleteShader(frag)
+	if err := linkProgram(prog); err != nil {
+		gl.DeleteProgram(prog)
+		return 0, err
+	}
+	return prog, nil
+}
+
+// linkProgram links prog and returns its info log on failure.
+func linkProgram(prog uint32) error {
 	gl.LinkProgram(prog)
 
 	var status int32
@@ -1474,17 +2531,9 @@ func newProgram(vertSrc, fragSrc string) (uint32, error) {
 		gl.GetProgramiv(prog, gl.INFO_LOG_LENGTH, &logLen)
 		log := strings.Repeat("\x00", int(logLen+1))
 		gl.GetProgramInfoLog(prog, logLen, nil, gl.Str(log))
-		return 0, fmt.Errorf("link failed: %v", log)
+		return fmt.Errorf("link failed: %v", log)
 	}
-
-	gl.DeleteShader(vert)
-	gl.DeleteShader(frag)
-	return prog, nil
-}
-
-// cosAngleDeg converts an angle in degrees to its cosine (for spot light cutoffs).
-func cosAngleDeg(deg float32) float32 {
-	return float32(gomath.Cos(float64(deg) * gomath.Pi / 180.0))
+	return nil
 }
 
 func compileShader(src string, shaderType uint32) (uint32, error) {
@@ -1501,6 +2550,7 @@ func compileShader(src string, shaderType uint32) (uint32, error) {
 		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLen)
 		log := strings.Repeat("\x00", int(logLen+1))
 		gl.GetShaderInfoLog(shader, logLen, nil, gl.Str(log))
+		gl.DeleteShader(shader)
 		return 0, fmt.Errorf("compile failed: %v", log)
 	}
 	return shader, nil
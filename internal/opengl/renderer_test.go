@@ -0,0 +1,429 @@
+package opengl
+
+import (
+	"runtime"
+	"testing"
+	"unsafe"
+
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+
+	"render-engine/core"
+	"render-engine/math"
+	"render-engine/scene"
+)
+
+// newTestRenderer locks the test to its OS thread and opens a w×h offscreen
+// context with a Renderer on it, skipping the test when no GL context is
+// available. The caller must call the returned cleanup.
+func newTestRenderer(t *testing.T, w, h int) (*Renderer, func()) {
+	t.Helper()
+	runtime.LockOSThread()
+	window, err := core.NewOffscreenContext(w, h)
+	if err != nil {
+		runtime.UnlockOSThread()
+		t.Skipf("no GL context available: %v", err)
+	}
+	r, err := NewRenderer()
+	if err != nil {
+		window.Destroy()
+		runtime.UnlockOSThread()
+		t.Skipf("no GL context available: %v", err)
+	}
+	return r, func() {
+		r.Destroy()
+		window.Destroy()
+		runtime.UnlockOSThread()
+	}
+}
+
+func TestUpdateMeshVerticesGrowsBuffer(t *testing.T) {
+	r, cleanup := newTestRenderer(t, 16, 16)
+	defer cleanup()
+
+	vertexSize := int32(unsafe.Sizeof(core.Vertex{}))
+	bufferSize := func(gpu *GPUMesh) int32 {
+		var size int32
+		gl.BindBuffer(gl.ARRAY_BUFFER, gpu.VBO)
+		gl.GetBufferParameteriv(gl.ARRAY_BUFFER, gl.BUFFER_SIZE, &size)
+		gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+		return size
+	}
+
+	mesh := scene.CreateMeshFromData("Dyn", make([]core.Vertex, 3), nil)
+	mesh.Dynamic = true
+	r.UpdateMeshVertices(mesh) // first call uploads
+	gpu := r.gpuMeshes[mesh]
+	if gpu == nil {
+		t.Fatal("mesh was not uploaded")
+	}
+	if got := bufferSize(gpu); got != 3*vertexSize {
+		t.Fatalf("initial buffer: expected %d bytes, got %d", 3*vertexSize, got)
+	}
+
+	// Growing the slice reallocates; shrinking it keeps the larger buffer
+	mesh.Vertices = make([]core.Vertex, 10)
+	r.UpdateMeshVertices(mesh)
+	if got := bufferSize(gpu); got != 10*vertexSize || gpu.VertexCap != 10 {
+		t.Fatalf("grown buffer: expected %d bytes / cap 10, got %d / cap %d", 10*vertexSize, got, gpu.VertexCap)
+	}
+	mesh.Vertices = mesh.Vertices[:4]
+	r.UpdateMeshVertices(mesh)
+	if got := bufferSize(gpu); got != 10*vertexSize {
+		t.Fatalf("shrunk slice: expected buffer to stay %d bytes, got %d", 10*vertexSize, got)
+	}
+}
+
+func TestShadowBiasUniforms(t *testing.T) {
+	r, cleanup := newTestRenderer(t, 16, 16)
+	defer cleanup()
+
+	r.SetShadowBias(0.004, 0.02)
+	r.SetShadowNormalOffset(0.05)
+	ident := math.Mat4Identity()
+	r.BeginFrame(core.ColorBlack, nil, core.ColorBlack, math.Vec3Zero, ident, false, ident, ident)
+
+	uniform := func(loc int32) float32 {
+		var v float32
+		gl.GetUniformfv(r.program, loc, &v)
+		return v
+	}
+	if got := uniform(r.shadowBiasLoc); got != 0.004 {
+		t.Errorf("shadowBias: expected 0.004, got %v", got)
+	}
+	if got := uniform(r.shadowSlopeBiasLoc); got != 0.02 {
+		t.Errorf("shadowSlopeBias: expected 0.02, got %v", got)
+	}
+	if got := uniform(r.shadowNormalOffsetLoc); got != 0.05 {
+		t.Errorf("shadowNormalOffset: expected 0.05, got %v", got)
+	}
+}
+
+func TestUnlitEmissiveBlooms(t *testing.T) {
+	r, cleanup := newTestRenderer(t, 16, 16)
+	defer cleanup()
+	if err := r.EnablePostProcess(16, 16); err != nil {
+		t.Fatalf("EnablePostProcess: %v", err)
+	}
+
+	// Quad covering the viewport in clip space
+	n := math.Vec3{X: 0, Y: 0, Z: 1}
+	quad := scene.CreateMeshFromData("sign", []core.Vertex{
+		{Position: math.Vec3{X: -1, Y: -1, Z: 0}, Normal: n, Color: core.ColorWhite},
+		{Position: math.Vec3{X: 1, Y: -1, Z: 0}, Normal: n, Color: core.ColorWhite},
+		{Position: math.Vec3{X: 1, Y: 1, Z: 0}, Normal: n, Color: core.ColorWhite},
+		{Position: math.Vec3{X: -1, Y: 1, Z: 0}, Normal: n, Color: core.ColorWhite},
+	}, []uint32{0, 1, 2, 2, 3, 0})
+	quad.Material = scene.NewMaterial("sign", core.Color{R: 0.2, G: 0.2, B: 0.2, A: 1})
+	quad.Material.Unlit = true
+
+	// draw renders the quad and returns the centre pixel of the HDR colour
+	// and bloom-only attachments
+	ident := math.Mat4Identity()
+	draw := func() (color, bloom [4]float32) {
+		r.BeginFrame(core.ColorBlack, nil, core.ColorBlack, math.Vec3Zero, ident, false, ident, ident)
+		r.DrawMesh(quad, ident, ident)
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, r.postProcess.FBO)
+		gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+		gl.ReadPixels(8, 8, 1, 1, gl.RGBA, gl.FLOAT, gl.Ptr(&color[0]))
+		gl.ReadBuffer(gl.COLOR_ATTACHMENT1)
+		gl.ReadPixels(8, 8, 1, 1, gl.RGBA, gl.FLOAT, gl.Ptr(&bloom[0]))
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+		return color, bloom
+	}
+
+	// Plain unlit: the albedo as is, nothing for bloom
+	if color, bloom := draw(); color[0] < 0.19 || color[0] > 0.21 || bloom[0] != 0 {
+		t.Errorf("plain unlit: expected 0.2 and no bloom, got %v and %v", color, bloom)
+	}
+
+	// Emissive unlit: brighter than the bright-pass threshold, and in the
+	// bloom-only target
+	quad.Material.EmissiveColor = core.Color{R: 3, G: 3, B: 3, A: 1}
+	quad.Material.BloomScale = 1
+	color, bloom := draw()
+	if threshold := r.postProcess.BloomThreshold; color[0] <= threshold {
+		t.Errorf("emissive unlit: expected brightness above the bloom threshold %v, got %v", threshold, color)
+	}
+	if bloom[0] < 2.9 {
+		t.Errorf("emissive unlit: expected the emissive in the bloom target, got %v", bloom)
+	}
+}
+
+func TestWireframeSurvivesPostPass(t *testing.T) {
+	r, cleanup := newTestRenderer(t, 16, 16)
+	defer cleanup()
+	if err := r.EnablePostProcess(16, 16); err != nil {
+		t.Fatalf("EnablePostProcess: %v", err)
+	}
+
+	n := math.Vec3{X: 0, Y: 0, Z: 1}
+	quad := scene.CreateMeshFromData("quad", []core.Vertex{
+		{Position: math.Vec3{X: -1, Y: -1, Z: 0}, Normal: n, Color: core.ColorWhite},
+		{Position: math.Vec3{X: 1, Y: -1, Z: 0}, Normal: n, Color: core.ColorWhite},
+		{Position: math.Vec3{X: 1, Y: 1, Z: 0}, Normal: n, Color: core.ColorWhite},
+		{Position: math.Vec3{X: -1, Y: 1, Z: 0}, Normal: n, Color: core.ColorWhite},
+	}, []uint32{0, 1, 2, 2, 3, 0})
+	quad.Material = scene.NewMaterial("quad", core.ColorWhite)
+	quad.Material.Unlit = true
+
+	// frame draws the quad and resolves the frame, returning a pixel of the
+	// HDR target away from the quad's edges and diagonal
+	ident := math.Mat4Identity()
+	frame := func() [4]float32 {
+		r.BeginFrame(core.ColorBlack, nil, core.ColorBlack, math.Vec3Zero, ident, false, ident, ident)
+		r.DrawMesh(quad, ident, ident)
+		var px [4]float32
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, r.postProcess.FBO)
+		gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+		gl.ReadPixels(12, 4, 1, 1, gl.RGBA, gl.FLOAT, gl.Ptr(&px[0]))
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+		r.BlitPostProcess()
+		return px
+	}
+
+	r.SetWireframe(true)
+	for i := 0; i < 2; i++ {
+		if px := frame(); px[0] != 0 {
+			t.Errorf("frame %d: expected the quad's interior unfilled in wireframe mode, got %v", i, px)
+		}
+		if !r.IsWireframe() {
+			t.Fatalf("frame %d: wireframe mode lost after the post pass", i)
+		}
+		var mode [2]int32
+		gl.GetIntegerv(gl.POLYGON_MODE, &mode[0])
+		if mode[0] != gl.FILL {
+			t.Errorf("frame %d: expected polygon mode FILL outside mesh draws, got %#x", i, mode[0])
+		}
+	}
+
+	r.SetWireframe(false)
+	if px := frame(); px[0] < 0.99 {
+		t.Errorf("wireframe off: expected the quad filled, got %v", px)
+	}
+}
+
+func TestWireOverlayReverseZ(t *testing.T) {
+	r, cleanup := newTestRenderer(t, 16, 16)
+	defer cleanup()
+	if err := r.EnablePostProcess(16, 16); err != nil {
+		t.Fatalf("EnablePostProcess: %v", err)
+	}
+
+	// A white quad inside the viewport at NDC depth 0.5, in front of the
+	// cleared depth under either convention
+	n := math.Vec3{X: 0, Y: 0, Z: 1}
+	quad := scene.CreateMeshFromData("quad", []core.Vertex{
+		{Position: math.Vec3{X: -0.5, Y: -0.5, Z: 0.5}, Normal: n, Color: core.ColorWhite},
+		{Position: math.Vec3{X: 0.5, Y: -0.5, Z: 0.5}, Normal: n, Color: core.ColorWhite},
+		{Position: math.Vec3{X: 0.5, Y: 0.5, Z: 0.5}, Normal: n, Color: core.ColorWhite},
+		{Position: math.Vec3{X: -0.5, Y: 0.5, Z: 0.5}, Normal: n, Color: core.ColorWhite},
+	}, []uint32{0, 1, 2, 2, 3, 0})
+	quad.Material = scene.NewMaterial("quad", core.ColorWhite)
+	quad.Material.Unlit = true
+	r.SetWireframeOverlay(true)
+	r.SetWireframeColor(core.ColorRed)
+
+	// overlayPixels draws the quad and counts the HDR target's red line pixels
+	ident := math.Mat4Identity()
+	overlayPixels := func() int {
+		r.BeginFrame(core.ColorBlack, nil, core.ColorBlack, math.Vec3Zero, ident, false, ident, ident)
+		r.DrawMesh(quad, ident, ident)
+		px := make([]float32, 16*16*4)
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, r.postProcess.FBO)
+		gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+		gl.ReadPixels(0, 0, 16, 16, gl.RGBA, gl.FLOAT, gl.Ptr(&px[0]))
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+		r.BlitPostProcess()
+		count := 0
+		for i := 0; i < len(px); i += 4 {
+			if px[i] > 0.5 && px[i+1] < 0.5 {
+				count++
+			}
+		}
+		return count
+	}
+
+	if got := overlayPixels(); got == 0 {
+		t.Fatal("standard depth: expected overlay lines over the quad")
+	}
+	if err := r.SetReverseZ(true); err != nil {
+		t.Skipf("reverse-Z unavailable: %v", err)
+	}
+	defer r.SetReverseZ(false)
+	if got := overlayPixels(); got == 0 {
+		t.Error("reverse-Z: expected overlay lines over the quad, got none")
+	}
+}
+
+func TestGLPrimitive(t *testing.T) {
+	strip := scene.CreateMeshFromData("strip", []core.Vertex{
+		{Position: math.Vec3{X: 0, Y: 0, Z: 0}},
+		{Position: math.Vec3{X: 1, Y: 0, Z: 0}},
+		{Position: math.Vec3{X: 1, Y: 1, Z: 0}},
+	}, []uint32{0, 1, 2})
+	strip.DrawMode = scene.DrawLineStrip
+
+	if got := glPrimitive(strip.DrawMode); got != gl.LINE_STRIP {
+		t.Errorf("line strip: expected GL_LINE_STRIP (%#x), got %#x", gl.LINE_STRIP, got)
+	}
+	for mode, want := range map[scene.DrawMode]uint32{
+		scene.DrawTriangles:     gl.TRIANGLES,
+		scene.DrawLines:         gl.LINES,
+		scene.DrawPoints:        gl.POINTS,
+		scene.DrawTriangleStrip: gl.TRIANGLE_STRIP,
+	} {
+		if got := glPrimitive(mode); got != want {
+			t.Errorf("mode %d: expected %#x, got %#x", mode, want, got)
+		}
+	}
+}
+
+func TestMaterialChangedSkipsRedundantUploads(t *testing.T) {
+	var r Renderer
+	a, b := scene.DefaultMaterial(), scene.DefaultMaterial()
+	b.Roughness = 0.25
+
+	steps := []struct {
+		name string
+		mat  *scene.Material
+		want bool
+	}{
+		{"first draw", a, true},
+		{"same material", a, false},
+		{"equal material", scene.DefaultMaterial(), false},
+		{"different material", b, true},
+		{"back to first", a, true},
+	}
+	for _, s := range steps {
+		if got := r.materialChanged(s.mat); got != s.want {
+			t.Errorf("%s: expected %v, got %v", s.name, s.want, got)
+		}
+	}
+
+	texturesRebound()
+	if !r.materialChanged(a) {
+		t.Error("after texturesRebound: expected a re-upload")
+	}
+	if r.MaterialBinds() != 4 {
+		t.Errorf("expected 4 material binds, got %d", r.MaterialBinds())
+	}
+}
+
+func TestUseVertexColor(t *testing.T) {
+	r, cleanup := newTestRenderer(t, 16, 16)
+	defer cleanup()
+	if err := r.EnablePostProcess(16, 16); err != nil {
+		t.Fatalf("EnablePostProcess: %v", err)
+	}
+
+	n := math.Vec3{X: 0, Y: 0, Z: 1}
+	orange := core.Color{R: 1, G: 0.5, B: 0, A: 1}
+	quad := scene.CreateMeshFromData("quad", []core.Vertex{
+		{Position: math.Vec3{X: -1, Y: -1, Z: 0}, Normal: n, Color: orange},
+		{Position: math.Vec3{X: 1, Y: -1, Z: 0}, Normal: n, Color: orange},
+		{Position: math.Vec3{X: 1, Y: 1, Z: 0}, Normal: n, Color: orange},
+		{Position: math.Vec3{X: -1, Y: 1, Z: 0}, Normal: n, Color: orange},
+	}, []uint32{0, 1, 2, 2, 3, 0})
+	quad.Material = scene.NewMaterial("quad", core.Color{R: 0.5, G: 1, B: 1, A: 1})
+	quad.Material.Unlit = true
+
+	ident := math.Mat4Identity()
+	for _, tc := range []struct {
+		use  bool
+		want [3]float32
+	}{
+		{true, [3]float32{0.5, 0.5, 0}}, // vertex colour × albedo
+		{false, [3]float32{0.5, 1, 1}},  // albedo alone
+	} {
+		quad.Material.UseVertexColor = tc.use
+		r.BeginFrame(core.ColorBlack, nil, core.ColorBlack, math.Vec3Zero, ident, false, ident, ident)
+		r.DrawMesh(quad, ident, ident)
+		var px [4]float32
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, r.postProcess.FBO)
+		gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+		gl.ReadPixels(8, 8, 1, 1, gl.RGBA, gl.FLOAT, gl.Ptr(&px[0]))
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+		r.BlitPostProcess()
+
+		for i, w := range tc.want {
+			if d := px[i] - w; d < -0.01 || d > 0.01 {
+				t.Errorf("UseVertexColor=%v: expected base colour %v, got %v", tc.use, tc.want, px[:3])
+				break
+			}
+		}
+	}
+}
+
+func TestDebugViewNormals(t *testing.T) {
+	r, cleanup := newTestRenderer(t, 16, 16)
+	defer cleanup()
+	if err := r.EnablePostProcess(16, 16); err != nil {
+		t.Fatalf("EnablePostProcess: %v", err)
+	}
+
+	// A lit, red quad facing +Z: the debug view ignores both
+	n := math.Vec3{X: 0, Y: 0, Z: 1}
+	quad := scene.CreateMeshFromData("quad", []core.Vertex{
+		{Position: math.Vec3{X: -1, Y: -1, Z: 0}, Normal: n, Color: core.ColorWhite},
+		{Position: math.Vec3{X: 1, Y: -1, Z: 0}, Normal: n, Color: core.ColorWhite},
+		{Position: math.Vec3{X: 1, Y: 1, Z: 0}, Normal: n, Color: core.ColorWhite},
+		{Position: math.Vec3{X: -1, Y: 1, Z: 0}, Normal: n, Color: core.ColorWhite},
+	}, []uint32{0, 1, 2, 2, 3, 0})
+	quad.Material = scene.NewMaterial("quad", core.ColorRed)
+
+	r.SetDebugView(DebugNormals)
+	ident := math.Mat4Identity()
+	r.BeginFrame(core.ColorBlack, nil, core.ColorBlack, math.Vec3Zero, ident, false, ident, ident)
+	r.DrawMesh(quad, ident, ident)
+	var px [4]float32
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, r.postProcess.FBO)
+	gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+	gl.ReadPixels(8, 8, 1, 1, gl.RGBA, gl.FLOAT, gl.Ptr(&px[0]))
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+	r.BlitPostProcess()
+
+	want := [3]float32{0.5, 0.5, 1}
+	for i, w := range want {
+		if d := px[i] - w; d < -0.01 || d > 0.01 {
+			t.Fatalf("DebugNormals of a +Z quad: expected %v, got %v", want, px[:3])
+		}
+	}
+	if got := r.postProcess.debugOut; got != 1 {
+		t.Errorf("expected the composite to skip tone mapping (debugOut 1), got %d", got)
+	}
+}
+
+func TestGatherLightsSkipsDisabled(t *testing.T) {
+	point := func(on bool) *scene.Light {
+		return &scene.Light{Type: scene.LightTypePoint, Intensity: 1, Range: 5, Disabled: !on}
+	}
+	off := point(false)
+	lights := []*scene.Light{off}
+	for i := 0; i < maxPointLights; i++ {
+		lights = append(lights, point(true))
+	}
+	sun := &scene.Light{Type: scene.LightTypeDirectional, Intensity: 1}
+	lights = append(lights, sun, nil)
+
+	fl := gatherLights(lights)
+	if len(fl.points) != maxPointLights {
+		t.Fatalf("expected all %d slots filled by enabled lights, got %d", maxPointLights, len(fl.points))
+	}
+	for i, l := range fl.points {
+		if l == off || l != lights[i+1] {
+			t.Errorf("slot %d: expected enabled light %d, got %p", i, i+1, l)
+		}
+	}
+	if fl.dir != sun || fl.dirDisabled {
+		t.Errorf("expected the enabled sun, got %v (disabled %v)", fl.dir, fl.dirDisabled)
+	}
+
+	// A switched-off sun leaves no directional light, and says so
+	sun.Disabled = true
+	if fl := gatherLights(lights); fl.dir != nil || !fl.dirDisabled {
+		t.Errorf("disabled sun: expected none and dirDisabled, got %v, %v", fl.dir, fl.dirDisabled)
+	}
+	if fl := gatherLights(nil); fl.dir != nil || fl.dirDisabled {
+		t.Errorf("no lights: expected no directional light and dirDisabled false, got %v, %v", fl.dir, fl.dirDisabled)
+	}
+}
@@ -0,0 +1,92 @@
+package opengl
+
+import (
+	"fmt"
+
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// RenderTarget is an off-screen LDR colour + depth framebuffer whose colour
+// texture can be sampled by materials (monitors, mirrors, minimaps).
+type RenderTarget struct {
+	FBO      uint32
+	ColorTex uint32 // RGBA8 colour attachment, sampleable
+	DepthRBO uint32 // DEPTH_COMPONENT32F renderbuffer (never sampled; float for reverse-Z)
+	Width    int32
+	Height   int32
+}
+
+// NewRenderTarget allocates a width×height colour+depth framebuffer.
+func NewRenderTarget(width, height int) (*RenderTarget, error) {
+	rt := &RenderTarget{}
+	if err := rt.alloc(width, height); err != nil {
+		return nil, err
+	}
+	return rt, nil
+}
+
+func (rt *RenderTarget) alloc(width, height int) error {
+	texturesRebound()
+	rt.Width  = int32(width)
+	rt.Height = int32(height)
+
+	gl.GenTextures(1, &rt.ColorTex)
+	gl.BindTexture(gl.TEXTURE_2D, rt.ColorTex)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8,
+		int32(width), int32(height), 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	gl.GenRenderbuffers(1, &rt.DepthRBO)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, rt.DepthRBO)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT32F, int32(width), int32(height))
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+
+	gl.GenFramebuffers(1, &rt.FBO)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, rt.FBO)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0,
+		gl.TEXTURE_2D, rt.ColorTex, 0)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT,
+		gl.RENDERBUFFER, rt.DepthRBO)
+	status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		rt.free()
+		return fmt.Errorf("render target FBO incomplete: status=0x%X", status)
+	}
+	return nil
+}
+
+func (rt *RenderTarget) free() {
+	if rt.FBO != 0 {
+		gl.DeleteFramebuffers(1, &rt.FBO)
+		rt.FBO = 0
+	}
+	if rt.ColorTex != 0 {
+		gl.DeleteTextures(1, &rt.ColorTex)
+		rt.ColorTex = 0
+	}
+	if rt.DepthRBO != 0 {
+		gl.DeleteRenderbuffers(1, &rt.DepthRBO)
+		rt.DepthRBO = 0
+	}
+}
+
+// Resize reallocates the attachments when the size changes.  The colour
+// texture gets a new GL name, so callers must re-read ColorTex afterwards.
+func (rt *RenderTarget) Resize(width, height int) error {
+	if int32(width) == rt.Width && int32(height) == rt.Height {
+		return nil
+	}
+	rt.free()
+	return rt.alloc(width, height)
+}
+
+// Destroy frees GPU resources.
+func (rt *RenderTarget) Destroy() {
+	rt.free()
+}
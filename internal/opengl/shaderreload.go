@@ -0,0 +1,82 @@
+package opengl
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// ShaderDirEnv names the environment variable pointing at a directory whose
+// main.vert and main.frag replace the built-in main shader, so it can be
+// edited and reloaded (ReloadShaders) without rebuilding the program. A file
+// that is missing keeps the built-in source for that stage.
+const ShaderDirEnv = "RENDER_ENGINE_SHADER_DIR"
+
+// Main shader file names in the ShaderDirEnv directory.
+const (
+	mainVertFile = "main.vert"
+	mainFragFile = "main.frag"
+)
+
+// mainShaderSources returns the main shader sources, from ShaderDirEnv when
+// it is set.
+func mainShaderSources() (vert, frag string, err error) {
+	return loadShaderSources(os.Getenv(ShaderDirEnv))
+}
+
+// loadShaderSources returns dir's main.vert and main.frag, NUL-terminated for
+// newProgram; the built-in vertSrc and fragSrc stand in for missing files,
+// and for both when dir is empty.
+func loadShaderSources(dir string) (vert, frag string, err error) {
+	if dir == "" {
+		return vertSrc, fragSrc, nil
+	}
+	if vert, err = readShaderFile(filepath.Join(dir, mainVertFile), vertSrc); err != nil {
+		return "", "", err
+	}
+	if frag, err = readShaderFile(filepath.Join(dir, mainFragFile), fragSrc); err != nil {
+		return "", "", err
+	}
+	return vert, frag, nil
+}
+
+func readShaderFile(path, builtin string) (string, error) {
+	src, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return builtin, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("read shader: %w", err)
+	}
+	return string(src) + "\x00", nil
+}
+
+// ReloadShaders recompiles the main shader from its current sources (see
+// ShaderDirEnv) and, if it compiles and links, swaps it in and re-resolves
+// its uniform locations. On failure the error carries the compiler log and
+// the previous program stays in use. Call between frames: per-frame
+// uniforms are set again by the next BeginFrame.
+func (r *Renderer) ReloadShaders() error {
+	vert, frag, err := mainShaderSources()
+	if err != nil {
+		return err
+	}
+	return r.reloadProgram(vert, frag)
+}
+
+// reloadProgram replaces the main program with one built from vert and frag,
+// keeping the current one if they don't compile.
+func (r *Renderer) reloadProgram(vert, frag string) error {
+	prog, err := newProgram(vert, frag)
+	if err != nil {
+		return fmt.Errorf("main shader compile: %w", err)
+	}
+	gl.DeleteProgram(r.program)
+	r.program = prog
+	r.resolveUniforms()
+	return nil
+}
@@ -0,0 +1,60 @@
+package opengl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+)
+
+func TestLoadShaderSources(t *testing.T) {
+	dir := t.TempDir()
+	frag := "#version 410 core\nout vec4 c;\nvoid main() { c = vec4(1.0); }\n"
+	if err := os.WriteFile(filepath.Join(dir, mainFragFile), []byte(frag), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	vs, fs, err := loadShaderSources(dir)
+	if err != nil {
+		t.Fatalf("loadShaderSources: %v", err)
+	}
+	if vs != vertSrc {
+		t.Error("missing main.vert: expected the built-in vertex shader")
+	}
+	if fs != frag+"\x00" {
+		t.Errorf("expected main.frag NUL-terminated, got %q", fs)
+	}
+
+	if vs, fs, _ := loadShaderSources(""); vs != vertSrc || fs != fragSrc {
+		t.Error("no directory: expected the built-in sources")
+	}
+}
+
+func TestReloadShadersKeepsProgramOnError(t *testing.T) {
+	r, cleanup := newTestRenderer(t, 16, 16)
+	defer cleanup()
+
+	before, mvpLoc := r.program, r.mvpLoc
+	broken := strings.Replace(fragSrc, "void main()", "void main() { undeclared = 1.0; }\nvoid unused()", 1)
+	if err := r.reloadProgram(vertSrc, broken); err == nil {
+		t.Fatal("expected a compile error for the broken shader")
+	}
+	if r.program != before || r.mvpLoc != mvpLoc {
+		t.Errorf("failed reload replaced the program: %d → %d", before, r.program)
+	}
+	if !gl.IsProgram(r.program) {
+		t.Error("previous program was deleted")
+	}
+
+	if err := r.reloadProgram(vertSrc, fragSrc); err != nil {
+		t.Fatalf("reloading the built-in shader: %v", err)
+	}
+	if r.program == before || gl.IsProgram(before) {
+		t.Error("successful reload should replace and delete the old program")
+	}
+	if r.mvpLoc < 0 {
+		t.Error("uniform locations not re-resolved")
+	}
+}
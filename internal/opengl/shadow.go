@@ -11,6 +11,13 @@ type ShadowMap struct {
 	FBO      uint32
 	DepthTex uint32
 	Size     int32
+
+	// RawSampler reads DepthTex without depth comparison, for the soft-shadow
+	// blocker search (a texture can only have one compare mode per sampler).
+	RawSampler uint32
+
+	// Linear is the comparison filtering of DepthTex (see SetFiltering).
+	Linear bool
 }
 
 // NewShadowMap creates a depth-only FBO of size×size resolution.
@@ -23,16 +30,12 @@ func NewShadowMap(size int) (*ShadowMap, error) {
 	gl.BindTexture(gl.TEXTURE_2D, sm.DepthTex)
 	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.DEPTH_COMPONENT32F,
 		int32(size), int32(size), 0, gl.DEPTH_COMPONENT, gl.FLOAT, nil)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_BORDER)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_BORDER)
 	// Fragments outside the shadow map are lit (border depth = 1.0)
 	border := [4]float32{1, 1, 1, 1}
 	gl.TexParameterfv(gl.TEXTURE_2D, gl.TEXTURE_BORDER_COLOR, &border[0])
-	// Enable hardware PCF: texture() returns 0.0 or 1.0 based on depth comparison
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_MODE, gl.COMPARE_REF_TO_TEXTURE)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_FUNC, gl.LEQUAL)
+	sm.setSampling(true)
 
 	// Depth-only framebuffer
 	gl.GenFramebuffers(1, &sm.FBO)
@@ -51,9 +54,49 @@ func NewShadowMap(size int) (*ShadowMap, error) {
 		return nil, fmt.Errorf("shadow FBO incomplete: status=0x%X", status)
 	}
 
+	// Sampler object overriding the compare mode so raw depths can be fetched
+	gl.GenSamplers(1, &sm.RawSampler)
+	gl.SamplerParameteri(sm.RawSampler, gl.TEXTURE_COMPARE_MODE, gl.NONE)
+	gl.SamplerParameteri(sm.RawSampler, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.SamplerParameteri(sm.RawSampler, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.SamplerParameteri(sm.RawSampler, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_BORDER)
+	gl.SamplerParameteri(sm.RawSampler, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_BORDER)
+	gl.SamplerParameterfv(sm.RawSampler, gl.TEXTURE_BORDER_COLOR, &border[0])
+
 	return sm, nil
 }
 
+// SetFiltering selects the comparison filtering of the shadow sampler.
+// Linear (the default) compares the four texels around each lookup and
+// blends the results, so every PCF tap is itself a 2×2 bilinear PCF and
+// shadow edges come out smooth; nearest compares one texel, giving the
+// blocky, texel-sized steps some drivers produce when the filter is left
+// unset. The compare mode and function are reasserted either way.
+func (sm *ShadowMap) SetFiltering(linear bool) {
+	gl.BindTexture(gl.TEXTURE_2D, sm.DepthTex)
+	sm.setSampling(linear)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+}
+
+// setSampling sets the filter and depth comparison of the bound DepthTex.
+// With COMPARE_REF_TO_TEXTURE a sampler2DShadow lookup returns the fraction
+// of texels passing ref <= stored depth, 1 meaning lit: the func must be
+// LEQUAL because the shader's reference is the fragment's light-space depth
+// (minus bias), which is at most the occluder depth stored in the map when
+// nothing lies between the fragment and the light. The shadow pass always
+// writes standard depth (near = 0), even with reverse-Z.
+func (sm *ShadowMap) setSampling(linear bool) {
+	filter := int32(gl.NEAREST)
+	if linear {
+		filter = gl.LINEAR
+	}
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, filter)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, filter)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_MODE, gl.COMPARE_REF_TO_TEXTURE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_FUNC, gl.LEQUAL)
+	sm.Linear = linear
+}
+
 // Destroy frees GPU resources.
 func (sm *ShadowMap) Destroy() {
 	if sm.FBO != 0 {
@@ -64,4 +107,8 @@ func (sm *ShadowMap) Destroy() {
 		gl.DeleteTextures(1, &sm.DepthTex)
 		sm.DepthTex = 0
 	}
+	if sm.RawSampler != 0 {
+		gl.DeleteSamplers(1, &sm.RawSampler)
+		sm.RawSampler = 0
+	}
 }
@@ -0,0 +1,66 @@
+package opengl
+
+import (
+	"testing"
+
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+)
+
+func TestShadowMapSampling(t *testing.T) {
+	r, cleanup := newTestRenderer(t, 16, 16)
+	defer cleanup()
+	if err := r.EnableShadows(64); err != nil {
+		t.Fatalf("EnableShadows: %v", err)
+	}
+
+	param := func(name uint32) int32 {
+		var v int32
+		gl.BindTexture(gl.TEXTURE_2D, r.shadowMap.DepthTex)
+		gl.GetTexParameteriv(gl.TEXTURE_2D, name, &v)
+		gl.BindTexture(gl.TEXTURE_2D, 0)
+		return v
+	}
+	check := func(label string, filter int32) {
+		t.Helper()
+		for _, p := range []struct {
+			name      string
+			want, got int32
+		}{
+			{"MIN_FILTER", filter, param(gl.TEXTURE_MIN_FILTER)},
+			{"MAG_FILTER", filter, param(gl.TEXTURE_MAG_FILTER)},
+			{"COMPARE_MODE", gl.COMPARE_REF_TO_TEXTURE, param(gl.TEXTURE_COMPARE_MODE)},
+			{"COMPARE_FUNC", gl.LEQUAL, param(gl.TEXTURE_COMPARE_FUNC)},
+		} {
+			if p.got != p.want {
+				t.Errorf("%s: expected %s %#x, got %#x", label, p.name, p.want, p.got)
+			}
+		}
+	}
+
+	check("default", gl.LINEAR)
+
+	// Lookups past the edge read the white border: depth 1, always lit
+	for _, wrap := range []uint32{gl.TEXTURE_WRAP_S, gl.TEXTURE_WRAP_T} {
+		if got := param(wrap); got != gl.CLAMP_TO_BORDER {
+			t.Errorf("wrap %#x: expected CLAMP_TO_BORDER, got %#x", wrap, got)
+		}
+	}
+	var border [4]float32
+	gl.BindTexture(gl.TEXTURE_2D, r.shadowMap.DepthTex)
+	gl.GetTexParameterfv(gl.TEXTURE_2D, gl.TEXTURE_BORDER_COLOR, &border[0])
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	if border != [4]float32{1, 1, 1, 1} {
+		t.Errorf("expected a white border, got %v", border)
+	}
+
+	r.SetShadowFiltering(false)
+	check("nearest", gl.NEAREST)
+
+	// A shadow map created later keeps the setting
+	if err := r.EnableShadows(64); err != nil {
+		t.Fatalf("EnableShadows: %v", err)
+	}
+	check("recreated", gl.NEAREST)
+	r.SetShadowFiltering(true)
+	check("linear", gl.LINEAR)
+}
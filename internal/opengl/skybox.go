@@ -2,6 +2,8 @@ package opengl
 
 import (
 	"fmt"
+	stdmath "math"
+	"time"
 	"unsafe"
 
 	gl "github.com/go-gl/gl/v4.1-core/gl"
@@ -10,18 +12,43 @@ import (
 	"render-engine/math"
 )
 
-// Skybox renders a procedural gradient sky using an inverted unit cube.
+// Skybox renders a procedural sky using an inverted unit cube: a three-stop
+// gradient by default, or a Preetham analytic atmosphere when Atmosphere is set.
+// A sun disc, the moon opposite it and a procedural starfield are drawn on top,
+// under an optional layer of animated noise clouds.
+// When EnvMap is set the HDR panorama is drawn instead of all of the above.
 // The cube vertex shader uses the xyww trick (gl_Position.z = gl_Position.w)
 // so every fragment lands at NDC depth 1.0 — always behind scene geometry.
+// Under reverse-Z it outputs z = 0 instead, the far plane of that convention.
 type Skybox struct {
 	vao  uint32
 	vbo  uint32
 	prog uint32
 
-	vpLoc      int32
-	zenithLoc  int32
-	horizonLoc int32
-	groundLoc  int32
+	vpLoc       int32
+	reverseZLoc int32
+	zenithLoc   int32
+	horizonLoc  int32
+	groundLoc   int32
+
+	atmosphereLoc   int32
+	sunDirLoc       int32
+	turbidityLoc    int32
+	groundAlbedoLoc int32
+	sunColorLoc     int32
+	sunCosRadiusLoc int32
+	sunGlowLoc      int32
+	moonColorLoc    int32
+	showStarsLoc    int32
+	envMapLoc       int32
+	useEnvMapLoc    int32
+
+	cloudCoverageLoc int32
+	cloudOffsetLoc   int32
+
+	// Cloud scroll, accumulated per draw so speed changes do not jump
+	cloudOffset [2]float64
+	lastDraw    time.Time
 
 	// ZenithColor is the sky colour directly overhead (Y = +1).
 	ZenithColor core.Color
@@ -29,6 +56,42 @@ type Skybox struct {
 	HorizonColor core.Color
 	// GroundColor is the colour below the horizon (Y = -1).
 	GroundColor core.Color
+
+	// Atmosphere switches from the gradient to the Preetham sky. The
+	// gradient colours remain in use as the night sky once the sun sets.
+	Atmosphere bool
+	// SunDirection points from the scene toward the sun (unit length).
+	// The render engine sets it from the first directional light each frame.
+	SunDirection math.Vec3
+	// Turbidity is the atmospheric haze: 2 = very clear, 10 = hazy (default 3).
+	Turbidity float32
+	// GroundAlbedo tints the horizon radiance below the horizon.
+	GroundAlbedo core.Color
+
+	// SunColor is the radiance of the sun disc drawn at SunDirection; keep
+	// it well above 1 so the disc reads as the brightest thing on screen.
+	SunColor core.Color
+	// SunSize is the disc's angular diameter in degrees (0 hides it; the
+	// real sun is 0.53°, default 2 reads better at typical resolutions).
+	SunSize float32
+	// SunGlow is the halo around the disc relative to its radiance (default 0.1).
+	SunGlow float32
+	// MoonColor is the radiance of the moon disc drawn opposite the sun at
+	// the same size; black hides it.
+	MoonColor core.Color
+	// ShowStars draws a procedural starfield that fades in as the sky darkens.
+	ShowStars bool
+
+	// CloudCoverage is the fraction of the sky under cloud: 0 hides the
+	// layer, 1 is overcast. CloudSpeed is the drift in cloud-plane units
+	// per second (about one cloud width per 2 units). Set through
+	// RenderEngine.SetClouds.
+	CloudCoverage float32
+	CloudSpeed    float32
+
+	// EnvMap is an equirectangular HDR panorama texture drawn as the sky
+	// (0 = procedural sky). Set through Renderer.SetEnvironmentMap.
+	EnvMap uint32
 }
 
 // ── Shaders ───────────────────────────────────────────────────────────────────
@@ -40,6 +103,7 @@ const skyVertSrc = `
 layout(location = 0) in vec3 inPosition;
 
 uniform mat4 skyVP;
+uniform bool reverseZ;
 
 out vec3 fragDir;
 
@@ -47,12 +111,15 @@ void main() {
     fragDir = inPosition;
     vec4 pos = skyVP * vec4(inPosition, 1.0);
     // xyww → after perspective divide: z/w = w/w = 1.0 (far plane)
-    gl_Position = pos.xyww;
+    // Reverse-Z puts the far plane at z = 0 instead
+    gl_Position = reverseZ ? vec4(pos.xy, 0.0, pos.w) : pos.xyww;
 }
 ` + "\x00"
 
 // skyFragSrc — gradient based on the fragment's vertical direction.
 // Above the horizon: lerp horizon→zenith.  Below: lerp horizon→ground.
+// In atmosphere mode the Preetham model (see atmosphere.go, which mirrors it
+// for IBL) replaces the gradient while the sun is up.
 const skyFragSrc = `
 #version 410 core
 in vec3 fragDir;
@@ -62,8 +129,146 @@ uniform vec3 zenith;
 uniform vec3 horizon;
 uniform vec3 ground;
 
+uniform bool  atmosphere;
+uniform vec3  sunDir;       // toward the sun, unit length
+uniform float turbidity;
+uniform vec3  groundAlbedo;
+
+uniform vec3  sunColor;
+uniform float sunCosRadius; // cos of the disc's angular radius; > 1 hides it
+uniform float sunGlow;
+uniform vec3  moonColor;
+uniform bool  showStars;
+
+uniform bool      useEnvMap;
+uniform sampler2D envMap;   // unit 0 — equirectangular HDR panorama
+
+uniform float cloudCoverage; // 0 = clear (layer skipped), 1 = overcast
+uniform vec2  cloudOffset;   // wind scroll on the cloud plane, wrapped to the noise period
+` + equirectGLSL + `
+
+const float PI = 3.14159265;
+const float skyLuminanceScale = 0.05;
+
+// Disc of the given cos-radius around axis, anti-aliased over one pixel.
+float disc(vec3 dir, vec3 axis, float cosRadius) {
+    float c  = dot(dir, axis);
+    float fw = fwidth(c);
+    return smoothstep(cosRadius - fw, cosRadius + fw, c);
+}
+
+float hash13(vec3 p) {
+    p  = fract(p * 0.1031);
+    p += dot(p, p.zyx + 31.32);
+    return fract((p.x + p.y) * p.z);
+}
+
+// Sparse point stars: a few cells of a 3D grid around the unit sphere hold
+// one star each, with a hashed brightness and a slight colour temperature.
+vec3 stars(vec3 dir) {
+    vec3  p    = dir * 250.0;
+    vec3  cell = floor(p);
+    float h    = hash13(cell);
+    if (h < 0.996) return vec3(0.0);
+    float b = (h - 0.996) / 0.004;
+    float d = length(fract(p) - 0.5);
+    vec3  tint = mix(vec3(0.75, 0.85, 1.0), vec3(1.0, 0.9, 0.75), hash13(cell + 17.0));
+    return tint * smoothstep(0.35, 0.0, d) * (0.3 + 1.5 * b);
+}
+
+// Value noise on a lattice that repeats every 256 units, so the scroll offset
+// can wrap without a visible jump.
+float hash12(vec2 p) {
+    p = mod(p, 256.0);
+    vec3 p3 = fract(vec3(p.xyx) * 0.1031);
+    p3 += dot(p3, p3.yzx + 33.33);
+    return fract((p3.x + p3.y) * p3.z);
+}
+
+float valueNoise(vec2 p) {
+    vec2 i = floor(p);
+    vec2 f = fract(p);
+    vec2 u = f * f * (3.0 - 2.0 * f);
+    return mix(mix(hash12(i),                  hash12(i + vec2(1.0, 0.0)), u.x),
+               mix(hash12(i + vec2(0.0, 1.0)), hash12(i + vec2(1.0, 1.0)), u.x), u.y);
+}
+
+// Five octaves at doubling frequencies; every octave's period divides 256.
+float fbm(vec2 p) {
+    float sum = 0.0;
+    float amp = 0.5;
+    for (int i = 0; i < 5; i++) {
+        sum += amp * valueNoise(p);
+        p   *= 2.0;
+        amp *= 0.5;
+    }
+    return sum;
+}
+
+// Cloud density (0..1) for a direction above the horizon. The direction is
+// projected onto a flat cloud plane, which bunches clouds up toward the
+// horizon like a real layer, then domain-warped fBm gives billowy shapes.
+float cloudDensity(vec3 dir) {
+    vec2 p = dir.xz / (dir.y + 0.08) * 0.6 + cloudOffset;
+    vec2 q = vec2(fbm(p), fbm(p + vec2(5.2, 1.3)));
+    float n = fbm(p + 3.0 * q + vec2(1.7, 9.2));
+    // fBm clusters around 0.5; coverage lowers the threshold
+    float edge = mix(0.72, 0.30, cloudCoverage);
+    return smoothstep(edge, edge + 0.18, n);
+}
+
+float perez(float cosTheta, float gamma, float cosGamma, float A, float B, float C, float D, float E) {
+    return (1.0 + A * exp(B / cosTheta)) * (1.0 + C * exp(D * gamma) + E * cosGamma * cosGamma);
+}
+
+// Preetham sky radiance (linear RGB) for a direction at or above the horizon.
+vec3 preetham(vec3 dir) {
+    float T = turbidity;
+    vec3  s = normalize(vec3(sunDir.x, max(sunDir.y, 0.0) + 1e-4, sunDir.z));
+    float thetaS = acos(min(s.y, 1.0));
+
+    float cosTheta = max(dir.y, 0.01);
+    float cosGamma = clamp(dot(dir, s), -1.0, 1.0);
+    float gamma    = acos(cosGamma);
+    float cosTS    = cos(thetaS);
+
+    float chi = (4.0 / 9.0 - T / 120.0) * (PI - 2.0 * thetaS);
+    float Yz  = (4.0453 * T - 4.9710) * tan(chi) - 0.2155 * T + 2.4192;
+    float t2 = thetaS * thetaS;
+    float t3 = t2 * thetaS;
+    float xz = T * T * (0.00166 * t3 - 0.00375 * t2 + 0.00209 * thetaS)
+             + T * (-0.02903 * t3 + 0.06377 * t2 - 0.03202 * thetaS + 0.00394)
+             + (0.11693 * t3 - 0.21196 * t2 + 0.06052 * thetaS + 0.25886);
+    float yz = T * T * (0.00275 * t3 - 0.00610 * t2 + 0.00317 * thetaS)
+             + T * (-0.04214 * t3 + 0.08970 * t2 - 0.04153 * thetaS + 0.00516)
+             + (0.15346 * t3 - 0.26756 * t2 + 0.06670 * thetaS + 0.26688);
+
+    float aY = 0.1787 * T - 1.4630, bY = -0.3554 * T + 0.4275, cY = -0.0227 * T + 5.3251, dY = 0.1206 * T - 2.5771, eY = -0.0670 * T + 0.3703;
+    float ax = -0.0193 * T - 0.2592, bx = -0.0665 * T + 0.0008, cx = -0.0004 * T + 0.2125, dx = -0.0641 * T - 0.8989, ex = -0.0033 * T + 0.0452;
+    float ay = -0.0167 * T - 0.2608, by = -0.0950 * T + 0.0092, cy = -0.0079 * T + 0.2102, dy = -0.0441 * T - 1.6537, ey = -0.0109 * T + 0.0529;
+
+    float Y = Yz * perez(cosTheta, gamma, cosGamma, aY, bY, cY, dY, eY) / perez(1.0, thetaS, cosTS, aY, bY, cY, dY, eY);
+    float x = xz * perez(cosTheta, gamma, cosGamma, ax, bx, cx, dx, ex) / perez(1.0, thetaS, cosTS, ax, bx, cx, dx, ex);
+    float y = yz * perez(cosTheta, gamma, cosGamma, ay, by, cy, dy, ey) / perez(1.0, thetaS, cosTS, ay, by, cy, dy, ey);
+    Y *= skyLuminanceScale;
+
+    // xyY → XYZ → linear sRGB
+    vec3 XYZ = vec3(x / y * Y, Y, (1.0 - x - y) / y * Y);
+    mat3 toRGB = mat3( 3.2406, -0.9689,  0.0557,
+                      -1.5372,  1.8758, -0.2040,
+                      -0.4986,  0.0415,  1.0570);
+    return max(toRGB * XYZ, vec3(0.0));
+}
+
 void main() {
-    float t = normalize(fragDir).y;     // -1 (down) to +1 (up)
+    vec3  dir = normalize(fragDir);
+    float t   = dir.y;     // -1 (down) to +1 (up)
+
+    if (useEnvMap) {
+        // Explicit LOD: the u seam would otherwise pick a tiny mip
+        outColor = vec4(textureLod(envMap, dirToEquirect(dir), 0.0).rgb, 1.0);
+        return;
+    }
 
     vec3 color;
     if (t >= 0.0) {
@@ -73,6 +278,40 @@ void main() {
         // Ground fades in quickly below the horizon
         color = mix(horizon, ground, min(-t * 3.0, 1.0));
     }
+
+    if (atmosphere) {
+        vec3 sky = preetham(normalize(vec3(dir.x, max(dir.y, 0.0), dir.z)));
+        if (t < 0.0) sky = mix(sky, sky * groundAlbedo, min(-t * 3.0, 1.0));
+        // Fade to the gradient (night sky) as the sun sets
+        color = mix(color, sky, smoothstep(-0.1, 0.05, normalize(sunDir).y));
+    }
+
+    // Celestial bodies sit above the horizon only
+    float above = smoothstep(-0.005, 0.005, t);
+    if (showStars) {
+        float lum  = dot(color, vec3(0.2126, 0.7152, 0.0722));
+        float dark = 1.0 - smoothstep(0.02, 0.15, lum);
+        color += stars(dir) * dark * smoothstep(0.0, 0.1, t);
+    }
+    if (sunCosRadius < 1.0) {
+        vec3  s    = normalize(sunDir);
+        float sun  = disc(dir, s, sunCosRadius);
+        float glow = sunGlow * exp(-(1.0 - dot(dir, s)) / (16.0 * (1.0 - sunCosRadius)));
+        color += sunColor * max(sun, glow) * above;
+        color  = mix(color, moonColor, disc(dir, -s, sunCosRadius) * above * step(0.001, dot(moonColor, vec3(1.0))));
+    }
+
+    // Clouds cover everything above. They are white in daylight, take on
+    // the horizon colour as the sun sets and darken with the sky at night;
+    // heavier cover turns them grey and their thick cores darker still.
+    if (cloudCoverage > 0.0 && t > 0.0) {
+        float d   = cloudDensity(dir);
+        float day = smoothstep(-0.05, 0.35, normalize(sunDir).y);
+        vec3  lit = mix(horizon * 1.5, vec3(1.0), day);
+        float overcast = mix(1.0, 0.45, smoothstep(0.4, 1.0, cloudCoverage));
+        vec3  cloud = lit * overcast * mix(1.0, 0.75, d);
+        color = mix(color, cloud, d * smoothstep(0.0, 0.12, t));
+    }
     outColor = vec4(color, 1.0);
 }
 ` + "\x00"
@@ -113,16 +352,47 @@ func NewSkybox() (*Skybox, error) {
 	}
 
 	sb := &Skybox{
-		prog:       prog,
-		vpLoc:      gl.GetUniformLocation(prog, gl.Str("skyVP\x00")),
-		zenithLoc:  gl.GetUniformLocation(prog, gl.Str("zenith\x00")),
-		horizonLoc: gl.GetUniformLocation(prog, gl.Str("horizon\x00")),
-		groundLoc:  gl.GetUniformLocation(prog, gl.Str("ground\x00")),
+		prog:        prog,
+		vpLoc:       gl.GetUniformLocation(prog, gl.Str("skyVP\x00")),
+		reverseZLoc: gl.GetUniformLocation(prog, gl.Str("reverseZ\x00")),
+		zenithLoc:   gl.GetUniformLocation(prog, gl.Str("zenith\x00")),
+		horizonLoc:  gl.GetUniformLocation(prog, gl.Str("horizon\x00")),
+		groundLoc:   gl.GetUniformLocation(prog, gl.Str("ground\x00")),
+
+		atmosphereLoc:   gl.GetUniformLocation(prog, gl.Str("atmosphere\x00")),
+		sunDirLoc:       gl.GetUniformLocation(prog, gl.Str("sunDir\x00")),
+		turbidityLoc:    gl.GetUniformLocation(prog, gl.Str("turbidity\x00")),
+		groundAlbedoLoc: gl.GetUniformLocation(prog, gl.Str("groundAlbedo\x00")),
+		sunColorLoc:     gl.GetUniformLocation(prog, gl.Str("sunColor\x00")),
+		sunCosRadiusLoc: gl.GetUniformLocation(prog, gl.Str("sunCosRadius\x00")),
+		sunGlowLoc:      gl.GetUniformLocation(prog, gl.Str("sunGlow\x00")),
+		moonColorLoc:    gl.GetUniformLocation(prog, gl.Str("moonColor\x00")),
+		showStarsLoc:    gl.GetUniformLocation(prog, gl.Str("showStars\x00")),
+		envMapLoc:       gl.GetUniformLocation(prog, gl.Str("envMap\x00")),
+		useEnvMapLoc:    gl.GetUniformLocation(prog, gl.Str("useEnvMap\x00")),
+
+		cloudCoverageLoc: gl.GetUniformLocation(prog, gl.Str("cloudCoverage\x00")),
+		cloudOffsetLoc:   gl.GetUniformLocation(prog, gl.Str("cloudOffset\x00")),
 
 		// Deep blue zenith, pale blue horizon, warm brown ground
 		ZenithColor:  core.Color{R: 0.10, G: 0.30, B: 0.70, A: 1},
 		HorizonColor: core.Color{R: 0.60, G: 0.80, B: 1.00, A: 1},
 		GroundColor:  core.Color{R: 0.30, G: 0.25, B: 0.20, A: 1},
+
+		// Mid-morning sun over a clear sky and dry-grass ground
+		SunDirection: math.Vec3{X: 0.3, Y: 0.8, Z: 0.5}.Normalize(),
+		Turbidity:    3,
+		GroundAlbedo: core.Color{R: 0.30, G: 0.28, B: 0.22, A: 1},
+
+		// Warm HDR sun (blooms), pale moon, stars on
+		SunColor:  core.Color{R: 10, G: 9.5, B: 8.5, A: 1},
+		SunSize:   2,
+		SunGlow:   0.1,
+		MoonColor: core.Color{R: 0.8, G: 0.85, B: 0.95, A: 1},
+		ShowStars: true,
+
+		// Clear sky; clouds drift slowly once coverage is raised
+		CloudSpeed: 0.02,
 	}
 
 	gl.GenVertexArrays(1, &sb.vao)
@@ -141,17 +411,57 @@ func NewSkybox() (*Skybox, error) {
 
 // Draw renders the sky.  skyVP must be the combined (view-without-translation)×proj
 // matrix — the caller is responsible for stripping the translation column from view.
-func (sb *Skybox) Draw(skyVP math.Mat4) {
-	// Depth LEQUAL so depth=1.0 fragments pass against the cleared depth value (1.0).
+// reverseZ must match the renderer's depth convention.
+func (sb *Skybox) Draw(skyVP math.Mat4, reverseZ bool) {
+	// Depth LEQUAL so depth=1.0 fragments pass against the cleared depth value (1.0)
+	// (GEQUAL against 0.0 under reverse-Z).
 	// Depth mask off — we don't want to write 1.0 into the depth buffer.
-	gl.DepthFunc(gl.LEQUAL)
+	if reverseZ {
+		gl.DepthFunc(gl.GEQUAL)
+	} else {
+		gl.DepthFunc(gl.LEQUAL)
+	}
 	gl.DepthMask(false)
 
 	gl.UseProgram(sb.prog)
 	gl.UniformMatrix4fv(sb.vpLoc, 1, false, (*float32)(unsafe.Pointer(&skyVP[0][0])))
+	if reverseZ {
+		gl.Uniform1i(sb.reverseZLoc, 1)
+	} else {
+		gl.Uniform1i(sb.reverseZLoc, 0)
+	}
 	gl.Uniform3f(sb.zenithLoc, sb.ZenithColor.R, sb.ZenithColor.G, sb.ZenithColor.B)
 	gl.Uniform3f(sb.horizonLoc, sb.HorizonColor.R, sb.HorizonColor.G, sb.HorizonColor.B)
 	gl.Uniform3f(sb.groundLoc, sb.GroundColor.R, sb.GroundColor.G, sb.GroundColor.B)
+	if sb.Atmosphere {
+		gl.Uniform1i(sb.atmosphereLoc, 1)
+	} else {
+		gl.Uniform1i(sb.atmosphereLoc, 0)
+	}
+	sun := sb.SunDirection.Normalize()
+	gl.Uniform3f(sb.sunDirLoc, sun.X, sun.Y, sun.Z)
+	gl.Uniform1f(sb.turbidityLoc, sb.Turbidity)
+	gl.Uniform3f(sb.groundAlbedoLoc, sb.GroundAlbedo.R, sb.GroundAlbedo.G, sb.GroundAlbedo.B)
+	gl.Uniform3f(sb.sunColorLoc, sb.SunColor.R, sb.SunColor.G, sb.SunColor.B)
+	gl.Uniform1f(sb.sunCosRadiusLoc, sunCosRadius(sb.SunSize))
+	gl.Uniform1f(sb.sunGlowLoc, sb.SunGlow)
+	gl.Uniform3f(sb.moonColorLoc, sb.MoonColor.R, sb.MoonColor.G, sb.MoonColor.B)
+	if sb.ShowStars {
+		gl.Uniform1i(sb.showStarsLoc, 1)
+	} else {
+		gl.Uniform1i(sb.showStarsLoc, 0)
+	}
+	if sb.EnvMap != 0 {
+		gl.Uniform1i(sb.useEnvMapLoc, 1)
+		gl.Uniform1i(sb.envMapLoc, 0)
+		gl.ActiveTexture(gl.TEXTURE0)
+		gl.BindTexture(gl.TEXTURE_2D, sb.EnvMap)
+	} else {
+		gl.Uniform1i(sb.useEnvMapLoc, 0)
+	}
+	gl.Uniform1f(sb.cloudCoverageLoc, sb.CloudCoverage)
+	off := sb.advanceClouds(time.Now())
+	gl.Uniform2f(sb.cloudOffsetLoc, float32(off[0]), float32(off[1]))
 
 	gl.BindVertexArray(sb.vao)
 	gl.DrawArrays(gl.TRIANGLES, 0, 36)
@@ -159,7 +469,47 @@ func (sb *Skybox) Draw(skyVP math.Mat4) {
 
 	// Restore depth state for scene geometry
 	gl.DepthMask(true)
-	gl.DepthFunc(gl.LESS)
+	if reverseZ {
+		gl.DepthFunc(gl.GREATER)
+	} else {
+		gl.DepthFunc(gl.LESS)
+	}
+}
+
+// cloudNoisePeriod is the repeat distance of the sky shader's cloud noise.
+const cloudNoisePeriod = 256
+
+// cloudWind is the unit drift direction on the cloud plane.
+var cloudWind = [2]float64{0.8, 0.6}
+
+// advanceClouds moves the cloud scroll on by CloudSpeed times the time since
+// the previous draw and returns it, each axis wrapped to the noise period.
+// Gaps longer than a second (a paused or hidden sky) are not caught up.
+func (sb *Skybox) advanceClouds(now time.Time) [2]float64 {
+	if !sb.lastDraw.IsZero() {
+		dt := now.Sub(sb.lastDraw).Seconds()
+		if dt > 1 {
+			dt = 1
+		}
+		for i := range sb.cloudOffset {
+			o := stdmath.Mod(sb.cloudOffset[i]+cloudWind[i]*dt*float64(sb.CloudSpeed), cloudNoisePeriod)
+			if o < 0 {
+				o += cloudNoisePeriod
+			}
+			sb.cloudOffset[i] = o
+		}
+	}
+	sb.lastDraw = now
+	return sb.cloudOffset
+}
+
+// sunCosRadius converts an angular diameter in degrees to the cosine of the
+// disc's angular radius, or 2 (no direction matches) when size <= 0.
+func sunCosRadius(size float32) float32 {
+	if size <= 0 {
+		return 2
+	}
+	return float32(stdmath.Cos(float64(size) * stdmath.Pi / 360))
 }
 
 // Destroy frees all GPU resources owned by this skybox.
@@ -0,0 +1,28 @@
+package opengl
+
+import (
+	stdmath "math"
+	"testing"
+	"time"
+)
+
+func TestAdvanceClouds(t *testing.T) {
+	sb := &Skybox{CloudSpeed: 2}
+	start := time.Now()
+	if off := sb.advanceClouds(start); off != [2]float64{} {
+		t.Fatalf("first draw should not scroll, got %v", off)
+	}
+
+	off := sb.advanceClouds(start.Add(500 * time.Millisecond))
+	if d := stdmath.Hypot(off[0], off[1]); stdmath.Abs(d-1) > 1e-6 {
+		t.Errorf("expected 1 unit of drift after 0.5 s at speed 2, got %v", d)
+	}
+
+	// A long stall only counts as one second, and the offset stays within
+	// the noise period
+	sb.cloudOffset = [2]float64{cloudNoisePeriod - 0.5, 0}
+	off = sb.advanceClouds(start.Add(time.Hour))
+	if off[0] < 0 || off[0] >= cloudNoisePeriod || stdmath.Abs(off[0]-(2*cloudWind[0]-0.5)) > 1e-6 {
+		t.Errorf("expected the offset to wrap to %v, got %v", 2*cloudWind[0]-0.5, off[0])
+	}
+}
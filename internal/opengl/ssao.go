@@ -2,7 +2,6 @@ package opengl
 
 import (
 	"fmt"
-	"math/rand"
 	"unsafe"
 
 	gl "github.com/go-gl/gl/v4.1-core/gl"
@@ -19,26 +18,62 @@ type SSAO struct {
 	aoFBO uint32
 	aoTex uint32
 
-	// blurFBO/BlurTex — 4-tap box-blurred occlusion (exported for composite)
+	// blurFBO/BlurTex — 5×5 blurred occlusion (exported for composite)
 	blurFBO uint32
 	BlurTex uint32
 
-	width, height int32
+	width, height int32 // AO target size: the viewport × scale
+
+	// Viewport size and the AO resolution factor relative to it
+	viewW, viewH int
+	scale        float32
 
 	// SSAO pass shader
 	ssaoProg      uint32
 	depthLocS     int32 // depthTex  unit 0
 	noiseLocS     int32 // noiseTex  unit 1
 	kernelLoc     int32 // kernel[0] base
+	sampleCountLoc int32
+	powerLoc      int32
 	projLocS      int32
 	invProjLocS   int32
 	radiusLoc     int32
 	biasLoc       int32
 	noiseScaleLoc int32
+	reverseZLocS  int32
 
 	// Blur pass shader
-	blurProg   uint32
-	blurSrcLoc int32
+	blurProg        uint32
+	blurSrcLoc      int32 // ssaoTex   unit 0
+	blurDepthLoc    int32 // depthTex  unit 1
+	blurInvProjLoc  int32
+	bilateralLoc    int32
+	depthFalloffLoc int32
+
+	// Temporal resolve shader
+	temporalProg      uint32
+	tmpCurLoc         int32 // aoTex      unit 0
+	tmpHistLoc        int32 // historyTex unit 1
+	tmpDepthLoc       int32 // depthTex   unit 2
+	tmpInvViewProjLoc int32
+	tmpViewProjLoc    int32
+	tmpPrevVPLoc      int32
+	tmpHasHistLoc     int32
+	tmpBlendLoc       int32
+	tmpToleranceLoc   int32
+	tmpReverseZLoc    int32
+
+	// Temporal history: ping-pong RGBA16F targets, R = accumulated AO,
+	// G = view distance of the surface it belongs to. Each frame reads
+	// histTex[histIdx] and writes the other, then flips histIdx.
+	histFBO      [2]uint32
+	histTex      [2]uint32
+	histIdx      int
+	histValid    bool      // histTex[histIdx] holds a usable previous frame
+	prevViewProj math.Mat4 // camera view-projection of that frame
+
+	// Sample count the uploaded kernel was generated for
+	kernelCount int
 
 	// 4×4 rotation noise texture
 	noiseTex uint32
@@ -50,6 +85,68 @@ type SSAO struct {
 	Radius   float32 // hemisphere radius in view-space units (default 0.5)
 	Bias     float32 // depth bias to prevent self-occlusion acne (default 0.025)
 	Strength float32 // blend factor: 0 = no AO, 1 = full AO (default 1.0)
+
+	// SampleCount is the number of kernel taps per pixel: 16, 32 or 64
+	// (default 64; other values round up to the next of those). Changing it
+	// regenerates the kernel on the next RunPasses.
+	SampleCount int
+	// Power sharpens the AO curve as pow(ao, Power); >1 darkens creases and
+	// contact shadows more than open surfaces (default 1.0).
+	Power float32
+
+	// Bilateral makes the blur depth-aware so AO does not bleed across
+	// silhouettes (default true); false uses a plain 5×5 box blur.
+	Bilateral bool
+	// DepthFalloff controls how fast a blur tap's weight drops with depth
+	// difference: weight = exp(-DepthFalloff × |Δz| / |z|), with z the
+	// centre pixel's view depth. At the default 50 a 2% relative depth step
+	// weighs e⁻¹; higher values keep edges crisper but blur less on steep
+	// slopes, lower values approach the box blur.
+	DepthFalloff float32
+
+	// Temporal blends each frame's AO with the previous frame's result,
+	// reprojected through the old view-projection, so low sample counts
+	// converge to a smooth result over a few frames (default false).
+	Temporal bool
+	// TemporalBlend is the weight of the current frame in the running
+	// average (default 0.1: roughly the last 10 frames contribute).
+	TemporalBlend float32
+	// TemporalDepthTolerance is the relative view-distance mismatch above
+	// which history is discarded as disoccluded (default 0.02 = 2%).
+	TemporalDepthTolerance float32
+
+	// ReverseZ tells the passes the depth buffer uses the reverse-Z
+	// convention (near = 1, far = 0). Kept in sync by Renderer.SetReverseZ.
+	ReverseZ bool
+}
+
+// MaxSSAOSamples is the size of the kernel uniform array.
+const MaxSSAOSamples = 64
+
+// ssaoTargetSize returns the AO/blur target dimensions for a viewport of
+// width × height rendered at scale (never smaller than 1×1).
+func ssaoTargetSize(width, height int, scale float32) (int32, int32) {
+	w := int32(float32(width) * scale)
+	h := int32(float32(height) * scale)
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return w, h
+}
+
+// ssaoSampleCount rounds n up to a supported kernel size (16, 32 or 64).
+func ssaoSampleCount(n int) int {
+	switch {
+	case n <= 16:
+		return 16
+	case n <= 32:
+		return 32
+	default:
+		return MaxSSAOSamples
+	}
 }
 
 // ── Shaders ───────────────────────────────────────────────────────────────────
@@ -64,11 +161,14 @@ out vec4 outAO;
 uniform sampler2D depthTex;   // unit 0 — scene depth [0,1]
 uniform sampler2D noiseTex;   // unit 1 — 4×4 XY rotation noise
 uniform vec3  kernel[64];
+uniform int   sampleCount;    // active kernel taps (16, 32 or 64)
+uniform float power;          // AO contrast curve exponent
 uniform mat4  proj;
 uniform mat4  invProj;
 uniform float radius;
 uniform float bias;
 uniform vec2  noiseScale;     // vec2(screenW/4, screenH/4) for tiling
+uniform bool  reverseZ;       // depth buffer uses near = 1, far = 0
 
 // Reconstruct view-space position from a UV + depth sample.
 vec3 viewPos(vec2 uv) {
@@ -80,7 +180,8 @@ vec3 viewPos(vec2 uv) {
 
 void main() {
     // Skip background (depth at or beyond far plane)
-    if (texture(depthTex, fragUV).r >= 0.9999) { outAO = vec4(1.0); return; }
+    float d = texture(depthTex, fragUV).r;
+    if (reverseZ ? d <= 0.0001 : d >= 0.9999) { outAO = vec4(1.0); return; }
 
     vec3 pos = viewPos(fragUV);
 
@@ -99,7 +200,7 @@ void main() {
     mat3 TBN = mat3(T, B, N);
 
     float occ = 0.0;
-    for (int i = 0; i < 64; i++) {
+    for (int i = 0; i < sampleCount; i++) {
         // Rotate kernel sample into view space and offset from fragment position
         vec3 s = pos + TBN * kernel[i] * radius;
 
@@ -119,27 +220,99 @@ void main() {
         occ += (geoZ >= s.z + bias ? 1.0 : 0.0) * rng;
     }
 
-    outAO = vec4(1.0 - occ / 64.0, 0.0, 0.0, 1.0);
+    float ao = 1.0 - occ / float(sampleCount);
+    outAO = vec4(pow(ao, power), 0.0, 0.0, 1.0);
 }
 ` + "\x00"
 
-// ssaoBlurFragSrc applies a 5×5 box blur to reduce SSAO noise.
+// ssaoBlurFragSrc applies a 5×5 blur to reduce SSAO noise. In bilateral
+// mode each tap is weighted by its view-depth similarity to the centre pixel,
+// so occlusion does not smear across depth discontinuities.
 const ssaoBlurFragSrc = `
 #version 410 core
 in  vec2 fragUV;
 out vec4 outAO;
 
-uniform sampler2D ssaoTex;
+uniform sampler2D ssaoTex;      // unit 0
+uniform sampler2D depthTex;     // unit 1 — scene depth [0,1]
+uniform mat4  invProj;
+uniform bool  bilateral;
+uniform float depthFalloff;     // edge-weight falloff per unit of relative depth
+
+// View-space depth of a depth-buffer sample (x/y do not affect z/w).
+float viewZ(vec2 uv) {
+    float d  = texture(depthTex, uv).r * 2.0 - 1.0;
+    vec4 vp  = invProj * vec4(0.0, 0.0, d, 1.0);
+    return vp.z / vp.w;
+}
 
 void main() {
     vec2 texel  = 1.0 / vec2(textureSize(ssaoTex, 0));
     float result = 0.0;
+    float total  = 0.0;
+    float zc     = bilateral ? viewZ(fragUV) : 0.0;
     for (int x = -2; x <= 2; x++) {
         for (int y = -2; y <= 2; y++) {
-            result += texture(ssaoTex, fragUV + vec2(x, y) * texel).r;
+            vec2  uv = fragUV + vec2(x, y) * texel;
+            float w  = 1.0;
+            if (bilateral) {
+                w = exp(-depthFalloff * abs(viewZ(uv) - zc) / max(abs(zc), 0.0001));
+            }
+            result += texture(ssaoTex, uv).r * w;
+            total  += w;
         }
     }
-    outAO = vec4(result / 25.0, 0.0, 0.0, 1.0);
+    outAO = vec4(result / total, 0.0, 0.0, 1.0);
+}
+` + "\x00"
+
+// ssaoTemporalFragSrc blends the current blurred AO with reprojected history.
+//
+// Each pixel's world position is rebuilt from the current depth and
+// projected with the previous frame's view-projection to find where the same
+// surface was last frame. The history texel there stores the view distance
+// it was computed at; if that differs from the expected previous distance by
+// more than the tolerance, the surface was not visible last frame (it was
+// just disoccluded, or a thin pole moved off it) and the history is dropped
+// for the current AO. This per-pixel depth check is what keeps thin
+// features from leaving ghost trails.
+const ssaoTemporalFragSrc = `
+#version 410 core
+in  vec2 fragUV;
+out vec4 outAO;
+
+uniform sampler2D aoTex;       // unit 0 — current blurred AO
+uniform sampler2D historyTex;  // unit 1 — R = AO, G = view distance
+uniform sampler2D depthTex;    // unit 2 — scene depth [0,1]
+uniform mat4  invViewProj;
+uniform mat4  viewProj;
+uniform mat4  prevViewProj;
+uniform bool  hasHistory;
+uniform float blend;           // weight of the current frame
+uniform float depthTolerance;  // relative view-distance mismatch that rejects history
+uniform bool  reverseZ;        // depth buffer uses near = 1, far = 0
+
+void main() {
+    float ao = texture(aoTex, fragUV).r;
+    float d  = texture(depthTex, fragUV).r;
+    if (reverseZ ? d <= 0.0001 : d >= 0.9999) { outAO = vec4(1.0, 0.0, 0.0, 1.0); return; } // sky
+
+    vec4 world = invViewProj * vec4(fragUV * 2.0 - 1.0, d * 2.0 - 1.0, 1.0);
+    world /= world.w;
+    float dist = (viewProj * world).w;
+
+    float result = ao;
+    if (hasHistory) {
+        vec4 prev = prevViewProj * world;
+        vec2 puv  = prev.xy / prev.w * 0.5 + 0.5;
+        if (prev.w > 0.0 && all(greaterThanEqual(puv, vec2(0.0))) && all(lessThanEqual(puv, vec2(1.0)))) {
+            vec2 h = texture(historyTex, puv).rg;
+            if (abs(h.g - prev.w) <= depthTolerance * prev.w) {
+                result = mix(h.r, ao, blend);
+            }
+        }
+    }
+    outAO = vec4(result, dist, 0.0, 1.0);
 }
 ` + "\x00"
 
@@ -148,11 +321,17 @@ void main() {
 // NewSSAO creates the SSAO shaders, kernel, noise texture, and output FBOs.
 func NewSSAO(width, height int) (*SSAO, error) {
 	s := &SSAO{
-		width:    int32(width),
-		height:   int32(height),
-		Radius:   0.5,
-		Bias:     0.025,
-		Strength: 1.0,
+		scale:        1,
+		Radius:       0.5,
+		Bias:         0.025,
+		Strength:     1.0,
+		SampleCount:  MaxSSAOSamples,
+		Power:        1.0,
+		Bilateral:    true,
+		DepthFalloff: 50,
+
+		TemporalBlend:          0.1,
+		TemporalDepthTolerance: 0.02,
 	}
 
 	// Compile SSAO pass shader (reuses ppVertSrc from postprocess.go)
@@ -169,6 +348,9 @@ func NewSSAO(width, height int) (*SSAO, error) {
 	s.radiusLoc    = gl.GetUniformLocation(ssaoProg, gl.Str("radius\x00"))
 	s.biasLoc      = gl.GetUniformLocation(ssaoProg, gl.Str("bias\x00"))
 	s.noiseScaleLoc = gl.GetUniformLocation(ssaoProg, gl.Str("noiseScale\x00"))
+	s.sampleCountLoc = gl.GetUniformLocation(ssaoProg, gl.Str("sampleCount\x00"))
+	s.powerLoc     = gl.GetUniformLocation(ssaoProg, gl.Str("power\x00"))
+	s.reverseZLocS = gl.GetUniformLocation(ssaoProg, gl.Str("reverseZ\x00"))
 
 	gl.UseProgram(ssaoProg)
 	gl.Uniform1i(s.depthLocS, 0)
@@ -180,11 +362,40 @@ func NewSSAO(width, height int) (*SSAO, error) {
 		gl.DeleteProgram(ssaoProg)
 		return nil, fmt.Errorf("ssao blur shader: %w", err)
 	}
-	s.blurProg   = blurProg
-	s.blurSrcLoc = gl.GetUniformLocation(blurProg, gl.Str("ssaoTex\x00"))
+	s.blurProg        = blurProg
+	s.blurSrcLoc      = gl.GetUniformLocation(blurProg, gl.Str("ssaoTex\x00"))
+	s.blurDepthLoc    = gl.GetUniformLocation(blurProg, gl.Str("depthTex\x00"))
+	s.blurInvProjLoc  = gl.GetUniformLocation(blurProg, gl.Str("invProj\x00"))
+	s.bilateralLoc    = gl.GetUniformLocation(blurProg, gl.Str("bilateral\x00"))
+	s.depthFalloffLoc = gl.GetUniformLocation(blurProg, gl.Str("depthFalloff\x00"))
 
 	gl.UseProgram(blurProg)
 	gl.Uniform1i(s.blurSrcLoc, 0)
+	gl.Uniform1i(s.blurDepthLoc, 1)
+
+	// Compile temporal resolve shader
+	tmpProg, err := newProgram(ppVertSrc, ssaoTemporalFragSrc)
+	if err != nil {
+		gl.DeleteProgram(ssaoProg)
+		gl.DeleteProgram(blurProg)
+		return nil, fmt.Errorf("ssao temporal shader: %w", err)
+	}
+	s.temporalProg      = tmpProg
+	s.tmpCurLoc         = gl.GetUniformLocation(tmpProg, gl.Str("aoTex\x00"))
+	s.tmpHistLoc        = gl.GetUniformLocation(tmpProg, gl.Str("historyTex\x00"))
+	s.tmpDepthLoc       = gl.GetUniformLocation(tmpProg, gl.Str("depthTex\x00"))
+	s.tmpInvViewProjLoc = gl.GetUniformLocation(tmpProg, gl.Str("invViewProj\x00"))
+	s.tmpViewProjLoc    = gl.GetUniformLocation(tmpProg, gl.Str("viewProj\x00"))
+	s.tmpPrevVPLoc      = gl.GetUniformLocation(tmpProg, gl.Str("prevViewProj\x00"))
+	s.tmpHasHistLoc     = gl.GetUniformLocation(tmpProg, gl.Str("hasHistory\x00"))
+	s.tmpBlendLoc       = gl.GetUniformLocation(tmpProg, gl.Str("blend\x00"))
+	s.tmpToleranceLoc   = gl.GetUniformLocation(tmpProg, gl.Str("depthTolerance\x00"))
+	s.tmpReverseZLoc    = gl.GetUniformLocation(tmpProg, gl.Str("reverseZ\x00"))
+
+	gl.UseProgram(tmpProg)
+	gl.Uniform1i(s.tmpCurLoc, 0)
+	gl.Uniform1i(s.tmpHistLoc, 1)
+	gl.Uniform1i(s.tmpDepthLoc, 2)
 
 	// Fullscreen-triangle VAO (no vertex data, uses gl_VertexID)
 	gl.GenVertexArrays(1, &s.quadVAO)
@@ -198,13 +409,24 @@ func NewSSAO(width, height int) (*SSAO, error) {
 
 // ── Kernel & noise ────────────────────────────────────────────────────────────
 
-// generateKernel creates 64 hemisphere sample points distributed with
-// importance sampling (more samples near the origin for better contact shadows).
+// generateKernel uploads the ssaoKernel for SampleCount, always from the same
+// seed so the AO pattern is identical on every run.
 func (s *SSAO) generateKernel() {
-	rng := rand.New(rand.NewSource(42)) // deterministic seed for reproducibility
+	n := ssaoSampleCount(s.SampleCount)
+	kernel := ssaoKernel(n, math.NewRand(42))
+
+	gl.UseProgram(s.ssaoProg)
+	gl.Uniform3fv(s.kernelLoc, int32(n), &kernel[0])
+	gl.Uniform1i(s.sampleCountLoc, int32(n))
+	s.kernelCount = n
+}
 
-	kernel := make([]float32, 64*3)
-	for i := 0; i < 64; i++ {
+// ssaoKernel returns n hemisphere sample points (xyz triples) drawn from rng,
+// distributed with importance sampling (more samples near the origin for
+// better contact shadows).
+func ssaoKernel(n int, rng *math.Rand) []float32 {
+	kernel := make([]float32, n*3)
+	for i := 0; i < n; i++ {
 		v := math.Vec3{
 			X: rng.Float32()*2 - 1,
 			Y: rng.Float32()*2 - 1,
@@ -212,7 +434,7 @@ func (s *SSAO) generateKernel() {
 		}.Normalize()
 
 		// Accelerating lerp: cluster more samples close to the origin
-		t := float32(i) / 64.0
+		t := float32(i) / float32(n)
 		scale := 0.1 + 0.9*t*t // lerp(0.1, 1.0, t²)
 		v = v.Mul(scale)
 
@@ -220,16 +442,14 @@ func (s *SSAO) generateKernel() {
 		kernel[i*3+1] = v.Y
 		kernel[i*3+2] = v.Z
 	}
-
-	gl.UseProgram(s.ssaoProg)
-	gl.Uniform3fv(s.kernelLoc, 64, &kernel[0])
+	return kernel
 }
 
 // generateNoise creates a 4×4 texture of random XY tangent-space rotation
 // vectors (Z=0).  The texture tiles over the screen to rotate the kernel
 // per-fragment without a per-fragment random number.
 func (s *SSAO) generateNoise() {
-	rng := rand.New(rand.NewSource(123))
+	rng := math.NewRand(123)
 
 	noise := make([]float32, 4*4*3) // RGB32F
 	for i := 0; i < 16; i++ {
@@ -250,40 +470,60 @@ func (s *SSAO) generateNoise() {
 
 // ── FBO management ────────────────────────────────────────────────────────────
 
+// allocFBOs creates the AO and blur targets for a width × height viewport,
+// sized down by the current scale.
 func (s *SSAO) allocFBOs(width, height int) {
-	s.width  = int32(width)
-	s.height = int32(height)
-
-	for _, pair := range []struct {
-		fbo *uint32
-		tex *uint32
-		tag string
-	}{
-		{&s.aoFBO, &s.aoTex, "SSAO"},
-		{&s.blurFBO, &s.BlurTex, "SSAO-blur"},
-	} {
-		gl.GenTextures(1, pair.tex)
-		gl.BindTexture(gl.TEXTURE_2D, *pair.tex)
-		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA16F,
-			int32(width), int32(height), 0, gl.RGBA, gl.FLOAT, nil)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
-		gl.BindTexture(gl.TEXTURE_2D, 0)
-
-		gl.GenFramebuffers(1, pair.fbo)
-		gl.BindFramebuffer(gl.FRAMEBUFFER, *pair.fbo)
-		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0,
-			gl.TEXTURE_2D, *pair.tex, 0)
-		if st := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); st != gl.FRAMEBUFFER_COMPLETE {
-			fmt.Printf("WARNING: %s FBO incomplete (0x%X)\n", pair.tag, st)
-		}
-		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	s.viewW, s.viewH = width, height
+	s.width, s.height = ssaoTargetSize(width, height, s.scale)
+
+	s.allocTarget(&s.aoFBO, &s.aoTex, "SSAO")
+	s.allocTarget(&s.blurFBO, &s.BlurTex, "SSAO-blur")
+	if s.Temporal {
+		s.allocHistory()
+	}
+}
+
+// allocHistory creates the temporal ping-pong targets. The previous contents
+// are gone, so the next resolve starts over from the current frame.
+func (s *SSAO) allocHistory() {
+	s.allocTarget(&s.histFBO[0], &s.histTex[0], "SSAO-history")
+	s.allocTarget(&s.histFBO[1], &s.histTex[1], "SSAO-history")
+	s.histValid = false
+}
+
+// allocTarget creates one AO-sized RGBA16F colour texture and its FBO.
+func (s *SSAO) allocTarget(fbo, tex *uint32, tag string) {
+	gl.GenTextures(1, tex)
+	gl.BindTexture(gl.TEXTURE_2D, *tex)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA16F,
+		s.width, s.height, 0, gl.RGBA, gl.FLOAT, nil)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	gl.GenFramebuffers(1, fbo)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, *fbo)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0,
+		gl.TEXTURE_2D, *tex, 0)
+	if st := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); st != gl.FRAMEBUFFER_COMPLETE {
+		fmt.Printf("WARNING: %s FBO incomplete (0x%X)\n", tag, st)
 	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
 }
 
 func (s *SSAO) freeFBOs() {
+	for i := 0; i < 2; i++ {
+		if s.histFBO[i] != 0 {
+			gl.DeleteFramebuffers(1, &s.histFBO[i])
+			s.histFBO[i] = 0
+		}
+		if s.histTex[i] != 0 {
+			gl.DeleteTextures(1, &s.histTex[i])
+			s.histTex[i] = 0
+		}
+	}
 	if s.aoFBO != 0 {
 		gl.DeleteFramebuffers(1, &s.aoFBO)
 		s.aoFBO = 0
@@ -302,12 +542,34 @@ func (s *SSAO) freeFBOs() {
 	}
 }
 
-// Resize recreates the AO and blur FBOs at the new pixel dimensions.
+// Resize recreates the AO and blur FBOs for a new viewport size; the targets
+// themselves are the viewport size × Scale.
 func (s *SSAO) Resize(width, height int) {
 	s.freeFBOs()
 	s.allocFBOs(width, height)
 }
 
+// Scale returns the AO resolution relative to the viewport (1 = full).
+func (s *SSAO) Scale() float32 { return s.scale }
+
+// SetScale renders AO at scale × the viewport resolution, clamped to
+// [0.25, 1]. At 0.5 the AO and blur passes touch a quarter of the pixels;
+// the composite then upsamples with a depth-aware filter so edges stay
+// clean.
+func (s *SSAO) SetScale(scale float32) {
+	if scale < 0.25 {
+		scale = 0.25
+	}
+	if scale > 1 {
+		scale = 1
+	}
+	if scale == s.scale {
+		return
+	}
+	s.scale = scale
+	s.Resize(s.viewW, s.viewH)
+}
+
 // Destroy frees all GPU resources.
 func (s *SSAO) Destroy() {
 	s.freeFBOs()
@@ -323,6 +585,10 @@ func (s *SSAO) Destroy() {
 		gl.DeleteProgram(s.blurProg)
 		s.blurProg = 0
 	}
+	if s.temporalProg != 0 {
+		gl.DeleteProgram(s.temporalProg)
+		s.temporalProg = 0
+	}
 	if s.quadVAO != 0 {
 		gl.DeleteVertexArrays(1, &s.quadVAO)
 		s.quadVAO = 0
@@ -331,12 +597,27 @@ func (s *SSAO) Destroy() {
 
 // ── Render passes ─────────────────────────────────────────────────────────────
 
-// RunPasses executes the SSAO and blur passes.
+// OutputTex returns the texture holding the final AO for compositing: the
+// temporal history when accumulation is on, otherwise BlurTex.
+func (s *SSAO) OutputTex() uint32 {
+	if s.Temporal && s.histTex[s.histIdx] != 0 {
+		return s.histTex[s.histIdx]
+	}
+	return s.BlurTex
+}
+
+// RunPasses executes the SSAO and blur passes, plus the temporal resolve when
+// Temporal is set.
 // depthTex must be the scene depth texture (PostProcessFBO.DepthTex).
-// proj must be the camera projection matrix (used to project kernel samples).
-// On return, BlurTex contains the blurred AO factor ready for compositing.
-func (s *SSAO) RunPasses(depthTex uint32, proj math.Mat4) {
-	invProj := proj.Inverse()
+// view and proj must be the camera matrices (proj projects kernel samples;
+// both reproject history).
+// On return, OutputTex contains the AO factor ready for compositing.
+func (s *SSAO) RunPasses(depthTex uint32, view, proj math.Mat4) {
+	invProj := depthUnproject(proj, s.ReverseZ)
+
+	if ssaoSampleCount(s.SampleCount) != s.kernelCount {
+		s.generateKernel()
+	}
 
 	gl.Disable(gl.DEPTH_TEST)
 	gl.BindVertexArray(s.quadVAO)
@@ -357,6 +638,12 @@ func (s *SSAO) RunPasses(depthTex uint32, proj math.Mat4) {
 		(*float32)(unsafe.Pointer(&invProj[0][0])))
 	gl.Uniform1f(s.radiusLoc, s.Radius)
 	gl.Uniform1f(s.biasLoc, s.Bias)
+	gl.Uniform1f(s.powerLoc, s.Power)
+	if s.ReverseZ {
+		gl.Uniform1i(s.reverseZLocS, 1)
+	} else {
+		gl.Uniform1i(s.reverseZLocS, 0)
+	}
 	gl.Uniform2f(s.noiseScaleLoc,
 		float32(s.width)/4.0,
 		float32(s.height)/4.0)
@@ -368,8 +655,70 @@ func (s *SSAO) RunPasses(depthTex uint32, proj math.Mat4) {
 	gl.UseProgram(s.blurProg)
 	gl.ActiveTexture(gl.TEXTURE0)
 	gl.BindTexture(gl.TEXTURE_2D, s.aoTex)
+	gl.ActiveTexture(gl.TEXTURE1)
+	gl.BindTexture(gl.TEXTURE_2D, depthTex)
+
+	if s.Bilateral {
+		gl.Uniform1i(s.bilateralLoc, 1)
+	} else {
+		gl.Uniform1i(s.bilateralLoc, 0)
+	}
+	gl.Uniform1f(s.depthFalloffLoc, s.DepthFalloff)
+	gl.UniformMatrix4fv(s.blurInvProjLoc, 1, false,
+		(*float32)(unsafe.Pointer(&invProj[0][0])))
 	gl.DrawArrays(gl.TRIANGLES, 0, 3)
 
+	// ── Pass 3: Temporal resolve ──────────────────────────────────────────────
+	if s.Temporal {
+		s.resolveTemporal(depthTex, view.Mul(proj))
+	} else {
+		s.histValid = false // stale by the time accumulation is re-enabled
+	}
+
 	gl.BindVertexArray(0)
 	gl.Enable(gl.DEPTH_TEST)
 }
+
+// resolveTemporal blends BlurTex with the history into the other history
+// buffer and makes that the current one.
+func (s *SSAO) resolveTemporal(depthTex uint32, viewProj math.Mat4) {
+	if s.histTex[0] == 0 {
+		s.allocHistory()
+	}
+	invViewProj := depthUnproject(viewProj, s.ReverseZ)
+	src, dst := s.histIdx, 1-s.histIdx
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, s.histFBO[dst])
+	gl.UseProgram(s.temporalProg)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, s.BlurTex)
+	gl.ActiveTexture(gl.TEXTURE1)
+	gl.BindTexture(gl.TEXTURE_2D, s.histTex[src])
+	gl.ActiveTexture(gl.TEXTURE2)
+	gl.BindTexture(gl.TEXTURE_2D, depthTex)
+
+	if s.histValid {
+		gl.Uniform1i(s.tmpHasHistLoc, 1)
+	} else {
+		gl.Uniform1i(s.tmpHasHistLoc, 0)
+	}
+	gl.Uniform1f(s.tmpBlendLoc, s.TemporalBlend)
+	gl.Uniform1f(s.tmpToleranceLoc, s.TemporalDepthTolerance)
+	if s.ReverseZ {
+		gl.Uniform1i(s.tmpReverseZLoc, 1)
+	} else {
+		gl.Uniform1i(s.tmpReverseZLoc, 0)
+	}
+	gl.UniformMatrix4fv(s.tmpInvViewProjLoc, 1, false,
+		(*float32)(unsafe.Pointer(&invViewProj[0][0])))
+	gl.UniformMatrix4fv(s.tmpViewProjLoc, 1, false,
+		(*float32)(unsafe.Pointer(&viewProj[0][0])))
+	gl.UniformMatrix4fv(s.tmpPrevVPLoc, 1, false,
+		(*float32)(unsafe.Pointer(&s.prevViewProj[0][0])))
+	gl.DrawArrays(gl.TRIANGLES, 0, 3)
+	gl.ActiveTexture(gl.TEXTURE0)
+
+	s.histIdx      = dst
+	s.histValid    = true
+	s.prevViewProj = viewProj
+}
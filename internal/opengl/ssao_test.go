@@ -0,0 +1,52 @@
+package opengl
+
+import (
+	"testing"
+
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+)
+
+func TestSSAOSampleCount(t *testing.T) {
+	for _, c := range []struct{ in, want int }{
+		{0, 16}, {16, 16}, {20, 32}, {32, 32}, {64, 64}, {128, 64},
+	} {
+		if got := ssaoSampleCount(c.in); got != c.want {
+			t.Errorf("ssaoSampleCount(%d): expected %d, got %d", c.in, c.want, got)
+		}
+	}
+}
+
+func TestSSAOHalfResolutionTargets(t *testing.T) {
+	if w, h := ssaoTargetSize(1280, 721, 0.5); w != 640 || h != 360 {
+		t.Errorf("ssaoTargetSize(1280, 721, 0.5): expected 640×360, got %d×%d", w, h)
+	}
+	if w, h := ssaoTargetSize(1, 1, 0.5); w != 1 || h != 1 {
+		t.Errorf("ssaoTargetSize(1, 1, 0.5): expected 1×1, got %d×%d", w, h)
+	}
+
+	_, cleanup := newTestRenderer(t, 16, 16)
+	defer cleanup()
+
+	s, err := NewSSAO(200, 100)
+	if err != nil {
+		t.Fatalf("NewSSAO: %v", err)
+	}
+	defer s.Destroy()
+
+	s.SetScale(0.5)
+	for _, tex := range []uint32{s.aoTex, s.BlurTex} {
+		var w, h int32
+		gl.BindTexture(gl.TEXTURE_2D, tex)
+		gl.GetTexLevelParameteriv(gl.TEXTURE_2D, 0, gl.TEXTURE_WIDTH, &w)
+		gl.GetTexLevelParameteriv(gl.TEXTURE_2D, 0, gl.TEXTURE_HEIGHT, &h)
+		if w != 100 || h != 50 {
+			t.Errorf("AO target at scale 0.5: expected 100×50, got %d×%d", w, h)
+		}
+	}
+
+	// Resizing the viewport keeps the scale
+	s.Resize(400, 300)
+	if s.width != 200 || s.height != 150 {
+		t.Errorf("after Resize(400, 300): expected 200×150, got %d×%d", s.width, s.height)
+	}
+}
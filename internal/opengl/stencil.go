@@ -0,0 +1,62 @@
+package opengl
+
+import (
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// Stencil bit allocation. The selection outline owns the top bit; user masks
+// (BeginStencilMask / SetStencilTest) use the other seven, so neither
+// disturbs the other. The HDR FBO's DEPTH32F_STENCIL8 target and the
+// default framebuffer both carry 8 stencil bits; off-screen render targets
+// have none.
+const (
+	outlineStencilBit = 0x80
+	userStencilMask   = 0x7F
+)
+
+// resetStencil returns the stencil state to its defaults (test off, all bits
+// writable) so the frame clear reaches every bit.
+func (r *Renderer) resetStencil() {
+	gl.Disable(gl.STENCIL_TEST)
+	gl.StencilMask(0xFF)
+	gl.StencilFunc(gl.ALWAYS, 0, 0xFF)
+	gl.StencilOp(gl.KEEP, gl.KEEP, gl.KEEP)
+}
+
+// BeginStencilMask makes the following draws write ref (1..127) into the
+// stencil wherever they pass the depth test, without touching colour or
+// depth. Any stencil test set with SetStencilTest is replaced.
+func (r *Renderer) BeginStencilMask(ref uint8) {
+	gl.Enable(gl.STENCIL_TEST)
+	gl.StencilMask(userStencilMask)
+	gl.StencilFunc(gl.ALWAYS, int32(ref&userStencilMask), userStencilMask)
+	gl.StencilOp(gl.KEEP, gl.KEEP, gl.REPLACE)
+	gl.ColorMask(false, false, false, false)
+	gl.DepthMask(false)
+}
+
+// EndStencilMask restores colour and depth writes and turns the stencil test
+// off again.
+func (r *Renderer) EndStencilMask() {
+	gl.ColorMask(true, true, true, true)
+	gl.DepthMask(true)
+	r.resetStencil()
+}
+
+// SetStencilTest limits the following draws to pixels whose mask value
+// equals ref (inside true) or differs from it (inside false). enabled false
+// turns the test off. The stencil itself is not modified.
+func (r *Renderer) SetStencilTest(enabled, inside bool, ref uint8) {
+	if !enabled {
+		r.resetStencil()
+		return
+	}
+	fn := uint32(gl.EQUAL)
+	if !inside {
+		fn = gl.NOTEQUAL
+	}
+	gl.Enable(gl.STENCIL_TEST)
+	gl.StencilMask(0x00)
+	gl.StencilFunc(fn, int32(ref&userStencilMask), userStencilMask)
+	gl.StencilOp(gl.KEEP, gl.KEEP, gl.KEEP)
+}
@@ -9,14 +9,62 @@ import (
 	"render-engine/scene"
 )
 
-// UploadTexture uploads a scene.Texture to the GPU and sets its GLID field.
-// Call this from the main goroutine (OpenGL context must be current).
+// textureAnisotropy is the anisotropic filtering level UploadTexture applies
+// to colour textures (1 = off); see SetTextureAnisotropy.
+var textureAnisotropy float32 = 1
+
+// maxAnisotropy is the driver's limit, 0 until queried and 1 when
+// anisotropic filtering is unsupported.
+var maxAnisotropy float32
+
+// SetTextureAnisotropy sets the anisotropic filtering level (1 = off, up to
+// 16 on most drivers) for textures uploaded afterwards. On top of the
+// trilinear mipmap filtering every texture gets, it keeps textures on
+// surfaces seen at grazing angles (floors, roads) sharp in the view
+// direction instead of blurring to a coarse mip. Values above the driver's
+// limit are clamped; without anisotropic filtering support it has no effect.
+func SetTextureAnisotropy(level float32) {
+	textureAnisotropy = max(level, 1)
+}
+
+// anisotropyFor clamps the requested level to the driver limit; 1 when
+// either disables anisotropic filtering.
+func anisotropyFor(level, limit float32) float32 {
+	if limit <= 1 || level <= 1 {
+		return 1
+	}
+	return min(level, limit)
+}
+
+// applyAnisotropy sets the bound 2D texture's anisotropy level. The
+// extension (core in GL 4.6) is near-universal, but the 4.1 context has to
+// ask for it.
+func applyAnisotropy() {
+	if maxAnisotropy == 0 {
+		maxAnisotropy = 1
+		if hasExtension("GL_EXT_texture_filter_anisotropic") || hasExtension("GL_ARB_texture_filter_anisotropic") {
+			gl.GetFloatv(gl.MAX_TEXTURE_MAX_ANISOTROPY, &maxAnisotropy)
+		}
+	}
+	if level := anisotropyFor(textureAnisotropy, maxAnisotropy); level > 1 {
+		gl.TexParameterf(gl.TEXTURE_2D, gl.TEXTURE_MAX_ANISOTROPY, level)
+	}
+}
+
+// UploadTexture uploads a scene.Texture to the GPU and sets its GLID field,
+// with a full mipmap chain sampled trilinearly and the anisotropy set by
+// SetTextureAnisotropy. Call this from the main goroutine (OpenGL context
+// must be current).
 // The texture can then be assigned to a Mesh.Texture and will be sampled
 // automatically during DrawMesh.
 func UploadTexture(tex *scene.Texture) error {
+	texturesRebound()
 	if tex == nil {
 		return fmt.Errorf("nil texture")
 	}
+	if len(tex.HDR) > 0 {
+		return uploadHDRTexture(tex)
+	}
 	if len(tex.Pixels) == 0 {
 		return fmt.Errorf("texture %q has no pixel data", tex.Name)
 	}
@@ -29,6 +77,7 @@ func UploadTexture(tex *scene.Texture) error {
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	applyAnisotropy()
 
 	gl.TexImage2D(
 		gl.TEXTURE_2D,
@@ -49,11 +98,40 @@ func UploadTexture(tex *scene.Texture) error {
 	return nil
 }
 
+// uploadHDRTexture uploads tex.HDR as an RGB16F texture with mipmaps.
+// S wraps (panoramas are continuous around the horizon); T clamps at the poles.
+func uploadHDRTexture(tex *scene.Texture) error {
+	if len(tex.HDR) < tex.Width*tex.Height*3 {
+		return fmt.Errorf("texture %q: HDR data shorter than %dx%d", tex.Name, tex.Width, tex.Height)
+	}
+
+	var id uint32
+	gl.GenTextures(1, &id)
+	gl.BindTexture(gl.TEXTURE_2D, id)
+
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGB16F,
+		int32(tex.Width), int32(tex.Height), 0,
+		gl.RGB, gl.FLOAT, unsafe.Pointer(&tex.HDR[0]))
+	gl.GenerateMipmap(gl.TEXTURE_2D)
+
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	tex.GLID = id
+	return nil
+}
+
 // DeleteTexture frees a previously uploaded GPU texture and zeroes its GLID.
 func DeleteTexture(tex *scene.Texture) {
 	if tex == nil || tex.GLID == 0 {
 		return
 	}
+	texturesRebound()
 	gl.DeleteTextures(1, &tex.GLID)
 	tex.GLID = 0
 }
@@ -0,0 +1,59 @@
+package opengl
+
+import (
+	"testing"
+
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+
+	"render-engine/scene"
+)
+
+func TestAnisotropyFor(t *testing.T) {
+	for _, c := range []struct{ level, limit, want float32 }{
+		{1, 16, 1},   // off
+		{8, 16, 8},   // within the limit
+		{32, 16, 16}, // clamped
+		{8, 1, 1},    // unsupported
+		{8, 0, 1},
+	} {
+		if got := anisotropyFor(c.level, c.limit); got != c.want {
+			t.Errorf("anisotropyFor(%v, %v): expected %v, got %v", c.level, c.limit, c.want, got)
+		}
+	}
+}
+
+func TestUploadTextureMipmaps(t *testing.T) {
+	_, cleanup := newTestRenderer(t, 16, 16)
+	defer cleanup()
+
+	SetTextureAnisotropy(4)
+	defer SetTextureAnisotropy(1)
+	tex := &scene.Texture{Name: "checker", Width: 8, Height: 4, Pixels: make([]byte, 8*4*4)}
+	if err := UploadTexture(tex); err != nil {
+		t.Fatalf("UploadTexture: %v", err)
+	}
+	defer DeleteTexture(tex)
+
+	gl.BindTexture(gl.TEXTURE_2D, tex.GLID)
+	defer gl.BindTexture(gl.TEXTURE_2D, 0)
+	var minFilter, w, h int32
+	gl.GetTexParameteriv(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, &minFilter)
+	if minFilter != gl.LINEAR_MIPMAP_LINEAR {
+		t.Errorf("min filter: expected LINEAR_MIPMAP_LINEAR, got %#x", minFilter)
+	}
+	// 8×4 → 4×2 → 2×1 → 1×1
+	gl.GetTexLevelParameteriv(gl.TEXTURE_2D, 3, gl.TEXTURE_WIDTH, &w)
+	gl.GetTexLevelParameteriv(gl.TEXTURE_2D, 3, gl.TEXTURE_HEIGHT, &h)
+	if w != 1 || h != 1 {
+		t.Errorf("mip 3: expected 1x1, got %dx%d", w, h)
+	}
+
+	if maxAnisotropy <= 1 {
+		return // no anisotropic filtering on this driver
+	}
+	var aniso float32
+	gl.GetTexParameterfv(gl.TEXTURE_2D, gl.TEXTURE_MAX_ANISOTROPY, &aniso)
+	if want := anisotropyFor(4, maxAnisotropy); aniso != want {
+		t.Errorf("anisotropy: expected %v (driver limit %v), got %v", want, maxAnisotropy, aniso)
+	}
+}
@@ -0,0 +1,133 @@
+package opengl
+
+import (
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// timedPasses lists the pass names reported by PassTimings, in frame order.
+var timedPasses = []string{"shadow", "scene", "decals", "velocity", "ssao", "motionblur", "bloom", "tonemap", "particles"}
+
+// ── GPU pass timer ────────────────────────────────────────────────────────────
+
+// gpuTimer measures GPU time per render pass with GL_TIME_ELAPSED queries.
+//
+// Queries are double-buffered: each frame records into one slot while the
+// slot written during the previous frame is read back, so results lag by one
+// frame but reading them never stalls the CPU on the GPU.  TIME_ELAPSED
+// queries cannot nest, so begin() closes whichever pass is still open.
+// A pass may be timed several times per frame (one per emitter, say); the
+// durations are summed.
+//
+// All methods are safe on a nil or unsupported timer.
+type gpuTimer struct {
+	supported bool
+	frames    [2]timerFrame
+	cur       int  // slot being recorded this frame
+	active    bool // a query is currently open
+	timings   map[string]float64
+}
+
+// timerFrame is one slot of the double buffer.
+type timerFrame struct {
+	pool   []uint32 // query objects owned by this slot, reused every other frame
+	passes []string // pass name of each query issued this frame (pool[i] ↔ passes[i])
+}
+
+// newGPUTimer checks for timer query support.  When the driver reports zero
+// counter bits the timer stays disabled and PassTimings returns zeros.
+func newGPUTimer() *gpuTimer {
+	var bits int32
+	gl.GetQueryiv(gl.TIME_ELAPSED, gl.QUERY_COUNTER_BITS, &bits)
+
+	t := &gpuTimer{
+		supported: bits > 0,
+		timings:   make(map[string]float64, len(timedPasses)),
+	}
+	for _, p := range timedPasses {
+		t.timings[p] = 0
+	}
+	return t
+}
+
+// begin starts timing pass, ending the previously open pass if any.
+func (t *gpuTimer) begin(pass string) {
+	if t == nil || !t.supported {
+		return
+	}
+	t.end()
+	f := &t.frames[t.cur]
+	n := len(f.passes)
+	if n == len(f.pool) {
+		var id uint32
+		gl.GenQueries(1, &id)
+		f.pool = append(f.pool, id)
+	}
+	f.passes = append(f.passes, pass)
+	gl.BeginQuery(gl.TIME_ELAPSED, f.pool[n])
+	t.active = true
+}
+
+// end closes the currently open pass.  No-op when nothing is being timed.
+func (t *gpuTimer) end() {
+	if t == nil || !t.active {
+		return
+	}
+	gl.EndQuery(gl.TIME_ELAPSED)
+	t.active = false
+}
+
+// endFrame closes any open pass, flips slots and collects the results from
+// the previous frame.  If those results are not ready yet the last known
+// timings are kept rather than blocking.
+func (t *gpuTimer) endFrame() {
+	if t == nil || !t.supported {
+		return
+	}
+	t.end()
+	t.cur ^= 1
+	f := &t.frames[t.cur]
+	if n := len(f.passes); n > 0 {
+		// Queries complete in submission order: if the last is ready, all are.
+		var avail int32
+		gl.GetQueryObjectiv(f.pool[n-1], gl.QUERY_RESULT_AVAILABLE, &avail)
+		if avail != 0 {
+			for p := range t.timings {
+				t.timings[p] = 0
+			}
+			for i, pass := range f.passes {
+				var ns uint64
+				gl.GetQueryObjectui64v(f.pool[i], gl.QUERY_RESULT, &ns)
+				t.timings[pass] += float64(ns) / 1e6
+			}
+		}
+	}
+	f.passes = f.passes[:0]
+}
+
+// snapshot returns a copy of the latest per-pass timings in milliseconds.
+func (t *gpuTimer) snapshot() map[string]float64 {
+	out := make(map[string]float64, len(timedPasses))
+	for _, p := range timedPasses {
+		out[p] = 0
+	}
+	if t == nil {
+		return out
+	}
+	for p, ms := range t.timings {
+		out[p] = ms
+	}
+	return out
+}
+
+func (t *gpuTimer) destroy() {
+	if t == nil {
+		return
+	}
+	t.end()
+	for i := range t.frames {
+		if f := &t.frames[i]; len(f.pool) > 0 {
+			gl.DeleteQueries(int32(len(f.pool)), &f.pool[0])
+			f.pool = nil
+		}
+	}
+}
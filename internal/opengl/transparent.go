@@ -0,0 +1,23 @@
+package opengl
+
+import (
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// BeginTransparent sets up alpha blending for meshes whose material is
+// Transparent: they blend over the opaque scene, are depth-tested against it
+// but don't write depth, and don't add to the bloom-only target. Draw them
+// farthest first between BeginTransparent and EndTransparent.
+func (r *Renderer) BeginTransparent() {
+	r.setBloomSourceWrites(false)
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	gl.DepthMask(false)
+}
+
+// EndTransparent restores opaque drawing state.
+func (r *Renderer) EndTransparent() {
+	gl.DepthMask(true)
+	gl.Disable(gl.BLEND)
+	r.setBloomSourceWrites(true)
+}
@@ -0,0 +1,295 @@
+package opengl
+
+import (
+	"fmt"
+	"unsafe"
+
+	gl "github.com/go-gl/gl/v4.1-core/gl"
+
+	"render-engine/core"
+	"render-engine/math"
+	"render-engine/scene"
+)
+
+// Barycentric wireframes draw each triangle filled and shade only a thin band
+// along its edges, so lines are anti-aliased, any width, and lie exactly on
+// the shaded surface instead of being rasterised by glPolygonMode(LINE).
+//
+// The fragment shader needs the fragment's barycentric coordinates: the
+// distance to edge i is bary[i] divided by its screen-space rate of change
+// (fwidth), i.e. in pixels. GL 4.1 has no built-in barycentrics and no way to
+// tell triangle corners apart in an indexed draw (a vertex shared by six
+// triangles is one corner of each), so without a geometry shader they have
+// to come from the vertex stream:
+//
+//   - Each mesh gets a de-indexed copy for the wireframe: three vertices per
+//     triangle, holding the position and a barycentric attribute of (1,0,0),
+//     (0,1,0) or (0,0,1). The copy is built on first use and cached.
+//   - Interpolation then produces the fragment's barycentrics for free.
+//   - An edge is hidden by adding 1 to the coordinate opposite it at all
+//     three corners: it is then ≥ 1 along that edge and never near 0. This
+//     removes the diagonals of quads split into two triangles (an edge shared
+//     by two coplanar triangles that is the longest side of both), so grids
+//     and boxes show their quads.
+//
+// (gl_VertexID % 3 in a non-indexed draw would also number the corners, but
+// the main mesh buffers are indexed, and edge hiding needs per-corner data.)
+
+// WireframeStyle selects how the wireframe overlay is drawn.
+type WireframeStyle int
+
+const (
+	WireframeLines       WireframeStyle = iota // glPolygonMode lines (default): every triangle edge, 1 px
+	WireframeBarycentric                       // anti-aliased edges in the fragment shader; quad diagonals hidden
+)
+
+const wireVertSrc = `
+#version 410 core
+layout(location = 0) in vec3 inPosition;
+layout(location = 1) in vec3 inBary;
+
+uniform mat4 mvp;
+
+out vec3 bary;
+
+void main() {
+    gl_Position = mvp * vec4(inPosition, 1.0);
+    bary = inBary;
+}
+` + "\x00"
+
+// wireFragSrc — coverage falls from 1 to 0 over one pixel at halfWidth
+// pixels from each edge; each triangle draws its half of the line.
+const wireFragSrc = `
+#version 410 core
+in vec3 bary;
+out vec4 outColor;
+
+uniform vec3  wireColor;
+uniform float halfWidth; // pixels
+
+void main() {
+    vec3  w = fwidth(bary);
+    vec3  a = smoothstep(w * max(halfWidth - 0.5, 0.0), w * (halfWidth + 0.5), bary);
+    float edge = 1.0 - min(min(a.x, a.y), a.z);
+    if (edge <= 0.0) discard;
+    outColor = vec4(wireColor, edge);
+}
+` + "\x00"
+
+// wirePass holds the barycentric wireframe shader and the per-mesh
+// de-indexed vertex streams.
+type wirePass struct {
+	prog         uint32
+	mvpLoc       int32
+	colorLoc     int32
+	halfWidthLoc int32
+	meshes       map[*scene.Mesh]*wireMesh
+}
+
+type wireMesh struct {
+	vao, vbo uint32
+	count    int32
+}
+
+// wireVertex is one corner in a wireMesh buffer.
+type wireVertex struct {
+	Position math.Vec3
+	Bary     [3]float32
+}
+
+func newWirePass() (*wirePass, error) {
+	prog, err := newProgram(wireVertSrc, wireFragSrc)
+	if err != nil {
+		return nil, fmt.Errorf("wireframe shader: %w", err)
+	}
+	return &wirePass{
+		prog:         prog,
+		mvpLoc:       gl.GetUniformLocation(prog, gl.Str("mvp\x00")),
+		colorLoc:     gl.GetUniformLocation(prog, gl.Str("wireColor\x00")),
+		halfWidthLoc: gl.GetUniformLocation(prog, gl.Str("halfWidth\x00")),
+		meshes:       make(map[*scene.Mesh]*wireMesh),
+	}, nil
+}
+
+func (w *wirePass) destroy() {
+	for mesh := range w.meshes {
+		w.release(mesh)
+	}
+	gl.DeleteProgram(w.prog)
+}
+
+// release frees mesh's wireframe stream; it is rebuilt on the next draw.
+func (w *wirePass) release(mesh *scene.Mesh) {
+	if wm := w.meshes[mesh]; wm != nil {
+		gl.DeleteVertexArrays(1, &wm.vao)
+		gl.DeleteBuffers(1, &wm.vbo)
+		delete(w.meshes, mesh)
+	}
+}
+
+func (w *wirePass) upload(mesh *scene.Mesh) *wireMesh {
+	if wm := w.meshes[mesh]; wm != nil {
+		return wm
+	}
+	verts := wireVertices(mesh)
+	if len(verts) == 0 {
+		return nil
+	}
+	wm := &wireMesh{count: int32(len(verts))}
+	stride := int32(unsafe.Sizeof(wireVertex{}))
+	gl.GenVertexArrays(1, &wm.vao)
+	gl.GenBuffers(1, &wm.vbo)
+	gl.BindVertexArray(wm.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, wm.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(verts)*int(stride), gl.Ptr(verts), gl.STATIC_DRAW)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, stride, gl.PtrOffset(0))
+	gl.EnableVertexAttribArray(1)
+	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, stride, gl.PtrOffset(12))
+	gl.BindVertexArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	w.meshes[mesh] = wm
+	return wm
+}
+
+// wireVertices de-indexes mesh's triangles with per-corner barycentrics,
+// hiding quad diagonals.
+func wireVertices(mesh *scene.Mesh) []wireVertex {
+	if mesh.DrawMode != scene.DrawTriangles {
+		return nil
+	}
+	n := len(mesh.Indices)
+	corner := func(i int) uint32 { return mesh.Indices[i] }
+	if n == 0 {
+		n = len(mesh.Vertices)
+		corner = func(i int) uint32 { return uint32(i) }
+	}
+	ntris := n / 3
+
+	// Edges → the triangles sharing them
+	type edge [2]uint32
+	key := func(a, b uint32) edge {
+		if a > b {
+			a, b = b, a
+		}
+		return edge{a, b}
+	}
+	shared := make(map[edge][]int, n)
+	for t := 0; t < ntris; t++ {
+		for k := 0; k < 3; k++ {
+			e := key(corner(t*3+k), corner(t*3+(k+1)%3))
+			shared[e] = append(shared[e], t)
+		}
+	}
+	pos := func(t, k int) math.Vec3 { return mesh.Vertices[corner(t*3+k)].Position }
+	normal := func(t int) math.Vec3 {
+		return pos(t, 1).Sub(pos(t, 0)).Cross(pos(t, 2).Sub(pos(t, 0))).Normalize()
+	}
+	// longest reports whether e is t's longest side
+	longest := func(t int, e edge) bool {
+		a, b := mesh.Vertices[e[0]].Position, mesh.Vertices[e[1]].Position
+		l := a.Sub(b).LengthSqr()
+		for k := 0; k < 3; k++ {
+			if pos(t, k).Sub(pos(t, (k+1)%3)).LengthSqr() > l*1.0001 {
+				return false
+			}
+		}
+		return true
+	}
+	diagonal := func(t int, e edge) bool {
+		ts := shared[e]
+		if len(ts) != 2 {
+			return false
+		}
+		o := ts[0]
+		if o == t {
+			o = ts[1]
+		}
+		return normal(t).Dot(normal(o)) > 0.9999 && longest(t, e) && longest(o, e)
+	}
+
+	out := make([]wireVertex, 0, ntris*3)
+	for t := 0; t < ntris; t++ {
+		var hide [3]float32
+		for k := 0; k < 3; k++ {
+			if diagonal(t, key(corner(t*3+k), corner(t*3+(k+1)%3))) {
+				hide[(k+2)%3] = 1 // the coordinate that is 0 along edge k
+			}
+		}
+		for k := 0; k < 3; k++ {
+			v := wireVertex{Position: pos(t, k), Bary: hide}
+			v.Bary[k]++
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// SetWireframeStyle selects how the wireframe overlay (SetWireframeOverlay)
+// draws. Instanced meshes always use WireframeLines.
+func (r *Renderer) SetWireframeStyle(style WireframeStyle) {
+	r.wireStyle = style
+}
+
+// SetWireframeWidth sets the line width in pixels of barycentric wireframes
+// (default 1.5).
+func (r *Renderer) SetWireframeWidth(px float32) {
+	r.wireWidth = px
+}
+
+// DrawMeshWireframe draws mesh's triangle edges as an anti-aliased
+// barycentric wireframe, width pixels wide, over whatever is already drawn
+// (typically the mesh itself, for a per-object overlay). Lines are depth
+// tested against the scene but not written to depth. Lazily creates the
+// wireframe shader on first call.
+func (r *Renderer) DrawMeshWireframe(mesh *scene.Mesh, mvp math.Mat4, color core.Color, width float32) {
+	if mesh == nil || width <= 0 {
+		return
+	}
+	if r.wire == nil {
+		w, err := newWirePass()
+		if err != nil {
+			fmt.Printf("wireframe init: %v\n", err)
+			return
+		}
+		r.wire = w
+	}
+	wm := r.wire.upload(mesh)
+	if wm == nil {
+		return
+	}
+
+	r.setBloomSourceWrites(false)
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	gl.DepthMask(false)
+	// Pull the lines toward the camera so they win against their own faces
+	gl.Enable(gl.POLYGON_OFFSET_FILL)
+	if r.reverseZ {
+		gl.DepthFunc(gl.GEQUAL)
+		gl.PolygonOffset(1, 1)
+	} else {
+		gl.DepthFunc(gl.LEQUAL)
+		gl.PolygonOffset(-1, -1)
+	}
+
+	gl.UseProgram(r.wire.prog)
+	gl.UniformMatrix4fv(r.wire.mvpLoc, 1, false, (*float32)(unsafe.Pointer(&mvp[0][0])))
+	gl.Uniform3f(r.wire.colorLoc, color.R, color.G, color.B)
+	gl.Uniform1f(r.wire.halfWidthLoc, width*0.5)
+	gl.BindVertexArray(wm.vao)
+	gl.DrawArrays(gl.TRIANGLES, 0, wm.count)
+	gl.BindVertexArray(0)
+
+	gl.Disable(gl.POLYGON_OFFSET_FILL)
+	if r.reverseZ {
+		gl.DepthFunc(gl.GREATER)
+	} else {
+		gl.DepthFunc(gl.LESS)
+	}
+	gl.DepthMask(true)
+	gl.Disable(gl.BLEND)
+	r.setBloomSourceWrites(true)
+	gl.UseProgram(r.program)
+}
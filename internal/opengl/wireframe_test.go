@@ -0,0 +1,44 @@
+package opengl
+
+import (
+	"testing"
+
+	"render-engine/scene"
+)
+
+func TestWireVerticesHideQuadDiagonals(t *testing.T) {
+	// Each face of a cube is two triangles: exactly their shared diagonal is
+	// hidden, i.e. every triangle has one coordinate raised at all corners
+	cube := scene.CreateCube(1)
+	verts := wireVertices(cube)
+	if len(verts) != len(cube.Indices) {
+		t.Fatalf("expected %d corners, got %d", len(cube.Indices), len(verts))
+	}
+	for tri := 0; tri < len(verts)/3; tri++ {
+		hidden := 0
+		for k := 0; k < 3; k++ {
+			if verts[tri*3].Bary[k] >= 1 && verts[tri*3+1].Bary[k] >= 1 && verts[tri*3+2].Bary[k] >= 1 {
+				hidden++
+			}
+		}
+		if hidden != 1 {
+			t.Errorf("triangle %d: expected 1 hidden edge, got %d (%v)", tri, hidden, verts[tri*3:tri*3+3])
+		}
+	}
+
+	// A grid keeps the edges between its cells
+	plane := scene.CreatePlane(2, 2, 2)
+	verts = wireVertices(plane)
+	shown := 0
+	for tri := 0; tri < len(verts)/3; tri++ {
+		for k := 0; k < 3; k++ {
+			if verts[tri*3].Bary[k] < 1 || verts[tri*3+1].Bary[k] < 1 || verts[tri*3+2].Bary[k] < 1 {
+				shown++
+			}
+		}
+	}
+	// 8 triangles × 2 visible sides each
+	if shown != 16 {
+		t.Errorf("plane: expected 16 visible triangle sides, got %d", shown)
+	}
+}
@@ -118,6 +118,23 @@ func TestMat4Translation(t *testing.T) {
 	}
 }
 
+func TestMat4Inverse(t *testing.T) {
+	m := Mat4TRS(NewVec3(1, -2, 3), NewVec3(0.3, 0.7, -0.2), NewVec3(2, 0.5, 1.5))
+	id := m.Mul(m.Inverse())
+
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			expected := float32(0)
+			if i == j {
+				expected = 1
+			}
+			if math.Abs(float64(id[i][j]-expected)) > 1e-4 {
+				t.Fatalf("M * M^-1: expected identity, got %v", id)
+			}
+		}
+	}
+}
+
 func TestQuaternionIdentity(t *testing.T) {
 	q := QuaternionIdentity()
 	
@@ -159,6 +176,29 @@ func TestMat4Perspective(t *testing.T) {
 	}
 }
 
+func TestMat4PerspectiveReverseZ(t *testing.T) {
+	near, far := float32(0.1), float32(100.0)
+	ndcZ := func(m Mat4, dist float32) float32 {
+		clip := Vec4{X: 0, Y: 0, Z: -dist, W: 1}.MulMat(m)
+		return clip.Z / clip.W
+	}
+
+	std := Mat4Perspective(math.Pi/4, 1, near, far)
+	if z := ndcZ(std, near); math.Abs(float64(z+1)) > 1e-4 {
+		t.Errorf("standard: near should map to -1, got %v", z)
+	}
+	rev := Mat4PerspectiveReverseZ(math.Pi/4, 1, near, far)
+	if z := ndcZ(rev, near); math.Abs(float64(z-1)) > 1e-4 {
+		t.Errorf("reverse-Z: near should map to 1, got %v", z)
+	}
+	if z := ndcZ(rev, far); math.Abs(float64(z)) > 1e-4 {
+		t.Errorf("reverse-Z: far should map to 0, got %v", z)
+	}
+	if rev[0][0] != std[0][0] || rev[1][1] != std[1][1] {
+		t.Error("reverse-Z: X/Y scale should match the standard projection")
+	}
+}
+
 func TestMat4LookAt(t *testing.T) {
 eye := NewVec3(0, 0, 5)
 	target := NewVec3(0, 0, 0)
@@ -195,3 +235,23 @@ func BenchmarkMat4Mul(b *testing.B) {
 		_ = m1.Mul(m2)
 	}
 }
+
+func TestRandSeed(t *testing.T) {
+	a, b := NewRand(5), NewRand(5)
+	first := make([]float32, 8)
+	for i := range first {
+		first[i] = a.Float32()
+		if v := b.Float32(); v != first[i] {
+			t.Fatalf("value %d: same seed gave %g and %g", i, first[i], v)
+		}
+	}
+	a.Seed(5)
+	if v := a.Float32(); v != first[0] {
+		t.Errorf("after Seed: expected the sequence to restart at %g, got %g", first[0], v)
+	}
+	for i := 0; i < 100; i++ {
+		if v := a.Range(-2, 3); v < -2 || v >= 3 {
+			t.Fatalf("Range(-2, 3) returned %g", v)
+		}
+	}
+}
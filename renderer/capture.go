@@ -0,0 +1,85 @@
+package renderer
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+
+	"render-engine/scene"
+)
+
+// RequestCapture makes the next Present keep a copy of its frame, taken just
+// before the buffer swap, for CaptureFrame and Screenshot to read. Headless
+// windows (core.NewOffscreenContext) keep every frame and need no request.
+func (re *RenderEngine) RequestCapture() {
+	re.captureRequested = true
+}
+
+// CaptureFrame returns the frame most recently shown by Present as an image.
+// Call it after Present, and on a visible window call RequestCapture before
+// that Present; the result is top-left origin and fully opaque. The copy is
+// taken before the swap, so it does not depend on what the window system
+// left on screen.
+func (re *RenderEngine) CaptureFrame() (*image.RGBA, error) {
+	pix, w, h := re.gl.ReadFramebuffer()
+	if w <= 0 || h <= 0 {
+		return nil, fmt.Errorf("capture: last frame not kept (call RequestCapture before Present)")
+	}
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	flipRowsOpaque(img.Pix, pix, w, h)
+	return img, nil
+}
+
+// Screenshot captures the last presented frame and writes it to path as PNG.
+// Like CaptureFrame, it needs RequestCapture before Present on a visible
+// window.
+func (re *RenderEngine) Screenshot(path string) error {
+	img, err := re.CaptureFrame()
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("screenshot: %w", err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return fmt.Errorf("screenshot: encode %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// CaptureHDR writes the last rendered frame's HDR scene colour to path as a
+// Radiance RGBE (.hdr) image: linear radiance before exposure, tone mapping
+// and bloom, for inspection or reuse as lighting. Call it after Present,
+// before the next BeginFrame. Requires post-processing (EnablePostProcess).
+func (re *RenderEngine) CaptureHDR(path string) error {
+	rgb, w, h := re.gl.ReadHDRColor()
+	if rgb == nil {
+		return fmt.Errorf("capture hdr: post-processing is disabled")
+	}
+	tex := &scene.Texture{Name: path, Width: w, Height: h, HDR: make([]float32, len(rgb))}
+	stride := w * 3
+	for y := 0; y < h; y++ {
+		copy(tex.HDR[y*stride:(y+1)*stride], rgb[(h-1-y)*stride:(h-y)*stride])
+	}
+	if err := scene.SaveHDR(path, tex); err != nil {
+		return fmt.Errorf("capture hdr: %w", err)
+	}
+	return nil
+}
+
+// flipRowsOpaque copies a bottom-up RGBA8 buffer (GL read-back order) into dst
+// top-down, forcing alpha to 255 since the default framebuffer's alpha channel
+// is not meaningful after tone mapping and text blending.
+func flipRowsOpaque(dst, src []byte, width, height int) {
+	stride := width * 4
+	for y := 0; y < height; y++ {
+		row := dst[y*stride : (y+1)*stride]
+		copy(row, src[(height-1-y)*stride:(height-y)*stride])
+		for x := 3; x < stride; x += 4 {
+			row[x] = 255
+		}
+	}
+}
@@ -0,0 +1,392 @@
+package renderer
+
+import (
+	"image"
+	"runtime"
+	"testing"
+
+	"render-engine/core"
+	"render-engine/math"
+	"render-engine/scene"
+)
+
+func TestFlipRowsOpaque(t *testing.T) {
+	// 1×2 image, bottom row first (GL order): bottom = blue, top = red
+	src := []byte{
+		0, 0, 255, 0,
+		255, 0, 0, 10,
+	}
+	dst := make([]byte, len(src))
+	flipRowsOpaque(dst, src, 1, 2)
+
+	expected := []byte{
+		255, 0, 0, 255,
+		0, 0, 255, 255,
+	}
+	for i := range expected {
+		if dst[i] != expected[i] {
+			t.Fatalf("flipRowsOpaque: expected %v, got %v", expected, dst)
+		}
+	}
+}
+
+func TestCaptureFrameClearedRed(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	window, err := core.NewOffscreenContext(64, 64)
+	if err != nil {
+		t.Skipf("no GL context available: %v", err)
+	}
+	defer window.Destroy()
+
+	re, err := NewRenderEngine(window)
+	if err != nil {
+		t.Skipf("no GL context available: %v", err)
+	}
+	defer re.Destroy()
+
+	if _, err := re.CaptureFrame(); err == nil {
+		t.Error("expected an error before the first Present")
+	}
+
+	re.gl.BeginFrame(core.ColorRed, nil, core.ColorBlack, math.Vec3Zero,
+		math.Mat4Identity(), false, math.Mat4Identity(), math.Mat4Identity())
+	re.Present()
+
+	// Whatever the back buffer holds after the swap must not leak into the
+	// capture: overwrite it with the start of a blue frame
+	re.gl.BeginFrame(core.ColorBlue, nil, core.ColorBlack, math.Vec3Zero,
+		math.Mat4Identity(), false, math.Mat4Identity(), math.Mat4Identity())
+
+	img, err := re.CaptureFrame()
+	if err != nil {
+		t.Fatalf("CaptureFrame: %v", err)
+	}
+	b := img.Bounds()
+	c := img.RGBAAt(b.Dx()/2, b.Dy()/2)
+	if c.R != 255 || c.G != 0 || c.B != 0 {
+		t.Errorf("center pixel: expected red, got %v", c)
+	}
+}
+
+func TestCaptureFrameNeedsRequest(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	window, err := core.NewOffscreenContext(64, 64)
+	if err != nil {
+		t.Skipf("no GL context available: %v", err)
+	}
+	defer window.Destroy()
+
+	re, err := NewRenderEngine(window)
+	if err != nil {
+		t.Skipf("no GL context available: %v", err)
+	}
+	defer re.Destroy()
+
+	// Treat the hidden window as a visible one: frames are swapped and only
+	// kept on request
+	window.Headless = false
+	frame := func() {
+		re.gl.BeginFrame(core.ColorGreen, nil, core.ColorBlack, math.Vec3Zero,
+			math.Mat4Identity(), false, math.Mat4Identity(), math.Mat4Identity())
+		re.Present()
+	}
+
+	frame()
+	if _, err := re.CaptureFrame(); err == nil {
+		t.Error("no request: expected an error")
+	}
+
+	re.RequestCapture()
+	frame()
+	img, err := re.CaptureFrame()
+	if err != nil {
+		t.Fatalf("after RequestCapture: %v", err)
+	}
+	if c := img.RGBAAt(32, 32); c.R != 0 || c.G != 255 || c.B != 0 {
+		t.Errorf("after RequestCapture: expected green, got %v", c)
+	}
+
+	// The request covers one frame; the next one drops the copy again
+	frame()
+	if _, err := re.CaptureFrame(); err == nil {
+		t.Error("frame after the request: expected an error")
+	}
+}
+
+// countPixels returns how many pixels of a satisfy pred.
+func countPixels(a *image.RGBA, pred func(x, y int) bool) int {
+	n := 0
+	b := a.Bounds()
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			if pred(x, y) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func absDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+// newPostProcessEngine opens a 64×64 offscreen context with post-processing
+// enabled, skipping the test when no GL context is available. The caller
+// must hold the OS thread and call the returned cleanup.
+func newPostProcessEngine(t *testing.T) (*RenderEngine, func()) {
+	window, err := core.NewOffscreenContext(64, 64)
+	if err != nil {
+		t.Skipf("no GL context available: %v", err)
+	}
+	re, err := NewRenderEngine(window)
+	if err != nil {
+		window.Destroy()
+		t.Skipf("no GL context available: %v", err)
+	}
+	cleanup := func() {
+		re.Destroy()
+		window.Destroy()
+	}
+	if err := re.EnablePostProcess(); err != nil {
+		cleanup()
+		t.Fatalf("EnablePostProcess: %v", err)
+	}
+	return re, cleanup
+}
+
+// captureScene renders the engine's scene, presents and captures the frame.
+func captureScene(t *testing.T, re *RenderEngine) *image.RGBA {
+	t.Helper()
+	if err := re.Render(); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	re.Present()
+	img, err := re.CaptureFrame()
+	if err != nil {
+		t.Fatalf("CaptureFrame: %v", err)
+	}
+	return img
+}
+
+func TestLensEffectsCapture(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	re, cleanup := newPostProcessEngine(t)
+	defer cleanup()
+
+	// An unlit white cube on black, off-centre so its edges sit at
+	// different distances from the screen centre
+	s := scene.NewScene()
+	s.SkyColor = core.ColorBlack
+	s.SetCamera(scene.NewCamera(1, 1, 0.1, 100))
+	cube := scene.CreateCube(1)
+	cube.Material = scene.NewMaterial("white", core.ColorWhite)
+	cube.Material.Unlit = true
+	node := scene.NewNode("cube")
+	node.Mesh = cube
+	node.SetPosition(math.Vec3{X: 0.6, Y: 0.3, Z: -4})
+	s.AddNode(node)
+	re.SetScene(s)
+
+	changed := func(a, b *image.RGBA) int {
+		return countPixels(a, func(x, y int) bool {
+			p, q := a.RGBAAt(x, y), b.RGBAAt(x, y)
+			return absDiff(p.R, q.R)+absDiff(p.G, q.G)+absDiff(p.B, q.B) > 24
+		})
+	}
+	fringed := func(a *image.RGBA) int {
+		return countPixels(a, func(x, y int) bool {
+			p := a.RGBAAt(x, y)
+			return absDiff(p.R, p.B) > 24
+		})
+	}
+
+	base := captureScene(t, re)
+	if n := fringed(base); n != 0 {
+		t.Fatalf("baseline: expected a grey image, %d pixels have colour fringes", n)
+	}
+
+	// Zero settings are no-ops
+	re.SetChromaticAberration(0)
+	re.SetLensDistortion(0, 0)
+	if n := changed(base, captureScene(t, re)); n != 0 {
+		t.Errorf("zero lens settings: %d pixels changed", n)
+	}
+
+	re.SetChromaticAberration(0.2)
+	if n := fringed(captureScene(t, re)); n < 16 {
+		t.Errorf("chromatic aberration: expected fringes along the cube edges, got %d pixels", n)
+	}
+	re.SetChromaticAberration(0)
+
+	re.SetLensDistortion(0.5, 0)
+	if n := changed(base, captureScene(t, re)); n < 16 {
+		t.Errorf("lens distortion: expected the image to warp, only %d pixels changed", n)
+	}
+}
+
+func TestFilmGrainCapture(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	re, cleanup := newPostProcessEngine(t)
+	defer cleanup()
+
+	// A flat mid-grey frame: every pixel identical without grain (and
+	// without the default dither)
+	re.SetDithering(false)
+	s := scene.NewScene()
+	s.SkyColor = core.Color{R: 0.2, G: 0.2, B: 0.2, A: 1}
+	s.SetCamera(scene.NewCamera(1, 1, 0.1, 100))
+	re.SetScene(s)
+
+	meanGreen := func(img *image.RGBA) float64 {
+		sum := 0
+		for i := 1; i < len(img.Pix); i += 4 {
+			sum += int(img.Pix[i])
+		}
+		return float64(sum) / float64(len(img.Pix)/4)
+	}
+
+	re.SetFilmGrain(0)
+	base := captureScene(t, re)
+	ref := base.RGBAAt(0, 0)
+	if n := countPixels(base, func(x, y int) bool { return base.RGBAAt(x, y) != ref }); n != 0 {
+		t.Fatalf("no grain: expected a flat frame, %d pixels differ", n)
+	}
+
+	re.SetFilmGrain(0.1)
+	grain := captureScene(t, re)
+	if n := countPixels(grain, func(x, y int) bool { return grain.RGBAAt(x, y) != ref }); n < 64*64/2 {
+		t.Errorf("grain: expected most pixels to vary, only %d differ", n)
+	}
+	if d := meanGreen(grain) - meanGreen(base); d < -1 || d > 1 {
+		t.Errorf("grain: mean brightness shifted by %.2f levels", d)
+	}
+}
+
+func TestDitheringCapture(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	re, cleanup := newPostProcessEngine(t)
+	defer cleanup()
+
+	// A full-screen unlit quad shading from dark grey on the left to a
+	// slightly lighter grey on the right: about ten 8-bit levels across
+	// 64 pixels, so each column has one true value and undithered output
+	// bands into flat strips
+	dark := core.Color{R: 0.05, G: 0.05, B: 0.05, A: 1}
+	light := core.Color{R: 0.07, G: 0.07, B: 0.07, A: 1}
+	n := math.Vec3{X: 0, Y: 0, Z: 1}
+	quad := scene.CreateMeshFromData("gradient", []core.Vertex{
+		{Position: math.Vec3{X: -2, Y: -2, Z: -1}, Normal: n, Color: dark},
+		{Position: math.Vec3{X: 2, Y: -2, Z: -1}, Normal: n, Color: light},
+		{Position: math.Vec3{X: 2, Y: 2, Z: -1}, Normal: n, Color: light},
+		{Position: math.Vec3{X: -2, Y: 2, Z: -1}, Normal: n, Color: dark},
+	}, []uint32{0, 1, 2, 2, 3, 0})
+	quad.Material = scene.NewMaterial("unlit", core.ColorWhite)
+	quad.Material.Unlit = true
+	node := scene.NewNode("gradient")
+	node.Mesh = quad
+
+	s := scene.NewScene()
+	s.SetCamera(scene.NewCamera(1, 1, 0.1, 100))
+	s.AddNode(node)
+	re.SetScene(s)
+
+	// Mean number of distinct green values per column: the spread of each
+	// column's histogram
+	columnSpread := func(img *image.RGBA) float64 {
+		b := img.Bounds()
+		total := 0
+		for x := 0; x < b.Dx(); x++ {
+			seen := map[uint8]bool{}
+			for y := 0; y < b.Dy(); y++ {
+				seen[img.RGBAAt(x, y).G] = true
+			}
+			total += len(seen)
+		}
+		return float64(total) / float64(b.Dx())
+	}
+
+	re.SetDithering(false)
+	if spread := columnSpread(captureScene(t, re)); spread != 1 {
+		t.Fatalf("no dither: expected one value per column, got %.2f", spread)
+	}
+
+	re.SetDithering(true)
+	if spread := columnSpread(captureScene(t, re)); spread < 1.5 {
+		t.Errorf("dither: expected columns to mix neighbouring levels, got %.2f values per column", spread)
+	}
+}
+
+func TestStencilMaskCapture(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	re, cleanup := newPostProcessEngine(t)
+	defer cleanup()
+
+	// Unlit quad in the XY plane at depth z, spanning x0..x1 and ±2 in Y
+	quad := func(x0, x1, z float32, c core.Color) (*scene.Mesh, *scene.Material) {
+		n := math.Vec3{X: 0, Y: 0, Z: 1}
+		m := scene.CreateMeshFromData("quad", []core.Vertex{
+			{Position: math.Vec3{X: x0, Y: -2, Z: z}, Normal: n, Color: core.ColorWhite},
+			{Position: math.Vec3{X: x1, Y: -2, Z: z}, Normal: n, Color: core.ColorWhite},
+			{Position: math.Vec3{X: x1, Y: 2, Z: z}, Normal: n, Color: core.ColorWhite},
+			{Position: math.Vec3{X: x0, Y: 2, Z: z}, Normal: n, Color: core.ColorWhite},
+		}, []uint32{0, 1, 2, 2, 3, 0})
+		mat := scene.NewMaterial("unlit", c)
+		mat.Unlit = true
+		return m, mat
+	}
+
+	// Red backdrop from the scene
+	back, backMat := quad(-4, 4, -2, core.ColorRed)
+	back.Material = backMat
+	node := scene.NewNode("backdrop")
+	node.Mesh = back
+	s := scene.NewScene()
+	s.SetCamera(scene.NewCamera(1, 1, 0.1, 100))
+	s.AddNode(node)
+	re.SetScene(s)
+	if err := re.Render(); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	// Mask the left half with a blue quad (which must not show), then draw
+	// a green quad over everything outside the mask
+	mask, maskMat := quad(-2, 0, -0.8, core.Color{B: 1, A: 1})
+	re.BeginStencilMask(1)
+	re.DrawMeshWithMaterial(mask, math.Mat4Identity(), maskMat)
+	re.EndStencilMask()
+	cover, coverMat := quad(-2, 2, -0.5, core.Color{G: 1, A: 1})
+	re.SetStencilTest(StencilOutside, 1)
+	re.DrawMeshWithMaterial(cover, math.Mat4Identity(), coverMat)
+	re.SetStencilTest(StencilOff, 0)
+
+	re.Present()
+	img, err := re.CaptureFrame()
+	if err != nil {
+		t.Fatalf("CaptureFrame: %v", err)
+	}
+	b := img.Bounds()
+	left := img.RGBAAt(b.Dx()/4, b.Dy()/2)
+	right := img.RGBAAt(3*b.Dx()/4, b.Dy()/2)
+	if left.R < 100 || left.G > 20 || left.B > 20 {
+		t.Errorf("masked half: expected the red backdrop, got %v", left)
+	}
+	if right.G < 100 || right.R > 20 || right.B > 20 {
+		t.Errorf("unmasked half: expected the green cover, got %v", right)
+	}
+}
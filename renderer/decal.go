@@ -0,0 +1,113 @@
+package renderer
+
+import (
+	"fmt"
+
+	"render-engine/internal/opengl"
+	"render-engine/math"
+	"render-engine/scene"
+)
+
+// Decal is a texture projected onto whatever scene geometry lies inside its
+// box (bullet holes, blood splats, signs), without editing any mesh. The box
+// is Size × Size across and Depth deep, centred on Position and facing along
+// Normal; the texture lies in the box's cross-section, upright when Normal is
+// horizontal. Fields can be changed between frames.
+type Decal struct {
+	Position math.Vec3
+	Normal   math.Vec3 // surface normal the decal faces; the projection runs along -Normal
+	Size     float32   // width and height in world units
+	Depth    float32   // box thickness along Normal (AddDecal sets Size / 2)
+	Texture  *scene.Texture
+}
+
+// Projector returns the decal's box matrix (row-vector convention): it maps
+// the unit cube [-1, 1]³ to the box in world space. Its rows are the box axes
+// scaled to half-extents — right and up across the texture, the normal
+// through it — followed by Position. The decal shader applies its inverse to
+// reconstructed surface positions to find them in box space.
+func (d *Decal) Projector() math.Mat4 {
+	n := d.Normal.Normalize()
+	// Any up vector not parallel to the normal; world up keeps wall decals upright
+	up := math.Vec3Up
+	if n.Y > 0.99 || n.Y < -0.99 {
+		up = math.Vec3{X: 0, Y: 0, Z: -1}
+	}
+	right := up.Cross(n).Normalize()
+	up = n.Cross(right)
+
+	hs, hd := d.Size*0.5, d.Depth*0.5
+	return math.Mat4{
+		{right.X * hs, right.Y * hs, right.Z * hs, 0},
+		{up.X * hs, up.Y * hs, up.Z * hs, 0},
+		{n.X * hd, n.Y * hd, n.Z * hd, 0},
+		{d.Position.X, d.Position.Y, d.Position.Z, 1},
+	}
+}
+
+// AddDecal projects tex onto the scene at position, facing along normal,
+// size world units across. The texture is uploaded if needed; its alpha
+// masks the decal and its colour multiplies the lit surface, so dark decals
+// work best. Decals need post-processing (they read the HDR depth buffer):
+// EnablePostProcess must be called first. The returned Decal can be moved or
+// resized and is removed with RemoveDecal.
+func (re *RenderEngine) AddDecal(position, normal math.Vec3, size float32, tex *scene.Texture) (*Decal, error) {
+	if tex == nil {
+		return nil, fmt.Errorf("decal: nil texture")
+	}
+	if normal.LengthSqr() < 1e-8 || size <= 0 {
+		return nil, fmt.Errorf("decal: needs a normal and a positive size")
+	}
+	if err := re.gl.EnableDecals(); err != nil {
+		return nil, fmt.Errorf("decal: %w", err)
+	}
+	if tex.GLID == 0 {
+		if err := opengl.UploadTexture(tex); err != nil {
+			return nil, fmt.Errorf("decal: %w", err)
+		}
+	}
+	d := &Decal{
+		Position: position,
+		Normal:   normal.Normalize(),
+		Size:     size,
+		Depth:    size * 0.5,
+		Texture:  tex,
+	}
+	re.decals = append(re.decals, d)
+	return d, nil
+}
+
+// RemoveDecal stops drawing d. The texture is left uploaded.
+func (re *RenderEngine) RemoveDecal(d *Decal) {
+	for i, x := range re.decals {
+		if x == d {
+			re.decals = append(re.decals[:i], re.decals[i+1:]...)
+			return
+		}
+	}
+}
+
+// ClearDecals removes every decal.
+func (re *RenderEngine) ClearDecals() { re.decals = nil }
+
+// Decals returns the active decals in draw order (later ones on top).
+func (re *RenderEngine) Decals() []*Decal { return re.decals }
+
+// drawDecals projects the active decals onto the opaque scene just drawn.
+func (re *RenderEngine) drawDecals(view, proj math.Mat4) {
+	if len(re.decals) == 0 {
+		return
+	}
+	draws := make([]opengl.DecalDraw, 0, len(re.decals))
+	for _, d := range re.decals {
+		if d.Texture == nil || d.Texture.GLID == 0 || d.Size <= 0 || d.Depth <= 0 {
+			continue
+		}
+		draws = append(draws, opengl.DecalDraw{
+			Projector: d.Projector(),
+			Normal:    d.Normal.Normalize(),
+			Texture:   d.Texture.GLID,
+		})
+	}
+	re.gl.DrawDecals(view, proj, draws)
+}
@@ -0,0 +1,52 @@
+package renderer
+
+import "time"
+
+// frameLimiter paces Present to at most one frame per period by sleeping
+// until each frame's deadline. Deadlines advance by exactly one period, so
+// a frame that finishes early makes up for one that ran a little late and
+// the average stays on target; after a stall longer than a period the
+// schedule restarts instead of rushing to catch up.
+type frameLimiter struct {
+	period time.Duration // 0 = no cap
+	next   time.Time     // deadline of the next frame; zero before the first
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+func newFrameLimiter() *frameLimiter {
+	return &frameLimiter{now: time.Now, sleep: time.Sleep}
+}
+
+// setFPS caps the rate at fps frames per second; fps <= 0 removes the cap.
+func (l *frameLimiter) setFPS(fps int) {
+	l.period = 0
+	if fps > 0 {
+		l.period = time.Second / time.Duration(fps)
+	}
+	l.next = time.Time{}
+}
+
+// wait sleeps until the current frame's deadline, if it is still ahead.
+func (l *frameLimiter) wait() {
+	if l.period <= 0 {
+		return
+	}
+	now := l.now()
+	if l.next.IsZero() || now.Sub(l.next) > l.period {
+		l.next = now
+	}
+	if d := l.next.Sub(now); d > 0 {
+		l.sleep(d)
+	}
+	l.next = l.next.Add(l.period)
+}
+
+// SetFrameCap limits Present to fps frames per second by sleeping before
+// the buffer swap (0, the default, is uncapped). It is meant for running
+// with vsync off (core.Window.SetSwapInterval(0)) to save GPU power and heat;
+// with vsync on, a cap above the refresh rate has no effect.
+func (re *RenderEngine) SetFrameCap(fps int) {
+	re.limiter.setFPS(fps)
+}
@@ -0,0 +1,47 @@
+package renderer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFrameLimiter(t *testing.T) {
+	// A fake clock: each frame does work, then the limiter sleeps
+	var clock time.Time
+	l := &frameLimiter{
+		now:   func() time.Time { return clock },
+		sleep: func(d time.Duration) { clock = clock.Add(d) },
+	}
+	l.setFPS(100)
+
+	const frames = 200
+	work := []time.Duration{2 * time.Millisecond, 14 * time.Millisecond, 5 * time.Millisecond, 9 * time.Millisecond}
+	start := clock
+	for i := 0; i < frames; i++ {
+		clock = clock.Add(work[i%len(work)])
+		l.wait()
+	}
+
+	period := 10 * time.Millisecond
+	if avg := clock.Sub(start) / frames; avg < period-period/frames {
+		t.Errorf("expected an average frame time of at least %v, got %v", period, avg)
+	}
+
+	// A stall does not make the following frames rush to catch up
+	clock = clock.Add(time.Second)
+	l.wait()
+	before := clock
+	clock = clock.Add(time.Millisecond)
+	l.wait()
+	if dt := clock.Sub(before); dt < period {
+		t.Errorf("after a stall: expected the next frame a full period later, got %v", dt)
+	}
+
+	// Uncapped: no sleeping
+	l.setFPS(0)
+	before = clock
+	l.wait()
+	if clock != before {
+		t.Errorf("uncapped limiter slept %v", clock.Sub(before))
+	}
+}
@@ -0,0 +1,120 @@
+package renderer
+
+import (
+	gomath "math"
+
+	"render-engine/core"
+	"render-engine/math"
+	"render-engine/scene"
+)
+
+// Light gizmo sizes for lights that carry no usable extent of their own.
+const (
+	gizmoFallbackRange  = 1.0 // point/spot lights with Range <= 0
+	gizmoArrowLength    = 2.0 // directional light arrow
+	gizmoCircleSegments = 32
+)
+
+// lightGizmos holds the unit line meshes used by DrawLightGizmos, created on
+// first draw and tinted per light through their (private) materials.
+type lightGizmos struct {
+	sphere *scene.Mesh
+	cone   *scene.Mesh
+	arrow  *scene.Mesh
+}
+
+// drawLightGizmos draws an unlit wireframe for every scene light in its own
+// colour: a sphere of radius Range for point lights, a cone of the outer spot
+// angle and length Range for spot lights, and an arrow at Position along
+// Direction for directional lights.  Gizmos bypass culling and count only as
+// debug draws in the stats.
+func (re *RenderEngine) drawLightGizmos(view, proj math.Mat4) {
+	if re.gizmos == nil {
+		re.gizmos = &lightGizmos{
+			sphere: scene.CreateUnitSphereWireframe(gizmoCircleSegments),
+			cone:   scene.CreateUnitConeWireframe(gizmoCircleSegments),
+			arrow:  scene.CreateArrowWireframe(),
+		}
+	}
+
+	viewProj := view.Mul(proj)
+	for _, l := range re.Scene.Lights {
+		if l == nil {
+			continue
+		}
+		rng := l.Range
+		if rng <= 0 {
+			rng = gizmoFallbackRange
+		}
+
+		var mesh  *scene.Mesh
+		var model math.Mat4
+		switch l.Type {
+		case scene.LightTypePoint:
+			mesh  = re.gizmos.sphere
+			model = gizmoBasis(l.Position, math.Vec3{X: 0, Y: 0, Z: 1}, rng, rng)
+		case scene.LightTypeSpot:
+			_, outerCos := l.SpotCutoffs()
+			c := gomath.Max(float64(outerCos), 0.01) // keep near-90° cones finite
+			radius := rng * float32(gomath.Sqrt(1-c*c)/c)
+			mesh  = re.gizmos.cone
+			model = gizmoBasis(l.Position, l.Direction, radius, rng)
+		case scene.LightTypeDirectional:
+			mesh  = re.gizmos.arrow
+			model = gizmoBasis(l.Position, l.Direction, gizmoArrowLength, gizmoArrowLength)
+		default:
+			continue
+		}
+
+		mesh.Material.Albedo = l.Color
+		re.gl.DrawMesh(mesh, model.Mul(viewProj), model)
+		re.frame.passes.Debug++
+	}
+}
+
+// gizmoBasis builds a model matrix mapping a gizmo's local +Z onto dir (scaled
+// by length) and its XY plane onto the perpendicular plane (scaled by radial),
+// translated to pos.  Rows are the images of the local axes (row-vector convention).
+func gizmoBasis(pos, dir math.Vec3, radial, length float32) math.Mat4 {
+	fwd := dir.Normalize()
+	if fwd.LengthSqr() < 1e-6 {
+		fwd = math.Vec3{X: 0, Y: -1, Z: 0}
+	}
+	up := math.Vec3Up
+	if gomath.Abs(float64(fwd.Dot(up))) > 0.999 {
+		up = math.Vec3{X: 0, Y: 0, Z: 1}
+	}
+	right := up.Cross(fwd).Normalize()
+	up     = fwd.Cross(right)
+
+	m := math.Mat4Identity()
+	m[0][0], m[0][1], m[0][2] = right.X*radial, right.Y*radial, right.Z*radial
+	m[1][0], m[1][1], m[1][2] = up.X*radial, up.Y*radial, up.Z*radial
+	m[2][0], m[2][1], m[2][2] = fwd.X*length, fwd.Y*length, fwd.Z*length
+	m[3][0], m[3][1], m[3][2] = pos.X, pos.Y, pos.Z
+	return m
+}
+
+// DrawFrustum draws cam's view frustum (see Camera.FrustumCorners) as a
+// yellow wireframe seen through the scene camera, e.g. to inspect a second
+// camera or shadow cascade bounds. Call between Render() and Present().
+func (re *RenderEngine) DrawFrustum(cam *scene.Camera) {
+	if re.Scene == nil || re.Scene.Camera == nil || cam == nil {
+		return
+	}
+	lines := scene.CreateFrustumWireframe(cam.FrustumCorners(0, 1))
+	if re.frustumMesh == nil {
+		lines.Dynamic = true
+		lines.Material.Albedo = core.Color{R: 1, G: 0.85, B: 0.1, A: 1}
+		re.frustumMesh = lines
+	} else {
+		re.frustumMesh.Vertices = lines.Vertices
+		re.UpdateMeshVertices(re.frustumMesh)
+	}
+
+	view := re.Scene.Camera.GetViewMatrix()
+	proj := re.Scene.Camera.GetProjectionMatrix()
+	model := math.Mat4Identity()
+	re.gl.DrawMesh(re.frustumMesh, model.Mul(view).Mul(proj), model)
+	re.frame.passes.Debug++
+}
@@ -0,0 +1,63 @@
+package renderer
+
+import (
+	"render-engine/core"
+	"render-engine/math"
+	"render-engine/scene"
+)
+
+// DrawLine queues the world-space segment a–b, drawn unlit in color by the
+// next Present(). Queued lines are depth-tested against the scene and
+// batched into one draw; they write depth only with LineDepthWrite set.
+// Call any time before Present(), e.g. for debug rays.
+func (re *RenderEngine) DrawLine(a, b math.Vec3, color core.Color) {
+	re.lineQueue = append(re.lineQueue,
+		core.Vertex{Position: a, Normal: math.Vec3Up, Color: color},
+		core.Vertex{Position: b, Normal: math.Vec3Up, Color: color},
+	)
+}
+
+// DrawLines queues the polyline through points (len(points)-1 segments) for
+// the next Present(), as DrawLine does for each consecutive pair. Fewer than
+// two points draw nothing.
+func (re *RenderEngine) DrawLines(points []math.Vec3, color core.Color) {
+	for i := 1; i < len(points); i++ {
+		re.DrawLine(points[i-1], points[i], color)
+	}
+}
+
+// flushLines draws the queued segments from the scene camera in a single
+// unlit draw and empties the queue. The line mesh is created on first use
+// and its vertex buffer rewritten every frame.
+func (re *RenderEngine) flushLines() {
+	if len(re.lineQueue) == 0 {
+		return
+	}
+	defer func() { re.lineQueue = re.lineQueue[:0] }()
+	if re.Scene == nil || re.Scene.Camera == nil {
+		return
+	}
+
+	if re.lineMesh == nil {
+		verts := append([]core.Vertex(nil), re.lineQueue...)
+		re.lineMesh = scene.CreateMeshFromData("DebugLines", verts, nil)
+		re.lineMesh.DrawMode = scene.DrawLines
+		re.lineMesh.Dynamic = true
+		re.lineMesh.Material = scene.DefaultMaterial()
+		re.lineMesh.Material.Name = "DebugLinesMaterial"
+		re.lineMesh.Material.Unlit = true
+	} else {
+		re.lineMesh.Vertices = append(re.lineMesh.Vertices[:0], re.lineQueue...)
+		re.gl.UpdateMeshVertices(re.lineMesh) // never culled: bounds not needed
+	}
+
+	view := re.Scene.Camera.GetViewMatrix()
+	proj := re.Scene.Camera.GetProjectionMatrix()
+	model := math.Mat4Identity()
+	if !re.LineDepthWrite {
+		re.gl.SetDepthWrite(false)
+	}
+	re.gl.DrawMesh(re.lineMesh, model.Mul(view).Mul(proj), model)
+	re.gl.SetDepthWrite(true)
+	re.frame.passes.Debug++
+}
@@ -0,0 +1,35 @@
+package renderer
+
+import (
+	"render-engine/core"
+	"render-engine/scene"
+)
+
+// outline is the selection highlight set by SetOutline.
+type outline struct {
+	node      *scene.Node
+	color     core.Color
+	thickness float32 // pixels
+}
+
+// SetOutline draws a color band thickness pixels wide around node's
+// silhouette, for editor selection highlights. It shows through other
+// geometry and ignores the node's material. Pass a nil node (or a thickness
+// of 0) to clear it. Only the node's own triangle mesh is outlined, not its
+// children; instanced nodes are not outlined.
+func (re *RenderEngine) SetOutline(node *scene.Node, color core.Color, thickness float32) {
+	if node == nil || thickness <= 0 {
+		re.outline = outline{}
+		return
+	}
+	re.outline = outline{node: node, color: color, thickness: thickness}
+}
+
+// OutlineNode returns the node SetOutline highlights, or nil.
+func (re *RenderEngine) OutlineNode() *scene.Node { return re.outline.node }
+
+// outlined reports whether node is drawn with the selection outline.
+func (re *RenderEngine) outlined(node *scene.Node) bool {
+	return node != nil && node == re.outline.node && len(node.Instances) == 0 &&
+		node.Mesh != nil && node.Mesh.DrawMode == scene.DrawTriangles
+}
@@ -3,6 +3,7 @@ package renderer
 import (
 	"fmt"
 	gomath "math"
+	"sort"
 
 	"render-engine/core"
 	"render-engine/math"
@@ -23,23 +24,82 @@ type RenderEngine struct {
 	gl             *opengl.Renderer
 	window         *core.Window
 	Scene          *scene.Scene
-	FrustumCulling     bool // disabled by default — verify matrix convention first
+	FrustumCulling     bool // skip nodes and instances outside the camera frustum (default on)
 	ShadowsEnabled     bool // enable via EnableShadows()
 	PostProcessEnabled bool // enable via EnablePostProcess()
 	SkyboxEnabled      bool // enable via EnableSkybox()
+	GroundGridEnabled  bool // enable via EnableGroundGrid()
 	DrawAABBs          bool // draw debug wireframe boxes around every node's AABB
-
-	shadowOrthoSize float32       // orthographic half-extent for the shadow volume
-	aabbMesh        *scene.Mesh   // unit-cube wireframe, created on first AABB draw
-
-	// Per-frame stats (populated during Render)
-	lastObjects   int
-	lastVertices  int
-	lastTriangles int
-	lastCulled    int
+	DrawNormals        bool // draw debug lines along every visible vertex normal
+	DrawLightGizmos    bool // draw unlit wireframes showing each light's range, cone or direction
+	LineDepthWrite     bool // DrawLine/DrawLines segments write depth (default off: depth-tested, never occlude)
+	DrawSceneEmitters  bool // Present draws the emitters attached to scene nodes (default on; off leaves them to DrawParticles)
+	UploadsPerFrame    int  // meshes + textures uploaded per ProcessUploadQueue call (default 4)
+
+	// Render layer masks: a pass draws the nodes whose Node.Layers share a
+	// bit with its mask. By default the scene pass draws every layer except
+	// scene.LayerShadowOnly and the shadow pass draws every layer.
+	SceneLayers  uint32
+	ShadowLayers uint32
+
+	shadowOrthoSize float32         // orthographic half-extent for the shadow volume
+	aabbMesh        *scene.Mesh     // unit-cube wireframe, created on first AABB draw
+	frustumMesh     *scene.Mesh     // DrawFrustum line mesh, re-uploaded per call
+	screenScaledMat *scene.Material // unlit tint shared by DrawMeshScreenScaled
+
+	// Per-mesh normal line meshes, built on first DrawNormals draw
+	normalMeshes map[*scene.Mesh]*scene.Mesh
+	normalLength float32
+
+	// Light gizmo line meshes, built on first DrawLightGizmos draw
+	gizmos *lightGizmos
+
+	// Framebuffer size the viewport and HDR targets were last sized for.
+	// fbSize polls the window and resizeTargets rebuilds the GL side of a
+	// Resize; both are swapped out in tests.
+	fbWidth, fbHeight int
+	fbSize            func() (int, int)
+	resizeTargets     func(width, height int)
+
+	// Frame rate cap applied in Present (SetFrameCap)
+	limiter *frameLimiter
+
+	// Motion vectors: frame number stamped into Node.Motion by the velocity
+	// pass, and the main camera's view-projection from the previous one
+	motionFrame  uint64
+	prevViewProj math.Mat4
+	hasPrevVP    bool
+
+	// Per-frame stats (populated during Render, added to by immediate draws)
+	frame drawStats
 
 	// Queued text commands, flushed in Present() after the HDR blit
 	textQueue []textCmd
+
+	// Queued DrawLine/DrawLines segments (vertex pairs), flushed in Present()
+	// before the HDR blit through the dynamic lineMesh
+	lineQueue []core.Vertex
+	lineMesh  *scene.Mesh
+
+	// Off-screen targets for RenderToTexture, keyed by camera
+	offscreen map[*scene.Camera]*offscreenView
+
+	// Projected decals (AddDecal), drawn after the opaque scene
+	decals []*Decal
+
+	// Selection highlight (SetOutline)
+	outline outline
+
+	// Meshes and textures waiting for ProcessUploadQueue
+	uploads uploadQueue
+
+	// Background override (SetClearColor); Scene.SkyColor when unset
+	clearColor    core.Color
+	hasClearColor bool
+
+	// The next Present keeps a copy of its frame for CaptureFrame
+	// (RequestCapture)
+	captureRequested bool
 }
 
 func NewRenderEngine(window *core.Window) (*RenderEngine, error) {
@@ -51,13 +111,24 @@ func NewRenderEngine(window *core.Window) (*RenderEngine, error) {
 	glRenderer.SetViewport(window.Width, window.Height)
 
 	fmt.Println("Render engine initialized (OpenGL)")
-	return &RenderEngine{
-		gl:              glRenderer,
-		window:          window,
-		FrustumCulling:  false,
-		ShadowsEnabled:  false,
-		shadowOrthoSize: 30.0,
-	}, nil
+	re := &RenderEngine{
+		gl:                glRenderer,
+		window:            window,
+		FrustumCulling:    true,
+		ShadowsEnabled:    false,
+		DrawSceneEmitters: true,
+		shadowOrthoSize:   30.0,
+		normalLength:      0.2,
+		UploadsPerFrame:   4,
+		SceneLayers:       scene.LayerAll &^ scene.LayerShadowOnly,
+		ShadowLayers:      scene.LayerAll,
+		fbWidth:           window.Width,
+		fbHeight:          window.Height,
+		fbSize:            window.GetFramebufferSize,
+		limiter:           newFrameLimiter(),
+	}
+	re.resizeTargets = re.resizeGLTargets
+	return re, nil
 }
 
 // EnableSkybox creates the procedural gradient skybox.
@@ -70,6 +141,74 @@ func (re *RenderEngine) EnableSkybox() error {
 	return nil
 }
 
+// SkyMode selects how the skybox shades the sky.
+type SkyMode int
+
+const (
+	SkyGradient   SkyMode = iota // three-stop zenith/horizon/ground gradient (default)
+	SkyAtmosphere                // Preetham analytic sky lit by the first directional light
+)
+
+// SetSkyMode switches the skybox between the colour gradient and the
+// atmospheric sky. In SkyAtmosphere mode the sun follows the scene's first
+// directional light, IBL ambient is sampled from the atmosphere each frame,
+// and the gradient colours show through as the night sky. EnableSkybox must
+// be called first.
+func (re *RenderEngine) SetSkyMode(mode SkyMode) {
+	sb := re.gl.SkyboxRef()
+	if sb == nil {
+		return
+	}
+	sb.Atmosphere = mode == SkyAtmosphere
+	if !sb.Atmosphere {
+		re.gl.SetIBLColors(sb.ZenithColor, sb.HorizonColor, sb.GroundColor)
+	}
+}
+
+// SetSkyTurbidity sets the atmospheric haze for SkyAtmosphere mode
+// (2 = very clear, 10 = hazy; default 3).
+func (re *RenderEngine) SetSkyTurbidity(turbidity float32) {
+	if sb := re.gl.SkyboxRef(); sb != nil {
+		sb.Turbidity = turbidity
+	}
+}
+
+// SetClouds draws an animated cloud layer over the sky. coverage is the
+// cloudy fraction from 0 (clear, layer off) to 1 (overcast, dark grey);
+// speed is the drift in cloud-plane units per second (default 0.02). Clouds
+// light up white by day and take on the horizon colour at dusk. EnableSkybox
+// must be called first; the environment-map sky has no clouds.
+func (re *RenderEngine) SetClouds(coverage, speed float32) {
+	sb := re.gl.SkyboxRef()
+	if sb == nil {
+		return
+	}
+	if coverage < 0 {
+		coverage = 0
+	} else if coverage > 1 {
+		coverage = 1
+	}
+	sb.CloudCoverage = coverage
+	sb.CloudSpeed    = speed
+}
+
+// EnableGroundGrid turns on the infinite editor grid on the Y = 0 plane, with
+// minor lines spacing world units apart in color and brighter major lines
+// every 10 cells. Calling it again updates spacing and colour. Clear
+// GroundGridEnabled to hide the grid.
+func (re *RenderEngine) EnableGroundGrid(spacing float32, color core.Color) error {
+	if err := re.gl.EnableGroundGrid(spacing, color); err != nil {
+		return fmt.Errorf("ground grid: %w", err)
+	}
+	re.GroundGridEnabled = true
+	return nil
+}
+
+// Skybox returns the skybox so its sun disc, stars and atmosphere settings
+// can be adjusted directly. Nil until EnableSkybox succeeds. The sun direction
+// is overwritten each frame from the scene's first directional light.
+func (re *RenderEngine) Skybox() *opengl.Skybox { return re.gl.SkyboxRef() }
+
 // SetSkyboxColors adjusts the three gradient stops and syncs IBL colours.
 // zenith = overhead, horizon = eye-level, ground = below the horizon.
 func (re *RenderEngine) SetSkyboxColors(zenith, horizon, ground core.Color) {
@@ -89,6 +228,35 @@ func (re *RenderEngine) SetFog(enabled bool, density float32, color core.Color)
 	re.gl.SetFog(enabled, density, color)
 }
 
+// SetEnvironmentMap lights the scene from an HDR panorama (see scene.LoadHDR)
+// and draws it as the sky. The texture is uploaded if needed, the skybox is
+// enabled if it is not already, and EnableIBLFromEnvironment is turned on.
+// Pass nil to return to the procedural sky.
+func (re *RenderEngine) SetEnvironmentMap(tex *scene.Texture) error {
+	if tex != nil && tex.GLID == 0 {
+		if err := opengl.UploadTexture(tex); err != nil {
+			return fmt.Errorf("environment map: %w", err)
+		}
+	}
+	if tex != nil && !re.SkyboxEnabled {
+		if err := re.EnableSkybox(); err != nil {
+			return err
+		}
+	}
+	return re.gl.SetEnvironmentMap(tex)
+}
+
+// EnableIBLFromEnvironment switches PBR and Phong ambient lighting between the
+// environment map and the sky gradient. Enabled, the map is convolved once on
+// the GPU into irradiance and prefiltered specular cubemaps plus a BRDF LUT
+// (the split-sum approximation). SetEnvironmentMap enables it.
+func (re *RenderEngine) EnableIBLFromEnvironment(enabled bool) error {
+	if err := re.gl.EnableIBLFromEnvironment(enabled); err != nil {
+		return fmt.Errorf("environment IBL: %w", err)
+	}
+	return nil
+}
+
 // EnableIBL activates sky-based ambient irradiance for PBR and Phong shading.
 // Call after NewRenderEngine; SetSkyboxColors must be called to supply colours.
 func (re *RenderEngine) EnableIBL() {
@@ -105,11 +273,85 @@ func (re *RenderEngine) EnablePostProcess() error {
 	return nil
 }
 
+// SetMSAA multisamples the scene with samples coverage samples per pixel
+// (1 = off, the default; typically 4 or 8), clamped to the driver's limit,
+// and returns the count in use. The samples are resolved before decals, soft
+// particles and post-processing, which therefore stay single-sampled.
+// EnablePostProcess must be called first.
+func (re *RenderEngine) SetMSAA(samples int) (int, error) {
+	n, err := re.gl.SetMSAA(samples)
+	if err != nil {
+		return n, fmt.Errorf("msaa: %w", err)
+	}
+	return n, nil
+}
+
 // SetExposure sets the HDR tone-mapping exposure (default 1.0).
 func (re *RenderEngine) SetExposure(exp float32) {
 	re.gl.SetExposure(exp)
 }
 
+// EnableMotionBlur turns on per-object motion blur: a velocity pass after the
+// scene writes each pixel's screen-space motion since the previous frame, and
+// the HDR image is then averaged over samples taps along it. strength scales
+// the blur length (1 = the full motion between frames, 0.5 = a 180° shutter).
+// samples < 2 or strength <= 0 turns motion blur off. EnablePostProcess must
+// be called first.
+func (re *RenderEngine) EnableMotionBlur(samples int, strength float32) error {
+	if samples < 2 || strength <= 0 {
+		re.gl.DisableMotionBlur()
+		return nil
+	}
+	if err := re.gl.EnableMotionBlur(samples, strength); err != nil {
+		return fmt.Errorf("motion blur: %w", err)
+	}
+	re.ResetMotionHistory()
+	return nil
+}
+
+// ResetMotionHistory forgets the previous-frame matrices, so the next frame
+// renders without motion blur. Call it after a camera cut or teleport, which
+// would otherwise smear the whole image.
+func (re *RenderEngine) ResetMotionHistory() {
+	re.hasPrevVP = false
+	// Skip a frame number so no node's record counts as the previous frame
+	re.motionFrame++
+}
+
+// SetChromaticAberration adds RGB fringing toward the screen edges: the red
+// and blue channels are sampled apart along the radius from the centre, by
+// strength × the distance (0.005–0.02 is subtle; 0 turns it off). Applied in
+// the tone-map pass, so post-processing must be enabled.
+func (re *RenderEngine) SetChromaticAberration(strength float32) {
+	re.gl.SetChromaticAberration(strength)
+}
+
+// SetLensDistortion warps the final image radially: each pixel samples the
+// scene at r(1 + k1 r² + k2 r⁴) from the centre (r = 0.5 at the edge
+// midpoints). Positive k1 gives barrel distortion, negative pincushion;
+// k1 = k2 = 0 turns it off. Requires post-processing.
+func (re *RenderEngine) SetLensDistortion(k1, k2 float32) {
+	re.gl.SetLensDistortion(k1, k2)
+}
+
+// SetFilmGrain adds animated film grain after tone mapping: zero-mean
+// monochrome noise that changes every frame, with strength its peak-to-peak
+// amplitude in display units (0.02–0.05 is subtle; 0 turns it off). Average
+// brightness is preserved, and even a little grain breaks up the 8-bit
+// banding of dark gradients such as the twilight sky. Requires
+// post-processing.
+func (re *RenderEngine) SetFilmGrain(strength float32) {
+	re.gl.SetFilmGrain(strength)
+}
+
+// SetDithering toggles the ordered dither (±0.5/255, 4×4 Bayer) applied to
+// the tone-mapped output before it is written at 8 bits per channel. It
+// removes the banding of smooth gradients such as the sky at no visible
+// cost, and is on by default. Requires post-processing.
+func (re *RenderEngine) SetDithering(enabled bool) {
+	re.gl.SetDithering(enabled)
+}
+
 // EnableBloom activates the bloom effect. EnablePostProcess must be called first.
 func (re *RenderEngine) EnableBloom() error {
 	return re.gl.EnableBloom()
@@ -121,6 +363,18 @@ func (re *RenderEngine) SetBloomThreshold(t float32) { re.gl.SetBloomThreshold(t
 // SetBloomStrength sets the additive bloom multiplier (default 0.6).
 func (re *RenderEngine) SetBloomStrength(s float32) { re.gl.SetBloomStrength(s) }
 
+// SetBloomPasses sets how many horizontal+vertical blur pairs the default
+// ping-pong bloom runs (default 4). More passes widen the glow.
+func (re *RenderEngine) SetBloomPasses(n int) { re.gl.SetBloomPasses(n) }
+
+// SetBloomMipCount replaces the ping-pong blur with an n-level mip chain
+// (½, ¼, ⅛… resolution, up to 8) that is downsampled and then upsampled
+// additively, giving a softer glow spanning several radii. 5 or 6 levels
+// suit 1080p. Since every level adds light, lower SetBloomStrength to
+// compensate. n = 0 restores the ping-pong blur. EnableBloom must be
+// called first.
+func (re *RenderEngine) SetBloomMipCount(n int) { re.gl.SetBloomMipCount(n) }
+
 // EnableShadows creates the shadow map FBO (2048×2048).
 // Call once after NewRenderEngine, before the first Render.
 func (re *RenderEngine) EnableShadows() error {
@@ -131,31 +385,148 @@ func (re *RenderEngine) EnableShadows() error {
 	return nil
 }
 
+// SetShadowBias tunes shadow acne against peter-panning: constant is the
+// depth bias of surfaces facing the light and slope the extra bias per unit
+// of tan(angle to the light), for surfaces steep to it. Defaults 0.001 and
+// 0.001; raise slope if acne shows on slanted faces, lower constant if
+// shadows detach from their casters.
+func (re *RenderEngine) SetShadowBias(constant, slope float32) {
+	re.gl.SetShadowBias(constant, slope)
+}
+
+// SetShadowFiltering selects the shadow map's comparison filtering: linear
+// (default) blends the depth tests of neighbouring texels for smooth PCF
+// edges, nearest gives hard, texel-sized steps.
+func (re *RenderEngine) SetShadowFiltering(linear bool) {
+	re.gl.SetShadowFiltering(linear)
+}
+
+// SetTextureAnisotropy sets the anisotropic filtering level (1 = off, the
+// default; typically up to 16) for material textures uploaded afterwards.
+// Textures already get mipmaps and trilinear filtering; anisotropy keeps
+// them sharp on surfaces seen at grazing angles.
+func (re *RenderEngine) SetTextureAnisotropy(level float32) {
+	opengl.SetTextureAnisotropy(level)
+}
+
+// SetShadowNormalOffset looks shadows up offset world units above each
+// surface along its normal (default 0), which removes acne on curved
+// surfaces where depth bias alone would need to be large.
+func (re *RenderEngine) SetShadowNormalOffset(offset float32) {
+	re.gl.SetShadowNormalOffset(offset)
+}
+
+// EnableReverseZ switches to a reverse-Z depth buffer (near = 1, far = 0 with
+// float depth), which removes z-fighting on distant geometry in large scenes.
+// Cameras are given the matching projection automatically. Returns an error
+// when the driver lacks glClipControl (GL 4.5 or GL_ARB_clip_control).
+func (re *RenderEngine) EnableReverseZ(enabled bool) error {
+	if err := re.gl.SetReverseZ(enabled); err != nil {
+		return fmt.Errorf("reverse-Z: %w", err)
+	}
+	return nil
+}
+
+// SetClearColor sets the background each frame starts from, instead of
+// Scene.SkyColor. It shows only where nothing is drawn: with a skybox the
+// colour clear is skipped altogether.
+func (re *RenderEngine) SetClearColor(c core.Color) {
+	re.clearColor = c
+	re.hasClearColor = true
+}
+
+// ResetClearColor drops the SetClearColor override, so frames clear to
+// Scene.SkyColor again.
+func (re *RenderEngine) ResetClearColor() {
+	re.hasClearColor = false
+}
+
+// frameClearColor returns the colour BeginFrame clears to.
+func (re *RenderEngine) frameClearColor() core.Color {
+	if re.hasClearColor {
+		return re.clearColor
+	}
+	return re.Scene.SkyColor
+}
+
 func (re *RenderEngine) SetScene(s *scene.Scene) {
 	re.Scene = s
 }
 
+// FrameScene moves the scene camera back along its view direction so every
+// visible node fits on screen (see Camera.FrameBounds), with a 10% margin.
+// Does nothing for an empty scene.
+func (re *RenderEngine) FrameScene() {
+	if re.Scene == nil || re.Scene.Camera == nil {
+		return
+	}
+	re.Scene.Camera.FrameBounds(re.Scene.Bounds(), 0.1)
+}
+
 func (re *RenderEngine) Render() error {
 	if re.Scene == nil || re.Scene.Camera == nil {
 		return fmt.Errorf("no scene or camera")
 	}
 
+	// Minimised window: nothing to draw into until it is restored
+	if !re.syncFramebufferSize() {
+		return nil
+	}
+
+	re.updateSkinnedMeshes()
+
+	view, proj := re.renderView(re.Scene.Camera, nil)
+
+	// ── AABB debug visualization ───────────────────────────────────────────
+	if re.DrawAABBs {
+		re.drawAABBs(view, proj)
+	}
+
+	// ── Vertex normal debug visualization ─────────────────────────────────
+	if re.DrawNormals {
+		re.drawNormals(view, proj)
+	}
+
+	// ── Light gizmos ──────────────────────────────────────────────────────
+	if re.DrawLightGizmos {
+		re.drawLightGizmos(view, proj)
+	}
+
+	return nil
+}
+
+// renderView runs the shadow pass and the main scene pass from cam into the
+// currently selected target (HDR FBO, default framebuffer or a render target).
+// Meshes whose albedo texture is skip are not drawn, which keeps an off-screen
+// pass from sampling the texture it is writing to. Draw stats are recorded
+// only for the main camera.
+func (re *RenderEngine) renderView(cam *scene.Camera, skip *scene.Texture) (view, proj math.Mat4) {
 	// ── Find directional light (first one wins) ───────────────────────────────
 	var dirLight *scene.Light
 	for _, l := range re.Scene.Lights {
-		if l != nil && l.Type == scene.LightTypeDirectional {
+		if l != nil && !l.Disabled && l.Type == scene.LightTypeDirectional {
 			dirLight = l
 			break
 		}
 	}
 
+	// ── Sky: sun follows the light; atmosphere feeds IBL ──────────────────────
+	if sb := re.gl.SkyboxRef(); sb != nil && dirLight != nil && dirLight.Direction.LengthSqr() > 0.001 {
+		sb.SunDirection = dirLight.Direction.Negate().Normalize()
+		if sb.Atmosphere {
+			re.gl.SetIBLColors(sb.AtmosphereIBL())
+		}
+	}
+
 	// ── Shadow pass ───────────────────────────────────────────────────────────
 	doShadows := re.ShadowsEnabled && re.gl.HasShadowMap() && dirLight != nil
 	lightVP := math.Mat4Identity()
 
+	var stats drawStats
+
 	if doShadows {
 		ortho := re.shadowOrthoSize
-		camPos := re.Scene.Camera.Position
+		camPos := cam.Position
 		lightDir := dirLight.Direction.Normalize()
 
 		// Guard: degenerate direction (zero vector)
@@ -179,11 +550,13 @@ func (re *RenderEngine) Render() error {
 			lightVP = lightView.Mul(lightProj)
 
 			re.gl.BeginShadowPass()
-			for _, node := range re.Scene.GetVisibleNodes() {
-				if node.Mesh == nil || node.Mesh.DrawMode != scene.DrawTriangles {
+			for _, node := range shadowCasters(re.Scene.GetVisibleNodes(), re.ShadowLayers) {
+				model := node.GetWorldMatrix()
+				stats.passes.Shadow++
+				if len(node.Instances) > 0 {
+					re.gl.DrawMeshShadowInstanced(node.Mesh, lightVP, instanceWorldMatrices(node.Instances, model))
 					continue
 				}
-				model := node.GetWorldMatrix()
 				lightMVP := model.Mul(lightView).Mul(lightProj)
 				re.gl.DrawMeshShadow(node.Mesh, lightMVP)
 			}
@@ -192,70 +565,370 @@ func (re *RenderEngine) Render() error {
 	}
 
 	// ── Main render pass ──────────────────────────────────────────────────────
-	// Compute proj before BeginFrame so it can be stored for the SSAO pass.
-	proj := re.Scene.Camera.GetProjectionMatrix()
+	// Compute view/proj before BeginFrame so they can be stored for the SSAO pass.
+	if cam.ReverseZ != re.gl.ReverseZ() {
+		cam.SetReverseZ(re.gl.ReverseZ())
+	}
+	view = cam.GetViewMatrix()
+	proj = cam.GetProjectionMatrix()
 	re.gl.BeginFrame(
-		re.Scene.SkyColor,
+		re.frameClearColor(),
 		re.Scene.Lights,
 		re.Scene.Ambient,
-		re.Scene.Camera.Position,
+		cam.Position,
 		lightVP,
 		doShadows,
+		view,
 		proj,
 	)
 
-	view := re.Scene.Camera.GetViewMatrix()
-
 	// Draw skybox first (depth=1.0 via xyww, before all scene geometry)
 	re.gl.DrawSkybox(view, proj)
 
 	// Build view-projection matrix for frustum culling
 	vp := view.Mul(proj)
-	frustum := scene.FrustumFromVP(vp)
+	frustum := cam.Frustum()
 
-	objects, vertices, triangles, culled := 0, 0, 0, 0
+	// Nodes drawn this frame, for the velocity pass
+	var moving []velocityDraw
+	withVelocity := cam == re.Scene.Camera && re.gl.HasMotionBlur()
 
-	for _, node := range re.Scene.GetVisibleNodes() {
-		if node.Mesh == nil {
+	// Selection outline: MVP of the outlined node if it was drawn
+	var outlineMVP *math.Mat4
+
+	// Transparent nodes, drawn after everything opaque
+	var transparent []transparentDraw
+
+	// Consecutive draws sharing a material skip its uniform and texture uploads
+	nodes := re.Scene.GetVisibleNodes()
+	sortByMaterial(nodes)
+
+	for _, node := range nodes {
+		if node.Mesh == nil || node.Layers&re.SceneLayers == 0 {
+			continue
+		}
+		if skip != nil && node.Mesh.Material != nil && node.Mesh.Material.AlbedoTexture == skip {
 			continue
 		}
 
 		model := node.GetWorldMatrix()
+		re.gl.SetReceiveShadow(node.ReceiveShadow)
+
+		// Instanced node: cull and count per instance, one draw for the rest
+		if len(node.Instances) > 0 {
+			var f *scene.Frustum
+			if re.FrustumCulling {
+				f = &frustum
+			}
+			models, variants := cullInstances(node.Mesh, instanceWorldMatrices(node.Instances, model), node.InstanceMaterialIndices, f, &stats)
+			re.gl.DrawMeshInstancedVariants(node.Mesh, node.Mesh.Material, view, proj, models, node.InstanceMaterialSet, variants)
+			if len(models) > 0 {
+				stats.call(&stats.passes.Instanced, node.Mesh.Material)
+			}
+			if withVelocity && len(models) > 0 {
+				moving = append(moving, velocityDraw{node: node, model: model, instances: models})
+			}
+			continue
+		}
 
-		// Frustum culling: skip draw if AABB is completely outside the frustum
 		if re.FrustumCulling {
-			aabb := scene.ComputeAABB(node.Mesh, model)
-			if !aabb.IntersectsFrustum(&frustum) {
-				culled++
+			if !inFrustum(node.Mesh, model, &frustum) {
+				stats.culled++
 				continue
 			}
 		}
 
 		mvp := model.Mul(view).Mul(proj)
-		re.gl.DrawMesh(node.Mesh, mvp, model)
+		if mat := node.Mesh.Material; mat != nil && mat.Transparent {
+			transparent = append(transparent, transparentDraw{node: node, model: model, mvp: mvp})
+			continue
+		}
+		if cam == re.Scene.Camera && re.outlined(node) {
+			re.gl.BeginOutlineMask()
+			re.gl.DrawMesh(node.Mesh, mvp, model)
+			re.gl.EndOutlineMask()
+			outlineMVP = &mvp
+		} else {
+			re.gl.DrawMesh(node.Mesh, mvp, model)
+		}
+		stats.add(node.Mesh, 1)
+		stats.call(&stats.passes.Scene, node.Mesh.Material)
+		if withVelocity {
+			moving = append(moving, velocityDraw{node: node, model: model})
+		}
+	}
 
-		objects++
-		vertices += len(node.Mesh.Vertices)
-		triangles += len(node.Mesh.Indices) / 3
+	// Decals go onto the opaque surfaces only, before anything blends over them
+	if cam == re.Scene.Camera {
+		re.drawDecals(view, proj)
 	}
 
-	re.lastObjects = objects
-	re.lastVertices = vertices
-	re.lastTriangles = triangles
-	re.lastCulled = culled
+	// Ground grid blends over the opaque scene without writing depth
+	if re.GroundGridEnabled {
+		re.gl.DrawGroundGrid(view, proj, cam.Position)
+		stats.passes.Debug++
+	}
 
-	// ── AABB debug visualization ───────────────────────────────────────────
-	if re.DrawAABBs {
-		re.drawAABBs(view, proj)
+	// Transparent surfaces blend over the opaque scene and the grid, farthest first
+	if len(transparent) > 0 {
+		sortBackToFront(transparent, cam.Position)
+		re.gl.BeginTransparent()
+		for _, d := range transparent {
+			re.gl.SetReceiveShadow(d.node.ReceiveShadow)
+			re.gl.DrawMesh(d.node.Mesh, d.mvp, d.model)
+			stats.add(d.node.Mesh, 1)
+			stats.call(&stats.passes.Scene, d.node.Mesh.Material)
+		}
+		re.gl.EndTransparent()
+	}
+	// Immediate draws after Render() are always shadowed
+	re.gl.SetReceiveShadow(true)
+
+	// Outline on top of everything opaque, around the stencil mask
+	if outlineMVP != nil {
+		o := re.outline
+		re.gl.DrawOutline(o.node.Mesh, *outlineMVP, o.color, o.thickness)
+		stats.passes.Debug++
 	}
 
-	return nil
+	if withVelocity {
+		re.drawVelocities(moving, vp)
+	}
+
+	if cam == re.Scene.Camera {
+		stats.materialBinds = re.gl.MaterialBinds()
+		re.frame = stats
+	}
+
+	return view, proj
+}
+
+// FrameStats is the draw breakdown of the most recent frame, returned by
+// Stats. Objects, Vertices, Triangles and Culled cover the main scene pass
+// only (instances count one object each), as DrawStats reports them.
+type FrameStats struct {
+	Objects, Vertices, Triangles, Culled int
+
+	// Passes counts draw calls by kind, including the immediate draws made
+	// between Render() and Present()
+	Passes PassDrawCalls
+
+	// Materials counts the scene and instanced draw calls made with each
+	// material; meshes without one are counted under nil
+	Materials map[*scene.Material]int
+
+	// MaterialBinds is how many times the scene pass uploaded material
+	// uniforms and textures: draws sorted together by material skip the
+	// upload when they share the previous draw's material
+	MaterialBinds int
+}
+
+// PassDrawCalls is the number of draw calls issued by each part of a frame.
+// An instanced draw is one call however many instances it has.
+type PassDrawCalls struct {
+	Scene     int // scene nodes and DrawMeshWithMaterial
+	Shadow    int // shadow map, one per shadow-casting node
+	Instanced int // instanced nodes and DrawMeshInstanced
+	Particles int // DrawParticles and DrawBillboard
+	Debug     int // AABBs, normals, gizmos, frustums, grid, outline, wireframes
+}
+
+// drawStats accumulates the per-frame numbers reported by DrawStats and Stats.
+type drawStats struct {
+	objects, vertices, triangles, culled int
+
+	passes        PassDrawCalls
+	materials     map[*scene.Material]int
+	materialBinds int
+}
+
+// add counts count drawn copies of mesh.
+func (s *drawStats) add(mesh *scene.Mesh, count int) {
+	s.objects += count
+	s.vertices += len(mesh.Vertices) * count
+	s.triangles += len(mesh.Indices) / 3 * count
+}
+
+// call counts one draw call with mat into pass (a field of s.passes).
+func (s *drawStats) call(pass *int, mat *scene.Material) {
+	*pass++
+	if s.materials == nil {
+		s.materials = make(map[*scene.Material]int)
+	}
+	s.materials[mat]++
+}
+
+// sortByMaterial reorders nodes so nodes sharing a material are adjacent,
+// each group where its material first appears; order within a group is
+// kept. Nodes without a mesh or material group under nil.
+func sortByMaterial(nodes []*scene.Node) {
+	group := make(map[*scene.Material]int)
+	key := func(n *scene.Node) *scene.Material {
+		if n.Mesh == nil {
+			return nil
+		}
+		return n.Mesh.Material
+	}
+	for _, n := range nodes {
+		if _, ok := group[key(n)]; !ok {
+			group[key(n)] = len(group)
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return group[key(nodes[i])] < group[key(nodes[j])]
+	})
+}
+
+// transparentDraw is a scene node held back for the transparent pass.
+type transparentDraw struct {
+	node       *scene.Node
+	model, mvp math.Mat4
+}
+
+// sortBackToFront orders draws by decreasing distance from eye to each
+// node's world position, so nearer surfaces blend over farther ones. Ties
+// keep their order.
+func sortBackToFront(draws []transparentDraw, eye math.Vec3) {
+	dist := func(d transparentDraw) float32 {
+		m := d.model
+		return math.Vec3{X: m[3][0], Y: m[3][1], Z: m[3][2]}.Sub(eye).LengthSqr()
+	}
+	sort.SliceStable(draws, func(i, j int) bool {
+		return dist(draws[i]) > dist(draws[j])
+	})
 }
 
-// Present resolves the HDR FBO (tone mapping, bloom, SSAO) to the default
-// framebuffer, flushes queued text (drawn on top of the HDR blit), and swaps
-// buffers. Call after Render() and any additional draw passes.
+// shadowCasters returns the nodes the shadow pass draws: triangle and
+// triangle-strip meshes with CastShadow set on a layer in mask. Lines and
+// points have no area to cast a shadow.
+func shadowCasters(nodes []*scene.Node, mask uint32) []*scene.Node {
+	var casters []*scene.Node
+	for _, node := range nodes {
+		if node.Mesh == nil {
+			continue
+		}
+		if mode := node.Mesh.DrawMode; mode != scene.DrawTriangles && mode != scene.DrawTriangleStrip {
+			continue
+		}
+		if !node.CastShadow || node.Layers&mask == 0 {
+			continue
+		}
+		casters = append(casters, node)
+	}
+	return casters
+}
+
+// inFrustum tests mesh at model against f: the bounding sphere rejects most
+// off-screen objects with one test per plane; survivors get the tighter AABB.
+func inFrustum(mesh *scene.Mesh, model math.Mat4, f *scene.Frustum) bool {
+	center, radius := scene.ComputeBoundingSphere(mesh, model)
+	if !f.IntersectsSphere(center, radius) {
+		return false
+	}
+	aabb := scene.ComputeAABB(mesh, model)
+	return aabb.IntersectsFrustum(f)
+}
+
+// instanceWorldMatrices places an instanced node's per-instance transforms
+// under the node's world matrix.
+func instanceWorldMatrices(instances []math.Mat4, world math.Mat4) []math.Mat4 {
+	out := make([]math.Mat4, len(instances))
+	for i, m := range instances {
+		out[i] = m.Mul(world)
+	}
+	return out
+}
+
+// cullInstances returns the instance world matrices of mesh inside f (all of
+// them when f is nil), counting drawn and culled instances into stats.
+// variants, the instances' material indices (may be nil or short), is
+// filtered alongside so it stays parallel to the returned matrices.
+func cullInstances(mesh *scene.Mesh, models []math.Mat4, variants []uint32, f *scene.Frustum, stats *drawStats) ([]math.Mat4, []uint32) {
+	if f == nil {
+		stats.add(mesh, len(models))
+		return models, variants
+	}
+	visible := models[:0:0]
+	var visibleVariants []uint32
+	for i, m := range models {
+		if inFrustum(mesh, m, f) {
+			visible = append(visible, m)
+			if i < len(variants) {
+				visibleVariants = append(visibleVariants, variants[i])
+			}
+		} else {
+			stats.culled++
+		}
+	}
+	stats.add(mesh, len(visible))
+	return visible, visibleVariants
+}
+
+// velocityDraw is a node drawn by the main pass, queued for the velocity pass.
+type velocityDraw struct {
+	node      *scene.Node
+	model     math.Mat4   // node world matrix
+	instances []math.Mat4 // drawn instance world matrices (instanced nodes only)
+}
+
+// drawVelocities runs the motion-vector pass over the nodes drawn this frame
+// and records their matrices in Node.Motion for the next one.
+//
+// Every vertex is transformed by this frame's model × view-projection and by
+// the previous frame's pair (see motionPrev), so the written velocity is the
+// sum of camera and object motion: a static node's model matrix is unchanged
+// and only the camera's view-projection differs, while a moving node also
+// carries its own previous model matrix. Instances share their node's
+// history. Line and point meshes are skipped and skinned deformation is not
+// tracked (a skinned mesh moves with its node only).
+func (re *RenderEngine) drawVelocities(draws []velocityDraw, viewProj math.Mat4) {
+	if !re.hasPrevVP {
+		re.prevViewProj = viewProj
+	}
+	re.motionFrame++
+	if re.gl.BeginVelocityPass() {
+		for _, d := range draws {
+			if d.node.Mesh.DrawMode != scene.DrawTriangles {
+				continue
+			}
+			prevModel, prevVP := motionPrev(d.node, d.model, re.prevViewProj, re.motionFrame)
+			if d.instances != nil {
+				// instance × world × world⁻¹ × prevWorld × prevVP
+				prevFromWorld := d.model.Inverse().Mul(prevModel).Mul(prevVP)
+				re.gl.DrawMeshVelocityInstanced(d.node.Mesh, viewProj, prevFromWorld, d.instances)
+			} else {
+				re.gl.DrawMeshVelocity(d.node.Mesh, d.model.Mul(viewProj), prevModel.Mul(prevVP))
+			}
+		}
+		re.gl.EndVelocityPass()
+	}
+	for _, d := range draws {
+		d.node.Motion = scene.MotionState{Model: d.model, ViewProj: viewProj, Frame: re.motionFrame}
+	}
+	re.prevViewProj = viewProj
+	re.hasPrevVP = true
+}
+
+// motionPrev returns the model and view-projection matrices to use as node's
+// previous-frame transform on frame. A node recorded on the frame before has
+// its own history; any other node (new, or hidden or culled last frame) is
+// treated as static: its current model with the camera's previous
+// view-projection, so it moves only with the camera.
+func motionPrev(node *scene.Node, model, prevViewProj math.Mat4, frame uint64) (math.Mat4, math.Mat4) {
+	if node.Motion.Frame != 0 && node.Motion.Frame+1 == frame {
+		return node.Motion.Model, node.Motion.ViewProj
+	}
+	return model, prevViewProj
+}
+
+// Present draws queued lines and the scene's node-attached particle
+// emitters into the scene, resolves the HDR FBO (tone mapping, bloom, SSAO)
+// to the default framebuffer, flushes queued text (drawn on top of the HDR
+// blit), waits out any SetFrameCap, and swaps buffers. Call after Render()
+// and any additional draw passes.
 func (re *RenderEngine) Present() {
+	re.flushLines()
+	re.drawNodeEmitters()
 	re.gl.BlitPostProcess()
 	// Flush text queue — drawn to the default framebuffer, always on top
 	if len(re.textQueue) > 0 {
@@ -266,7 +939,21 @@ func (re *RenderEngine) Present() {
 		}
 		re.textQueue = re.textQueue[:0]
 	}
-	re.window.SwapBuffers()
+	// Keep the finished frame for CaptureFrame: the back buffer is undefined
+	// after the swap, and reading the front buffer is unreliable under a
+	// compositor or with the window hidden or covered. The copy costs a
+	// full-screen blit, so only a requested or headless frame pays for it.
+	if re.captureRequested || re.window.Headless {
+		re.gl.CopyFramebuffer()
+		re.captureRequested = false
+	} else {
+		re.gl.DiscardFramebufferCopy()
+	}
+	re.limiter.wait()
+	// Headless contexts are never shown
+	if !re.window.Headless {
+		re.window.SwapBuffers()
+	}
 }
 
 // DrawText queues a text string to be drawn at screen position (x, y) in the
@@ -283,25 +970,138 @@ func (re *RenderEngine) DrawText(text string, x, y int, scale float32, color cor
 }
 
 func (re *RenderEngine) Resize(width, height uint32) {
-	re.gl.SetViewport(int(width), int(height))
-	if re.PostProcessEnabled {
-		re.gl.ResizePostProcess(int(width), int(height))
-	}
+	re.fbWidth, re.fbHeight = int(width), int(height)
+	re.resizeTargets(int(width), int(height))
 	if re.Scene != nil && re.Scene.Camera != nil {
 		re.Scene.Camera.UpdateAspectRatio(float32(width), float32(height))
 	}
 }
 
+// resizeGLTargets resizes the viewport and, with post-processing on, the HDR
+// and SSAO targets.
+func (re *RenderEngine) resizeGLTargets(width, height int) {
+	re.gl.SetViewport(width, height)
+	if re.PostProcessEnabled {
+		re.gl.ResizePostProcess(width, height)
+	}
+}
+
+// syncFramebufferSize polls the window's framebuffer size and calls Resize when
+// it no longer matches the size the viewport and HDR targets were built for.
+// OpenGL has no out-of-date swapchain error to react to, so this runs once at
+// the start of every Render. Returns false while the framebuffer is zero-sized
+// (minimised window), in which case the frame should be skipped.
+func (re *RenderEngine) syncFramebufferSize() bool {
+	w, h := re.fbSize()
+	if w <= 0 || h <= 0 {
+		return false
+	}
+	if w != re.fbWidth || h != re.fbHeight {
+		re.Resize(uint32(w), uint32(h))
+	}
+	return true
+}
+
 // DrawParticles renders a ParticleEmitter's live particles as camera-facing
 // billboards.  Call between Render() and Present() so particles are included
 // in the HDR FBO and benefit from tone mapping and bloom.
+//
+// Emitters whose Bounds lie outside the view frustum (with FrustumCulling on)
+// or beyond their CullDistance are skipped; a GPUSimulation emitter, which
+// is advanced by its draw, is frozen meanwhile.
 func (re *RenderEngine) DrawParticles(emitter *scene.ParticleEmitter) {
 	if re.Scene == nil || re.Scene.Camera == nil || emitter == nil {
 		return
 	}
+	cam := re.Scene.Camera
+	var f *scene.Frustum
+	if re.FrustumCulling {
+		frustum := cam.Frustum()
+		f = &frustum
+	}
+	fade, visible := emitterVisible(emitter, cam.Position, f)
+	if !visible {
+		if emitter.GPUSimulation {
+			emitter.TakeGPUStep() // drop the pending step rather than jump ahead later
+		}
+		return
+	}
+	re.gl.DrawParticles(emitter, cam.GetViewMatrix(), cam.GetProjectionMatrix(), fade)
+	re.frame.passes.Particles++
+}
+
+// emitterVisible reports whether emitter should be drawn from eye, testing
+// its bounding sphere against f when f is non-nil, and returns its distance
+// fade.
+func emitterVisible(emitter *scene.ParticleEmitter, eye math.Vec3, f *scene.Frustum) (fade float32, visible bool) {
+	fade = emitter.DistanceFade(eye)
+	if fade <= 0 {
+		return 0, false
+	}
+	if f != nil {
+		if center, radius := emitter.Bounds(); !f.IntersectsSphere(center, radius) {
+			return fade, false
+		}
+	}
+	return fade, true
+}
+
+// drawNodeEmitters draws the emitters attached to visible scene nodes (see
+// scene.Node.Emitters) in the scene layers, unless DrawSceneEmitters is off.
+// Present calls it before the HDR resolve, after everything drawn between
+// Render and Present, so the particles blend over it.
+func (re *RenderEngine) drawNodeEmitters() {
+	if !re.DrawSceneEmitters || re.Scene == nil || re.Scene.Root == nil {
+		return
+	}
+	re.Scene.Root.Traverse(func(node *scene.Node) {
+		if !node.Visible || node.Layers&re.SceneLayers == 0 {
+			return
+		}
+		for _, e := range node.Emitters {
+			re.DrawParticles(e)
+		}
+	})
+}
+
+// DrawBillboard draws a camera-facing sprite (icon, health bar, impostor)
+// size.X × size.Y world units, centred on worldPos: tex (nil = a plain quad)
+// tinted by color, unlit and alpha-blended into the HDR FBO. Sprites are
+// depth-tested but don't occlude. The texture is uploaded if needed. Call
+// between Render() and Present().
+func (re *RenderEngine) DrawBillboard(tex *scene.Texture, worldPos math.Vec3, size math.Vec2, color core.Color) {
+	re.DrawBillboardLocked(tex, worldPos, size, color, math.Vec3{})
+}
+
+// DrawBillboardLocked is DrawBillboard with the sprite's up locked to
+// lockAxis: it turns only about that axis to face the camera, so tree and
+// character impostors stay upright (pass math.Vec3Up) when seen from above.
+// A zero axis faces the screen as DrawBillboard does.
+func (re *RenderEngine) DrawBillboardLocked(tex *scene.Texture, worldPos math.Vec3, size math.Vec2, color core.Color, lockAxis math.Vec3) {
+	if re.Scene == nil || re.Scene.Camera == nil {
+		return
+	}
+	var glTex uint32
+	if tex != nil {
+		if tex.GLID == 0 {
+			if err := opengl.UploadTexture(tex); err != nil {
+				fmt.Printf("billboard: %v\n", err)
+				return
+			}
+		}
+		glTex = tex.GLID
+	}
 	view := re.Scene.Camera.GetViewMatrix()
 	proj := re.Scene.Camera.GetProjectionMatrix()
-	re.gl.DrawParticles(emitter, view, proj)
+	re.gl.DrawBillboard(glTex, worldPos, size, color, lockAxis, view, proj)
+	re.frame.passes.Particles++
+}
+
+// ReleaseParticles frees the GPU buffers of an emitter drawn with
+// GPUSimulation set. Call it when the emitter is discarded; otherwise they
+// live as long as the engine.
+func (re *RenderEngine) ReleaseParticles(emitter *scene.ParticleEmitter) {
+	re.gl.ReleaseParticles(emitter)
 }
 
 // DrawMeshInstanced renders mesh at every transform in models using a single
@@ -320,6 +1120,76 @@ func (re *RenderEngine) DrawMeshInstanced(mesh *scene.Mesh, models []math.Mat4)
 	view := re.Scene.Camera.GetViewMatrix()
 	proj := re.Scene.Camera.GetProjectionMatrix()
 	re.gl.DrawMeshInstanced(mesh, view, proj, models)
+	re.frame.call(&re.frame.passes.Instanced, mesh.Material)
+}
+
+// DrawMeshInstancedWithMaterial is DrawMeshInstanced with mat applied instead
+// of mesh.Material, so one mesh can be instanced in several material variants.
+func (re *RenderEngine) DrawMeshInstancedWithMaterial(mesh *scene.Mesh, models []math.Mat4, mat *scene.Material) {
+	if re.Scene == nil || re.Scene.Camera == nil || len(models) == 0 {
+		return
+	}
+	view := re.Scene.Camera.GetViewMatrix()
+	proj := re.Scene.Camera.GetProjectionMatrix()
+	re.gl.DrawMeshInstancedWithMaterial(mesh, mat, view, proj, models)
+	re.frame.call(&re.frame.passes.Instanced, mat)
+}
+
+// DrawMeshWithMaterial draws mesh once at model with mat applied instead of
+// mesh.Material, reusing the mesh's GPU buffers; mesh.Material is left
+// untouched. Like DrawMeshInstanced, call between Render() and Present().
+func (re *RenderEngine) DrawMeshWithMaterial(mesh *scene.Mesh, model math.Mat4, mat *scene.Material) {
+	if re.Scene == nil || re.Scene.Camera == nil || mesh == nil {
+		return
+	}
+	view := re.Scene.Camera.GetViewMatrix()
+	proj := re.Scene.Camera.GetProjectionMatrix()
+	re.gl.DrawMeshWithMaterial(mesh, mat, model.Mul(view).Mul(proj), model)
+	re.frame.call(&re.frame.passes.Scene, mat)
+}
+
+// DrawMeshScreenScaled draws mesh at worldPos scaled so one unit of it spans
+// pixelSize pixels on screen at any distance, as editor gizmos and icons
+// need. It is unlit and tinted by color (white keeps the vertex colours).
+// The scale is pixelSize × Camera.PixelWorldSize: the world height of a
+// pixel grows linearly with the view depth. Call between Render() and
+// Present().
+func (re *RenderEngine) DrawMeshScreenScaled(mesh *scene.Mesh, worldPos math.Vec3, pixelSize float32, color core.Color) {
+	if re.Scene == nil || re.Scene.Camera == nil || mesh == nil {
+		return
+	}
+	scale := pixelSize * re.Scene.Camera.PixelWorldSize(worldPos, float32(re.fbHeight))
+	if scale <= 0 {
+		return
+	}
+	if re.screenScaledMat == nil {
+		re.screenScaledMat = scene.NewMaterial("screen_scaled", color)
+		re.screenScaledMat.Unlit = true
+	}
+	re.screenScaledMat.Albedo = color
+	model := math.Mat4Scale(math.Vec3{X: scale, Y: scale, Z: scale}).Mul(math.Mat4Translation(worldPos))
+	re.DrawMeshWithMaterial(mesh, model, re.screenScaledMat)
+}
+
+// UpdateMeshVertices pushes edited mesh.Vertices to the GPU so the change
+// shows on the next draw, and refreshes the mesh's culling bounds. Set
+// mesh.Dynamic before the first draw for meshes updated every frame.
+// Must be called from the main thread.
+func (re *RenderEngine) UpdateMeshVertices(mesh *scene.Mesh) {
+	mesh.RecomputeBounds()
+	re.gl.UpdateMeshVertices(mesh)
+}
+
+// ReloadShaders recompiles the main scene shader, reading main.vert and
+// main.frag from the directory in the RENDER_ENGINE_SHADER_DIR environment
+// variable when it is set (the built-in sources otherwise). If either fails
+// to compile the error holds the compiler log and the previous shader keeps
+// drawing. Call between frames, from the main thread.
+func (re *RenderEngine) ReloadShaders() error {
+	if err := re.gl.ReloadShaders(); err != nil {
+		return fmt.Errorf("reload shaders: %w", err)
+	}
+	return nil
 }
 
 // EnableSSAO creates the SSAO pipeline.  EnablePostProcess must be called first.
@@ -339,16 +1209,144 @@ func (re *RenderEngine) SetSSAOBias(v float32) { re.gl.SetSSAOBias(v) }
 // SetSSAOStrength sets the AO blend factor: 0 = no AO, 1 = full AO (default 1.0).
 func (re *RenderEngine) SetSSAOStrength(v float32) { re.gl.SetSSAOStrength(v) }
 
+// SetSSAOSamples sets the SSAO kernel size per pixel: 16, 32 or 64 (default 64).
+// Fewer samples are cheaper but noisier.
+func (re *RenderEngine) SetSSAOSamples(n int) { re.gl.SetSSAOSamples(n) }
+
+// SetSSAOPower sets the AO contrast curve, applied as pow(ao, p) (default 1.0).
+// Values above 1 deepen creases and contact shadows.
+func (re *RenderEngine) SetSSAOPower(p float32) { re.gl.SetSSAOPower(p) }
+
+// SetSSAOScale renders SSAO at scale × the framebuffer resolution: 1.0 (full,
+// default) or 0.5 (half, about 4× cheaper). Half-res AO is upsampled with a
+// depth-aware filter, so silhouettes stay clean.
+func (re *RenderEngine) SetSSAOScale(scale float32) { re.gl.SetSSAOScale(scale) }
+
+// EnableTemporalAO blends each frame's SSAO with the previous frame's,
+// reprojected by camera motion, for a much smoother result at low sample
+// counts. History is rejected per pixel where depth shows the surface was
+// not visible last frame, so moving edges do not ghost.
+func (re *RenderEngine) EnableTemporalAO(enabled bool) { re.gl.SetSSAOTemporal(enabled) }
+
+// SSAOBlur selects the filter that denoises the raw SSAO output.
+type SSAOBlur int
+
+const (
+	SSAOBlurBilateral SSAOBlur = iota // depth-aware: preserves silhouettes (default)
+	SSAOBlurBox                       // plain 5×5 box: cheaper, but halos at depth edges
+)
+
+// SetSSAOBlur selects the SSAO blur filter (default SSAOBlurBilateral).
+func (re *RenderEngine) SetSSAOBlur(mode SSAOBlur) { re.gl.SetSSAOBilateral(mode == SSAOBlurBilateral) }
+
+// SetSSAOBlurFalloff sets how sharply the bilateral blur drops taps across
+// depth edges (default 50). A tap's weight is exp(-falloff × |Δz| / |z|),
+// so at 50 a 2% relative depth step already weighs e⁻¹; raise it if halos
+// persist, lower it if flat slopes look noisy.
+func (re *RenderEngine) SetSSAOBlurFalloff(v float32) { re.gl.SetSSAODepthFalloff(v) }
+
 // SetWireframe toggles wireframe rendering mode on/off.
 func (re *RenderEngine) SetWireframe(enabled bool) {
 	re.gl.SetWireframe(enabled)
 }
 
+// SetPresentMode changes how Present synchronises with the display and returns
+// the mode actually applied (unsupported modes fall back to FIFO).
+func (re *RenderEngine) SetPresentMode(mode core.PresentMode) core.PresentMode {
+	return re.window.SetPresentMode(mode)
+}
+
+// SetFullscreen switches the window between windowed and fullscreen on
+// window.Monitor, then resizes the viewport and HDR targets to match.
+func (re *RenderEngine) SetFullscreen(enabled bool) {
+	re.window.SetFullscreen(enabled)
+	re.syncFramebufferSize()
+}
+
 // IsWireframe returns whether wireframe mode is currently active.
 func (re *RenderEngine) IsWireframe() bool {
 	return re.gl.IsWireframe()
 }
 
+// SetWireframeOverlay toggles drawing triangle edges over the shaded scene.
+func (re *RenderEngine) SetWireframeOverlay(enabled bool) {
+	re.gl.SetWireframeOverlay(enabled)
+}
+
+// IsWireframeOverlay returns whether the wireframe overlay is active.
+func (re *RenderEngine) IsWireframeOverlay() bool {
+	return re.gl.IsWireframeOverlay()
+}
+
+// SetWireframeColor sets the colour of the wireframe overlay lines (default black).
+func (re *RenderEngine) SetWireframeColor(c core.Color) {
+	re.gl.SetWireframeColor(c)
+}
+
+// WireframeStyle selects how the wireframe overlay draws triangle edges.
+type WireframeStyle int
+
+const (
+	WireframeLines       WireframeStyle = iota // 1 px polygon-mode lines along every triangle edge (default)
+	WireframeBarycentric                       // anti-aliased lines shaded on the surface; quad diagonals hidden
+)
+
+// SetWireframeStyle selects how SetWireframeOverlay draws. Barycentric lines
+// are smooth, SetWireframeWidth pixels wide, and show split quads as quads;
+// instanced meshes keep the line style. Full wireframe mode (SetWireframe)
+// is unaffected.
+func (re *RenderEngine) SetWireframeStyle(style WireframeStyle) {
+	if style == WireframeBarycentric {
+		re.gl.SetWireframeStyle(opengl.WireframeBarycentric)
+	} else {
+		re.gl.SetWireframeStyle(opengl.WireframeLines)
+	}
+}
+
+// SetWireframeWidth sets the width in pixels of barycentric wireframe lines
+// (default 1.5).
+func (re *RenderEngine) SetWireframeWidth(px float32) {
+	re.gl.SetWireframeWidth(px)
+}
+
+// DebugView selects a material input to show instead of the lit colour.
+type DebugView int
+
+const (
+	DebugViewOff   DebugView = iota // normal shading (default)
+	DebugNormals                    // world-space normal after normal mapping, as N × 0.5 + 0.5
+	DebugUV                         // first UV set as red and green, repeating every unit
+	DebugRoughness                  // PBR roughness (metallic-roughness texture G, else Material.Roughness)
+	DebugMetallic                   // PBR metallic (metallic-roughness texture B, else Material.Metallic)
+	DebugAO                         // SSAO occlusion at full strength (white when SSAO is off)
+)
+
+// SetDebugView shades every mesh with one of its material inputs, unlit and
+// without tone mapping, so the colours on screen are the values themselves:
+// a +Z normal shows as (0.5, 0.5, 1). DebugViewOff restores normal shading.
+func (re *RenderEngine) SetDebugView(v DebugView) {
+	re.gl.SetDebugView(opengl.DebugView(v)) // same order as opengl.DebugView
+}
+
+// DebugView returns the active material debug view.
+func (re *RenderEngine) DebugView() DebugView {
+	return DebugView(re.gl.DebugView())
+}
+
+// DrawMeshWireframe draws mesh's edges at model as a barycentric wireframe,
+// width pixels wide, over what is already drawn: call it after drawing the
+// mesh to outline one object without turning on the scene-wide overlay.
+// Call between Render() and Present().
+func (re *RenderEngine) DrawMeshWireframe(mesh *scene.Mesh, model math.Mat4, color core.Color, width float32) {
+	if re.Scene == nil || re.Scene.Camera == nil || mesh == nil {
+		return
+	}
+	view := re.Scene.Camera.GetViewMatrix()
+	proj := re.Scene.Camera.GetProjectionMatrix()
+	re.gl.DrawMeshWireframe(mesh, model.Mul(view).Mul(proj), color, width)
+	re.frame.passes.Debug++
+}
+
 // UploadTexture uploads a texture to the GPU. Must be called from the main thread.
 func (re *RenderEngine) UploadTexture(tex *scene.Texture) error {
 	return opengl.UploadTexture(tex)
@@ -360,6 +1358,9 @@ func (re *RenderEngine) DeleteTexture(tex *scene.Texture) {
 }
 
 func (re *RenderEngine) Destroy() {
+	for cam := range re.offscreen {
+		re.ReleaseRenderTexture(cam)
+	}
 	re.gl.Destroy()
 }
 
@@ -367,9 +1368,39 @@ func (re *RenderEngine) WaitIdle() {
 	// No-op for OpenGL; synchronous by nature.
 }
 
-// DrawStats returns stats from the most recent Render call.
+// DrawStats returns the main scene pass totals from the most recent Render
+// call; Stats has the full breakdown.
 func (re *RenderEngine) DrawStats() (objects, vertices, triangles, culled int) {
-	return re.lastObjects, re.lastVertices, re.lastTriangles, re.lastCulled
+	s := re.Stats()
+	return s.Objects, s.Vertices, s.Triangles, s.Culled
+}
+
+// Stats returns the draw breakdown of the current frame: everything drawn by
+// the most recent Render call plus the immediate draws made since. The
+// Materials map is a copy.
+func (re *RenderEngine) Stats() FrameStats {
+	f := re.frame
+	materials := make(map[*scene.Material]int, len(f.materials))
+	for m, n := range f.materials {
+		materials[m] = n
+	}
+	return FrameStats{
+		Objects:       f.objects,
+		Vertices:      f.vertices,
+		Triangles:     f.triangles,
+		Culled:        f.culled,
+		Passes:        f.passes,
+		Materials:     materials,
+		MaterialBinds: f.materialBinds,
+	}
+}
+
+// PassTimings returns per-pass GPU times in milliseconds, keyed "shadow",
+// "scene", "decals", "velocity", "ssao", "motionblur", "bloom", "tonemap"
+// and "particles". Values lag one frame behind so the query readback never
+// stalls; all zeros when timer queries are unsupported.
+func (re *RenderEngine) PassTimings() map[string]float64 {
+	return re.gl.PassTimings()
 }
 
 // drawAABBs draws a wireframe unit-cube scaled/translated to each visible node's
@@ -409,5 +1440,52 @@ func (re *RenderEngine) drawAABBs(view, proj math.Mat4) {
 
 		mvp := aabbModel.Mul(view).Mul(proj)
 		re.gl.DrawMesh(re.aabbMesh, mvp, identity)
+		re.frame.passes.Debug++
+	}
+}
+
+// updateSkinnedMeshes poses every visible skinned mesh from its skeleton's
+// current joint transforms and re-uploads the deformed vertices.
+func (re *RenderEngine) updateSkinnedMeshes() {
+	for _, node := range re.Scene.GetVisibleNodes() {
+		if node.Mesh.Update(node.GetWorldMatrix()) {
+			re.UpdateMeshVertices(node.Mesh)
+		}
+	}
+}
+
+// SetNormalDebugLength sets the length of the DrawNormals lines in object-space
+// units (default 0.2). Cached line meshes are rebuilt on the next draw.
+func (re *RenderEngine) SetNormalDebugLength(length float32) {
+	if length == re.normalLength {
+		return
+	}
+	re.normalLength = length
+	for _, lines := range re.normalMeshes {
+		re.gl.ReleaseMesh(lines)
+	}
+	re.normalMeshes = nil
+}
+
+// drawNormals draws each visible triangle mesh's vertex normals as unlit line
+// segments.  Line meshes are built lazily and cached per source mesh, so
+// meshes whose vertices change afterwards keep showing their old normals.
+func (re *RenderEngine) drawNormals(view, proj math.Mat4) {
+	if re.normalMeshes == nil {
+		re.normalMeshes = make(map[*scene.Mesh]*scene.Mesh)
+	}
+
+	for _, node := range re.Scene.GetVisibleNodes() {
+		if node.Mesh == nil || node.Mesh.DrawMode != scene.DrawTriangles {
+			continue
+		}
+		lines, ok := re.normalMeshes[node.Mesh]
+		if !ok {
+			lines = scene.CreateNormalLines(node.Mesh, re.normalLength)
+			re.normalMeshes[node.Mesh] = lines
+		}
+		worldMat := node.GetWorldMatrix()
+		re.gl.DrawMesh(lines, worldMat.Mul(view).Mul(proj), worldMat)
+		re.frame.passes.Debug++
 	}
 }
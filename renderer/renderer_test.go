@@ -0,0 +1,375 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+
+	"render-engine/core"
+	"render-engine/math"
+	"render-engine/scene"
+)
+
+func TestInstancedNodeStats(t *testing.T) {
+	const n = 5
+	instances := make([]math.Mat4, n)
+	for i := range instances {
+		instances[i] = math.Mat4Translation(math.Vec3{X: float32(i) * 10, Y: 0, Z: 0})
+	}
+	cube := scene.CreateCube(1)
+	node := scene.NewInstancedNode("cubes", cube, instances)
+	node.SetPosition(math.Vec3{X: 0, Y: 0, Z: -20})
+	models := instanceWorldMatrices(node.Instances, node.GetWorldMatrix())
+
+	// Culling off: every instance is drawn and counted as one object
+	var stats drawStats
+	drawn, _ := cullInstances(cube, models, nil, nil, &stats)
+	if len(drawn) != n || stats.objects != n || stats.culled != 0 {
+		t.Fatalf("no culling: expected %d drawn objects, got %d drawn, stats %+v", n, len(drawn), stats)
+	}
+	if stats.vertices != n*len(cube.Vertices) || stats.triangles != n*len(cube.Indices)/3 {
+		t.Errorf("no culling: vertex/triangle counts %+v not scaled by %d instances", stats, n)
+	}
+
+	// A narrow camera at the origin looking down -Z sees only the first cube;
+	// the rest are culled one by one
+	cam := scene.NewCamera(0.5, 1, 0.1, 100)
+	frustum := scene.FrustumFromVP(cam.GetViewMatrix().Mul(cam.GetProjectionMatrix()))
+	stats = drawStats{}
+	drawn, variants := cullInstances(cube, models, []uint32{3, 1, 2, 1, 0}, &frustum, &stats)
+	if len(drawn) != 1 || stats.objects != 1 || stats.culled != n-1 {
+		t.Errorf("culling: expected 1 drawn and %d culled, got %d drawn, stats %+v", n-1, len(drawn), stats)
+	}
+	// Material indices are culled with their instances
+	if len(variants) != 1 || variants[0] != 3 {
+		t.Errorf("culling: expected the first instance's variant [3], got %v", variants)
+	}
+}
+
+func TestStatsPerMaterial(t *testing.T) {
+	red, blue := scene.CreateCube(1), scene.CreateCube(1)
+	red.Material = &scene.Material{Name: "red", Albedo: core.ColorRed}
+	blue.Material = &scene.Material{Name: "blue", Albedo: core.ColorBlue}
+
+	var stats drawStats
+	for _, m := range []*scene.Mesh{red, blue, red} {
+		stats.add(m, 1)
+		stats.call(&stats.passes.Scene, m.Material)
+	}
+	stats.call(&stats.passes.Instanced, blue.Material)
+	stats.passes.Shadow += 3
+
+	re := &RenderEngine{frame: stats}
+	got := re.Stats()
+	if len(got.Materials) != 2 || got.Materials[red.Material] != 2 || got.Materials[blue.Material] != 2 {
+		t.Errorf("expected 2 materials with 2 draws each, got %v", got.Materials)
+	}
+	want := PassDrawCalls{Scene: 3, Shadow: 3, Instanced: 1}
+	if got.Passes != want || got.Objects != 3 {
+		t.Errorf("expected passes %+v and 3 objects, got %+v", want, got)
+	}
+
+	// The map handed out is the caller's to keep
+	got.Materials[red.Material] = 0
+	if re.Stats().Materials[red.Material] != 2 {
+		t.Error("Stats returned the engine's own material map")
+	}
+	if objects, _, _, _ := re.DrawStats(); objects != 3 {
+		t.Errorf("DrawStats: expected 3 objects, got %d", objects)
+	}
+}
+
+func TestSortByMaterial(t *testing.T) {
+	red := &scene.Material{Name: "red"}
+	blue := &scene.Material{Name: "blue"}
+	node := func(name string, mat *scene.Material) *scene.Node {
+		n := scene.NewNode(name)
+		n.Mesh = scene.CreateCube(1)
+		n.Mesh.Material = mat
+		return n
+	}
+	nodes := []*scene.Node{
+		node("r1", red), node("b1", blue), node("n1", nil),
+		node("r2", red), scene.NewNode("empty"), node("b2", blue),
+	}
+
+	sortByMaterial(nodes)
+	var got []string
+	for _, n := range nodes {
+		got = append(got, n.Name)
+	}
+	want := "r1 r2 b1 b2 n1 empty"
+	if strings.Join(got, " ") != want {
+		t.Errorf("expected %q, got %q", want, strings.Join(got, " "))
+	}
+}
+
+func TestSortBackToFront(t *testing.T) {
+	draw := func(name string, z float32) transparentDraw {
+		return transparentDraw{node: scene.NewNode(name), model: math.Mat4Translation(math.Vec3{X: 0, Y: 0, Z: z})}
+	}
+	draws := []transparentDraw{draw("near", -2), draw("far", -10), draw("tie", 2), draw("mid", -5)}
+
+	sortBackToFront(draws, math.Vec3Zero)
+	var got []string
+	for _, d := range draws {
+		got = append(got, d.node.Name)
+	}
+	want := "far mid near tie"
+	if strings.Join(got, " ") != want {
+		t.Errorf("expected %q, got %q", want, strings.Join(got, " "))
+	}
+}
+
+func TestClearColorOverride(t *testing.T) {
+	re := &RenderEngine{Scene: scene.NewScene()}
+	re.Scene.SkyColor = core.ColorBlue
+	if c := re.frameClearColor(); c != core.ColorBlue {
+		t.Errorf("default: expected the sky colour, got %v", c)
+	}
+
+	re.SetClearColor(core.ColorBlack)
+	if c := re.frameClearColor(); c != core.ColorBlack {
+		t.Errorf("override: expected black, got %v", c)
+	}
+
+	re.ResetClearColor()
+	if c := re.frameClearColor(); c != core.ColorBlue {
+		t.Errorf("reset: expected the sky colour again, got %v", c)
+	}
+}
+
+func TestSyncFramebufferSize(t *testing.T) {
+	w, h := 800, 600
+	var resizes [][2]int
+	re := &RenderEngine{
+		fbWidth:       800,
+		fbHeight:      600,
+		fbSize:        func() (int, int) { return w, h },
+		resizeTargets: func(width, height int) { resizes = append(resizes, [2]int{width, height}) },
+	}
+
+	// Unchanged size: draw, no resize
+	if !re.syncFramebufferSize() || len(resizes) != 0 {
+		t.Fatalf("same size: expected a frame and no resize, got %v", resizes)
+	}
+
+	// New size: resized once, and not again on the next frame
+	w, h = 1024, 768
+	re.syncFramebufferSize()
+	re.syncFramebufferSize()
+	if len(resizes) != 1 || resizes[0] != [2]int{1024, 768} {
+		t.Fatalf("resize: expected one resize to 1024x768, got %v", resizes)
+	}
+	if re.fbWidth != 1024 || re.fbHeight != 768 {
+		t.Errorf("resize: expected fbWidth/fbHeight 1024x768, got %dx%d", re.fbWidth, re.fbHeight)
+	}
+
+	// Minimised: the frame is skipped and the targets keep their size
+	w, h = 0, 0
+	if re.syncFramebufferSize() {
+		t.Error("minimised: expected the frame to be skipped")
+	}
+	if len(resizes) != 1 || re.fbWidth != 1024 || re.fbHeight != 768 {
+		t.Errorf("minimised: expected no resize, got %v and %dx%d", resizes, re.fbWidth, re.fbHeight)
+	}
+}
+
+func TestLineQueue(t *testing.T) {
+	re := &RenderEngine{}
+	path := []math.Vec3{{X: 0, Y: 0, Z: 0}, {X: 1, Y: 0, Z: 0}, {X: 1, Y: 1, Z: 0}, {X: 1, Y: 1, Z: 1}}
+	re.DrawLines(path, core.ColorRed)
+	re.DrawLine(math.Vec3{}, math.Vec3{X: 0, Y: 5, Z: 0}, core.ColorBlue)
+	re.DrawLines(path[:1], core.ColorRed) // a single point is no segment
+
+	const segments = 3 + 1
+	if got := len(re.lineQueue); got != 2*segments {
+		t.Fatalf("expected %d queued vertices (2 per segment), got %d", 2*segments, got)
+	}
+	if a, b := re.lineQueue[2].Position, re.lineQueue[3].Position; a != path[1] || b != path[2] {
+		t.Errorf("second polyline segment: expected %v–%v, got %v–%v", path[1], path[2], a, b)
+	}
+	if c := re.lineQueue[7].Color; c != core.ColorBlue {
+		t.Errorf("DrawLine colour: expected blue, got %v", c)
+	}
+}
+
+func TestShadowCasters(t *testing.T) {
+	cube := scene.CreateCube(1)
+	wall, glass, hidden, lines := scene.NewNode("wall"), scene.NewNode("glass"), scene.NewNode("hidden"), scene.NewNode("lines")
+	for _, n := range []*scene.Node{wall, glass, hidden} {
+		n.Mesh = cube
+	}
+	glass.CastShadow = false
+	hidden.Layers = scene.LayerShadowOnly
+	lines.Mesh = scene.CreateUnitBoxWireframe()
+	nodes := []*scene.Node{wall, glass, hidden, lines}
+
+	got := shadowCasters(nodes, scene.LayerAll)
+	if len(got) != 2 || got[0] != wall || got[1] != hidden {
+		t.Errorf("expected wall and the shadow-only node to cast, got %v", nodeNames(got))
+	}
+	if got := shadowCasters(nodes, scene.LayerShadowOnly); len(got) != 1 || got[0] != hidden {
+		t.Errorf("masked to LayerShadowOnly: expected only the hidden caster, got %v", nodeNames(got))
+	}
+}
+
+func nodeNames(nodes []*scene.Node) []string {
+	names := make([]string, len(nodes))
+	for i, n := range nodes {
+		names[i] = n.Name
+	}
+	return names
+}
+
+func TestMotionPrev(t *testing.T) {
+	prevVP := math.Mat4Translation(math.Vec3{X: 1, Y: 0, Z: 0})
+	model := math.Mat4Translation(math.Vec3{X: 0, Y: 2, Z: 0})
+	moved := math.Mat4Translation(math.Vec3{X: 0, Y: 1, Z: 0})
+	nodeVP := math.Mat4Translation(math.Vec3{X: 3, Y: 0, Z: 0})
+
+	// Never drawn: static, moves only with the camera
+	node := scene.NewNode("n")
+	if m, vp := motionPrev(node, model, prevVP, 5); m != model || vp != prevVP {
+		t.Errorf("new node: expected current model and camera's previous VP, got %v, %v", m, vp)
+	}
+
+	// Drawn last frame: its own recorded matrices
+	node.Motion = scene.MotionState{Model: moved, ViewProj: nodeVP, Frame: 4}
+	if m, vp := motionPrev(node, model, prevVP, 5); m != moved || vp != nodeVP {
+		t.Errorf("recorded node: expected recorded matrices, got %v, %v", m, vp)
+	}
+
+	// Recorded longer ago (hidden or culled in between): static again
+	if m, vp := motionPrev(node, model, prevVP, 6); m != model || vp != prevVP {
+		t.Errorf("stale record: expected current model and camera's previous VP, got %v, %v", m, vp)
+	}
+}
+
+func TestDecalProjector(t *testing.T) {
+	near := func(a, b math.Vec3) bool { return a.Sub(b).Length() < 1e-5 }
+
+	// A 2×2 decal on a wall facing +Z at (1, 2, 3), 1 unit deep
+	d := &Decal{Position: math.Vec3{X: 1, Y: 2, Z: 3}, Normal: math.Vec3{X: 0, Y: 0, Z: 1}, Size: 2, Depth: 1}
+	p := d.Projector()
+	cases := []struct{ local, world math.Vec3 }{
+		{math.Vec3{}, d.Position},                                    // box centre
+		{math.Vec3{X: 1, Y: 0, Z: 0}, math.Vec3{X: 2, Y: 2, Z: 3}},   // texture right edge: +X, upright
+		{math.Vec3{X: 0, Y: 1, Z: 0}, math.Vec3{X: 1, Y: 3, Z: 3}},   // texture top edge: world up
+		{math.Vec3{X: 0, Y: 0, Z: 1}, math.Vec3{X: 1, Y: 2, Z: 3.5}}, // front face: half the depth out along the normal
+	}
+	for _, c := range cases {
+		if got := p.MulVec3(c.local); !near(got, c.world) {
+			t.Errorf("local %v: expected world %v, got %v", c.local, c.world, got)
+		}
+		if got := p.Inverse().MulVec3(c.world); !near(got, c.local) {
+			t.Errorf("world %v: expected local %v, got %v", c.world, c.local, got)
+		}
+	}
+
+	// Floor decal: the normal is parallel to world up and still gets a basis
+	d.Normal = math.Vec3{X: 0, Y: 1, Z: 0}
+	if got := d.Projector().MulVec3(math.Vec3{X: 0, Y: 0, Z: 1}); !near(got, math.Vec3{X: 1, Y: 2.5, Z: 3}) {
+		t.Errorf("floor decal: expected the box front at y = 2.5, got %v", got)
+	}
+
+	// Tracking: RemoveDecal drops only the given decal
+	re := &RenderEngine{}
+	a, b := &Decal{}, &Decal{}
+	re.decals = []*Decal{a, b}
+	re.RemoveDecal(a)
+	if ds := re.Decals(); len(ds) != 1 || ds[0] != b {
+		t.Errorf("expected only the second decal to remain, got %v", ds)
+	}
+}
+
+func TestOutlineTracking(t *testing.T) {
+	re := &RenderEngine{}
+	node := scene.NewNode("selected")
+	node.Mesh = scene.CreateCube(1)
+	orange := core.Color{R: 1, G: 0.5, B: 0, A: 1}
+
+	re.SetOutline(node, orange, 3)
+	if re.OutlineNode() != node || !re.outlined(node) {
+		t.Fatalf("expected %q to be outlined", node.Name)
+	}
+	if re.outline.color != orange || re.outline.thickness != 3 {
+		t.Errorf("expected colour %v and thickness 3, got %+v", orange, re.outline)
+	}
+	other := scene.NewNode("other")
+	other.Mesh = node.Mesh
+	if re.outlined(other) {
+		t.Error("only the selected node should be outlined")
+	}
+
+	// Nodes without a mesh are tracked but not drawn
+	re.SetOutline(scene.NewNode("empty"), orange, 3)
+	if re.OutlineNode() == nil || re.outlined(re.OutlineNode()) {
+		t.Error("a node without a mesh should be tracked but not outlined")
+	}
+
+	// nil or zero thickness clears it
+	re.SetOutline(node, orange, 3)
+	re.SetOutline(nil, orange, 3)
+	if re.OutlineNode() != nil {
+		t.Error("SetOutline(nil) should clear the outline")
+	}
+	re.SetOutline(node, orange, 0)
+	if re.OutlineNode() != nil {
+		t.Error("zero thickness should clear the outline")
+	}
+}
+
+func TestUploadQueueOrder(t *testing.T) {
+	// Two nodes share a mesh whose material has one texture: it is queued
+	// once, texture first
+	tex := &scene.Texture{Name: "albedo"}
+	mesh := scene.CreateCube(1)
+	mesh.Material = scene.NewMaterial("m", core.ColorWhite)
+	mesh.Material.AlbedoTexture = tex
+	root := scene.NewNode("root")
+	root.Mesh = mesh
+	child := scene.NewNode("child")
+	child.Mesh = mesh
+	root.AddChild(child)
+
+	re := &RenderEngine{}
+	re.QueueUpload(root)
+	re.QueueMeshUpload(mesh)
+	items := re.uploads.items
+	if len(items) != 2 || items[0].tex != tex || items[1].mesh != mesh {
+		t.Fatalf("queue: expected [texture, mesh], got %+v", items)
+	}
+}
+
+func TestEmitterCulling(t *testing.T) {
+	// Camera at the origin looking down -Z
+	cam := scene.NewCamera(1.0, 1, 0.1, 100)
+	frustum := cam.Frustum()
+	eye := cam.Position
+
+	e := scene.NewParticleEmitter(16)
+	e.Position = math.Vec3{X: 0, Y: 0, Z: -10}
+	if _, ok := emitterVisible(e, eye, &frustum); !ok {
+		t.Error("emitter ahead of the camera: expected visible")
+	}
+
+	// Behind the camera, further than any particle can travel
+	e.Position = math.Vec3{X: 0, Y: 0, Z: 30}
+	if _, ok := emitterVisible(e, eye, &frustum); ok {
+		t.Error("emitter behind the camera: expected culled")
+	}
+	if _, ok := emitterVisible(e, eye, nil); !ok {
+		t.Error("frustum culling off: expected visible")
+	}
+
+	// CullDistance culls beyond it and fades over its last quarter
+	e.Position = math.Vec3{X: 0, Y: 0, Z: -35}
+	e.CullDistance = 40
+	if fade, ok := emitterVisible(e, eye, &frustum); !ok || fade != 0.5 {
+		t.Errorf("35 of 40 units away: expected visible at fade 0.5, got %v %g", ok, fade)
+	}
+	e.Position = math.Vec3{X: 0, Y: 0, Z: -45}
+	if _, ok := emitterVisible(e, eye, &frustum); ok {
+		t.Error("beyond CullDistance: expected culled")
+	}
+}
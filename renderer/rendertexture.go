@@ -0,0 +1,74 @@
+package renderer
+
+import (
+	"fmt"
+
+	"render-engine/internal/opengl"
+	"render-engine/scene"
+)
+
+// offscreenView is the cached render target and texture for one camera.
+type offscreenView struct {
+	target  *opengl.RenderTarget
+	texture *scene.Texture
+}
+
+// RenderToTexture renders the scene from cam into an off-screen width×height
+// target and returns it as a texture that can be assigned to a material's
+// AlbedoTexture (security monitors, minimaps, portals).
+//
+// The target is cached per camera, so calling this every frame reuses the
+// same FBO and returns the same *scene.Texture. Call it before Render: it
+// runs its own shadow pass and leaves the shader's per-frame uniforms set
+// for cam. Meshes that sample the returned texture are skipped during the
+// pass to avoid a feedback loop. The output is LDR and not post-processed.
+func (re *RenderEngine) RenderToTexture(cam *scene.Camera, width, height int) (*scene.Texture, error) {
+	if re.Scene == nil || cam == nil {
+		return nil, fmt.Errorf("render to texture: no scene or camera")
+	}
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("render to texture: invalid size %dx%d", width, height)
+	}
+
+	if re.offscreen == nil {
+		re.offscreen = make(map[*scene.Camera]*offscreenView)
+	}
+	ov := re.offscreen[cam]
+	if ov == nil {
+		rt, err := opengl.NewRenderTarget(width, height)
+		if err != nil {
+			return nil, fmt.Errorf("render to texture: %w", err)
+		}
+		ov = &offscreenView{
+			target:  rt,
+			texture: &scene.Texture{Name: "render-target"},
+		}
+		re.offscreen[cam] = ov
+	} else if err := ov.target.Resize(width, height); err != nil {
+		delete(re.offscreen, cam)
+		return nil, fmt.Errorf("render to texture: %w", err)
+	}
+	ov.texture.Width = width
+	ov.texture.Height = height
+	ov.texture.GLID = ov.target.ColorTex
+
+	if cam.AspectRatio != float32(width)/float32(height) {
+		cam.UpdateAspectRatio(float32(width), float32(height))
+	}
+
+	re.gl.SetRenderTarget(ov.target)
+	re.renderView(cam, ov.texture)
+	re.gl.SetRenderTarget(nil)
+
+	return ov.texture, nil
+}
+
+// ReleaseRenderTexture frees the off-screen target cached for cam. Textures
+// previously returned for cam become invalid.
+func (re *RenderEngine) ReleaseRenderTexture(cam *scene.Camera) {
+	if ov, ok := re.offscreen[cam]; ok {
+		ov.target.Destroy()
+		ov.texture.GLID = 0
+		delete(re.offscreen, cam)
+	}
+}
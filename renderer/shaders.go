@@ -1,3 +0,0 @@
-package renderer
-// Shaders are now compiled by the opengl package at runtime.
-// This file is intentionally empty.
@@ -0,0 +1,32 @@
+package renderer
+
+// StencilTest selects which pixels later draws may touch relative to a mask
+// drawn with BeginStencilMask.
+type StencilTest int
+
+const (
+	StencilOff     StencilTest = iota // no stencil test (default)
+	StencilInside                     // draw only where the mask value equals ref
+	StencilOutside                    // draw only where it differs from ref
+)
+
+// BeginStencilMask starts a stencil mask: meshes drawn until EndStencilMask
+// (with DrawMeshWithMaterial and friends, after Render) write ref into the
+// stencil where they are visible and leave colour and depth alone. ref is 1
+// to 127; the top stencil bit belongs to the selection outline. Masks are
+// cleared with the frame. Use SetStencilTest to draw inside or outside the
+// mask, e.g. the other side of a portal or a masked UI panel.
+func (re *RenderEngine) BeginStencilMask(ref uint8) {
+	re.gl.BeginStencilMask(ref)
+}
+
+// EndStencilMask finishes the mask and restores normal drawing.
+func (re *RenderEngine) EndStencilMask() {
+	re.gl.EndStencilMask()
+}
+
+// SetStencilTest limits the following draws to pixels inside or outside the
+// mask with value ref, until it is set back to StencilOff or the next Render.
+func (re *RenderEngine) SetStencilTest(test StencilTest, ref uint8) {
+	re.gl.SetStencilTest(test != StencilOff, test == StencilInside, ref)
+}
@@ -0,0 +1,121 @@
+package renderer
+
+import (
+	"fmt"
+
+	"render-engine/internal/opengl"
+	"render-engine/scene"
+)
+
+// uploadQueue holds meshes and textures waiting for ProcessUploadQueue, in
+// upload order: each mesh's textures come just before it.
+type uploadQueue struct {
+	items  []uploadItem
+	queued map[any]bool // dedupes shared meshes and textures
+}
+
+type uploadItem struct {
+	mesh *scene.Mesh
+	tex  *scene.Texture
+}
+
+func (q *uploadQueue) addTexture(tex *scene.Texture) {
+	if tex == nil || tex.GLID != 0 || q.queued[tex] {
+		return
+	}
+	q.queued[tex] = true
+	q.items = append(q.items, uploadItem{tex: tex})
+}
+
+func (q *uploadQueue) addMesh(mesh *scene.Mesh) {
+	if mesh == nil || q.queued[mesh] {
+		return
+	}
+	q.queued[mesh] = true
+	if mat := mesh.Material; mat != nil {
+		q.addTexture(mat.AlbedoTexture)
+		q.addTexture(mat.NormalTexture)
+		q.addTexture(mat.MetallicRoughnessTexture)
+		q.addTexture(mat.EmissiveTexture)
+		q.addTexture(mat.LightmapTexture)
+		q.addTexture(mat.SpecularTexture)
+		q.addTexture(mat.OpacityTexture)
+		q.addTexture(mat.OcclusionTexture)
+	}
+	q.items = append(q.items, uploadItem{mesh: mesh})
+}
+
+func (q *uploadQueue) addNode(n *scene.Node) {
+	q.addMesh(n.Mesh)
+	for _, c := range n.Children {
+		q.addNode(c)
+	}
+}
+
+// QueueUpload queues the meshes and material textures of the given node
+// trees (typically a GLTFResult's Roots) for ProcessUploadQueue. Together
+// they move a model loaded with scene.LoadGLTFAsync onto the GPU a little at
+// a time, instead of all on the first frame that draws it. Must be called
+// from the main thread.
+func (re *RenderEngine) QueueUpload(roots ...*scene.Node) {
+	re.initUploadQueue()
+	for _, n := range roots {
+		if n != nil {
+			re.uploads.addNode(n)
+		}
+	}
+}
+
+// QueueMeshUpload queues meshes (typically from scene.LoadOBJAsync) and
+// their material textures for ProcessUploadQueue.
+func (re *RenderEngine) QueueMeshUpload(meshes ...*scene.Mesh) {
+	re.initUploadQueue()
+	for _, m := range meshes {
+		re.uploads.addMesh(m)
+	}
+}
+
+func (re *RenderEngine) initUploadQueue() {
+	if re.uploads.queued == nil {
+		re.uploads.queued = make(map[any]bool)
+	}
+}
+
+// ProcessUploadQueue uploads up to UploadsPerFrame queued meshes and
+// textures and returns how many are still waiting; call it once a frame on
+// the main thread until it returns 0. Nodes whose data hasn't been uploaded
+// yet still render (their meshes upload on first draw, untextured until
+// their textures arrive), so they can be added to the scene before the
+// queue drains if the hitch is acceptable. A texture that fails to upload
+// is dropped from the queue and its error returned.
+func (re *RenderEngine) ProcessUploadQueue() (int, error) {
+	q := &re.uploads
+	budget := re.UploadsPerFrame
+	if budget <= 0 {
+		budget = 1
+	}
+	var err error
+	n := 0
+	for ; n < len(q.items) && n < budget; n++ {
+		it := q.items[n]
+		if it.tex != nil {
+			delete(q.queued, it.tex)
+			if it.tex.GLID != 0 {
+				continue
+			}
+			if e := opengl.UploadTexture(it.tex); e != nil {
+				err = fmt.Errorf("upload %q: %w", it.tex.Name, e)
+				n++
+				break
+			}
+			continue
+		}
+		delete(q.queued, it.mesh)
+		re.gl.UploadMesh(it.mesh)
+	}
+	q.items = q.items[n:]
+	if len(q.items) == 0 {
+		q.items = nil
+	}
+	return len(q.items), err
+}
@@ -0,0 +1,84 @@
+package scene
+
+import (
+	stdmath "math"
+
+	"render-engine/math"
+)
+
+// EmptyAABB returns a box containing nothing: Min is +Inf and Max is -Inf,
+// so merging or expanding it yields exactly what was added. Use it to start
+// a union.
+func EmptyAABB() AABB {
+	inf := float32(stdmath.Inf(1))
+	return AABB{
+		Min: math.Vec3{X: inf, Y: inf, Z: inf},
+		Max: math.Vec3{X: -inf, Y: -inf, Z: -inf},
+	}
+}
+
+// IsEmpty reports whether box contains no points (Min > Max on some axis),
+// as EmptyAABB and unions of nothing do.
+func (box AABB) IsEmpty() bool {
+	return box.Min.X > box.Max.X || box.Min.Y > box.Max.Y || box.Min.Z > box.Max.Z
+}
+
+// Merge returns the smallest box containing both box and other. Empty boxes
+// add nothing.
+func (box AABB) Merge(other AABB) AABB {
+	if other.IsEmpty() {
+		return box
+	}
+	if box.IsEmpty() {
+		return other
+	}
+	return box.ExpandToInclude(other.Min).ExpandToInclude(other.Max)
+}
+
+// ExpandToInclude returns box grown just enough to contain p.
+func (box AABB) ExpandToInclude(p math.Vec3) AABB {
+	box.Min = math.Vec3{X: min(box.Min.X, p.X), Y: min(box.Min.Y, p.Y), Z: min(box.Min.Z, p.Z)}
+	box.Max = math.Vec3{X: max(box.Max.X, p.X), Y: max(box.Max.Y, p.Y), Z: max(box.Max.Z, p.Z)}
+	return box
+}
+
+// Contains reports whether p lies inside box; points on its faces count.
+func (box AABB) Contains(p math.Vec3) bool {
+	return p.X >= box.Min.X && p.X <= box.Max.X &&
+		p.Y >= box.Min.Y && p.Y <= box.Max.Y &&
+		p.Z >= box.Min.Z && p.Z <= box.Max.Z
+}
+
+// Center returns the midpoint of box. Meaningless for an empty box.
+func (box AABB) Center() math.Vec3 {
+	return box.Min.Add(box.Max).Mul(0.5)
+}
+
+// Size returns the extent of box along each axis. Meaningless for an empty
+// box.
+func (box AABB) Size() math.Vec3 {
+	return box.Max.Sub(box.Min)
+}
+
+// Transform returns the world-space box enclosing box transformed by m,
+// found by transforming its 8 corners. An empty box stays empty.
+func (box AABB) Transform(m math.Mat4) AABB {
+	if box.IsEmpty() {
+		return box
+	}
+	out := EmptyAABB()
+	for i := 0; i < 8; i++ {
+		c := box.Min
+		if i&1 != 0 {
+			c.X = box.Max.X
+		}
+		if i&2 != 0 {
+			c.Y = box.Max.Y
+		}
+		if i&4 != 0 {
+			c.Z = box.Max.Z
+		}
+		out = out.ExpandToInclude(m.MulVec3(c))
+	}
+	return out
+}
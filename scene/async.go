@@ -0,0 +1,58 @@
+package scene
+
+// Background loading
+//
+// LoadGLTFAsync and LoadOBJAsync run the synchronous loaders on a new
+// goroutine so a large model doesn't stall the frame loop. Loading is pure
+// CPU work — file I/O, image decoding, building vertex arrays — and never
+// touches OpenGL, whose calls are only valid on the thread that owns the
+// context. The threading contract is:
+//
+//   - The loaded meshes, materials and textures belong to the loader
+//     goroutine until the result is received from the channel, and to the
+//     receiver afterwards; nothing else references them.
+//   - Receive on the main thread (usually with a non-blocking select in the
+//     frame loop) and upload from there: queue the result with
+//     RenderEngine.QueueUpload or QueueMeshUpload and drain it a few items a
+//     frame with ProcessUploadQueue, then add the nodes to the scene.
+//   - The scene graph is not safe for concurrent use: never hand a Scene or
+//     a node already in one to another goroutine.
+//
+// Each channel is buffered and delivers exactly one value, then is closed,
+// so an abandoned load doesn't leak its goroutine.
+
+// GLTFLoad is the outcome of LoadGLTFAsync.
+type GLTFLoad struct {
+	Path   string
+	Result *GLTFResult // nil when Err is set
+	Err    error
+}
+
+// OBJLoad is the outcome of LoadOBJAsync.
+type OBJLoad struct {
+	Path   string
+	Meshes []*Mesh // nil when Err is set
+	Err    error
+}
+
+// LoadGLTFAsync loads path as LoadGLTF does, on a background goroutine.
+func LoadGLTFAsync(path string) <-chan GLTFLoad {
+	ch := make(chan GLTFLoad, 1)
+	go func() {
+		defer close(ch)
+		res, err := LoadGLTF(path)
+		ch <- GLTFLoad{Path: path, Result: res, Err: err}
+	}()
+	return ch
+}
+
+// LoadOBJAsync loads path as LoadOBJ does, on a background goroutine.
+func LoadOBJAsync(path string) <-chan OBJLoad {
+	ch := make(chan OBJLoad, 1)
+	go func() {
+		defer close(ch)
+		meshes, err := LoadOBJ(path)
+		ch <- OBJLoad{Path: path, Meshes: meshes, Err: err}
+	}()
+	return ch
+}
@@ -6,7 +6,10 @@ import (
 	reMath "render-engine/math"
 )
 
-// Camera represents a view camera
+// Camera represents a view camera. FOV (vertical, radians) and AspectRatio
+// are read directly, the clip planes also through Near and Far; change them
+// with SetFOV, UpdateAspectRatio and SetClipPlanes, which rebuild the cached
+// projection.
 type Camera struct {
 	Position    reMath.Vec3
 	Rotation    reMath.Quaternion
@@ -14,6 +17,10 @@ type Camera struct {
 	AspectRatio float32
 	NearPlane   float32
 	FarPlane    float32
+
+	// ReverseZ selects a reverse-Z projection (near → depth 1, far → 0).
+	// Set via SetReverseZ; the renderer keeps it in sync with its depth mode.
+	ReverseZ bool
 	
 	// Cached matrices
 	viewMatrix       reMath.Mat4
@@ -41,6 +48,35 @@ func (c *Camera) UpdateAspectRatio(width, height float32) {
 	}
 }
 
+// SetFOV sets the vertical field of view in radians.
+func (c *Camera) SetFOV(fov float32) {
+	c.FOV = fov
+	c.dirty = true
+}
+
+// SetClipPlanes sets the near and far clip distances, e.g. to push the far
+// plane out for large worlds. Ignored unless 0 < near < far.
+func (c *Camera) SetClipPlanes(near, far float32) {
+	if near <= 0 || far <= near {
+		return
+	}
+	c.NearPlane = near
+	c.FarPlane = far
+	c.dirty = true
+}
+
+// Near returns the near clip distance.
+func (c *Camera) Near() float32 { return c.NearPlane }
+
+// Far returns the far clip distance.
+func (c *Camera) Far() float32 { return c.FarPlane }
+
+// SetReverseZ switches between the standard and reverse-Z projection.
+func (c *Camera) SetReverseZ(enabled bool) {
+	c.ReverseZ = enabled
+	c.dirty = true
+}
+
 func (c *Camera) SetPosition(pos reMath.Vec3) {
 	c.Position = pos
 	c.dirty = true
@@ -89,8 +125,9 @@ func (c *Camera) GetViewProjectionMatrix() reMath.Mat4 {
 	return c.viewProjMatrix
 }
 
+// GetForward returns the view direction. The camera looks down its local -Z.
 func (c *Camera) GetForward() reMath.Vec3 {
-	return c.Rotation.RotateVector(reMath.Vec3Front)
+	return c.Rotation.RotateVector(reMath.Vec3Front.Negate())
 }
 
 func (c *Camera) GetRight() reMath.Vec3 {
@@ -101,27 +138,151 @@ func (c *Camera) GetUp() reMath.Vec3 {
 	return c.Rotation.RotateVector(reMath.Vec3Up)
 }
 
+// WorldToScreen projects world point p to screen pixels for a w×h viewport
+// (origin top-left, y down). visible is false when p is behind the camera,
+// outside the near/far range or off screen; sx, sy are still returned for
+// off-screen points in front of the camera so callers can clamp markers to
+// the edge.
+func (c *Camera) WorldToScreen(p reMath.Vec3, w, h float32) (sx, sy float32, visible bool) {
+	clip := c.GetViewProjectionMatrix().MulVec(p.ToVec4(1))
+	if clip.W <= 0 {
+		return 0, 0, false
+	}
+	ndcX, ndcY := clip.X/clip.W, clip.Y/clip.W
+	sx = (ndcX + 1) * 0.5 * w
+	sy = (1 - ndcY) * 0.5 * h
+	depth := c.viewDepth(p)
+	visible = ndcX >= -1 && ndcX <= 1 && ndcY >= -1 && ndcY <= 1 &&
+		depth >= c.NearPlane && depth <= c.FarPlane
+	return sx, sy, visible
+}
+
+// ScreenToWorld returns the world point under screen pixel (sx, sy) of a w×h
+// viewport (origin top-left, y down) at depth world units in front of the
+// camera, measured along the view direction.
+func (c *Camera) ScreenToWorld(sx, sy, depth, w, h float32) reMath.Vec3 {
+	return c.unprojectAtDepth(c.GetViewProjectionMatrix().Inverse(), 2*sx/w-1, 1-2*sy/h, depth)
+}
+
+// Frustum returns the camera's world-space view frustum, extracted for its
+// depth convention (standard or reverse-Z).
+func (c *Camera) Frustum() Frustum {
+	if c.ReverseZ {
+		return FrustumFromVPReverseZ(c.GetViewProjectionMatrix())
+	}
+	return FrustumFromVP(c.GetViewProjectionMatrix())
+}
+
+// PixelWorldSize returns the world-space height one pixel of an h-pixel-tall
+// viewport covers at p: the view's height at p's depth d, 2·d·tan(FOV/2),
+// divided by h. Scaling a one-unit mesh at p by n × PixelWorldSize makes it
+// n pixels tall wherever p is. Returns 0 for points behind the camera.
+func (c *Camera) PixelWorldSize(p reMath.Vec3, h float32) float32 {
+	depth := c.viewDepth(p)
+	if depth <= 0 || h <= 0 {
+		return 0
+	}
+	return 2 * depth * float32(math.Tan(float64(c.FOV)*0.5)) / h
+}
+
+// FrameBounds moves the camera back along its current view direction until
+// b fits on screen, keeping its rotation. The box's bounding sphere is
+// fitted into the narrower of the vertical and horizontal fields of view,
+// so the whole box shows at any orientation. padding enlarges the sphere
+// by that fraction of its radius (0.1 leaves a 10% margin). The clip
+// planes are widened when the box would cross them. Empty boxes are
+// ignored.
+func (c *Camera) FrameBounds(b AABB, padding float32) {
+	if b.IsEmpty() {
+		return
+	}
+	radius := b.Size().Length() * 0.5 * (1 + padding)
+	if radius <= 0 {
+		radius = c.NearPlane // a single point: just step back from it
+	}
+	halfV := float64(c.FOV) * 0.5
+	halfH := math.Atan(math.Tan(halfV) * float64(c.AspectRatio))
+	dist := radius / float32(math.Sin(math.Min(halfV, halfH)))
+
+	c.Position = b.Center().Sub(c.GetForward().Mul(dist))
+	c.dirty = true
+
+	near, far := c.NearPlane, c.FarPlane
+	if d := dist - radius; d < near {
+		near = d * 0.5
+	}
+	if d := dist + radius; d > far {
+		far = d * 1.01
+	}
+	c.SetClipPlanes(near, far)
+}
+
+// FrustumCorners returns the 8 world-space corners of the slice of the view
+// frustum between nearFrac and farFrac of the near→far range (0, 1 = the
+// whole frustum), linear in view depth as cascade splits are. Corners 0–3
+// lie on the near slice and 4–7 on the far slice, each in the order
+// bottom-left, bottom-right, top-right, top-left as seen from the camera.
+func (c *Camera) FrustumCorners(nearFrac, farFrac float32) [8]reMath.Vec3 {
+	inv := c.GetViewProjectionMatrix().Inverse()
+	span := c.FarPlane - c.NearPlane
+	depths := [2]float32{c.NearPlane + nearFrac*span, c.NearPlane + farFrac*span}
+	ndc := [4][2]float32{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}}
+
+	var corners [8]reMath.Vec3
+	for slice, depth := range depths {
+		for i, xy := range ndc {
+			corners[slice*4+i] = c.unprojectAtDepth(inv, xy[0], xy[1], depth)
+		}
+	}
+	return corners
+}
+
+// unprojectAtDepth unprojects NDC (x, y) at the near and far planes through
+// invViewProj and interpolates along that ray to the given view depth.
+func (c *Camera) unprojectAtDepth(invViewProj reMath.Mat4, ndcX, ndcY, depth float32) reMath.Vec3 {
+	nearZ, farZ := float32(-1), float32(1)
+	if c.ReverseZ {
+		nearZ, farZ = 1, 0
+	}
+	near := invViewProj.MulVec3(reMath.Vec3{X: ndcX, Y: ndcY, Z: nearZ})
+	far := invViewProj.MulVec3(reMath.Vec3{X: ndcX, Y: ndcY, Z: farZ})
+
+	dNear, dFar := c.viewDepth(near), c.viewDepth(far)
+	t := (depth - dNear) / (dFar - dNear)
+	return near.Add(far.Sub(near).Mul(t))
+}
+
+// viewDepth is the distance of p in front of the camera along its view direction.
+func (c *Camera) viewDepth(p reMath.Vec3) float32 {
+	return p.Sub(c.Position).Dot(c.GetForward())
+}
+
 func (c *Camera) updateMatrices() {
-	// Create view matrix from position and rotation
-	rotationMatrix := c.Rotation.ToMat4()
+	// Create view matrix: move the eye to the origin, then undo its rotation
+	rotationMatrix := c.Rotation.Conjugate().ToMat4()
 	translationMatrix := reMath.Mat4Translation(c.Position.Negate())
-	c.viewMatrix = rotationMatrix.Mul(translationMatrix)
+	c.viewMatrix = translationMatrix.Mul(rotationMatrix)
 	
 	// Create projection matrix
-	c.projectionMatrix = reMath.Mat4Perspective(c.FOV, c.AspectRatio, c.NearPlane, c.FarPlane)
+	if c.ReverseZ {
+		c.projectionMatrix = reMath.Mat4PerspectiveReverseZ(c.FOV, c.AspectRatio, c.NearPlane, c.FarPlane)
+	} else {
+		c.projectionMatrix = reMath.Mat4Perspective(c.FOV, c.AspectRatio, c.NearPlane, c.FarPlane)
+	}
 	
-	// View projection matrix
-	c.viewProjMatrix = c.projectionMatrix.Mul(c.viewMatrix)
+	// View projection matrix (row vectors: view first)
+	c.viewProjMatrix = c.viewMatrix.Mul(c.projectionMatrix)
 	
 	c.dirty = false
 }
 
 func (c *Camera) QuaternionFromLookAt(target, up reMath.Vec3) reMath.Quaternion {
 	forward := target.Sub(c.Position).Normalize()
-	right := up.Cross(forward).Normalize()
-	upNew := forward.Cross(right)
+	right := forward.Cross(up).Normalize()
+	upNew := right.Cross(forward)
 	
-	// Convert rotation matrix to quaternion
+	// Convert rotation matrix (columns: local X, Y, Z in world space; the
+	// camera looks down local -Z) to quaternion
 	m := reMath.Mat4{
 		{right.X, upNew.X, -forward.X, 0},
 		{right.Y, upNew.Y, -forward.Y, 0},
@@ -0,0 +1,249 @@
+package scene
+
+import (
+	stdmath "math"
+
+	"render-engine/math"
+)
+
+// ── Shared helpers ────────────────────────────────────────────────────────────
+
+// smoothFactor is the fraction of the remaining distance to a target covered
+// in dt by exponential smoothing with time constant tau. Applying it over
+// several short steps gives the same result as one long step, so smoothed
+// values converge at the same rate at any frame rate. tau <= 0 snaps.
+func smoothFactor(dt, tau float32) float32 {
+	if tau <= 0 {
+		return 1
+	}
+	return 1 - float32(stdmath.Exp(float64(-dt/tau)))
+}
+
+// yawPitchDir returns the unit direction for yaw and pitch in degrees. Yaw 0
+// faces +X and -90 faces -Z; positive pitch looks up.
+func yawPitchDir(yaw, pitch float32) math.Vec3 {
+	y := float64(yaw) * stdmath.Pi / 180
+	p := float64(pitch) * stdmath.Pi / 180
+	return math.Vec3{
+		X: float32(stdmath.Cos(y) * stdmath.Cos(p)),
+		Y: float32(stdmath.Sin(p)),
+		Z: float32(stdmath.Sin(y) * stdmath.Cos(p)),
+	}
+}
+
+// ── FlyController ─────────────────────────────────────────────────────────────
+
+// FlyInput is one frame of input for FlyController.Update, read from the
+// keyboard, mouse or gamepad by the caller.
+type FlyInput struct {
+	// Move is the wanted direction relative to the view, each axis in
+	// [-1, 1]: X strafes right, Y rises, Z moves forward.
+	Move math.Vec3
+	// LookX and LookY are mouse deltas in pixels since the last update
+	// (right and down positive).
+	LookX, LookY float32
+	// TurnX and TurnY are turn rates in [-1, 1], e.g. a gamepad stick
+	// (right and down positive), scaled by TurnRate.
+	TurnX, TurnY float32
+}
+
+// FlyController is a first-person free-fly camera with inertia and smoothed
+// mouse look. Look input moves TargetYaw/TargetPitch; Yaw/Pitch follow them
+// exponentially. Movement accelerates toward MaxSpeed in the input direction
+// and decays with Friction once input stops. Both are integrated exactly per
+// step, so the camera behaves the same at 30 and 240 FPS.
+type FlyController struct {
+	// Yaw and Pitch are the current view angles in degrees (yaw -90 faces -Z);
+	// TargetYaw and TargetPitch are where look smoothing is heading.
+	Yaw, Pitch             float32
+	TargetYaw, TargetPitch float32
+	// Velocity is the current world-space velocity in units per second.
+	Velocity math.Vec3
+
+	MaxSpeed     float32 // units/s at full input (default 6)
+	Acceleration float32 // units/s² toward the input velocity (default 40)
+	Friction     float32 // exponential velocity decay per second without input (default 10)
+
+	LookSensitivity float32 // degrees per pixel of mouse movement (default 0.1)
+	TurnRate        float32 // degrees per second at full TurnX/TurnY (default 120)
+	LookSmoothing   float32 // look time constant in seconds; 0 = no smoothing (default 0.03)
+	MaxPitch        float32 // pitch limit in degrees (default 88)
+
+	// Planar keeps movement on the XZ plane regardless of pitch and ignores
+	// Move.Y, for walking cameras that handle height themselves.
+	Planar bool
+
+	// MaxDeltaTime caps the step so hitches do not fling the camera (default 0.05).
+	MaxDeltaTime float32
+}
+
+// NewFlyController returns a controller facing -Z with the default tuning.
+func NewFlyController() *FlyController {
+	return &FlyController{
+		Yaw:             -90,
+		TargetYaw:       -90,
+		MaxSpeed:        6,
+		Acceleration:    40,
+		Friction:        10,
+		LookSensitivity: 0.1,
+		TurnRate:        120,
+		LookSmoothing:   0.03,
+		MaxPitch:        88,
+		MaxDeltaTime:    0.05,
+	}
+}
+
+// Forward returns the current view direction.
+func (fc *FlyController) Forward() math.Vec3 {
+	return yawPitchDir(fc.Yaw, fc.Pitch)
+}
+
+// Update applies one frame of input, moves cam by the resulting velocity and
+// points it along the smoothed yaw and pitch.
+func (fc *FlyController) Update(cam *Camera, in FlyInput, deltaTime float32) {
+	dt := deltaTime
+	if fc.MaxDeltaTime > 0 && dt > fc.MaxDeltaTime {
+		dt = fc.MaxDeltaTime
+	}
+	if dt <= 0 {
+		return
+	}
+
+	// Look: input moves the target, the view follows it
+	fc.TargetYaw += in.LookX*fc.LookSensitivity + in.TurnX*fc.TurnRate*dt
+	fc.TargetPitch -= in.LookY*fc.LookSensitivity + in.TurnY*fc.TurnRate*dt
+	fc.TargetPitch = clampPitch(fc.TargetPitch, fc.MaxPitch)
+	k := smoothFactor(dt, fc.LookSmoothing)
+	fc.Yaw += (fc.TargetYaw - fc.Yaw) * k
+	fc.Pitch += (fc.TargetPitch - fc.Pitch) * k
+
+	// Movement basis
+	forward := fc.Forward()
+	moveForward := forward
+	if fc.Planar {
+		moveForward = yawPitchDir(fc.Yaw, 0)
+	}
+	right := yawPitchDir(fc.Yaw+90, 0)
+
+	wish := moveForward.Mul(in.Move.Z).Add(right.Mul(in.Move.X))
+	if !fc.Planar {
+		wish = wish.Add(math.Vec3Up.Mul(in.Move.Y))
+	}
+	if l := wish.Length(); l > 1 {
+		wish = wish.Mul(1 / l)
+	}
+
+	v0 := fc.Velocity
+	fc.Velocity = fc.stepVelocity(v0, wish, dt)
+
+	// Trapezoidal step: exact while the velocity changes linearly
+	pos := cam.Position.Add(v0.Add(fc.Velocity).Mul(0.5 * dt))
+	cam.SetPosition(pos)
+	cam.LookAt(pos.Add(forward), math.Vec3Up)
+}
+
+// stepVelocity advances v by dt: toward wish × MaxSpeed at Acceleration while
+// there is input, otherwise decaying by Friction.
+func (fc *FlyController) stepVelocity(v, wish math.Vec3, dt float32) math.Vec3 {
+	if wish.Length() == 0 {
+		return v.Mul(float32(stdmath.Exp(float64(-fc.Friction * dt))))
+	}
+	diff := wish.Mul(fc.MaxSpeed).Sub(v)
+	step := fc.Acceleration * dt
+	d := diff.Length()
+	if d <= step {
+		return v.Add(diff)
+	}
+	return v.Add(diff.Mul(step / d))
+}
+
+func clampPitch(pitch, limit float32) float32 {
+	if limit <= 0 {
+		return pitch
+	}
+	if pitch > limit {
+		return limit
+	}
+	if pitch < -limit {
+		return -limit
+	}
+	return pitch
+}
+
+// ── OrbitController ───────────────────────────────────────────────────────────
+
+// OrbitInput is one frame of input for OrbitController.Update.
+type OrbitInput struct {
+	// OrbitX and OrbitY are drag deltas in pixels (right and down positive).
+	// Dragging turns the scene with the cursor.
+	OrbitX, OrbitY float32
+	// Zoom is the scroll amount this frame; positive moves closer.
+	Zoom float32
+}
+
+// OrbitController circles a camera around Target at Distance. Like
+// FlyController, input moves the Target* values and the current yaw, pitch
+// and distance follow them with frame-rate independent exponential smoothing.
+type OrbitController struct {
+	Target math.Vec3
+
+	// Current (smoothed) and target orbit angles in degrees and distance.
+	// Yaw 0 puts the camera on the +Z side of the target looking toward -Z.
+	Yaw, Pitch, Distance                   float32
+	TargetYaw, TargetPitch, TargetDistance float32
+
+	Sensitivity float32 // degrees per pixel of drag (default 0.3)
+	ZoomSpeed   float32 // fraction of the distance per zoom step (default 0.1)
+	MinDistance float32 // default 0.1
+	MaxDistance float32 // default 1000
+	MaxPitch    float32 // pitch limit in degrees (default 85)
+	Smoothing   float32 // time constant in seconds; 0 = no smoothing (default 0.08)
+}
+
+// NewOrbitController returns a controller orbiting target at distance,
+// slightly above the horizon.
+func NewOrbitController(target math.Vec3, distance float32) *OrbitController {
+	return &OrbitController{
+		Target:         target,
+		Pitch:          20,
+		Distance:       distance,
+		TargetPitch:    20,
+		TargetDistance: distance,
+		Sensitivity:    0.3,
+		ZoomSpeed:      0.1,
+		MinDistance:    0.1,
+		MaxDistance:    1000,
+		MaxPitch:       85,
+		Smoothing:      0.08,
+	}
+}
+
+// Update applies one frame of input and places cam on the orbit, looking at
+// Target.
+func (oc *OrbitController) Update(cam *Camera, in OrbitInput, deltaTime float32) {
+	oc.TargetYaw += in.OrbitX * oc.Sensitivity
+	oc.TargetPitch = clampPitch(oc.TargetPitch+in.OrbitY*oc.Sensitivity, oc.MaxPitch)
+	if in.Zoom != 0 {
+		oc.TargetDistance *= float32(stdmath.Exp(float64(-in.Zoom * oc.ZoomSpeed)))
+	}
+	if oc.TargetDistance < oc.MinDistance {
+		oc.TargetDistance = oc.MinDistance
+	}
+	if oc.MaxDistance > 0 && oc.TargetDistance > oc.MaxDistance {
+		oc.TargetDistance = oc.MaxDistance
+	}
+
+	k := float32(1)
+	if deltaTime > 0 {
+		k = smoothFactor(deltaTime, oc.Smoothing)
+	}
+	oc.Yaw += (oc.TargetYaw - oc.Yaw) * k
+	oc.Pitch += (oc.TargetPitch - oc.Pitch) * k
+	oc.Distance += (oc.TargetDistance - oc.Distance) * k
+
+	// Yaw 0 sits on +Z: the direction from the target is yaw + 90 in
+	// yawPitchDir's convention
+	pos := oc.Target.Add(yawPitchDir(oc.Yaw+90, oc.Pitch).Mul(oc.Distance))
+	cam.SetPosition(pos)
+	cam.LookAt(oc.Target, math.Vec3Up)
+}
@@ -0,0 +1,326 @@
+// Package environment holds scene-level effects that drive both the scene and
+// the render engine, such as the day/night cycle.
+package environment
+
+import (
+	"fmt"
+	stdmath "math"
+
+	"render-engine/core"
+	"render-engine/math"
+	"render-engine/renderer"
+	"render-engine/scene"
+)
+
+// Cycle positions of the default keyframes. The cycle runs 0..1 and wraps:
+// 0 is noon, 0.5 midnight.
+const (
+	KeyNoon       float32 = 0.00
+	KeyGoldenHour float32 = 0.22
+	KeyDusk       float32 = 0.30
+	KeyMidnight   float32 = 0.50
+	KeyPreDawn    float32 = 0.70
+	KeyDawn       float32 = 0.78
+)
+
+// DayKeyframe holds the sky and light state at one point of the cycle.
+type DayKeyframe struct {
+	T            float32    // cycle position 0..1
+	Zenith       core.Color // sky overhead
+	Horizon      core.Color // sky at eye level
+	Ground       core.Color // sky/ground below horizon
+	FogColor     core.Color // fog blend colour (should match the horizon)
+	FogDensity   float32
+	SunColor     core.Color
+	SunIntensity float32
+	Ambient      core.Color
+}
+
+// DefaultDayKeyframes returns the stock noon, golden hour, dusk, midnight,
+// pre-dawn and dawn keyframes.
+func DefaultDayKeyframes() []DayKeyframe {
+	return []DayKeyframe{
+		{ // noon — bright midday
+			T:            KeyNoon,
+			Zenith:       core.Color{R: 0.20, G: 0.42, B: 0.90, A: 1},
+			Horizon:      core.Color{R: 0.58, G: 0.75, B: 0.95, A: 1},
+			Ground:       core.Color{R: 0.12, G: 0.10, B: 0.08, A: 1},
+			FogColor:     core.Color{R: 0.62, G: 0.78, B: 0.95, A: 1},
+			FogDensity:   0.011,
+			SunColor:     core.Color{R: 1.00, G: 0.98, B: 0.92, A: 1},
+			SunIntensity: 1.20,
+			Ambient:      core.Color{R: 0.16, G: 0.18, B: 0.26, A: 1},
+		},
+		{ // late afternoon / golden hour
+			T:            KeyGoldenHour,
+			Zenith:       core.Color{R: 0.14, G: 0.20, B: 0.60, A: 1},
+			Horizon:      core.Color{R: 0.90, G: 0.52, B: 0.18, A: 1},
+			Ground:       core.Color{R: 0.08, G: 0.07, B: 0.06, A: 1},
+			FogColor:     core.Color{R: 0.85, G: 0.55, B: 0.25, A: 1},
+			FogDensity:   0.018,
+			SunColor:     core.Color{R: 1.00, G: 0.65, B: 0.25, A: 1},
+			SunIntensity: 0.90,
+			Ambient:      core.Color{R: 0.10, G: 0.12, B: 0.20, A: 1},
+		},
+		{ // dusk / twilight
+			T:            KeyDusk,
+			Zenith:       core.Color{R: 0.08, G: 0.10, B: 0.28, A: 1},
+			Horizon:      core.Color{R: 0.50, G: 0.22, B: 0.28, A: 1},
+			Ground:       core.Color{R: 0.04, G: 0.03, B: 0.04, A: 1},
+			FogColor:     core.Color{R: 0.35, G: 0.18, B: 0.22, A: 1},
+			FogDensity:   0.020,
+			SunColor:     core.Color{R: 0.70, G: 0.40, B: 0.55, A: 1},
+			SunIntensity: 0.25,
+			Ambient:      core.Color{R: 0.06, G: 0.07, B: 0.14, A: 1},
+		},
+		{ // midnight
+			T:            KeyMidnight,
+			Zenith:       core.Color{R: 0.02, G: 0.03, B: 0.10, A: 1},
+			Horizon:      core.Color{R: 0.04, G: 0.04, B: 0.08, A: 1},
+			Ground:       core.Color{R: 0.01, G: 0.01, B: 0.02, A: 1},
+			FogColor:     core.Color{R: 0.03, G: 0.03, B: 0.06, A: 1},
+			FogDensity:   0.010,
+			SunColor:     core.Color{R: 0.40, G: 0.45, B: 0.65, A: 1}, // moonlight
+			SunIntensity: 0.12,
+			Ambient:      core.Color{R: 0.03, G: 0.04, B: 0.09, A: 1},
+		},
+		{ // pre-dawn
+			T:            KeyPreDawn,
+			Zenith:       core.Color{R: 0.06, G: 0.08, B: 0.25, A: 1},
+			Horizon:      core.Color{R: 0.40, G: 0.18, B: 0.24, A: 1},
+			Ground:       core.Color{R: 0.03, G: 0.03, B: 0.04, A: 1},
+			FogColor:     core.Color{R: 0.30, G: 0.15, B: 0.20, A: 1},
+			FogDensity:   0.020,
+			SunColor:     core.Color{R: 0.75, G: 0.42, B: 0.60, A: 1},
+			SunIntensity: 0.20,
+			Ambient:      core.Color{R: 0.06, G: 0.07, B: 0.14, A: 1},
+		},
+		{ // sunrise / dawn
+			T:            KeyDawn,
+			Zenith:       core.Color{R: 0.12, G: 0.18, B: 0.55, A: 1},
+			Horizon:      core.Color{R: 0.88, G: 0.45, B: 0.22, A: 1},
+			Ground:       core.Color{R: 0.08, G: 0.06, B: 0.05, A: 1},
+			FogColor:     core.Color{R: 0.75, G: 0.40, B: 0.20, A: 1},
+			FogDensity:   0.015,
+			SunColor:     core.Color{R: 1.00, G: 0.60, B: 0.28, A: 1},
+			SunIntensity: 0.70,
+			Ambient:      core.Color{R: 0.09, G: 0.10, B: 0.17, A: 1},
+		},
+	}
+}
+
+// DayNightCycle animates sky, sun, ambient and fog along one timeline of
+// keyframes. Call Update once per frame to advance it and Apply to push the
+// interpolated state to the render engine and scene.
+type DayNightCycle struct {
+	Time   float32 // cycle position 0..1: 0 = noon, 0.5 = midnight
+	Speed  float32 // full-cycle duration in seconds (default 120)
+	Active bool    // auto-advance when true
+
+	// SunAngularSize is the sun/moon disc diameter in degrees, driving soft
+	// shadow penumbrae. Exaggerated from the real 0.53° so the effect reads
+	// at the shadow map's resolution.
+	SunAngularSize float32
+
+	// Keyframes, sorted by T; the cycle blends linearly between neighbours
+	// and wraps from the last back to the first.
+	Keyframes []DayKeyframe
+
+	// Lamps (see AddLamp) glow in as the sun's intensity falls from
+	// LampsStartBelow to LampsFullBelow and fade out the same way at dawn.
+	// Both are in sun-intensity units (DayKeyframe.SunIntensity), not times
+	// of day; the keyframes decide when that happens: with the defaults the
+	// lamps come on between golden hour and dusk and go off after sunrise.
+	LampsStartBelow float32
+	LampsFullBelow  float32
+
+	lamps []lamp
+}
+
+// lamp is a light switched by the cycle, with its full intensity. off
+// records that the cycle disabled it, so only those lamps are re-enabled.
+type lamp struct {
+	light     *scene.Light
+	intensity float32
+	off       bool
+}
+
+// NewDayNightCycle returns a running cycle starting at noon with the default
+// keyframes.
+func NewDayNightCycle() *DayNightCycle {
+	return &DayNightCycle{
+		Time:           KeyNoon,
+		Speed:          120,
+		Active:         true,
+		SunAngularSize: 1.5,
+		Keyframes:      DefaultDayKeyframes(),
+
+		LampsStartBelow: 0.9,
+		LampsFullBelow:  0.25,
+	}
+}
+
+// AddLamp hands l to the cycle, which ramps its intensity between zero by
+// day and its current Intensity at night, and disables it while it is off.
+func (dn *DayNightCycle) AddLamp(l *scene.Light) {
+	dn.lamps = append(dn.lamps, lamp{light: l, intensity: l.Intensity})
+}
+
+// LampLevel returns how far the lamps are on at the current time, 0 (off)
+// to 1 (full): a smoothstep of the sampled sun intensity between
+// LampsStartBelow and LampsFullBelow.
+func (dn *DayNightCycle) LampLevel() float32 {
+	sun := dn.Sample().SunIntensity
+	lo, hi := dn.LampsFullBelow, dn.LampsStartBelow
+	if hi <= lo {
+		if sun <= lo {
+			return 1
+		}
+		return 0
+	}
+	x := (hi - sun) / (hi - lo)
+	x = max(0, min(x, 1))
+	return x * x * (3 - 2*x)
+}
+
+// ApplyLamps sets every lamp's intensity for the current time; Apply calls
+// it. A lamp that is fully off is also Disabled, freeing its light slot, and
+// enabled again as it glows in. Lamps switched off by hand stay Disabled.
+func (dn *DayNightCycle) ApplyLamps() {
+	level := dn.LampLevel()
+	for i := range dn.lamps {
+		l := &dn.lamps[i]
+		l.light.Intensity = l.intensity * level
+		if level == 0 {
+			if !l.light.Disabled {
+				l.light.Disabled = true
+				l.off = true
+			}
+		} else if l.off {
+			l.light.Disabled = false
+			l.off = false
+		}
+	}
+}
+
+// Update advances the cycle by dt seconds while Active.
+func (dn *DayNightCycle) Update(dt float32) {
+	if !dn.Active || dn.Speed <= 0 {
+		return
+	}
+	dn.Time += dt / dn.Speed
+	dn.Time -= float32(stdmath.Floor(float64(dn.Time)))
+}
+
+// TimeOfDay returns the current time as hours on a 24-hour clock
+// (12 = noon, 0 = midnight).
+func (dn *DayNightCycle) TimeOfDay() float32 {
+	h := float32(stdmath.Mod(float64(dn.Time*24+12), 24))
+	if h < 0 {
+		h += 24
+	}
+	return h
+}
+
+// Clock returns the time of day as a 12-hour label such as "07:30 PM".
+func (dn *DayNightCycle) Clock() string {
+	hours := dn.TimeOfDay()
+	h := int(hours)
+	m := int((hours - float32(h)) * 60)
+	period := "AM"
+	if h >= 12 {
+		period = "PM"
+	}
+	displayH := h % 12
+	if displayH == 0 {
+		displayH = 12
+	}
+	return fmt.Sprintf("%02d:%02d %s", displayH, m, period)
+}
+
+// Sample returns the keyframe state at the current time, blended between the
+// surrounding keyframes. T of the result is the current time.
+func (dn *DayNightCycle) Sample() DayKeyframe {
+	return sampleKeyframes(dn.Keyframes, dn.Time)
+}
+
+// sampleKeyframes blends the two keyframes around t (0..1), wrapping from the
+// last keyframe to the first.
+func sampleKeyframes(keys []DayKeyframe, t float32) DayKeyframe {
+	if len(keys) == 0 {
+		return DayKeyframe{T: t}
+	}
+	// a = last keyframe at or before t; before the first one, the wrapped last
+	i := len(keys) - 1
+	for j, k := range keys {
+		if k.T <= t {
+			i = j
+		}
+	}
+	a, b := keys[i], keys[(i+1)%len(keys)]
+
+	span := b.T - a.T
+	if span <= 0 {
+		span += 1
+	}
+	local := t - a.T
+	if local < 0 {
+		local += 1
+	}
+	f := local / span
+	if len(keys) == 1 {
+		f = 0
+	}
+
+	return DayKeyframe{
+		T:            t,
+		Zenith:       a.Zenith.Lerp(b.Zenith, f),
+		Horizon:      a.Horizon.Lerp(b.Horizon, f),
+		Ground:       a.Ground.Lerp(b.Ground, f),
+		FogColor:     a.FogColor.Lerp(b.FogColor, f),
+		FogDensity:   a.FogDensity + (b.FogDensity-a.FogDensity)*f,
+		SunColor:     a.SunColor.Lerp(b.SunColor, f),
+		SunIntensity: a.SunIntensity + (b.SunIntensity-a.SunIntensity)*f,
+		Ambient:      a.Ambient.Lerp(b.Ambient, f),
+	}
+}
+
+// SunDirection returns the direction the sun's light travels at the current
+// time: straight down (tilted slightly along Z) at noon, straight up at
+// midnight, when the light stands in for the moon.
+func (dn *DayNightCycle) SunDirection() math.Vec3 {
+	angle := float64(dn.Time * 2 * stdmath.Pi)
+	return math.Vec3{
+		X: float32(stdmath.Sin(angle)),
+		Y: -float32(stdmath.Cos(angle)),
+		Z: 0.35,
+	}.Normalize()
+}
+
+// Apply pushes the current state to the engine and scene: skybox gradient
+// (which also feeds the sky IBL), fog, scene ambient and clear colour, the
+// sun light's direction, colour and intensity, and the lamps. sun may be
+// nil.
+func (dn *DayNightCycle) Apply(re *renderer.RenderEngine, s *scene.Scene, sun *scene.Light) {
+	k := dn.Sample()
+	dn.ApplyLamps()
+
+	if sun != nil {
+		sun.Direction = dn.SunDirection()
+		sun.Color = k.SunColor
+		sun.Intensity = k.SunIntensity
+		sun.AngularSize = dn.SunAngularSize
+	}
+
+	s.Ambient = k.Ambient
+	s.SkyColor = k.Horizon // fallback clear colour
+
+	re.SetSkyboxColors(k.Zenith, k.Horizon, k.Ground)
+	if sb := re.Skybox(); sb != nil {
+		// The disc follows the light's tint; the moon is the light at night
+		// but sits opposite the sun, so it keeps the skybox's own colour.
+		sb.SunColor = core.Color{R: k.SunColor.R * 10, G: k.SunColor.G * 10, B: k.SunColor.B * 10, A: 1}
+		sb.SunSize = dn.SunAngularSize
+	}
+	re.SetFog(true, k.FogDensity, k.FogColor)
+}
@@ -0,0 +1,125 @@
+package environment
+
+import (
+	"math"
+	"testing"
+
+	"render-engine/core"
+	"render-engine/scene"
+)
+
+func TestDayNightNoonKeyframe(t *testing.T) {
+	approx := func(a, b float32) bool { return math.Abs(float64(a-b)) < 1e-5 }
+
+	dn := NewDayNightCycle()
+	noon := DefaultDayKeyframes()[0]
+	if got := dn.Sample(); got != noon {
+		t.Errorf("default cycle at noon: expected %+v, got %+v", noon, got)
+	}
+	if h := dn.TimeOfDay(); h != 12 {
+		t.Errorf("expected 12:00 at noon, got %v h", h)
+	}
+	if c := dn.Clock(); c != "12:00 PM" {
+		t.Errorf("expected clock label 12:00 PM, got %q", c)
+	}
+
+	// A custom timeline: noon must come back exactly, and half way to dusk
+	// must be the midpoint
+	red := core.Color{R: 1, A: 1}
+	blue := core.Color{B: 1, A: 1}
+	dn.Keyframes = []DayKeyframe{
+		{T: KeyNoon, Zenith: red, Horizon: red, SunColor: red, SunIntensity: 2, FogDensity: 0.01},
+		{T: KeyDusk, Zenith: blue, Horizon: blue, SunColor: blue, SunIntensity: 0, FogDensity: 0.03},
+	}
+	got := dn.Sample()
+	if got != dn.Keyframes[0] {
+		t.Errorf("custom cycle at noon: expected %+v, got %+v", dn.Keyframes[0], got)
+	}
+
+	dn.Time = KeyDusk / 2
+	got = dn.Sample()
+	if !approx(got.SunIntensity, 1) || !approx(got.Zenith.R, 0.5) || !approx(got.Zenith.B, 0.5) {
+		t.Errorf("half way to dusk: expected intensity 1 and a purple zenith, got %v and %+v", got.SunIntensity, got.Zenith)
+	}
+
+	// Past the last keyframe the cycle wraps back toward noon
+	dn.Time = KeyDusk + (1-KeyDusk)/2
+	if got = dn.Sample(); !approx(got.SunIntensity, 1) {
+		t.Errorf("wrap-around: expected intensity 1, got %v", got.SunIntensity)
+	}
+
+	// Update wraps the time into [0, 1)
+	dn.Time, dn.Speed = 0.9, 10
+	dn.Update(2)
+	if !approx(dn.Time, 0.1) {
+		t.Errorf("expected time to wrap to 0.1, got %v", dn.Time)
+	}
+}
+
+func TestDayNightLamps(t *testing.T) {
+	dn := NewDayNightCycle()
+	lampA := &scene.Light{Type: scene.LightTypePoint, Intensity: 3}
+	lampB := &scene.Light{Type: scene.LightTypePoint, Intensity: 1.5}
+	dn.AddLamp(lampA)
+	dn.AddLamp(lampB)
+
+	dn.Time = KeyMidnight
+	dn.ApplyLamps()
+	if lampA.Intensity != 3 || lampB.Intensity != 1.5 || lampA.Disabled {
+		t.Errorf("midnight: expected full intensity 3 and 1.5, got %v and %v (disabled %v)", lampA.Intensity, lampB.Intensity, lampA.Disabled)
+	}
+
+	dn.Time = KeyNoon
+	dn.ApplyLamps()
+	if lampA.Intensity != 0 || lampB.Intensity != 0 || !lampA.Disabled || !lampB.Disabled {
+		t.Errorf("noon: expected lamps off and disabled, got %v and %v (disabled %v, %v)", lampA.Intensity, lampB.Intensity, lampA.Disabled, lampB.Disabled)
+	}
+
+	// Dusk falls between the thresholds: the lamps are glowing in, and the
+	// level never falls while the sun sets
+	prev := float32(0)
+	for i := 0; i <= 20; i++ {
+		dn.Time = KeyNoon + (KeyMidnight-KeyNoon)*float32(i)/20
+		level := dn.LampLevel()
+		if level < prev-1e-6 {
+			t.Errorf("t=%v: lamp level fell from %v to %v during sunset", dn.Time, prev, level)
+		}
+		prev = level
+	}
+	dn.Time = (KeyGoldenHour + KeyDusk) / 2
+	if level := dn.LampLevel(); level <= 0 || level >= 1 {
+		t.Errorf("between golden hour and dusk: expected a partial lamp level, got %v", level)
+	}
+	dn.ApplyLamps()
+	if lampA.Disabled || lampA.Intensity <= 0 || lampA.Intensity >= 3 {
+		t.Errorf("between golden hour and dusk: expected lamp A part lit, got %v (disabled %v)", lampA.Intensity, lampA.Disabled)
+	}
+}
+
+func TestDayNightLampSwitchedOffByHand(t *testing.T) {
+	dn := NewDayNightCycle()
+	lamp := &scene.Light{Type: scene.LightTypePoint, Intensity: 2}
+	dn.AddLamp(lamp)
+
+	lamp.Disabled = true
+	for _, tm := range []float32{KeyDusk, KeyMidnight, KeyNoon, KeyMidnight} {
+		dn.Time = tm
+		dn.ApplyLamps()
+		if !lamp.Disabled {
+			t.Fatalf("t=%v: hand-disabled lamp was switched back on", tm)
+		}
+	}
+
+	// Switched back on, it follows the cycle again
+	lamp.Disabled = false
+	dn.Time = KeyNoon
+	dn.ApplyLamps()
+	if !lamp.Disabled {
+		t.Error("noon: expected the cycle to disable the lamp")
+	}
+	dn.Time = KeyMidnight
+	dn.ApplyLamps()
+	if lamp.Disabled || lamp.Intensity != 2 {
+		t.Errorf("midnight: expected lamp on at 2, got %v (disabled %v)", lamp.Intensity, lamp.Disabled)
+	}
+}
@@ -0,0 +1,44 @@
+package scene
+
+import "render-engine/core"
+
+// MakeFlatShaded gives every triangle of m its own three vertices, all with
+// the triangle's face normal, so it renders faceted with any material. This
+// bakes into the mesh what Material.FlatShading does in the shader, at the
+// cost of up to three vertices per triangle; use it where the shader path
+// isn't available (exports, custom shaders). Normals follow the winding
+// (counter-clockwise is the front). Non-triangle and skinned meshes are left
+// unchanged. Call before uploading the mesh to the GPU.
+func MakeFlatShaded(m *Mesh) {
+	if m == nil || m.DrawMode != DrawTriangles || m.Skeleton != nil {
+		return
+	}
+	corner := func(i int) uint32 {
+		if len(m.Indices) > 0 {
+			return m.Indices[i]
+		}
+		return uint32(i)
+	}
+	n := len(m.Indices)
+	if n == 0 {
+		n = len(m.Vertices)
+	}
+
+	vertices := make([]core.Vertex, 0, n-n%3)
+	indices := make([]uint32, 0, n-n%3)
+	for t := 0; t+2 < n; t += 3 {
+		v0, v1, v2 := m.Vertices[corner(t)], m.Vertices[corner(t+1)], m.Vertices[corner(t+2)]
+		face := v1.Position.Sub(v0.Position).Cross(v2.Position.Sub(v0.Position))
+		if face.LengthSqr() > 0 {
+			face = face.Normalize()
+			v0.Normal, v1.Normal, v2.Normal = face, face, face
+		}
+		base := uint32(len(vertices))
+		vertices = append(vertices, v0, v1, v2)
+		indices = append(indices, base, base+1, base+2)
+	}
+	m.Vertices = vertices
+	m.Indices = indices
+	m.IndexCount = uint32(len(indices))
+	m.RecomputeBounds()
+}
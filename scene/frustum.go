@@ -23,17 +23,16 @@ type Frustum struct {
 // FrustumFromVP extracts the six frustum planes from a view-projection matrix.
 // The planes are normalized so DistanceTo returns a true distance in world units.
 //
-// Convention: The Go engine stores matrices as [col][row] and passes them to
-// GLSL with transpose=false. The GLSL shader multiplies as "mvp * col_vector".
-// Because Go's Mul uses row-major semantics internally, the GLSL matrix is the
-// transpose of the Go matrix. Gribb/Hartmann frustum extraction operates on the
-// GLSL matrix rows, which correspond to Go matrix columns (vp[col][0..3]).
+// Convention: the engine uses row vectors (clip = p * vp, translation in
+// vp[3][0..2]) and uploads matrices to GLSL untransposed, so the GLSL matrix
+// is the transpose of the Go one. Gribb/Hartmann extraction works on the rows
+// of the column-vector matrix, which are the columns of the Go matrix.
 func FrustumFromVP(vp math.Mat4) Frustum {
-	// Row i of the GLSL matrix = Column i of the Go matrix = vp[i][0..3]
-	r0 := math.Vec4{X: vp[0][0], Y: vp[0][1], Z: vp[0][2], W: vp[0][3]}
-	r1 := math.Vec4{X: vp[1][0], Y: vp[1][1], Z: vp[1][2], W: vp[1][3]}
-	r2 := math.Vec4{X: vp[2][0], Y: vp[2][1], Z: vp[2][2], W: vp[2][3]}
-	r3 := math.Vec4{X: vp[3][0], Y: vp[3][1], Z: vp[3][2], W: vp[3][3]}
+	// Row i of the GLSL matrix = column i of the Go matrix = vp[0..3][i]
+	r0 := math.Vec4{X: vp[0][0], Y: vp[1][0], Z: vp[2][0], W: vp[3][0]}
+	r1 := math.Vec4{X: vp[0][1], Y: vp[1][1], Z: vp[2][1], W: vp[3][1]}
+	r2 := math.Vec4{X: vp[0][2], Y: vp[1][2], Z: vp[2][2], W: vp[3][2]}
+	r3 := math.Vec4{X: vp[0][3], Y: vp[1][3], Z: vp[2][3], W: vp[3][3]}
 
 	var f Frustum
 	// Left:   r3 + r0
@@ -51,6 +50,21 @@ func FrustumFromVP(vp math.Mat4) Frustum {
 	return f
 }
 
+// FrustumFromVPReverseZ is FrustumFromVP for a reverse-Z projection
+// (math.Mat4PerspectiveReverseZ), whose clip depth runs from w at the near
+// plane to 0 at the far plane rather than -w..w. The OpenGL-range near and
+// far extraction would turn into a near plane and no far plane at all.
+func FrustumFromVPReverseZ(vp math.Mat4) Frustum {
+	f := FrustumFromVP(vp)
+	r2 := math.Vec4{X: vp[0][2], Y: vp[1][2], Z: vp[2][2], W: vp[3][2]}
+	r3 := math.Vec4{X: vp[0][3], Y: vp[1][3], Z: vp[2][3], W: vp[3][3]}
+	// Near:   r3 - r2 (z ≤ w)
+	f.Planes[4] = normalizePlane(r3.X-r2.X, r3.Y-r2.Y, r3.Z-r2.Z, r3.W-r2.W)
+	// Far:    r2      (z ≥ 0)
+	f.Planes[5] = normalizePlane(r2.X, r2.Y, r2.Z, r2.W)
+	return f
+}
+
 func normalizePlane(a, b, c, d float32) Plane {
 	l := math.Vec3{X: a, Y: b, Z: c}.Length()
 	if l == 0 {
@@ -90,58 +104,44 @@ func (box AABB) IntersectsFrustum(f *Frustum) bool {
 	return true
 }
 
-// ComputeAABB computes the world-space AABB for a mesh transformed by worldMatrix.
-// If the mesh has a cached local AABB, it transforms the 8 corners (fast path).
-// Otherwise it falls back to iterating all vertices.
-func ComputeAABB(mesh *Mesh, worldMatrix math.Mat4) AABB {
-	if mesh.HasLocalAABB {
-		return transformAABB(mesh.LocalAABB, worldMatrix)
+// IntersectsSphere returns false if the sphere is completely outside the
+// frustum: one signed distance per plane, far cheaper than the AABB test.
+func (f *Frustum) IntersectsSphere(center math.Vec3, radius float32) bool {
+	for i := 0; i < 6; i++ {
+		if f.Planes[i].DistanceTo(center) < -radius {
+			return false
+		}
 	}
-	return computeAABBSlow(mesh, worldMatrix)
+	return true
 }
 
-// transformAABB transforms a local AABB by a world matrix by testing all 8 corners.
-func transformAABB(local AABB, m math.Mat4) AABB {
-	mn, mx := local.Min, local.Max
-	corners := [8]math.Vec3{
-		{X: mn.X, Y: mn.Y, Z: mn.Z},
-		{X: mx.X, Y: mn.Y, Z: mn.Z},
-		{X: mn.X, Y: mx.Y, Z: mn.Z},
-		{X: mx.X, Y: mx.Y, Z: mn.Z},
-		{X: mn.X, Y: mn.Y, Z: mx.Z},
-		{X: mx.X, Y: mn.Y, Z: mx.Z},
-		{X: mn.X, Y: mx.Y, Z: mx.Z},
-		{X: mx.X, Y: mx.Y, Z: mx.Z},
+// ComputeBoundingSphere returns the world-space bounding sphere for a mesh
+// transformed by worldMatrix: the cached local sphere with its centre
+// transformed and its radius scaled by the largest axis scale, so it stays
+// conservative under non-uniform scale.
+func ComputeBoundingSphere(mesh *Mesh, worldMatrix math.Mat4) (center math.Vec3, radius float32) {
+	c, r := mesh.BoundingSphere()
+	// Rows 0-2 of the matrix are the world-space images of the local axes
+	sx := math.Vec3{X: worldMatrix[0][0], Y: worldMatrix[0][1], Z: worldMatrix[0][2]}.Length()
+	sy := math.Vec3{X: worldMatrix[1][0], Y: worldMatrix[1][1], Z: worldMatrix[1][2]}.Length()
+	sz := math.Vec3{X: worldMatrix[2][0], Y: worldMatrix[2][1], Z: worldMatrix[2][2]}.Length()
+	maxScale := sx
+	if sy > maxScale {
+		maxScale = sy
 	}
-	first := m.MulVec3(corners[0])
-	out := AABB{Min: first, Max: first}
-	for i := 1; i < 8; i++ {
-		wp := m.MulVec3(corners[i])
-		if wp.X < out.Min.X { out.Min.X = wp.X }
-		if wp.Y < out.Min.Y { out.Min.Y = wp.Y }
-		if wp.Z < out.Min.Z { out.Min.Z = wp.Z }
-		if wp.X > out.Max.X { out.Max.X = wp.X }
-		if wp.Y > out.Max.Y { out.Max.Y = wp.Y }
-		if wp.Z > out.Max.Z { out.Max.Z = wp.Z }
+	if sz > maxScale {
+		maxScale = sz
 	}
-	return out
+	return worldMatrix.MulVec3(c), r * maxScale
 }
 
-// computeAABBSlow is the fallback when no cached local AABB is available.
-func computeAABBSlow(mesh *Mesh, worldMatrix math.Mat4) AABB {
+// ComputeAABB computes the world-space AABB for a mesh transformed by
+// worldMatrix by transforming the 8 corners of the mesh's cached local
+// bounds (Mesh.LocalBounds), so the cost doesn't grow with the vertex count.
+func ComputeAABB(mesh *Mesh, worldMatrix math.Mat4) AABB {
 	if len(mesh.Vertices) == 0 {
 		return AABB{}
 	}
-	first := worldMatrix.MulVec3(mesh.Vertices[0].Position)
-	out := AABB{Min: first, Max: first}
-	for i := 1; i < len(mesh.Vertices); i++ {
-		wp := worldMatrix.MulVec3(mesh.Vertices[i].Position)
-		if wp.X < out.Min.X { out.Min.X = wp.X }
-		if wp.Y < out.Min.Y { out.Min.Y = wp.Y }
-		if wp.Z < out.Min.Z { out.Min.Z = wp.Z }
-		if wp.X > out.Max.X { out.Max.X = wp.X }
-		if wp.Y > out.Max.Y { out.Max.Y = wp.Y }
-		if wp.Z > out.Max.Z { out.Max.Z = wp.Z }
-	}
-	return out
+	min, max := mesh.LocalBounds()
+	return AABB{Min: min, Max: max}.Transform(worldMatrix)
 }
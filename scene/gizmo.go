@@ -0,0 +1,101 @@
+package scene
+
+import (
+	"render-engine/core"
+	"render-engine/math"
+)
+
+// Transform gizmo dimensions, in gizmo units: the meshes below are one unit
+// long and are drawn scaled (usually to a constant pixel size, see
+// RenderEngine.DrawMeshScreenScaled). Hit testing uses the same numbers.
+const (
+	GizmoAxisLength    = 1.0   // handles reach from the origin to here along each axis
+	GizmoShaftRadius   = 0.02  // translate and scale shafts
+	GizmoHeadRadius    = 0.07  // translate cone base
+	GizmoHeadLength    = 0.2   // translate cone height
+	GizmoBoxSize       = 0.12  // scale handle cube edge
+	GizmoRingRadius    = 1.0   // rotate rings
+	GizmoRingThickness = 0.015 // rotate ring tube radius
+)
+
+// GizmoAxisColors are the handle colours for the X, Y and Z axes.
+var GizmoAxisColors = [3]core.Color{
+	{R: 0.9, G: 0.2, B: 0.2, A: 1},
+	{R: 0.3, G: 0.85, B: 0.3, A: 1},
+	{R: 0.25, G: 0.4, B: 0.95, A: 1},
+}
+
+// gizmoBases maps a part built along +Y onto each axis: the rows are the
+// images of the part's X, Y and Z, cyclic so triangle winding is kept.
+var gizmoBases = [3][3]math.Vec3{
+	{{X: 0, Y: 0, Z: 1}, {X: 1, Y: 0, Z: 0}, {X: 0, Y: 1, Z: 0}}, // +X
+	{{X: 1, Y: 0, Z: 0}, {X: 0, Y: 1, Z: 0}, {X: 0, Y: 0, Z: 1}}, // +Y
+	{{X: 0, Y: 1, Z: 0}, {X: 0, Y: 0, Z: 1}, {X: 1, Y: 0, Z: 0}}, // +Z
+}
+
+// gizmoBuilder merges primitive parts into one vertex-coloured mesh.
+type gizmoBuilder struct {
+	vertices []core.Vertex
+	indices  []uint32
+}
+
+// add appends part, built along +Y, moved up by offset and turned onto axis,
+// in the axis colour.
+func (b *gizmoBuilder) add(part *Mesh, axis int, offset float32) {
+	basis := gizmoBases[axis]
+	turn := func(v math.Vec3) math.Vec3 {
+		return basis[0].Mul(v.X).Add(basis[1].Mul(v.Y)).Add(basis[2].Mul(v.Z))
+	}
+	base := uint32(len(b.vertices))
+	for _, v := range part.Vertices {
+		v.Position = turn(v.Position.Add(math.Vec3{X: 0, Y: offset, Z: 0}))
+		v.Normal = turn(v.Normal)
+		v.Color = GizmoAxisColors[axis]
+		b.vertices = append(b.vertices, v)
+	}
+	for _, i := range part.Indices {
+		b.indices = append(b.indices, base+i)
+	}
+}
+
+func (b *gizmoBuilder) mesh(name string) *Mesh {
+	m := CreateMeshFromData(name, b.vertices, b.indices)
+	m.Material = DefaultMaterial()
+	m.Material.Name = "Gizmo"
+	m.Material.Unlit = true
+	return m
+}
+
+// CreateTranslateGizmo creates the move handles: an arrow along each of +X,
+// +Y and +Z, GizmoAxisLength long, coloured by GizmoAxisColors.
+func CreateTranslateGizmo() *Mesh {
+	const shaft = GizmoAxisLength - GizmoHeadLength
+	var b gizmoBuilder
+	for axis := 0; axis < 3; axis++ {
+		b.add(CreateCylinder(GizmoShaftRadius, shaft, 12), axis, shaft/2)
+		b.add(CreateCone(GizmoHeadRadius, GizmoHeadLength, 16), axis, shaft+GizmoHeadLength/2)
+	}
+	return b.mesh("TranslateGizmo")
+}
+
+// CreateRotateGizmo creates the rotate handles: a ring of radius
+// GizmoRingRadius around each axis, in the plane the axis is normal to.
+func CreateRotateGizmo() *Mesh {
+	var b gizmoBuilder
+	for axis := 0; axis < 3; axis++ {
+		b.add(CreateTorus(GizmoRingRadius, GizmoRingThickness, 64, 8), axis, 0)
+	}
+	return b.mesh("RotateGizmo")
+}
+
+// CreateScaleGizmo creates the scale handles: a shaft along each axis ending
+// in a cube, GizmoAxisLength long.
+func CreateScaleGizmo() *Mesh {
+	const shaft = GizmoAxisLength - GizmoBoxSize
+	var b gizmoBuilder
+	for axis := 0; axis < 3; axis++ {
+		b.add(CreateCylinder(GizmoShaftRadius, shaft, 12), axis, shaft/2)
+		b.add(CreateCube(GizmoBoxSize), axis, shaft+GizmoBoxSize/2)
+	}
+	return b.mesh("ScaleGizmo")
+}
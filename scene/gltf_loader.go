@@ -2,6 +2,7 @@ package scene
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"image"
 	_ "image/jpeg"
@@ -21,14 +22,21 @@ import (
 //	for _, tex := range result.Textures {
 //	    renderEngine.UploadTexture(tex)
 //	}
+//
+// or queue Roots with RenderEngine.QueueUpload to spread the uploads over
+// several frames (see LoadGLTFAsync).
 type GLTFResult struct {
-	Roots    []*Node    // top-level nodes; add each with scene.AddNode(n)
-	Textures []*Texture // textures that need GPU upload
+	Roots     []*Node     // top-level nodes; add each with scene.AddNode(n)
+	Textures  []*Texture  // textures that need GPU upload
+	Skeletons []*Skeleton // skins; pose them by animating their Joints
 }
 
 // LoadGLTF opens a .glb or .gltf file and returns a ready-to-use scene graph.
-// Mesh geometry, materials, base-colour textures, and the node hierarchy are
-// all populated.  PBR metallic-roughness is approximated to Blinn-Phong.
+// Mesh geometry, materials (base colour, normal, occlusion and emissive
+// textures, KHR_materials_emissive_strength), the node hierarchy and skins
+// are all populated. Images may be in a GLB buffer view, in a base64 data URI
+// or in a file next to the .gltf.  PBR metallic-roughness is approximated to Blinn-Phong.
+// Skinned meshes are deformed on the CPU (see Mesh.Update).
 func LoadGLTF(path string) (*GLTFResult, error) {
 	doc, err := gltf.Open(path)
 	if err != nil {
@@ -46,11 +54,17 @@ func LoadGLTF(path string) (*GLTFResult, error) {
 		img := doc.Images[*gt.Source]
 
 		var tex *Texture
-		if img.BufferView != nil {
-			// Binary GLB: image data lives in a buffer view
-			raw, err := modeler.ReadBufferView(doc, doc.BufferViews[*img.BufferView])
+		if img.BufferView != nil || img.IsEmbeddedResource() {
+			// Image bytes in a buffer view (binary GLB) or a base64 data URI
+			// (self-contained .gltf)
+			var raw []byte
+			if img.BufferView != nil {
+				raw, err = modeler.ReadBufferView(doc, doc.BufferViews[*img.BufferView])
+			} else {
+				raw, err = img.MarshalData()
+			}
 			if err != nil {
-				fmt.Printf("gltf: image %d bufferview: %v\n", *gt.Source, err)
+				fmt.Printf("gltf: image %d data: %v\n", *gt.Source, err)
 				continue
 			}
 			name := img.Name
@@ -62,7 +76,7 @@ func LoadGLTF(path string) (*GLTFResult, error) {
 				fmt.Printf("gltf: image %d decode: %v\n", *gt.Source, err)
 				continue
 			}
-		} else if img.URI != "" && !img.IsEmbeddedResource() {
+		} else if img.URI != "" {
 			// External file referenced by relative URI
 			tex, err = LoadTexture(filepath.Join(dir, img.URI))
 			if err != nil {
@@ -112,6 +126,29 @@ func LoadGLTF(path string) (*GLTFResult, error) {
 				mat.NormalTexture = texCache[idx]
 			}
 		}
+
+		// Ambient occlusion texture (R channel)
+		if gm.OcclusionTexture != nil && gm.OcclusionTexture.Index != nil {
+			idx := *gm.OcclusionTexture.Index
+			if idx >= 0 && idx < len(texCache) && texCache[idx] != nil {
+				mat.OcclusionTexture = texCache[idx]
+			}
+		}
+
+		// Emissive: factor × KHR_materials_emissive_strength, which takes it
+		// above 1 so it blooms
+		es := gltfEmissiveStrength(gm)
+		ef := gm.EmissiveFactor
+		mat.EmissiveColor = core.Color{
+			R: float32(ef[0]) * es, G: float32(ef[1]) * es,
+			B: float32(ef[2]) * es, A: 1,
+		}
+		if gm.EmissiveTexture != nil {
+			idx := gm.EmissiveTexture.Index
+			if idx < len(texCache) && texCache[idx] != nil {
+				mat.EmissiveTexture = texCache[idx]
+			}
+		}
 		matCache[i] = mat
 	}
 
@@ -185,6 +222,29 @@ func LoadGLTF(path string) (*GLTFResult, error) {
 		}
 	}
 
+	// ── 4b. Skins ─────────────────────────────────────────────────────────────
+	skeletons := make([]*Skeleton, len(doc.Skins))
+	for si, gs := range doc.Skins {
+		skel, err := loadGLTFSkin(doc, gs, nodes)
+		if err != nil {
+			fmt.Printf("gltf: skin %d: %v\n", si, err)
+			continue
+		}
+		skeletons[si] = skel
+		result.Skeletons = append(result.Skeletons, skel)
+	}
+	for _, gn := range doc.Nodes {
+		if gn.Skin == nil || gn.Mesh == nil || *gn.Skin >= len(skeletons) ||
+			skeletons[*gn.Skin] == nil || *gn.Mesh >= len(meshPrims) {
+			continue
+		}
+		for _, m := range meshPrims[*gn.Mesh] {
+			if m.Skeleton == nil {
+				m.SetSkeleton(skeletons[*gn.Skin])
+			}
+		}
+	}
+
 	// ── 5. Root nodes ─────────────────────────────────────────────────────────
 	if doc.Scene != nil && *doc.Scene < len(doc.Scenes) {
 		for _, rootIdx := range doc.Scenes[*doc.Scene].Nodes {
@@ -231,6 +291,9 @@ func loadGLTFPrimitive(doc *gltf.Document, meshName string, primIdx int, prim gl
 
 	var normals [][3]float32
 	var uvs     [][2]float32
+	var uvs2    [][2]float32
+	var joints  [][4]uint16
+	var weights [][4]float32
 
 	if idx, ok := prim.Attributes["NORMAL"]; ok {
 		normals, _ = modeler.ReadNormal(doc, doc.Accessors[idx], nil)
@@ -238,6 +301,17 @@ func loadGLTFPrimitive(doc *gltf.Document, meshName string, primIdx int, prim gl
 	if idx, ok := prim.Attributes["TEXCOORD_0"]; ok {
 		uvs, _ = modeler.ReadTextureCoord(doc, doc.Accessors[idx], nil)
 	}
+	// Second UV set, usually lightmap coordinates
+	if idx, ok := prim.Attributes["TEXCOORD_1"]; ok {
+		uvs2, _ = modeler.ReadTextureCoord(doc, doc.Accessors[idx], nil)
+	}
+	// Only the first joint/weight set: at most MaxJointInfluences per vertex
+	if idx, ok := prim.Attributes["JOINTS_0"]; ok {
+		joints, _ = modeler.ReadJoints(doc, doc.Accessors[idx], nil)
+	}
+	if idx, ok := prim.Attributes["WEIGHTS_0"]; ok {
+		weights, _ = modeler.ReadWeights(doc, doc.Accessors[idx], nil)
+	}
 
 	verts := make([]core.Vertex, len(positions))
 	for i, p := range positions {
@@ -253,6 +327,13 @@ func loadGLTFPrimitive(doc *gltf.Document, meshName string, primIdx int, prim gl
 		if i < len(uvs) {
 			v.UV = math.Vec2{X: uvs[i][0], Y: uvs[i][1]}
 		}
+		if i < len(uvs2) {
+			v.UV2 = math.Vec2{X: uvs2[i][0], Y: uvs2[i][1]}
+		}
+		if i < len(joints) && i < len(weights) {
+			v.Joints  = joints[i]
+			v.Weights = normalizeWeights(weights[i])
+		}
 		verts[i] = v
 	}
 
@@ -267,6 +348,65 @@ func loadGLTFPrimitive(doc *gltf.Document, meshName string, primIdx int, prim gl
 	return CreateMeshFromData(name, verts, indices), nil
 }
 
+// loadGLTFSkin builds a Skeleton from a glTF skin.  Missing inverse bind
+// matrices default to identity, as the spec requires.
+func loadGLTFSkin(doc *gltf.Document, gs *gltf.Skin, nodes []*Node) (*Skeleton, error) {
+	skel := &Skeleton{Name: gs.Name}
+	for _, j := range gs.Joints {
+		if j >= len(nodes) || nodes[j] == nil {
+			return nil, fmt.Errorf("joint node %d out of range", j)
+		}
+		skel.Joints = append(skel.Joints, nodes[j])
+	}
+
+	skel.InverseBindMatrices = make([]math.Mat4, len(skel.Joints))
+	for i := range skel.InverseBindMatrices {
+		skel.InverseBindMatrices[i] = math.Mat4Identity()
+	}
+	if gs.InverseBindMatrices != nil {
+		ibms, err := modeler.ReadInverseBindMatrices(doc, doc.Accessors[*gs.InverseBindMatrices], nil)
+		if err != nil {
+			return nil, fmt.Errorf("inverse bind matrices: %w", err)
+		}
+		// glTF stores column-major column-vector matrices, which is exactly
+		// the [col][row] row-vector layout of math.Mat4.
+		for i := 0; i < len(ibms) && i < len(skel.InverseBindMatrices); i++ {
+			skel.InverseBindMatrices[i] = math.Mat4(ibms[i])
+		}
+	}
+	return skel, nil
+}
+
+// normalizeWeights rescales joint weights to sum to 1 (exporters often leave
+// small rounding drift).  All-zero weights stay zero, meaning "not skinned".
+func normalizeWeights(w [4]float32) [4]float32 {
+	sum := w[0] + w[1] + w[2] + w[3]
+	if sum <= 0 {
+		return [4]float32{}
+	}
+	for k := range w {
+		w[k] /= sum
+	}
+	return w
+}
+
+// gltfEmissiveStrength returns gm's KHR_materials_emissive_strength
+// multiplier, or 1 without the extension. The gltf package keeps extensions
+// it has no decoder for as raw JSON.
+func gltfEmissiveStrength(gm *gltf.Material) float32 {
+	raw, ok := gm.Extensions["KHR_materials_emissive_strength"].(json.RawMessage)
+	if !ok {
+		return 1
+	}
+	var ext struct {
+		EmissiveStrength *float64 `json:"emissiveStrength"`
+	}
+	if err := json.Unmarshal(raw, &ext); err != nil || ext.EmissiveStrength == nil {
+		return 1
+	}
+	return float32(*ext.EmissiveStrength)
+}
+
 // decodeImageBytes decodes a PNG or JPEG byte slice into an RGBA8 scene.Texture.
 func decodeImageBytes(name string, data []byte) (*Texture, error) {
 	img, _, err := image.Decode(bytes.NewReader(data))
@@ -1,5 +1,7 @@
 package scene
 
+import stdmath "math"
+
 import "render-engine/core"
 import "render-engine/math"
 
@@ -119,3 +121,134 @@ func CreateUnitBoxWireframe() *Mesh {
 
 	return m
 }
+
+// CreateNormalLines builds a line mesh with one segment per vertex of src,
+// running from the vertex position along its normal for length units.
+// Segments live in src's object space, so draw with the same model matrix.
+func CreateNormalLines(src *Mesh, length float32) *Mesh {
+	yellow := core.Color{R: 1.0, G: 0.9, B: 0.1, A: 1}
+
+	vertices := make([]core.Vertex, 0, len(src.Vertices)*2)
+	indices  := make([]uint32, 0, len(src.Vertices)*2)
+
+	for _, v := range src.Vertices {
+		base := uint32(len(vertices))
+		tip  := v.Position.Add(v.Normal.Normalize().Mul(length))
+		vertices = append(vertices,
+			core.Vertex{Position: v.Position, Normal: v.Normal, Color: yellow},
+			core.Vertex{Position: tip, Normal: v.Normal, Color: yellow},
+		)
+		indices = append(indices, base, base+1)
+	}
+
+	m := CreateMeshFromData(src.Name+"Normals", vertices, indices)
+	m.DrawMode = DrawLines
+
+	mat := DefaultMaterial()
+	mat.Name   = "NormalsMaterial"
+	mat.Unlit  = true
+	m.Material = mat
+
+	return m
+}
+
+// ── Light gizmos ──────────────────────────────────────────────────────────────
+//
+// Unit-sized unlit line meshes for visualising lights.  Each is oriented along
+// +Z and meant to be scaled, rotated and tinted per light at draw time.
+
+// gizmoLines accumulates line segments for the gizmo meshes below.
+type gizmoLines struct {
+	vertices []core.Vertex
+	indices  []uint32
+}
+
+func (g *gizmoLines) add(a, b math.Vec3) {
+	white := core.Color{R: 1, G: 1, B: 1, A: 1}
+	base  := uint32(len(g.vertices))
+	g.vertices = append(g.vertices,
+		core.Vertex{Position: a, Normal: math.Vec3Up, Color: white},
+		core.Vertex{Position: b, Normal: math.Vec3Up, Color: white},
+	)
+	g.indices = append(g.indices, base, base+1)
+}
+
+// circle adds a closed polyline of the given radius; point(cos, sin) maps the
+// unit circle into the desired plane.
+func (g *gizmoLines) circle(segments int, point func(c, s float32) math.Vec3) {
+	prev := point(1, 0)
+	for i := 1; i <= segments; i++ {
+		theta := float64(i) * 2.0 * stdmath.Pi / float64(segments)
+		next  := point(float32(stdmath.Cos(theta)), float32(stdmath.Sin(theta)))
+		g.add(prev, next)
+		prev = next
+	}
+}
+
+func (g *gizmoLines) mesh(name string) *Mesh {
+	m := CreateMeshFromData(name, g.vertices, g.indices)
+	m.DrawMode = DrawLines
+
+	mat := DefaultMaterial()
+	mat.Name   = name + "Material"
+	mat.Unlit  = true
+	m.Material = mat
+
+	return m
+}
+
+// CreateUnitSphereWireframe creates three orthogonal great circles of radius 1,
+// used to show a point light's Range.
+func CreateUnitSphereWireframe(segments int) *Mesh {
+	if segments < 3 {
+		segments = 3
+	}
+	var g gizmoLines
+	g.circle(segments, func(c, s float32) math.Vec3 { return math.Vec3{X: c, Y: s, Z: 0} })
+	g.circle(segments, func(c, s float32) math.Vec3 { return math.Vec3{X: c, Y: 0, Z: s} })
+	g.circle(segments, func(c, s float32) math.Vec3 { return math.Vec3{X: 0, Y: c, Z: s} })
+	return g.mesh("UnitSphereWireframe")
+}
+
+// CreateUnitConeWireframe creates a cone with its apex at the origin and a base
+// circle of radius 1 at z = 1, plus four apex-to-rim edges.  Scale XY by
+// range·tan(angle) and Z by range to match a spot light's cone.
+func CreateUnitConeWireframe(segments int) *Mesh {
+	if segments < 4 {
+		segments = 4
+	}
+	var g gizmoLines
+	g.circle(segments, func(c, s float32) math.Vec3 { return math.Vec3{X: c, Y: s, Z: 1} })
+	for _, rim := range []math.Vec3{{X: 1, Z: 1}, {X: -1, Z: 1}, {Y: 1, Z: 1}, {Y: -1, Z: 1}} {
+		g.add(math.Vec3Zero, rim)
+	}
+	return g.mesh("UnitConeWireframe")
+}
+
+// CreateArrowWireframe creates a unit-length arrow from the origin to z = 1
+// with a four-barbed head, used to show a directional light's direction.
+func CreateArrowWireframe() *Mesh {
+	const head, barb = 0.75, 0.12
+	tip := math.Vec3{X: 0, Y: 0, Z: 1}
+
+	var g gizmoLines
+	g.add(math.Vec3Zero, tip)
+	g.add(tip, math.Vec3{X: barb, Y: 0, Z: head})
+	g.add(tip, math.Vec3{X: -barb, Y: 0, Z: head})
+	g.add(tip, math.Vec3{X: 0, Y: barb, Z: head})
+	g.add(tip, math.Vec3{X: 0, Y: -barb, Z: head})
+	return g.mesh("ArrowWireframe")
+}
+
+// CreateFrustumWireframe creates the 12 edges of a frustum from its corners
+// in Camera.FrustumCorners order (near quad 0–3, far quad 4–7).
+func CreateFrustumWireframe(corners [8]math.Vec3) *Mesh {
+	var g gizmoLines
+	for i := 0; i < 4; i++ {
+		j := (i + 1) % 4
+		g.add(corners[i], corners[j])     // near quad
+		g.add(corners[4+i], corners[4+j]) // far quad
+		g.add(corners[i], corners[4+i])   // side edge
+	}
+	return g.mesh("FrustumWireframe")
+}
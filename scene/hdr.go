@@ -0,0 +1,214 @@
+package scene
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	stdmath "math"
+	"os"
+	"strings"
+)
+
+// LoadHDR reads a Radiance RGBE (.hdr) panorama from disk into a Texture
+// whose HDR field holds linear RGB floats. Pixels is left empty.
+func LoadHDR(path string) (*Texture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open hdr %q: %w", path, err)
+	}
+	defer f.Close()
+
+	tex, err := DecodeHDR(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("decode hdr %q: %w", path, err)
+	}
+	tex.Name = path
+	return tex, nil
+}
+
+// SaveHDR writes tex's HDR pixels (linear RGB floats, top row first) to
+// path as a Radiance RGBE (.hdr) image that LoadHDR reads back.
+func SaveHDR(path string, tex *Texture) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create hdr %q: %w", path, err)
+	}
+	w := bufio.NewWriter(f)
+	if err := EncodeHDR(w, tex); err != nil {
+		f.Close()
+		return fmt.Errorf("encode hdr %q: %w", path, err)
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("write hdr %q: %w", path, err)
+	}
+	return f.Close()
+}
+
+// EncodeHDR writes tex's HDR pixels as a Radiance RGBE image with flat
+// (uncompressed) scanlines in the standard "-Y height +X width" orientation.
+// Each pixel keeps about 8 bits of precision relative to its brightest
+// channel; negative values are clamped to zero.
+func EncodeHDR(w io.Writer, tex *Texture) error {
+	if tex.Width <= 0 || tex.Height <= 0 {
+		return fmt.Errorf("invalid size %dx%d", tex.Width, tex.Height)
+	}
+	if len(tex.HDR) < tex.Width*tex.Height*3 {
+		return fmt.Errorf("%dx%d image needs %d floats, got %d",
+			tex.Width, tex.Height, tex.Width*tex.Height*3, len(tex.HDR))
+	}
+	if _, err := fmt.Fprintf(w, "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y %d +X %d\n", tex.Height, tex.Width); err != nil {
+		return err
+	}
+	scan := make([]byte, tex.Width*4)
+	for y := 0; y < tex.Height; y++ {
+		row := tex.HDR[y*tex.Width*3:]
+		for x := 0; x < tex.Width; x++ {
+			q := floatToRGBE(row[x*3], row[x*3+1], row[x*3+2])
+			copy(scan[x*4:], q[:])
+		}
+		if _, err := w.Write(scan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeHDR parses a Radiance RGBE image: a text header ending in a blank
+// line, a "-Y height +X width" resolution line, then scanlines that are
+// either flat RGBE quads or new-style per-channel run-length encoded.
+// Only the standard top-to-bottom, left-to-right orientation is supported.
+func DecodeHDR(r *bufio.Reader) (*Texture, error) {
+	magic, err := r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	if !strings.HasPrefix(magic, "#?") {
+		return nil, fmt.Errorf("not a Radiance file (missing #? signature)")
+	}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("read header: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, "FORMAT=") && line != "FORMAT=32-bit_rle_rgbe" {
+			return nil, fmt.Errorf("unsupported format %q", strings.TrimPrefix(line, "FORMAT="))
+		}
+	}
+
+	res, err := r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("read resolution: %w", err)
+	}
+	var width, height int
+	if _, err := fmt.Sscanf(res, "-Y %d +X %d", &height, &width); err != nil {
+		return nil, fmt.Errorf("unsupported resolution line %q", strings.TrimSpace(res))
+	}
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid size %dx%d", width, height)
+	}
+
+	out := make([]float32, width*height*3)
+	scan := make([]byte, width*4)
+	for y := 0; y < height; y++ {
+		if err := readHDRScanline(r, scan, width); err != nil {
+			return nil, fmt.Errorf("scanline %d: %w", y, err)
+		}
+		row := out[y*width*3:]
+		for x := 0; x < width; x++ {
+			row[x*3], row[x*3+1], row[x*3+2] = rgbeToFloat(scan[x*4], scan[x*4+1], scan[x*4+2], scan[x*4+3])
+		}
+	}
+
+	return &Texture{Width: width, Height: height, HDR: out}, nil
+}
+
+// readHDRScanline fills scan with width RGBE quads.
+func readHDRScanline(r *bufio.Reader, scan []byte, width int) error {
+	var head [4]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return err
+	}
+	// New-style RLE marker: 2, 2, then the width as a big-endian 15-bit value.
+	// Widths outside [8, 0x7fff] are always stored flat.
+	if width < 8 || width > 0x7fff || head[0] != 2 || head[1] != 2 || head[2]&0x80 != 0 {
+		copy(scan, head[:])
+		_, err := io.ReadFull(r, scan[4:])
+		return err
+	}
+	if int(head[2])<<8|int(head[3]) != width {
+		return fmt.Errorf("RLE width mismatch")
+	}
+
+	// Four planes (R, G, B, E), each run-length encoded separately
+	for ch := 0; ch < 4; ch++ {
+		for x := 0; x < width; {
+			count, err := r.ReadByte()
+			if err != nil {
+				return err
+			}
+			if count > 128 {
+				// Run: one value repeated count-128 times
+				n := int(count) - 128
+				if x+n > width {
+					return fmt.Errorf("RLE run overflows scanline")
+				}
+				v, err := r.ReadByte()
+				if err != nil {
+					return err
+				}
+				for i := 0; i < n; i++ {
+					scan[(x+i)*4+ch] = v
+				}
+				x += n
+				continue
+			}
+			// Literal: count raw values
+			n := int(count)
+			if n == 0 || x+n > width {
+				return fmt.Errorf("bad RLE literal length %d", n)
+			}
+			for i := 0; i < n; i++ {
+				v, err := r.ReadByte()
+				if err != nil {
+					return err
+				}
+				scan[(x+i)*4+ch] = v
+			}
+			x += n
+		}
+	}
+	return nil
+}
+
+// rgbeToFloat decodes one shared-exponent pixel: each mantissa is scaled by
+// 2^(e-136), i.e. 2^(e-128) for the exponent bias and 1/256 for the 8-bit
+// mantissa. e = 0 is black.
+func rgbeToFloat(r, g, b, e byte) (float32, float32, float32) {
+	if e == 0 {
+		return 0, 0, 0
+	}
+	f := float32(stdmath.Ldexp(1, int(e)-136))
+	return float32(r) * f, float32(g) * f, float32(b) * f
+}
+
+// floatToRGBE is the inverse of rgbeToFloat: the brightest channel sets the
+// shared exponent and the mantissas are truncated to 8 bits. Values too
+// small to represent (and non-positive ones) encode as black.
+func floatToRGBE(r, g, b float32) [4]byte {
+	r, g, b = max(r, 0), max(g, 0), max(b, 0)
+	m := max(r, g, b)
+	if m < 1e-32 {
+		return [4]byte{}
+	}
+	frac, exp := stdmath.Frexp(float64(m)) // m = frac × 2^exp, frac in [0.5, 1)
+	if exp+128 > 255 {
+		return [4]byte{255, 255, 255, 255}
+	}
+	scale := float32(frac * 256 / float64(m))
+	return [4]byte{byte(r * scale), byte(g * scale), byte(b * scale), byte(exp + 128)}
+}
@@ -10,13 +10,30 @@ type Material struct {
 	Albedo    core.Color // base diffuse color (multiplied with albedo texture if set)
 	Specular  core.Color // Phong specular highlight color (ignored when UsePBR = true)
 	Shininess float32    // Phong shininess exponent (1–256+; ignored when UsePBR = true)
-	Unlit     bool       // skip lighting calculation — output raw albedo/texture color
+	Unlit     bool       // skip lighting calculation — output raw albedo/texture color plus emissive
+
+	// FlatShading lights each triangle with its own face normal (faceted,
+	// low-poly look) instead of the interpolated vertex normals, without
+	// duplicating vertices; see MakeFlatShaded for baking it into a mesh.
+	FlatShading bool
+
+	// UseVertexColor multiplies the mesh's vertex colours into the base
+	// colour (default true). Turn it off to shade with Albedo alone, e.g. a
+	// plain-coloured material on a mesh with baked vertex colours.
+	UseVertexColor bool
+
+	// Transparent blends the surface over what is behind it with Albedo.A
+	// (times the albedo texture's alpha) as its opacity. Transparent meshes
+	// are drawn after the opaque ones, farthest first, without writing depth;
+	// opaque materials ignore Albedo.A. Instanced nodes are always opaque.
+	Transparent bool
 
 	// PBR parameters (used when UsePBR = true)
 	UsePBR      bool       // switch to Cook-Torrance BRDF instead of Phong
 	Metallic    float32    // 0 = dielectric, 1 = fully metallic
 	Roughness   float32    // 0 = perfectly smooth, 1 = fully rough
-	EmissiveColor core.Color // self-emitted radiance (additive; use bright values for HDR glow)
+	EmissiveColor core.Color // self-emitted radiance (additive, Phong too; use bright values for HDR glow)
+	BloomScale    float32    // emissive × BloomScale always blooms, independent of the bloom threshold
 
 	// Optional albedo texture; if set, it is multiplied with Albedo.
 	// Upload via opengl.UploadTexture before rendering.
@@ -28,12 +45,31 @@ type Material struct {
 
 	// Optional PBR combined metallic-roughness texture (glTF convention):
 	//   G channel = roughness, B channel = metallic.
+	// Phong materials read 1 - G as a scale on Shininess (an MTL map_Ns).
 	// Upload via opengl.UploadTexture before rendering.
 	MetallicRoughnessTexture *Texture
 
 	// Optional emissive texture; multiplied with EmissiveColor.
 	// Upload via opengl.UploadTexture before rendering.
 	EmissiveTexture *Texture
+
+	// Optional baked lightmap, sampled with the mesh's second UV set
+	// (Vertex.UV2) and multiplied into the lit colour as indirect light.
+	// Upload via opengl.UploadTexture before rendering.
+	LightmapTexture *Texture
+
+	// Optional Phong specular map; its RGB multiplies Specular.
+	// Upload via opengl.UploadTexture before rendering.
+	SpecularTexture *Texture
+
+	// Optional opacity map; its red channel multiplies the alpha of
+	// Transparent materials. Upload via opengl.UploadTexture before rendering.
+	OpacityTexture *Texture
+
+	// Optional baked ambient occlusion map; its red channel darkens the
+	// ambient and image-based light (not direct lights), sampled with the
+	// first UV set. Upload via opengl.UploadTexture before rendering.
+	OcclusionTexture *Texture
 }
 
 // DefaultMaterial returns a plain white matte Phong material.
@@ -44,6 +80,8 @@ func DefaultMaterial() *Material {
 		Specular:  core.Color{R: 0.3, G: 0.3, B: 0.3, A: 1},
 		Shininess: 32,
 		Roughness: 0.5,
+
+		UseVertexColor: true,
 	}
 }
 
@@ -55,6 +93,8 @@ func NewMaterial(name string, albedo core.Color) *Material {
 		Specular:  core.Color{R: 0.5, G: 0.5, B: 0.5, A: 1},
 		Shininess: 32,
 		Roughness: 0.5,
+
+		UseVertexColor: true,
 	}
 }
 
@@ -66,5 +106,7 @@ func NewPBRMaterial(name string, albedo core.Color, metallic, roughness float32)
 		Metallic:  metallic,
 		Roughness: roughness,
 		UsePBR:    true,
+
+		UseVertexColor: true,
 	}
 }
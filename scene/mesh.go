@@ -1,6 +1,8 @@
 package scene
 
 import (
+	stdmath "math"
+
 	"render-engine/core"
 	"render-engine/math"
 )
@@ -12,6 +14,8 @@ const (
 	DrawTriangles DrawMode = iota // gl.TRIANGLES (default)
 	DrawLines                     // gl.LINES — pairs of indices form line segments
 	DrawPoints                    // gl.POINTS
+	DrawLineStrip                 // gl.LINE_STRIP — each index continues the line from the previous one
+	DrawTriangleStrip             // gl.TRIANGLE_STRIP — each index after the first two adds a triangle
 )
 
 // Mesh holds CPU-side vertex/index data.
@@ -24,10 +28,26 @@ type Mesh struct {
 	MaterialName string
 	DrawMode     DrawMode // defaults to DrawTriangles
 
-	// Cached local-space AABB (computed by CreateMeshFromData).
+	// Dynamic hints that Vertices will be rewritten after upload (skinning,
+	// morphing, cloth); the renderer then allocates the VBO for frequent
+	// updates. Push changes with RenderEngine.UpdateMeshVertices.
+	Dynamic bool
+
+	// Skeleton, when set, makes this a skinned mesh: Update deforms BindPose
+	// (the rest-pose vertices) into Vertices from the joints' current pose.
+	Skeleton *Skeleton
+	BindPose []core.Vertex
+
+	// Cached local-space AABB (computed by CreateMeshFromData, or on first
+	// LocalBounds call; refreshed by RecomputeBounds).
 	LocalAABB    AABB
 	HasLocalAABB bool
 
+	// Cached local-space bounding sphere (computed lazily by BoundingSphere).
+	sphereCenter math.Vec3
+	sphereRadius float32
+	hasSphere    bool
+
 	// Material holds surface shading properties. If nil, DefaultMaterial() is used.
 	Material *Material
 
@@ -59,6 +79,55 @@ func CreateMeshFromData(name string, vertices []core.Vertex, indices []uint32) *
 	return m
 }
 
+// RecomputeBounds refreshes the cached local-space bounds from Vertices.
+// Call after editing vertex positions so culling uses the new extent.
+func (m *Mesh) RecomputeBounds() {
+	m.hasSphere = false
+	if len(m.Vertices) == 0 {
+		m.HasLocalAABB = false
+		return
+	}
+	m.LocalAABB = computeLocalAABB(m.Vertices)
+	m.HasLocalAABB = true
+}
+
+// LocalBounds returns the local-space AABB of the vertices. It is cached on
+// the mesh: meshes not built by CreateMeshFromData compute it on first use,
+// and RecomputeBounds (called by RenderEngine.UpdateMeshVertices) refreshes
+// it after the vertices change. An empty mesh has zero bounds.
+func (m *Mesh) LocalBounds() (min, max math.Vec3) {
+	if !m.HasLocalAABB && len(m.Vertices) > 0 {
+		m.LocalAABB = computeLocalAABB(m.Vertices)
+		m.HasLocalAABB = true
+	}
+	return m.LocalAABB.Min, m.LocalAABB.Max
+}
+
+// BoundingSphere returns a local-space sphere enclosing every vertex, centred
+// on the AABB centre. It is computed on first use and cached until
+// RecomputeBounds is called.
+func (m *Mesh) BoundingSphere() (center math.Vec3, radius float32) {
+	if m.hasSphere {
+		return m.sphereCenter, m.sphereRadius
+	}
+	if len(m.Vertices) == 0 {
+		return math.Vec3Zero, 0
+	}
+	min, max := m.LocalBounds()
+	center = min.Add(max).Mul(0.5)
+	var maxSq float32
+	for _, v := range m.Vertices {
+		d := v.Position.Sub(center)
+		if sq := d.Dot(d); sq > maxSq {
+			maxSq = sq
+		}
+	}
+	m.sphereCenter = center
+	m.sphereRadius = float32(stdmath.Sqrt(float64(maxSq)))
+	m.hasSphere = true
+	return m.sphereCenter, m.sphereRadius
+}
+
 // computeLocalAABB returns the tight AABB of the given vertex positions.
 func computeLocalAABB(vertices []core.Vertex) AABB {
 	min := vertices[0].Position
@@ -75,8 +144,6 @@ func computeLocalAABB(vertices []core.Vertex) AABB {
 	return AABB{Min: min, Max: max}
 }
 
-func (m *Mesh) Update(deltaTime float32) {}
-
 func (m *Mesh) Destroy() {
 	// GPU resources are freed by the renderer backend.
 	// CPU data is garbage-collected automatically.
@@ -14,12 +14,74 @@ type Node struct {
 	Mesh       *Mesh
 	Visible    bool
 	Id         uint32
+
+	// Instances, when non-empty, draws Mesh once per matrix (each relative
+	// to the node's world transform) in a single instanced draw call instead
+	// of once at the node itself. See NewInstancedNode.
+	Instances []math.Mat4
+
+	// InstanceMaterialSet gives instances material variants (armour tints,
+	// worn and polished metal) within the one draw call: instance i takes
+	// its albedo, opacity, metallic and roughness from
+	// InstanceMaterialSet[InstanceMaterialIndices[i]]. Textures and every
+	// other parameter come from Mesh.Material. At most MaxInstanceMaterials
+	// entries are used; a missing or out-of-range index selects entry 0.
+	InstanceMaterialSet     []*Material
+	InstanceMaterialIndices []uint32
+
+	// Layers is the set of render layers (bits) the node belongs to. A pass
+	// draws it only when the pass's mask (RenderEngine.SceneLayers,
+	// ShadowLayers) shares a bit with it. NewNode sets LayerDefault.
+	Layers uint32
+
+	// CastShadow includes the node in the shadow map (default true). Turn
+	// it off for glass and other surfaces light should pass through.
+	CastShadow bool
+
+	// ReceiveShadow lets shadows fall on the node (default true). Turn it
+	// off for surfaces that should stay lit whatever is in front of them,
+	// such as signs and water.
+	ReceiveShadow bool
+
+	// Emitters are particle emitters owned by the node: Scene.Update moves
+	// each one's Position to the node's world position and simulates it,
+	// and the renderer draws them each frame while the node is visible,
+	// with no DrawParticles call needed. Particles already emitted stay
+	// where they are, so a moving node leaves a trail.
+	Emitters []*ParticleEmitter
+
+	// Motion records the matrices the node was last drawn with, which the
+	// renderer compares against the current ones to write motion vectors.
+	// Maintained by RenderEngine while motion blur is enabled.
+	Motion MotionState
 	
 	// Cached world transform
 	worldMatrixDirty bool
 	worldMatrix      math.Mat4
 }
 
+// MotionState is a node's model and camera view-projection matrices from the
+// frame it was last drawn on.
+type MotionState struct {
+	Model    math.Mat4
+	ViewProj math.Mat4
+	Frame    uint64 // renderer frame the matrices belong to; 0 = never drawn
+}
+
+// MaxInstanceMaterials is the number of Node.InstanceMaterialSet entries the
+// renderer packs into its per-draw variant table.
+const MaxInstanceMaterials = 8
+
+// Render layers for Node.Layers. The remaining bits are free for the
+// application's own groups.
+const (
+	LayerDefault    uint32 = 1 << iota // world geometry
+	LayerUI                            // in-world UI: panels, labels, markers
+	LayerShadowOnly                    // invisible shadow casters: kept out of the default SceneLayers
+
+	LayerAll = ^uint32(0)
+)
+
 var nodeIdCounter uint32 = 0
 
 func NewNode(name string) *Node {
@@ -30,10 +92,23 @@ func NewNode(name string) *Node {
 		Children:         make([]*Node, 0),
 		Visible:          true,
 		Id:               nodeIdCounter,
+		Layers:           LayerDefault,
+		CastShadow:       true,
+		ReceiveShadow:    true,
 		worldMatrixDirty: true,
 	}
 }
 
+// NewInstancedNode creates a node that draws mesh at every transform in
+// instances through the instanced path. Render culls and counts each instance
+// separately and the shadow pass draws them instanced as well.
+func NewInstancedNode(name string, mesh *Mesh, instances []math.Mat4) *Node {
+	n := NewNode(name)
+	n.Mesh = mesh
+	n.Instances = instances
+	return n
+}
+
 func (n *Node) AddChild(child *Node) {
 	if child.Parent != nil {
 		child.Parent.RemoveChild(child)
@@ -111,16 +186,42 @@ func (n *Node) GetUp() math.Vec3 {
 	return n.Transform.GetUp()
 }
 
-// Update updates the node and its children
+// AddEmitter attaches e to the node; see Node.Emitters.
+func (n *Node) AddEmitter(e *ParticleEmitter) {
+	n.Emitters = append(n.Emitters, e)
+}
+
+// WorldPosition returns the node's origin in world space.
+func (n *Node) WorldPosition() math.Vec3 {
+	m := n.GetWorldMatrix()
+	return math.Vec3{X: m[3][0], Y: m[3][1], Z: m[3][2]}
+}
+
+// Update advances the node's emitters, at its current world position, and
+// recurses into its children
 func (n *Node) Update(deltaTime float32) {
-	// Update mesh if any
-	if n.Mesh != nil {
-		n.Mesh.Update(deltaTime)
+	n.update(deltaTime, nil)
+}
+
+// update is Update, skipping emitters beyond their CullDistance from cam
+// (nil = simulate all).
+func (n *Node) update(deltaTime float32, cam *Camera) {
+	// Skinned meshes are posed by the renderer each frame (Mesh.Update needs
+	// the node's final world matrix and a re-upload), not here.
+	if len(n.Emitters) > 0 {
+		pos := n.WorldPosition()
+		for _, e := range n.Emitters {
+			e.Position = pos
+			if cam != nil && e.DistanceFade(cam.Position) == 0 {
+				continue
+			}
+			e.Update(deltaTime)
+		}
 	}
-	
+
 	// Update children
 	for _, child := range n.Children {
-		child.Update(deltaTime)
+		child.update(deltaTime, cam)
 	}
 }
 
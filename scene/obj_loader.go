@@ -249,7 +249,11 @@ func buildMeshFromOBJ(
 		generateFlatNormals(vertices, indices)
 	}
 
-	return CreateMeshFromData(name, vertices, indices)
+	mesh := CreateMeshFromData(name, vertices, indices)
+	if len(uvs) > 0 {
+		ComputeTangents(mesh)
+	}
+	return mesh
 }
 
 // generateFlatNormals computes area-weighted normals and writes them to the vertex slice.
@@ -279,6 +283,25 @@ func generateFlatNormals(vertices []core.Vertex, indices []uint32) {
 
 // ── MTL loader ───────────────────────────────────────────────────────────────
 
+// loadMTL reads the materials of a .mtl file. Supported directives:
+//
+//	Kd, Ks, Ns        diffuse colour, specular colour, shininess (Phong)
+//	Ke                emissive colour
+//	d / Tr            opacity / transparency (1 - d); below 1 sets Transparent
+//	Pm, Pr            PBR metallic and roughness; either switches to UsePBR
+//	map_Kd            albedo texture
+//	map_Ks            specular map
+//	map_Ns            shininess map, inverted into the roughness (green) of
+//	                  MetallicRoughnessTexture, with Pm as its metallic (blue);
+//	                  Phong materials un-invert it to scale Ns
+//	map_d             opacity map; sets Transparent
+//	map_Bump, bump,   tangent-space normal map
+//	norm
+//
+// Ka is skipped: ambient light comes from Scene.Ambient, not the material.
+// Texture paths are relative to dir; map options such as "-bm 1" before the
+// file name are ignored. A texture that fails to load is reported and left
+// out, without failing the load.
 func loadMTL(path, dir string) (map[string]*Material, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -288,6 +311,7 @@ func loadMTL(path, dir string) (map[string]*Material, error) {
 
 	mats := map[string]*Material{}
 	var cur *Material
+	shininessMaps := map[*Material]*Texture{}
 
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
@@ -299,44 +323,133 @@ func loadMTL(path, dir string) (map[string]*Material, error) {
 		if len(fields) == 0 {
 			continue
 		}
-
-		switch fields[0] {
-		case "newmtl":
+		if fields[0] == "newmtl" {
 			if len(fields) > 1 {
 				m := DefaultMaterial()
 				m.Name = fields[1]
 				mats[fields[1]] = m
 				cur = m
 			}
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+
+		switch fields[0] {
 		case "Kd":
-			if cur != nil && len(fields) >= 4 {
-				r, _ := strconv.ParseFloat(fields[1], 32)
-				g, _ := strconv.ParseFloat(fields[2], 32)
-				b, _ := strconv.ParseFloat(fields[3], 32)
-				cur.Albedo = core.Color{R: float32(r), G: float32(g), B: float32(b), A: 1}
+			if c, ok := mtlColor(fields); ok {
+				c.A = cur.Albedo.A
+				cur.Albedo = c
 			}
 		case "Ks":
-			if cur != nil && len(fields) >= 4 {
-				r, _ := strconv.ParseFloat(fields[1], 32)
-				g, _ := strconv.ParseFloat(fields[2], 32)
-				b, _ := strconv.ParseFloat(fields[3], 32)
-				cur.Specular = core.Color{R: float32(r), G: float32(g), B: float32(b), A: 1}
+			if c, ok := mtlColor(fields); ok {
+				cur.Specular = c
+			}
+		case "Ke":
+			if c, ok := mtlColor(fields); ok {
+				cur.EmissiveColor = c
 			}
 		case "Ns":
-			if cur != nil && len(fields) >= 2 {
+			if len(fields) >= 2 {
 				ns, _ := strconv.ParseFloat(fields[1], 32)
 				cur.Shininess = float32(math.Max(1, ns))
 			}
-		case "map_Kd":
-			if cur != nil && len(fields) >= 2 {
-				texPath := filepath.Join(dir, fields[1])
-				tex, err := LoadTexture(texPath)
-				if err == nil {
-					cur.AlbedoTexture = tex
+		case "d", "Tr":
+			if len(fields) >= 2 {
+				v, err := strconv.ParseFloat(fields[len(fields)-1], 32) // skip "d -halo"
+				if err != nil {
+					continue
 				}
+				if fields[0] == "Tr" {
+					v = 1 - v
+				}
+				cur.Albedo.A = float32(math.Max(0, math.Min(1, v)))
+				cur.Transparent = cur.Albedo.A < 1
+			}
+		case "Pm":
+			if len(fields) >= 2 {
+				v, _ := strconv.ParseFloat(fields[1], 32)
+				cur.Metallic = float32(v)
+				cur.UsePBR = true
+			}
+		case "Pr":
+			if len(fields) >= 2 {
+				v, _ := strconv.ParseFloat(fields[1], 32)
+				cur.Roughness = float32(v)
+				cur.UsePBR = true
+			}
+		case "map_Kd":
+			if tex := mtlTexture(dir, fields); tex != nil {
+				cur.AlbedoTexture = tex
+			}
+		case "map_Ks":
+			if tex := mtlTexture(dir, fields); tex != nil {
+				cur.SpecularTexture = tex
+			}
+		case "map_Ns":
+			if tex := mtlTexture(dir, fields); tex != nil {
+				shininessMaps[cur] = tex
+			}
+		case "map_d":
+			if tex := mtlTexture(dir, fields); tex != nil {
+				cur.OpacityTexture = tex
+				cur.Transparent = true
+			}
+		case "map_Bump", "map_bump", "bump", "norm":
+			if tex := mtlTexture(dir, fields); tex != nil {
+				cur.NormalTexture = tex
 			}
 		}
 	}
 
+	// Pm may follow map_Ns, so the roughness maps are built last
+	for m, ns := range shininessMaps {
+		m.MetallicRoughnessTexture = shininessToRoughness(ns, m.Metallic)
+	}
 	return mats, scanner.Err()
 }
+
+// shininessToRoughness converts a map_Ns shininess map (bright = glossy)
+// into a metallic-roughness texture: green is 1 - shininess, blue is the
+// constant metallic.
+func shininessToRoughness(ns *Texture, metallic float32) *Texture {
+	out := &Texture{Name: ns.Name, Width: ns.Width, Height: ns.Height, Pixels: make([]byte, len(ns.Pixels))}
+	b := byte(math.Max(0, math.Min(1, float64(metallic))) * 255)
+	for i := 0; i+3 < len(ns.Pixels); i += 4 {
+		out.Pixels[i+1] = 255 - ns.Pixels[i]
+		out.Pixels[i+2] = b
+		out.Pixels[i+3] = 255
+	}
+	return out
+}
+
+// mtlColor parses an "<op> r g b" colour directive.
+func mtlColor(fields []string) (core.Color, bool) {
+	if len(fields) < 4 {
+		return core.Color{}, false
+	}
+	var rgb [3]float32
+	for i := range rgb {
+		v, err := strconv.ParseFloat(fields[i+1], 32)
+		if err != nil {
+			return core.Color{}, false
+		}
+		rgb[i] = float32(v)
+	}
+	return core.Color{R: rgb[0], G: rgb[1], B: rgb[2], A: 1}, true
+}
+
+// mtlTexture loads the texture named by a map directive's last field,
+// printing a warning and returning nil if it is missing or fails to load.
+func mtlTexture(dir string, fields []string) *Texture {
+	if len(fields) < 2 {
+		return nil
+	}
+	tex, err := LoadTexture(filepath.Join(dir, fields[len(fields)-1]))
+	if err != nil {
+		fmt.Printf("mtl: %s: %v\n", fields[0], err)
+		return nil
+	}
+	return tex
+}
@@ -2,7 +2,6 @@ package scene
 
 import (
 	stdmath "math"
-	"math/rand"
 
 	"render-engine/core"
 	"render-engine/math"
@@ -28,14 +27,19 @@ type Particle struct {
 
 // ParticleEmitter spawns and simulates CPU particles.
 // Build quads on CPU; rendered as camera-facing billboards via DrawParticles.
+// With GPUSimulation set, the particles live and move on the GPU instead.
 type ParticleEmitter struct {
 	// Spawn position + direction
 	Position  math.Vec3
 	Direction math.Vec3 // mean emission direction (must be normalised)
 	Spread    float32   // half-angle cone spread in radians (0 = pencil, π/2 = hemisphere)
 
-	// Spawn rate
-	Rate int // particles per second
+	// EmissionRate is the number of particles spawned per second, spread
+	// over frames by an accumulator so density doesn't depend on the frame
+	// rate; fractional rates (0.5 = one every two seconds) work. Capacity is
+	// a hard cap on live particles: spawns due while the pool is full are
+	// dropped, not saved up for a burst when it drains.
+	EmissionRate float32
 
 	// Per-particle random ranges
 	MinLife, MaxLife   float32 // lifetime range (seconds)
@@ -51,75 +55,116 @@ type ParticleEmitter struct {
 
 	// Rendering
 	BlendMode BlendMode
+	// SoftFadeDistance fades particles out over this many world units as they
+	// near the opaque surface behind them, hiding hard intersection lines with
+	// the ground. 0 = off. Needs post-processing (the HDR depth buffer).
+	SoftFadeDistance float32
 
 	// Control
 	Active bool // if false no new particles are spawned; existing ones finish out
 
+	// CullDistance, when > 0, is how far from the camera the emitter stays
+	// live: beyond it the renderer skips it and Scene.Update stops
+	// simulating it, and over the last quarter before it its particles fade
+	// out (see DistanceFade) so it doesn't pop.
+	CullDistance float32
+
+	// GPUSimulation moves the particles into GPU buffers, integrated by the
+	// renderer with transform feedback and drawn straight from there: no
+	// per-frame upload, so Capacity can run to hundreds of thousands. Update
+	// then only counts spawns, and Particles stays empty (Count reports 0).
+	// Set it before the first Update.
+	GPUSimulation bool
+
+	// Rand drives every random choice of the emitter (lifetime, speed,
+	// direction), so emitters with equally seeded generators spawn identical
+	// particles. The constructors seed their own; nil uses math.DefaultRand.
+	Rand *math.Rand
+
 	// Live particles (read by the renderer)
 	Particles []Particle
 
 	pool       int
 	spawnAccum float32
-	rng        *rand.Rand
+
+	// Pending GPU work, drained by TakeGPUStep
+	gpuDt    float32
+	gpuSpawn int
 }
 
 // NewParticleEmitter returns a fire-like emitter with sensible defaults.
 // Adjust fields before the first Update to customise behaviour.
 func NewParticleEmitter(maxParticles int) *ParticleEmitter {
 	return &ParticleEmitter{
-		Direction:  math.Vec3{X: 0, Y: 1, Z: 0},
-		Spread:     0.4,
-		Rate:       80,
-		MinLife:    0.6,
-		MaxLife:    1.8,
-		MinSpeed:   2.0,
-		MaxSpeed:   5.0,
-		MinSize:    0.06,
-		MaxSize:    0.22,
-		StartColor: core.Color{R: 1.0, G: 0.7, B: 0.15, A: 1.0},
-		EndColor:   core.Color{R: 0.8, G: 0.05, B: 0.0, A: 0.0},
-		Gravity:    math.Vec3{Y: 0.3},
-		BlendMode:  BlendAdditive,
-		Active:     true,
-		Particles:  make([]Particle, 0, maxParticles),
-		pool:       maxParticles,
-		rng:        rand.New(rand.NewSource(42)),
+		Direction:    math.Vec3{X: 0, Y: 1, Z: 0},
+		Spread:       0.4,
+		EmissionRate: 80,
+		MinLife:      0.6,
+		MaxLife:      1.8,
+		MinSpeed:     2.0,
+		MaxSpeed:     5.0,
+		MinSize:      0.06,
+		MaxSize:      0.22,
+		StartColor:   core.Color{R: 1.0, G: 0.7, B: 0.15, A: 1.0},
+		EndColor:     core.Color{R: 0.8, G: 0.05, B: 0.0, A: 0.0},
+		Gravity:      math.Vec3{Y: 0.3},
+		BlendMode:    BlendAdditive,
+		Active:       true,
+		Particles:    make([]Particle, 0, maxParticles),
+		pool:         maxParticles,
+		Rand:         math.NewRand(42),
 	}
 }
 
 // NewSmokeEmitter returns a slow rising smoke emitter.
 func NewSmokeEmitter(maxParticles int) *ParticleEmitter {
 	return &ParticleEmitter{
-		Direction:  math.Vec3{X: 0, Y: 1, Z: 0},
-		Spread:     0.5,
-		Rate:       20,
-		MinLife:    2.0,
-		MaxLife:    4.0,
-		MinSpeed:   0.5,
-		MaxSpeed:   1.5,
-		MinSize:    0.15,
-		MaxSize:    0.5,
-		StartColor: core.Color{R: 0.3, G: 0.3, B: 0.3, A: 0.4},
-		EndColor:   core.Color{R: 0.6, G: 0.6, B: 0.6, A: 0.0},
-		Gravity:    math.Vec3{Y: 0.1},
-		BlendMode:  BlendAlpha,
-		Active:     true,
-		Particles:  make([]Particle, 0, maxParticles),
-		pool:       maxParticles,
-		rng:        rand.New(rand.NewSource(99)),
+		Direction:    math.Vec3{X: 0, Y: 1, Z: 0},
+		Spread:       0.5,
+		EmissionRate: 20,
+		MinLife:      2.0,
+		MaxLife:      4.0,
+		MinSpeed:     0.5,
+		MaxSpeed:     1.5,
+		MinSize:      0.15,
+		MaxSize:      0.5,
+		StartColor:   core.Color{R: 0.3, G: 0.3, B: 0.3, A: 0.4},
+		EndColor:     core.Color{R: 0.6, G: 0.6, B: 0.6, A: 0.0},
+		Gravity:      math.Vec3{Y: 0.1},
+		BlendMode:    BlendAlpha,
+		Active:       true,
+		Particles:    make([]Particle, 0, maxParticles),
+		pool:         maxParticles,
+		Rand:         math.NewRand(99),
 	}
 }
 
 // Update advances the simulation by dt seconds.
 // Call once per frame before DrawParticles.
 func (e *ParticleEmitter) Update(dt float32) {
+	if e.GPUSimulation {
+		e.Particles = e.Particles[:0]
+		e.gpuDt += dt
+		if e.Active {
+			e.spawnAccum += e.EmissionRate * dt
+			n := int(e.spawnAccum)
+			e.spawnAccum -= float32(n)
+			e.gpuSpawn = min(e.gpuSpawn+n, e.pool)
+		}
+		return
+	}
+
 	// Spawn new particles
 	if e.Active {
-		e.spawnAccum += float32(e.Rate) * dt
+		e.spawnAccum += e.EmissionRate * dt
 		for e.spawnAccum >= 1.0 && len(e.Particles) < e.pool {
 			e.spawnParticle()
 			e.spawnAccum -= 1.0
 		}
+		// Pool full: drop the whole spawns still due, keep the fraction
+		if e.spawnAccum >= 1.0 {
+			e.spawnAccum -= float32(int(e.spawnAccum))
+		}
 	}
 
 	// Integrate and cull dead particles (compact in-place)
@@ -146,10 +191,66 @@ func (e *ParticleEmitter) Update(dt float32) {
 // Count returns the number of live particles.
 func (e *ParticleEmitter) Count() int { return len(e.Particles) }
 
+// Capacity returns the maximum number of live particles.
+func (e *ParticleEmitter) Capacity() int { return e.pool }
+
+// Bounds returns a sphere around the emitter's particles for culling. CPU
+// particles are bounded where they are, together with the spawn point; GPU
+// particles can't be read back, so their sphere is centred on Position and
+// reaches as far as a particle can travel in MaxLife.
+func (e *ParticleEmitter) Bounds() (center math.Vec3, radius float32) {
+	if e.GPUSimulation || len(e.Particles) == 0 {
+		g := e.Gravity.Length()
+		travel := e.MaxSpeed*e.MaxLife + 0.5*g*e.MaxLife*e.MaxLife
+		return e.Position, travel + e.MaxSize
+	}
+	lo, hi := e.Position, e.Position
+	for i := range e.Particles {
+		p := e.Particles[i].Position
+		lo = math.Vec3{X: min(lo.X, p.X), Y: min(lo.Y, p.Y), Z: min(lo.Z, p.Z)}
+		hi = math.Vec3{X: max(hi.X, p.X), Y: max(hi.Y, p.Y), Z: max(hi.Z, p.Z)}
+	}
+	center = lo.Add(hi).Mul(0.5)
+	return center, hi.Sub(center).Length() + max(e.MinSize, e.MaxSize)
+}
+
+// DistanceFade returns the opacity scale for particles seen from eye: 1
+// within three quarters of CullDistance, falling linearly to 0 at it. Always
+// 1 when CullDistance is 0.
+func (e *ParticleEmitter) DistanceFade(eye math.Vec3) float32 {
+	if e.CullDistance <= 0 {
+		return 1
+	}
+	d := e.Position.Sub(eye).Length()
+	start := e.CullDistance * 0.75
+	if d <= start {
+		return 1
+	}
+	return max(0, 1-(d-start)/(e.CullDistance-start))
+}
+
+// TakeGPUStep returns the time and the number of new particles accumulated
+// by Update since the last call, and resets both. The renderer calls it when
+// it advances a GPUSimulation emitter.
+func (e *ParticleEmitter) TakeGPUStep() (dt float32, spawn int) {
+	dt, spawn = e.gpuDt, e.gpuSpawn
+	e.gpuDt, e.gpuSpawn = 0, 0
+	return dt, spawn
+}
+
+// Seed gives the emitter its own generator seeded with seed.
+func (e *ParticleEmitter) Seed(seed int64) {
+	e.Rand = math.NewRand(seed)
+}
+
 func (e *ParticleEmitter) spawnParticle() {
-	life := e.MinLife + e.rng.Float32()*(e.MaxLife-e.MinLife)
-	speed := e.MinSpeed + e.rng.Float32()*(e.MaxSpeed-e.MinSpeed)
-	dir := randomInCone(e.Direction, e.Spread, e.rng)
+	rng := e.Rand
+	if rng == nil {
+		rng = math.DefaultRand
+	}
+	life := rng.Range(e.MinLife, e.MaxLife)
+	speed := rng.Range(e.MinSpeed, e.MaxSpeed)
+	dir := randomInCone(e.Direction, e.Spread, rng)
 	e.Particles = append(e.Particles, Particle{
 		Position: e.Position,
 		Velocity: dir.Mul(speed),
@@ -163,7 +264,7 @@ func (e *ParticleEmitter) spawnParticle() {
 // randomInCone returns a uniformly-distributed unit vector within a cone of
 // half-angle spread around axis.  Uses the concentric-disk → spherical cap
 // mapping so the distribution is uniform (not polar-biased).
-func randomInCone(axis math.Vec3, spread float32, rng *rand.Rand) math.Vec3 {
+func randomInCone(axis math.Vec3, spread float32, rng *math.Rand) math.Vec3 {
 	phi := rng.Float32() * 2.0 * float32(stdmath.Pi)
 	// Uniform distribution over the spherical cap
 	cosMin := float32(stdmath.Cos(float64(spread)))
@@ -1,6 +1,8 @@
 package scene
 
 import (
+	stdmath "math"
+
 	"render-engine/core"
 	"render-engine/math"
 )
@@ -12,6 +14,15 @@ type Scene struct {
 	Lights   []*Light
 	Ambient  core.Color
 	SkyColor core.Color
+
+	// Animators are advanced by Update, in order, before the node graph
+	Animators []Animator
+}
+
+// Animator is a per-frame system Scene.Update advances: day/night cycles,
+// tweens, scripted movers.
+type Animator interface {
+	Update(dt float32)
 }
 
 // Light types
@@ -21,6 +32,20 @@ const (
 	LightTypeSpot
 )
 
+// Distance falloff modes for point and spot lights
+const (
+	// FalloffSmoothRange fades as (1 - d²/Range²)², reaching zero exactly at
+	// Range. Intensity is a unitless multiplier.
+	FalloffSmoothRange = iota
+	// FalloffInverseSquare is the physical Intensity / d², with Intensity in
+	// candela, so the result is illuminance in lux. Range is ignored.
+	FalloffInverseSquare
+)
+
+// falloffMinDistance clamps d in inverse-square falloff (1 cm), so surfaces
+// touching the light do not divide by zero. Matches the shader.
+const falloffMinDistance = 0.01
+
 // Light represents a light source
 type Light struct {
 	Type       int
@@ -30,6 +55,75 @@ type Light struct {
 	Intensity  float32
 	Range      float32
 	SpotAngle  float32
+
+	// Spot cone half-angles in degrees. Zero means unset: outer falls back to
+	// SpotAngle, inner to 0.8 × outer. Inner == outer gives a hard edge.
+	SpotInnerAngle float32
+	SpotOuterAngle float32
+
+	// AngularSize is the apparent diameter in degrees of a directional light
+	// (the sun is ~0.53°). Non-zero values give contact-hardening shadows whose
+	// penumbra widens with distance from the occluder. 0 = uniform PCF.
+	AngularSize float32
+
+	// Falloff selects the point/spot distance attenuation
+	// (FalloffSmoothRange or FalloffInverseSquare).
+	Falloff int
+
+	// Disabled switches the light off without removing it from the scene:
+	// it contributes nothing, casts no shadow and frees its shader slot for
+	// the next light of its type.
+	Disabled bool
+}
+
+// Attenuation returns the distance falloff factor at dist from a point or
+// spot light, as the shader computes it. Multiply by Intensity for the
+// light's strength at that distance.
+func (l *Light) Attenuation(dist float32) float32 {
+	if l.Falloff == FalloffInverseSquare {
+		d := float32(stdmath.Max(float64(dist), falloffMinDistance))
+		return 1 / (d * d)
+	}
+	r := float32(stdmath.Max(float64(l.Range), 0.001))
+	a := 1 - (dist*dist)/(r*r)
+	if a < 0 {
+		a = 0
+	} else if a > 1 {
+		a = 1
+	}
+	return a * a
+}
+
+// SpotCutoffs returns the cosines of the spot light's inner (full intensity)
+// and outer (zero intensity) cone half-angles, as consumed by the shader.
+func (l *Light) SpotCutoffs() (innerCos, outerCos float32) {
+	outer := l.SpotOuterAngle
+	if outer <= 0 {
+		outer = l.SpotAngle
+	}
+	inner := l.SpotInnerAngle
+	if inner <= 0 {
+		inner = outer * 0.8
+	}
+	if inner > outer {
+		inner = outer
+	}
+	return cosDeg(inner), cosDeg(outer)
+}
+
+// ShadowSoftness converts AngularSize into the shader's penumbra scale: the
+// penumbra width in shadow-map UV per unit of normalised light-space depth.
+// The renderer's shadow volume is twice as deep as it is wide, so the world
+// penumbra (depth gap × tan θ) maps to 2·tan θ in UV per unit depth.
+func (l *Light) ShadowSoftness() float32 {
+	if l.AngularSize <= 0 {
+		return 0
+	}
+	return 2 * float32(stdmath.Tan(float64(l.AngularSize)*stdmath.Pi/180.0))
+}
+
+func cosDeg(deg float32) float32 {
+	return float32(stdmath.Cos(float64(deg) * stdmath.Pi / 180.0))
 }
 
 func NewScene() *Scene {
@@ -66,9 +160,33 @@ func (s *Scene) RemoveLight(light *Light) {
 	}
 }
 
+// AddAnimator registers a to be advanced by Update.
+func (s *Scene) AddAnimator(a Animator) {
+	s.Animators = append(s.Animators, a)
+}
+
+// RemoveAnimator unregisters a.
+func (s *Scene) RemoveAnimator(a Animator) {
+	for i, x := range s.Animators {
+		if x == a {
+			s.Animators = append(s.Animators[:i], s.Animators[i+1:]...)
+			return
+		}
+	}
+}
+
+// Update advances everything in the scene that changes over time by
+// deltaTime seconds: the registered animators first, so they can move
+// nodes, then the node graph (Node.Update), which moves attached particle
+// emitters to their nodes and simulates them. Call once per frame before
+// RenderEngine.Render.
 func (s *Scene) Update(deltaTime float32) {
+	for _, a := range s.Animators {
+		a.Update(deltaTime)
+	}
 	if s.Root != nil {
-		s.Root.Update(deltaTime)
+		// Emitters out of the camera's range are frozen until it returns
+		s.Root.update(deltaTime, s.Camera)
 	}
 }
 
@@ -85,6 +203,28 @@ func (s *Scene) GetVisibleNodes() []*Node {
 	return visible
 }
 
+// Bounds returns the world-space box enclosing every visible node's mesh,
+// each instance of instanced nodes included: what a camera must see to show
+// the whole scene. It is empty (AABB.IsEmpty) when no visible node has
+// geometry.
+func (s *Scene) Bounds() AABB {
+	bounds := EmptyAABB()
+	for _, node := range s.GetVisibleNodes() {
+		if len(node.Mesh.Vertices) == 0 {
+			continue
+		}
+		world := node.GetWorldMatrix()
+		if len(node.Instances) == 0 {
+			bounds = bounds.Merge(ComputeAABB(node.Mesh, world))
+			continue
+		}
+		for _, inst := range node.Instances {
+			bounds = bounds.Merge(ComputeAABB(node.Mesh, inst.Mul(world)))
+		}
+	}
+	return bounds
+}
+
 // Create a default scene with some objects
 func CreateDefaultScene(device interface{}) (*Scene, error) {
 	scene := NewScene()
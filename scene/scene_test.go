@@ -0,0 +1,1370 @@
+package scene
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+
+	"render-engine/core"
+	reMath "render-engine/math"
+)
+
+func TestSpotCutoffs(t *testing.T) {
+	approx := func(a, b float32) bool { return math.Abs(float64(a-b)) < 1e-5 }
+	cos := func(deg float64) float32 { return float32(math.Cos(deg * math.Pi / 180)) }
+
+	// Narrow inner, wide outer: a soft penumbra between 10° and 40°
+	l := &Light{Type: LightTypeSpot, SpotInnerAngle: 10, SpotOuterAngle: 40}
+	inner, outer := l.SpotCutoffs()
+	if !approx(inner, cos(10)) || !approx(outer, cos(40)) {
+		t.Errorf("explicit cone: expected (%v, %v), got (%v, %v)", cos(10), cos(40), inner, outer)
+	}
+	if inner <= outer {
+		t.Errorf("inner cutoff cosine %v should exceed outer %v", inner, outer)
+	}
+
+	// Legacy SpotAngle only: inner falls back to 0.8 × SpotAngle
+	l = &Light{Type: LightTypeSpot, SpotAngle: 30}
+	inner, outer = l.SpotCutoffs()
+	if !approx(inner, cos(24)) || !approx(outer, cos(30)) {
+		t.Errorf("fallback cone: expected (%v, %v), got (%v, %v)", cos(24), cos(30), inner, outer)
+	}
+
+	// Inner wider than outer is clamped to a hard edge
+	l = &Light{Type: LightTypeSpot, SpotInnerAngle: 50, SpotOuterAngle: 20}
+	inner, outer = l.SpotCutoffs()
+	if inner != outer {
+		t.Errorf("clamped cone: expected inner == outer, got (%v, %v)", inner, outer)
+	}
+}
+
+func TestLightFalloff(t *testing.T) {
+	approx := func(a, b float32) bool { return math.Abs(float64(a-b)) <= 1e-5*math.Max(1, math.Abs(float64(b))) }
+
+	smooth := &Light{Type: LightTypePoint, Range: 10}
+	inverse := &Light{Type: LightTypePoint, Range: 10, Falloff: FalloffInverseSquare}
+
+	cases := []struct {
+		dist           float32
+		smooth, invSqr float32
+	}{
+		{1, 0.9801, 1},    // (1 - 1/100)²
+		{2, 0.9216, 0.25}, // (1 - 4/100)²
+		{5, 0.5625, 0.04}, // (1 - 25/100)²
+		{10, 0, 0.01},     // smooth reaches zero at Range
+		{20, 0, 0.0025},   // inverse square keeps going past Range
+	}
+	for _, c := range cases {
+		if got := smooth.Attenuation(c.dist); !approx(got, c.smooth) {
+			t.Errorf("smooth range at %v: expected %v, got %v", c.dist, c.smooth, got)
+		}
+		if got := inverse.Attenuation(c.dist); !approx(got, c.invSqr) {
+			t.Errorf("inverse square at %v: expected %v, got %v", c.dist, c.invSqr, got)
+		}
+	}
+
+	// Doubling the distance quarters inverse-square attenuation
+	if r := inverse.Attenuation(4) / inverse.Attenuation(2); !approx(r, 0.25) {
+		t.Errorf("inverse square doubling ratio: expected 0.25, got %v", r)
+	}
+
+	// Near d = 0 inverse square is clamped instead of blowing up
+	if got := inverse.Attenuation(0); math.IsInf(float64(got), 0) || !approx(got, 1e4) {
+		t.Errorf("inverse square at 0: expected clamped 1e4, got %v", got)
+	}
+	if got := smooth.Attenuation(0); got != 1 {
+		t.Errorf("smooth range at 0: expected 1, got %v", got)
+	}
+}
+
+func TestFlyControllerSmoothing(t *testing.T) {
+	approx := func(a, b, eps float32) bool { return math.Abs(float64(a-b)) < float64(eps) }
+
+	// One mouse flick, then no input: the view converges on the target
+	run := func(fps int) *FlyController {
+		fc := NewFlyController()
+		cam := NewCamera(1, 1, 0.1, 100)
+		dt := 1 / float32(fps)
+		fc.Update(cam, FlyInput{LookX: 300, LookY: -100}, dt)
+		for i := 1; i < fps; i++ {
+			fc.Update(cam, FlyInput{}, dt)
+		}
+		return fc
+	}
+	fc := run(60)
+	if !approx(fc.TargetYaw, -60, 1e-4) || !approx(fc.TargetPitch, 10, 1e-4) {
+		t.Fatalf("target: expected (-60, 10), got (%v, %v)", fc.TargetYaw, fc.TargetPitch)
+	}
+	if !approx(fc.Yaw, fc.TargetYaw, 1e-3) || !approx(fc.Pitch, fc.TargetPitch, 1e-3) {
+		t.Errorf("after 1s: expected view at target (%v, %v), got (%v, %v)", fc.TargetYaw, fc.TargetPitch, fc.Yaw, fc.Pitch)
+	}
+
+	// Halfway through, 30 and 240 FPS agree
+	half := func(fps int) float32 {
+		fc := NewFlyController()
+		cam := NewCamera(1, 1, 0.1, 100)
+		fc.TargetYaw = 0
+		dt := 1 / float32(fps)
+		for i := 0; i < fps/10; i++ { // 100 ms
+			fc.Update(cam, FlyInput{}, dt)
+		}
+		return fc.Yaw
+	}
+	if a, b := half(30), half(240); !approx(a, b, 1e-3) || approx(a, 0, 1) || approx(a, -90, 1) {
+		t.Errorf("look smoothing depends on frame rate: 30 FPS %v, 240 FPS %v", a, b)
+	}
+
+	// Holding forward accelerates to MaxSpeed; releasing decays to rest
+	fc = NewFlyController()
+	cam := NewCamera(1, 1, 0.1, 100)
+	for i := 0; i < 60; i++ {
+		fc.Update(cam, FlyInput{Move: reMath.Vec3{Z: 1}}, 1.0/60)
+	}
+	if !approx(fc.Velocity.Length(), fc.MaxSpeed, 1e-3) || fc.Velocity.Z >= 0 {
+		t.Errorf("held forward: expected speed %v toward -Z, got %v", fc.MaxSpeed, fc.Velocity)
+	}
+	for i := 0; i < 120; i++ {
+		fc.Update(cam, FlyInput{}, 1.0/60)
+	}
+	if fc.Velocity.Length() > 1e-3 {
+		t.Errorf("released: expected velocity to decay, got %v", fc.Velocity)
+	}
+}
+
+func TestOrbitControllerSmoothing(t *testing.T) {
+	target := reMath.Vec3{X: 1, Y: 2, Z: 3}
+	oc := NewOrbitController(target, 10)
+	cam := NewCamera(1, 1, 0.1, 100)
+
+	oc.Update(cam, OrbitInput{OrbitX: 100, Zoom: 5}, 1.0/60)
+	for i := 0; i < 120; i++ {
+		oc.Update(cam, OrbitInput{}, 1.0/60)
+	}
+	wantDist := float32(10 * math.Exp(-0.5))
+	if math.Abs(float64(oc.Distance-wantDist)) > 1e-3 || math.Abs(float64(oc.Yaw-30)) > 1e-3 {
+		t.Errorf("expected yaw 30 at distance %v, got yaw %v at %v", wantDist, oc.Yaw, oc.Distance)
+	}
+	if d := cam.Position.Sub(target).Length(); math.Abs(float64(d-oc.Distance)) > 1e-3 {
+		t.Errorf("camera %v from target, expected %v", d, oc.Distance)
+	}
+	if dot := cam.GetForward().Dot(target.Sub(cam.Position).Normalize()); dot < 0.9999 {
+		t.Errorf("camera should face the target, forward·dir = %v", dot)
+	}
+}
+
+func TestCameraLookAtBasis(t *testing.T) {
+	near := func(a, b reMath.Vec3) bool { return a.Sub(b).Length() < 1e-4 }
+
+	// An unrotated camera looks down -Z with +X right and +Y up
+	cam := NewCamera(1.0, 16.0/9.0, 0.1, 100)
+	if f := cam.GetForward(); !near(f, reMath.Vec3{X: 0, Y: 0, Z: -1}) {
+		t.Errorf("default forward: expected -Z, got %v", f)
+	}
+
+	pos, target := reMath.Vec3{X: 2, Y: 3, Z: 8}, reMath.Vec3{X: -1, Y: 0, Z: -2}
+	cam.SetPosition(pos)
+	cam.LookAt(target, reMath.Vec3Up)
+	forward, right, up := cam.GetForward(), cam.GetRight(), cam.GetUp()
+
+	if !near(forward, target.Sub(pos).Normalize()) {
+		t.Errorf("forward: expected %v, got %v", target.Sub(pos).Normalize(), forward)
+	}
+	// Right-handed and level: right × up = back, right stays horizontal
+	if !near(right.Cross(up), forward.Negate()) || math.Abs(float64(right.Y)) > 1e-4 || up.Y <= 0 {
+		t.Errorf("basis not right-handed and upright: right %v, up %v, forward %v", right, up, forward)
+	}
+
+	// The view matrix puts the eye at the origin and the target down -Z
+	view := cam.GetViewMatrix()
+	if p := view.MulVec3(pos); !near(p, reMath.Vec3Zero) {
+		t.Errorf("eye in view space: expected origin, got %v", p)
+	}
+	dist := target.Sub(pos).Length()
+	if p := view.MulVec3(target); !near(p, reMath.Vec3{X: 0, Y: 0, Z: -dist}) {
+		t.Errorf("target in view space: expected (0, 0, %v), got %v", -dist, p)
+	}
+	// A point to the camera's right and above lands at +X, +Y
+	if p := view.MulVec3(target.Add(right).Add(up)); !near(p, reMath.Vec3{X: 1, Y: 1, Z: -dist}) {
+		t.Errorf("right/up offset in view space: expected (1, 1, %v), got %v", -dist, p)
+	}
+
+	// Row vectors: the view is applied first
+	if vp, want := cam.GetViewProjectionMatrix(), view.Mul(cam.GetProjectionMatrix()); vp != want {
+		t.Errorf("view-projection: expected view × proj %v, got %v", want, vp)
+	}
+}
+
+func TestCameraScreenRoundTrip(t *testing.T) {
+	cam := NewCamera(1.0, 16.0/9.0, 0.1, 100)
+	cam.SetPosition(reMath.Vec3{X: 2, Y: 3, Z: 8})
+	cam.LookAt(reMath.Vec3{X: -1, Y: 0, Z: -2}, reMath.Vec3Up)
+	const w, h = 1280, 720
+
+	for _, reverseZ := range []bool{false, true} {
+		cam.SetReverseZ(reverseZ)
+		for _, p := range []reMath.Vec3{{X: -1, Y: 0, Z: -2}, {X: 0.5, Y: 1.5, Z: 1}, {X: -4, Y: -1, Z: -20}} {
+			sx, sy, visible := cam.WorldToScreen(p, w, h)
+			if !visible {
+				t.Fatalf("reverseZ=%v: %v should be visible, got (%v, %v)", reverseZ, p, sx, sy)
+			}
+			depth := p.Sub(cam.Position).Dot(cam.GetForward())
+			back := cam.ScreenToWorld(sx, sy, depth, w, h)
+			if back.Sub(p).Length() > 1e-3*depth {
+				t.Errorf("reverseZ=%v: round trip %v → (%v, %v) → %v", reverseZ, p, sx, sy, back)
+			}
+		}
+	}
+
+	// The look-at target lands on the screen centre; points behind are not visible
+	sx, sy, _ := cam.WorldToScreen(reMath.Vec3{X: -1, Y: 0, Z: -2}, w, h)
+	if math.Abs(float64(sx-w/2)) > 1e-2 || math.Abs(float64(sy-h/2)) > 1e-2 {
+		t.Errorf("target: expected screen centre, got (%v, %v)", sx, sy)
+	}
+	if _, _, visible := cam.WorldToScreen(reMath.Vec3{X: 5, Y: 6, Z: 18}, w, h); visible {
+		t.Errorf("point behind the camera reported visible")
+	}
+}
+
+func TestCameraFrameBounds(t *testing.T) {
+	box := AABB{Min: reMath.Vec3{X: -3, Y: 0, Z: -1}, Max: reMath.Vec3{X: 5, Y: 2, Z: 40}}
+	corners := func() []reMath.Vec3 {
+		var out []reMath.Vec3
+		for i := 0; i < 8; i++ {
+			c := box.Min
+			if i&1 != 0 {
+				c.X = box.Max.X
+			}
+			if i&2 != 0 {
+				c.Y = box.Max.Y
+			}
+			if i&4 != 0 {
+				c.Z = box.Max.Z
+			}
+			out = append(out, c)
+		}
+		return out
+	}()
+
+	// Wide and tall-narrow viewports, looking along -Z and diagonally down
+	for _, aspect := range []float32{16.0 / 9.0, 0.5} {
+		for _, dir := range []reMath.Vec3{{X: 0, Y: 0, Z: -1}, {X: 1, Y: -1, Z: -1}} {
+			cam := NewCamera(1.0, aspect, 1, 10)
+			cam.LookAt(cam.Position.Add(dir), reMath.Vec3Up)
+			forward := cam.GetForward()
+			cam.FrameBounds(box, 0.1)
+
+			if f := cam.GetForward(); f.Sub(forward).Length() > 1e-5 {
+				t.Errorf("aspect %v, dir %v: view direction changed from %v to %v", aspect, dir, forward, f)
+			}
+			vp := cam.GetViewProjectionMatrix()
+			for _, p := range corners {
+				clip := vp.MulVec(p.ToVec4(1))
+				ndc := reMath.Vec3{X: clip.X / clip.W, Y: clip.Y / clip.W, Z: clip.Z / clip.W}
+				if clip.W <= 0 || math.Abs(float64(ndc.X)) > 1 || math.Abs(float64(ndc.Y)) > 1 || math.Abs(float64(ndc.Z)) > 1 {
+					t.Errorf("aspect %v, dir %v: corner %v outside the NDC cube at %v", aspect, dir, p, ndc)
+				}
+			}
+		}
+	}
+
+	// Empty boxes leave the camera alone
+	cam := NewCamera(1.0, 1, 0.1, 100)
+	cam.FrameBounds(EmptyAABB(), 0.1)
+	if cam.Position != reMath.Vec3Zero || cam.NearPlane != 0.1 || cam.FarPlane != 100 {
+		t.Errorf("empty box moved the camera to %v, clip %v..%v", cam.Position, cam.NearPlane, cam.FarPlane)
+	}
+}
+
+func TestCameraPixelWorldSize(t *testing.T) {
+	cam := NewCamera(1.0, 16.0/9.0, 0.1, 100)
+	cam.SetPosition(reMath.Vec3{X: 1, Y: 2, Z: 3})
+	cam.LookAt(reMath.Vec3{X: 1, Y: 2, Z: -10}, reMath.Vec3Up)
+	const h = 720
+
+	near := cam.PixelWorldSize(reMath.Vec3{X: 1, Y: 2, Z: -2}, h) // 5 units ahead
+	far := cam.PixelWorldSize(reMath.Vec3{X: 2, Y: 3, Z: -17}, h) // 20 units ahead, off axis
+	want := 2 * 5 * float32(math.Tan(0.5)) / h
+	if math.Abs(float64(near-want)) > 1e-6 {
+		t.Errorf("at depth 5: expected %v, got %v", want, near)
+	}
+	if math.Abs(float64(far/near-4)) > 1e-4 {
+		t.Errorf("4× the depth should give 4× the scale, got %v and %v", near, far)
+	}
+
+	// A 50-pixel object scaled this way projects to 50 pixels
+	p := reMath.Vec3{X: 2, Y: 3, Z: -17}
+	_, y0, _ := cam.WorldToScreen(p, h*16/9, h)
+	_, y1, _ := cam.WorldToScreen(p.Add(reMath.Vec3{Y: 50 * far}), h*16/9, h)
+	if math.Abs(float64(y0-y1-50)) > 0.5 {
+		t.Errorf("expected 50 pixels on screen, got %v", y0-y1)
+	}
+	if s := cam.PixelWorldSize(reMath.Vec3{X: 1, Y: 2, Z: 10}, h); s != 0 {
+		t.Errorf("behind the camera: expected 0, got %v", s)
+	}
+}
+
+func TestCameraSetFOVAndClipPlanes(t *testing.T) {
+	cam := NewCamera(1.0, 16.0/9.0, 0.1, 100)
+	before := cam.GetProjectionMatrix()
+
+	cam.SetFOV(0.5)
+	after := cam.GetProjectionMatrix()
+	if after == before {
+		t.Fatal("projection unchanged after SetFOV")
+	}
+	if want := NewCamera(0.5, 16.0/9.0, 0.1, 100).GetProjectionMatrix(); after != want {
+		t.Errorf("after SetFOV: expected %v, got %v", want, after)
+	}
+
+	cam.SetClipPlanes(1, 5000)
+	if want := NewCamera(0.5, 16.0/9.0, 1, 5000).GetProjectionMatrix(); cam.GetProjectionMatrix() != want {
+		t.Errorf("after SetClipPlanes: expected %v, got %v", want, cam.GetProjectionMatrix())
+	}
+	cam.SetClipPlanes(10, 2) // far before near: ignored
+	if cam.Near() != 1 || cam.Far() != 5000 {
+		t.Errorf("invalid planes should be ignored, got near %v far %v", cam.Near(), cam.Far())
+	}
+}
+
+func TestMeshLocalBounds(t *testing.T) {
+	m := NewMesh("blob")
+	for i := 0; i < 50; i++ {
+		f := float32(i)
+		p := reMath.Vec3{X: float32(math.Sin(float64(f))) * f, Y: f*0.3 - 4, Z: float32(math.Cos(float64(f*1.7))) * 2}
+		m.Vertices = append(m.Vertices, core.Vertex{Position: p})
+	}
+	scan := func() (mn, mx reMath.Vec3) {
+		mn, mx = m.Vertices[0].Position, m.Vertices[0].Position
+		for _, v := range m.Vertices {
+			p := v.Position
+			mn = reMath.Vec3{X: min(mn.X, p.X), Y: min(mn.Y, p.Y), Z: min(mn.Z, p.Z)}
+			mx = reMath.Vec3{X: max(mx.X, p.X), Y: max(mx.Y, p.Y), Z: max(mx.Z, p.Z)}
+		}
+		return mn, mx
+	}
+
+	mn, mx := m.LocalBounds()
+	if wantMin, wantMax := scan(); mn != wantMin || mx != wantMax {
+		t.Errorf("expected %v..%v, got %v..%v", wantMin, wantMax, mn, mx)
+	}
+	if !m.HasLocalAABB {
+		t.Error("bounds not cached after LocalBounds")
+	}
+	move := reMath.Mat4Translation(reMath.Vec3{X: 10, Y: 0, Z: 0})
+	if box := ComputeAABB(m, move); box.Min != mn.Add(reMath.Vec3{X: 10}) || box.Max != mx.Add(reMath.Vec3{X: 10}) {
+		t.Errorf("ComputeAABB: expected the cached bounds moved by 10, got %v", box)
+	}
+
+	// Edited vertices show after RecomputeBounds (as UpdateMeshVertices does)
+	m.Vertices[7].Position = reMath.Vec3{X: 100, Y: -100, Z: 0}
+	m.RecomputeBounds()
+	mn, mx = m.LocalBounds()
+	if wantMin, wantMax := scan(); mn != wantMin || mx != wantMax {
+		t.Errorf("after RecomputeBounds: expected %v..%v, got %v..%v", wantMin, wantMax, mn, mx)
+	}
+}
+
+func vec3Near(a, b reMath.Vec3, eps float32) bool {
+	return a.Sub(b).Length() <= eps
+}
+
+func TestAABBMerge(t *testing.T) {
+	v := func(x, y, z float32) reMath.Vec3 { return reMath.Vec3{X: x, Y: y, Z: z} }
+	a := AABB{Min: v(0, 0, 0), Max: v(1, 1, 1)}
+	b := AABB{Min: v(2, -1, 0.5), Max: v(3, 0, 0.75)}
+
+	if got, want := a.Merge(b), (AABB{Min: v(0, -1, 0), Max: v(3, 1, 1)}); got != want {
+		t.Errorf("disjoint boxes: expected %v, got %v", want, got)
+	}
+	if got := a.Merge(AABB{Min: v(0.25, 0.25, 0.25), Max: v(0.5, 0.5, 0.5)}); got != a {
+		t.Errorf("box inside a: expected a unchanged, got %v", got)
+	}
+	// An empty box is the identity; the zero AABB is a point at the origin
+	if got := EmptyAABB().Merge(b); got != b {
+		t.Errorf("empty.Merge(b): expected b, got %v", got)
+	}
+	if got := b.Merge(EmptyAABB()); got != b {
+		t.Errorf("b.Merge(empty): expected b, got %v", got)
+	}
+	if got := b.Merge(AABB{}); got.Min != v(0, -1, 0) {
+		t.Errorf("b.Merge(zero box): expected Min (0,-1,0), got %v", got.Min)
+	}
+	if !EmptyAABB().Merge(EmptyAABB()).IsEmpty() {
+		t.Error("empty.Merge(empty): expected empty")
+	}
+	if got := EmptyAABB().ExpandToInclude(v(1, 2, 3)); got.Min != v(1, 2, 3) || got.Max != v(1, 2, 3) {
+		t.Errorf("empty expanded to a point: expected a point box, got %v", got)
+	}
+	if c, s := b.Center(), b.Size(); c != v(2.5, -0.5, 0.625) || s != v(1, 1, 0.25) {
+		t.Errorf("Center/Size: expected (2.5,-0.5,0.625)/(1,1,0.25), got %v/%v", c, s)
+	}
+
+	// 90° about Y maps X to -Z: the box turns but keeps its size
+	turned := a.Transform(reMath.Mat4RotationY(float32(math.Pi / 2)))
+	if s := turned.Size(); !vec3Near(s, v(1, 1, 1), 1e-5) {
+		t.Errorf("rotated unit box: expected size 1, got %v", s)
+	}
+	if !EmptyAABB().Transform(reMath.Mat4Translation(v(1, 0, 0))).IsEmpty() {
+		t.Error("transformed empty box: expected empty")
+	}
+}
+
+func TestAABBContains(t *testing.T) {
+	v := func(x, y, z float32) reMath.Vec3 { return reMath.Vec3{X: x, Y: y, Z: z} }
+	box := AABB{Min: v(-1, 0, 0), Max: v(1, 2, 3)}
+	cases := []struct {
+		p    reMath.Vec3
+		want bool
+	}{
+		{v(0, 1, 1), true},
+		{v(-1, 0, 0), true}, // corners and faces count
+		{v(1, 2, 3), true},
+		{v(1, 1, 1), true},
+		{v(1.0001, 1, 1), false},
+		{v(0, -0.0001, 1), false},
+		{v(0, 1, 3.5), false},
+	}
+	for _, c := range cases {
+		if got := box.Contains(c.p); got != c.want {
+			t.Errorf("Contains(%v): expected %v, got %v", c.p, c.want, got)
+		}
+	}
+	if EmptyAABB().Contains(v(0, 0, 0)) {
+		t.Error("empty box contains the origin")
+	}
+	if point := (AABB{}); !point.Contains(v(0, 0, 0)) {
+		t.Error("zero box: expected it to contain the origin")
+	}
+}
+
+func TestSceneBounds(t *testing.T) {
+	s := NewScene()
+	if !s.Bounds().IsEmpty() {
+		t.Errorf("empty scene: expected empty bounds, got %v", s.Bounds())
+	}
+
+	cube := CreateCube(2) // -1..1
+	a := NewNode("a")
+	a.Mesh = cube
+	a.SetPosition(reMath.Vec3{X: 10, Y: 0, Z: 0})
+	row := NewInstancedNode("row", cube, []reMath.Mat4{
+		reMath.Mat4Translation(reMath.Vec3{X: 0, Y: 0, Z: -5}),
+		reMath.Mat4Translation(reMath.Vec3{X: 0, Y: 4, Z: 0}),
+	})
+	hidden := NewNode("hidden")
+	hidden.Mesh = cube
+	hidden.SetPosition(reMath.Vec3{X: -100, Y: 0, Z: 0})
+	hidden.Visible = false
+	for _, n := range []*Node{a, row, hidden} {
+		s.AddNode(n)
+	}
+
+	want := AABB{Min: reMath.Vec3{X: -1, Y: -1, Z: -6}, Max: reMath.Vec3{X: 11, Y: 5, Z: 1}}
+	if got := s.Bounds(); !vec3Near(got.Min, want.Min, 1e-5) || !vec3Near(got.Max, want.Max, 1e-5) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestFrustumCulling(t *testing.T) {
+	// 90° square frustum looking down -X from (5, 0, 0): at distance d the
+	// side planes are d away from the axis; near 1, far 100.
+	box := func(x, y, z, half float32) AABB {
+		c := reMath.Vec3{X: x, Y: y, Z: z}
+		h := reMath.Vec3{X: half, Y: half, Z: half}
+		return AABB{Min: c.Sub(h), Max: c.Add(h)}
+	}
+	cases := []struct {
+		name string
+		box  AABB
+		want bool
+	}{
+		{"inside", box(-5, 0, 0, 1), true},
+		{"left outside", box(-5, 0, 20, 1), false}, // camera right is -Z, left +Z
+		{"left straddling", box(-5, 0, 10, 1), true},
+		{"right outside", box(-5, 0, -20, 1), false},
+		{"right straddling", box(-5, 0, -10, 1), true},
+		{"bottom outside", box(-5, -20, 0, 1), false},
+		{"bottom straddling", box(-5, -10, 0, 1), true},
+		{"top outside", box(-5, 20, 0, 1), false},
+		{"top straddling", box(-5, 10, 0, 1), true},
+		{"before near", box(4.5, 0, 0, 0.2), false},
+		{"near straddling", box(4, 0, 0, 0.2), true},
+		{"behind camera", box(10, 0, 0, 1), false},
+		{"beyond far", box(-150, 0, 0, 1), false},
+		{"far straddling", box(-95, 0, 0, 1), true},
+	}
+	for _, reverseZ := range []bool{false, true} {
+		cam := NewCamera(math.Pi/2, 1, 1, 100)
+		cam.SetReverseZ(reverseZ)
+		cam.SetPosition(reMath.Vec3{X: 5, Y: 0, Z: 0})
+		cam.LookAt(reMath.Vec3{X: -5, Y: 0, Z: 0}, reMath.Vec3Up)
+		f := cam.Frustum()
+
+		for _, tc := range cases {
+			if got := tc.box.IntersectsFrustum(&f); got != tc.want {
+				t.Errorf("reverseZ=%v, %s: expected %v, got %v", reverseZ, tc.name, tc.want, got)
+			}
+		}
+		// Planes are normalized: a point 10 ahead on the axis is 10·sin 45°
+		// from each side plane, 9 past near and 90 short of far
+		p := reMath.Vec3{X: -5, Y: 0, Z: 0}
+		want := [6]float32{7.0711, 7.0711, 7.0711, 7.0711, 9, 90}
+		for i, pl := range f.Planes {
+			if d := pl.DistanceTo(p); math.Abs(float64(d-want[i])) > 1e-3 {
+				t.Errorf("reverseZ=%v, plane %d: expected distance %v, got %v", reverseZ, i, want[i], d)
+			}
+		}
+	}
+}
+
+func TestCameraFrustumCorners(t *testing.T) {
+	cam := NewCamera(1.0, 16.0/9.0, 0.5, 50)
+	cam.SetPosition(reMath.Vec3{X: 1, Y: 2, Z: 3})
+	cam.LookAt(reMath.Vec3{X: 4, Y: 1, Z: -5}, reMath.Vec3Up)
+
+	for _, slice := range [][2]float32{{0, 1}, {0.1, 0.3}} {
+		corners := cam.FrustumCorners(slice[0], slice[1])
+		wantNear := cam.NearPlane + slice[0]*(cam.FarPlane-cam.NearPlane)
+		wantFar := cam.NearPlane + slice[1]*(cam.FarPlane-cam.NearPlane)
+		for i := 0; i < 4; i++ {
+			dn := corners[i].Sub(cam.Position).Length()
+			df := corners[4+i].Sub(cam.Position).Length()
+			if dn >= df {
+				t.Errorf("slice %v corner %d: near %v should be closer than far %v", slice, i, dn, df)
+			}
+			// Corners sit at the requested view depths
+			zn := corners[i].Sub(cam.Position).Dot(cam.GetForward())
+			zf := corners[4+i].Sub(cam.Position).Dot(cam.GetForward())
+			if math.Abs(float64(zn-wantNear)) > 1e-3*float64(wantFar) || math.Abs(float64(zf-wantFar)) > 1e-3*float64(wantFar) {
+				t.Errorf("slice %v corner %d: depths (%v, %v), expected (%v, %v)", slice, i, zn, zf, wantNear, wantFar)
+			}
+		}
+	}
+}
+
+func TestCreateNormalLines(t *testing.T) {
+	src := CreateCube(1.0)
+	lines := CreateNormalLines(src, 0.5)
+
+	if lines.DrawMode != DrawLines {
+		t.Fatalf("expected DrawLines, got %v", lines.DrawMode)
+	}
+	if len(lines.Vertices) != 2*len(src.Vertices) || len(lines.Indices) != 2*len(src.Vertices) {
+		t.Fatalf("expected one segment per source vertex, got %d vertices / %d indices",
+			len(lines.Vertices), len(lines.Indices))
+	}
+	for i, v := range src.Vertices {
+		base, tip := lines.Vertices[2*i].Position, lines.Vertices[2*i+1].Position
+		if base != v.Position {
+			t.Fatalf("segment %d: base %v does not match vertex %v", i, base, v.Position)
+		}
+		if d := tip.Sub(base).Length(); math.Abs(float64(d-0.5)) > 1e-5 {
+			t.Fatalf("segment %d: expected length 0.5, got %v", i, d)
+		}
+	}
+}
+
+func TestSkinTwoBoneRotation(t *testing.T) {
+	approx := func(a, b float32) bool { return math.Abs(float64(a-b)) < 1e-4 }
+
+	// Two joints at the origin; the child will be rotated 90° about Z
+	root := NewNode("root")
+	tip := NewNode("tip")
+	root.AddChild(tip)
+	skel := NewSkeleton("arm", []*Node{root, tip})
+
+	up := reMath.Vec3{X: 0, Y: 1, Z: 0}
+	verts := []core.Vertex{
+		{Position: up, Normal: up, Joints: [4]uint16{0}, Weights: [4]float32{1}},          // root only
+		{Position: up, Normal: up, Joints: [4]uint16{1}, Weights: [4]float32{1}},          // tip only
+		{Position: up, Normal: up, Joints: [4]uint16{0, 1}, Weights: [4]float32{0.5, 0.5}}, // blended
+	}
+	mesh := CreateMeshFromData("arm", verts, nil)
+	mesh.SetSkeleton(skel)
+
+	rot := reMath.QuaternionFromAxisAngle(reMath.Vec3{X: 0, Y: 0, Z: 1}, math.Pi/2)
+	tip.SetRotation(rot)
+	if !mesh.Update(reMath.Mat4Identity()) {
+		t.Fatal("Update returned false for a skinned mesh")
+	}
+
+	rotated := rot.RotateVector(up)
+	want := []reMath.Vec3{up, rotated, up.Add(rotated).Mul(0.5)}
+	for i, w := range want {
+		p := mesh.Vertices[i].Position
+		if !approx(p.X, w.X) || !approx(p.Y, w.Y) || !approx(p.Z, w.Z) {
+			t.Errorf("vertex %d: expected %v, got %v", i, w, p)
+		}
+	}
+	if n := mesh.Vertices[1].Normal; !approx(n.X, rotated.X) || !approx(n.Y, rotated.Y) {
+		t.Errorf("tip normal: expected %v, got %v", rotated, n)
+	}
+	if mesh.BindPose[1].Position != up {
+		t.Errorf("bind pose was modified: %v", mesh.BindPose[1].Position)
+	}
+}
+
+func TestGLTFPrimitiveSecondUVSet(t *testing.T) {
+	doc := gltf.NewDocument()
+	prim := gltf.Primitive{Attributes: gltf.PrimitiveAttributes{
+		"POSITION":   modeler.WritePosition(doc, [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}),
+		"TEXCOORD_0": modeler.WriteTextureCoord(doc, [][2]float32{{0, 0}, {1, 0}, {0, 1}}),
+		"TEXCOORD_1": modeler.WriteTextureCoord(doc, [][2]float32{{0.25, 0.5}, {0.75, 0.5}, {0.25, 1}}),
+	}}
+
+	mesh, err := loadGLTFPrimitive(doc, "quad", 0, prim)
+	if err != nil {
+		t.Fatalf("loadGLTFPrimitive: %v", err)
+	}
+	if uv := mesh.Vertices[1].UV; uv != (reMath.Vec2{X: 1, Y: 0}) {
+		t.Errorf("vertex 1 UV: expected (1, 0), got %v", uv)
+	}
+	if uv2 := mesh.Vertices[1].UV2; uv2 != (reMath.Vec2{X: 0.75, Y: 0.5}) {
+		t.Errorf("vertex 1 UV2: expected (0.75, 0.5), got %v", uv2)
+	}
+}
+
+func TestCubeBoundingSphere(t *testing.T) {
+	const size = 2
+	center, radius := CreateCube(size).BoundingSphere()
+	if center != reMath.Vec3Zero {
+		t.Errorf("center: expected origin, got %v", center)
+	}
+	if want := float32(math.Sqrt(3) / 2 * size); math.Abs(float64(radius-want)) > 1e-5 {
+		t.Errorf("radius: expected %v, got %v", want, radius)
+	}
+}
+
+func TestDecodeHDR(t *testing.T) {
+	// 8×2 image: row 0 run-length encoded, row 1 flat. RGBE (128, 64, 32, 129)
+	// is 2^(129-136) × mantissa = (1, 0.5, 0.25).
+	var buf bytes.Buffer
+	buf.WriteString("#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y 2 +X 8\n")
+	buf.Write([]byte{2, 2, 0, 8})
+	buf.Write([]byte{128 + 8, 128})                      // R: run
+	buf.Write([]byte{128 + 8, 64})                       // G: run
+	buf.Write([]byte{8, 32, 32, 32, 32, 32, 32, 32, 32}) // B: literal
+	buf.Write([]byte{128 + 8, 129})                      // E: run
+	for x := 0; x < 8; x++ {
+		if x == 3 {
+			buf.Write([]byte{128, 64, 32, 129})
+		} else {
+			buf.Write([]byte{0, 0, 0, 0})
+		}
+	}
+
+	tex, err := DecodeHDR(bufio.NewReader(&buf))
+	if err != nil {
+		t.Fatalf("DecodeHDR: %v", err)
+	}
+	if tex.Width != 8 || tex.Height != 2 || len(tex.HDR) != 8*2*3 {
+		t.Fatalf("expected 8x2 with 48 floats, got %dx%d with %d", tex.Width, tex.Height, len(tex.HDR))
+	}
+	px := func(x, y int) [3]float32 {
+		i := (y*8 + x) * 3
+		return [3]float32{tex.HDR[i], tex.HDR[i+1], tex.HDR[i+2]}
+	}
+	want := [3]float32{1, 0.5, 0.25}
+	if got := px(5, 0); got != want {
+		t.Errorf("RLE pixel: expected %v, got %v", want, got)
+	}
+	if got := px(3, 1); got != want {
+		t.Errorf("flat pixel: expected %v, got %v", want, got)
+	}
+	if got := px(0, 1); got != [3]float32{} {
+		t.Errorf("e=0 pixel: expected black, got %v", got)
+	}
+}
+
+func TestEncodeHDRRoundTrip(t *testing.T) {
+	src := &Texture{Width: 3, Height: 1, HDR: []float32{
+		4, 0, 0,
+		0.3, 12.5, 0.001,
+		0, 0, 0,
+	}}
+	var buf bytes.Buffer
+	if err := EncodeHDR(&buf, src); err != nil {
+		t.Fatalf("EncodeHDR: %v", err)
+	}
+	tex, err := DecodeHDR(bufio.NewReader(&buf))
+	if err != nil {
+		t.Fatalf("DecodeHDR: %v", err)
+	}
+	if tex.Width != 3 || tex.Height != 1 {
+		t.Fatalf("expected 3x1, got %dx%d", tex.Width, tex.Height)
+	}
+	// Mantissas are 8 bits relative to the pixel's brightest channel
+	for p := 0; p < 3; p++ {
+		peak := max(src.HDR[p*3], src.HDR[p*3+1], src.HDR[p*3+2])
+		for c := 0; c < 3; c++ {
+			want, got := src.HDR[p*3+c], tex.HDR[p*3+c]
+			if math.Abs(float64(got-want)) > float64(peak)/128 {
+				t.Errorf("pixel %d channel %d: expected %v, got %v", p, c, want, got)
+			}
+		}
+	}
+	if tex.HDR[0] != 4 {
+		t.Errorf("(4,0,0) should be exact in RGBE, got %v", tex.HDR[0])
+	}
+}
+
+func TestGPUParticleSpawnCounting(t *testing.T) {
+	e := NewParticleEmitter(100)
+	e.GPUSimulation = true
+	e.EmissionRate = 30
+	e.Update(0.25) // 7.5 spawns
+	e.Update(0.25) // 15
+	if dt, spawn := e.TakeGPUStep(); dt != 0.5 || spawn != 15 {
+		t.Errorf("first step: expected (0.5, 15), got (%v, %v)", dt, spawn)
+	}
+	if e.Count() != 0 {
+		t.Errorf("GPU emitter kept %d CPU particles", e.Count())
+	}
+
+	// Spawns beyond the pool are dropped; nothing is pending once taken
+	e.Update(10)
+	if _, spawn := e.TakeGPUStep(); spawn != e.Capacity() {
+		t.Errorf("spawns: expected capacity %d, got %d", e.Capacity(), spawn)
+	}
+	if dt, spawn := e.TakeGPUStep(); dt != 0 || spawn != 0 {
+		t.Errorf("second take: expected nothing pending, got (%v, %v)", dt, spawn)
+	}
+}
+
+func TestParticleEmissionRate(t *testing.T) {
+	run := func(rate float32, pool int) *ParticleEmitter {
+		e := NewParticleEmitter(pool)
+		e.EmissionRate = rate
+		e.MinLife, e.MaxLife = 10, 10 // nothing dies within the second
+		for i := 0; i < 60; i++ {
+			e.Update(1.0 / 60)
+		}
+		return e
+	}
+	// One second at R per second spawns R (±1 for the accumulator's rounding)
+	for _, rate := range []float32{0.5, 25, 100} {
+		if got, want := run(rate, 1000).Count(), int(rate); got < want-1 || got > want+1 {
+			t.Errorf("rate %g: expected about %d particles, got %d", rate, want, got)
+		}
+	}
+
+	// The pool caps the count, and spawns missed while full are not saved up
+	e := run(100, 20)
+	if e.Count() != 20 {
+		t.Fatalf("pool 20: expected 20 particles, got %d", e.Count())
+	}
+	e.Particles = e.Particles[:0]
+	e.Update(1.0 / 60)
+	if got := e.Count(); got > 2 {
+		t.Errorf("after draining: expected at most 2 spawns in one frame, got %d", got)
+	}
+}
+
+func TestParticleSeedReproducible(t *testing.T) {
+	spawn := func(seed int64) []Particle {
+		e := NewParticleEmitter(64)
+		e.Seed(seed)
+		e.Update(0.1)
+		return e.Particles
+	}
+	a, b := spawn(7), spawn(7)
+	if len(a) == 0 || len(a) != len(b) {
+		t.Fatalf("expected the same non-zero particle count, got %d and %d", len(a), len(b))
+	}
+	for i := range a {
+		if a[i].Position != b[i].Position || a[i].Velocity != b[i].Velocity {
+			t.Fatalf("particle %d differs with the same seed: %v vs %v", i, a[i].Position, b[i].Position)
+		}
+	}
+	if c := spawn(8); c[0].Velocity == a[0].Velocity {
+		t.Error("a different seed should give a different particle")
+	}
+}
+
+func TestSceneUpdateSkipsFarEmitters(t *testing.T) {
+	s := NewScene()
+	s.SetCamera(NewCamera(1, 1, 0.1, 100))
+	near, far := NewNode("near"), NewNode("far")
+	far.SetPosition(reMath.Vec3{X: 0, Y: 0, Z: -50})
+	for _, n := range []*Node{near, far} {
+		e := NewParticleEmitter(64)
+		e.CullDistance = 20
+		n.AddEmitter(e)
+		s.Root.AddChild(n)
+	}
+	s.Update(0.5)
+	if near.Emitters[0].Count() == 0 {
+		t.Error("emitter within CullDistance: expected particles")
+	}
+	if far.Emitters[0].Count() != 0 {
+		t.Errorf("emitter beyond CullDistance: expected no simulation, got %d particles", far.Emitters[0].Count())
+	}
+}
+
+func TestLoadGLTFAsyncMatchesSync(t *testing.T) {
+	// A two-primitive mesh on a root node with two children: the loader
+	// splits the primitives into child nodes, so the tree is larger than the file's
+	doc := gltf.NewDocument()
+	tri := func() gltf.Primitive {
+		return gltf.Primitive{Attributes: gltf.PrimitiveAttributes{
+			"POSITION": modeler.WritePosition(doc, [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}),
+		}}
+	}
+	p0, p1 := tri(), tri()
+	doc.Meshes = []*gltf.Mesh{{Name: "tris", Primitives: []*gltf.Primitive{&p0, &p1}}}
+	doc.Nodes = []*gltf.Node{
+		{Name: "root", Mesh: gltf.Index(0), Children: []int{1, 2}},
+		{Name: "a", Mesh: gltf.Index(0)},
+		{Name: "b"},
+	}
+	doc.Scenes[0].Nodes = []int{0}
+	path := t.TempDir() + "/tree.glb"
+	if err := gltf.SaveBinary(doc, path); err != nil {
+		t.Fatalf("SaveBinary: %v", err)
+	}
+
+	count := func(roots []*Node) int {
+		var walk func(n *Node) int
+		walk = func(n *Node) int {
+			c := 1
+			for _, ch := range n.Children {
+				c += walk(ch)
+			}
+			return c
+		}
+		total := 0
+		for _, r := range roots {
+			total += walk(r)
+		}
+		return total
+	}
+
+	sync, err := LoadGLTF(path)
+	if err != nil {
+		t.Fatalf("LoadGLTF: %v", err)
+	}
+	load, ok := <-LoadGLTFAsync(path)
+	if !ok || load.Err != nil {
+		t.Fatalf("LoadGLTFAsync: %v", load.Err)
+	}
+	if load.Path != path {
+		t.Errorf("path: expected %q, got %q", path, load.Path)
+	}
+	if want, got := count(sync.Roots), count(load.Result.Roots); got != want || want < 3 {
+		t.Errorf("node count: sync %d, async %d", want, got)
+	}
+}
+
+func TestLoadGLTFDataURITexture(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPNG(t, dir, "red.png", color.RGBA{255, 0, 0, 255})
+	data, err := os.ReadFile(filepath.Join(dir, "red.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A self-contained .gltf: the image is a base64 data URI, not a file
+	doc := gltf.NewDocument()
+	doc.Images = []*gltf.Image{{URI: "data:image/png;base64," + base64.StdEncoding.EncodeToString(data)}}
+	doc.Textures = []*gltf.Texture{{Source: gltf.Index(0)}}
+	doc.Materials = []*gltf.Material{{
+		Name:                 "red",
+		PBRMetallicRoughness: &gltf.PBRMetallicRoughness{BaseColorTexture: &gltf.TextureInfo{Index: 0}},
+	}}
+	prim := gltf.Primitive{
+		Attributes: gltf.PrimitiveAttributes{
+			"POSITION": modeler.WritePosition(doc, [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}),
+		},
+		Material: gltf.Index(0),
+	}
+	doc.Meshes = []*gltf.Mesh{{Name: "tri", Primitives: []*gltf.Primitive{&prim}}}
+	doc.Nodes = []*gltf.Node{{Name: "tri", Mesh: gltf.Index(0)}}
+	doc.Scenes[0].Nodes = []int{0}
+	path := filepath.Join(dir, "embedded.gltf")
+	if err := gltf.Save(doc, path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	os.Remove(filepath.Join(dir, "red.png")) // nothing to find on disk
+
+	res, err := LoadGLTF(path)
+	if err != nil {
+		t.Fatalf("LoadGLTF: %v", err)
+	}
+	if len(res.Textures) != 1 {
+		t.Fatalf("expected 1 texture, got %d", len(res.Textures))
+	}
+	tex := res.Textures[0]
+	if tex.Width != 2 || tex.Pixels[0] != 255 || tex.Pixels[1] != 0 {
+		t.Errorf("expected the 2×2 red image, got %dx%d starting %v", tex.Width, tex.Height, tex.Pixels[:4])
+	}
+	if mesh := res.Roots[0].Mesh; mesh == nil || mesh.Material.AlbedoTexture != tex {
+		t.Error("the material should use the embedded texture as albedo")
+	}
+}
+
+func TestLoadGLTFEmissiveStrengthAndOcclusion(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPNG(t, dir, "ao.png", color.RGBA{128, 128, 128, 255})
+
+	doc := gltf.NewDocument()
+	doc.ExtensionsUsed = []string{"KHR_materials_emissive_strength"}
+	doc.Images = []*gltf.Image{{URI: "ao.png"}}
+	doc.Textures = []*gltf.Texture{{Source: gltf.Index(0)}}
+	doc.Materials = []*gltf.Material{{
+		Name:             "lamp",
+		EmissiveFactor:   [3]float64{0.2, 0.4, 1},
+		OcclusionTexture: &gltf.OcclusionTexture{Index: gltf.Index(0)},
+		Extensions: gltf.Extensions{
+			"KHR_materials_emissive_strength": map[string]any{"emissiveStrength": 5},
+		},
+	}}
+	prim := gltf.Primitive{
+		Attributes: gltf.PrimitiveAttributes{
+			"POSITION": modeler.WritePosition(doc, [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}),
+		},
+		Material: gltf.Index(0),
+	}
+	doc.Meshes = []*gltf.Mesh{{Name: "tri", Primitives: []*gltf.Primitive{&prim}}}
+	doc.Nodes = []*gltf.Node{{Name: "tri", Mesh: gltf.Index(0)}}
+	doc.Scenes[0].Nodes = []int{0}
+	path := filepath.Join(dir, "lamp.gltf")
+	if err := gltf.Save(doc, path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	res, err := LoadGLTF(path)
+	if err != nil {
+		t.Fatalf("LoadGLTF: %v", err)
+	}
+	mat := res.Roots[0].Mesh.Material
+	approx := func(a, b float32) bool { return math.Abs(float64(a-b)) < 1e-6 }
+	if e := mat.EmissiveColor; !approx(e.R, 1) || !approx(e.G, 2) || !approx(e.B, 5) {
+		t.Errorf("emissive: expected 5× (0.2, 0.4, 1), got %v", e)
+	}
+	if mat.OcclusionTexture == nil || mat.OcclusionTexture.Width != 2 {
+		t.Errorf("expected ao.png as the occlusion texture, got %v", mat.OcclusionTexture)
+	}
+}
+
+// writeTestPNG writes a 2×2 image filled with c to dir/name.
+func writeTestPNG(t *testing.T, dir, name string, c color.RGBA) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadMTLDirectives(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPNG(t, dir, "normal.png", color.RGBA{128, 128, 255, 255})
+
+	mtl := `# test materials
+newmtl glass
+Ka 0.1 0.1 0.1
+d 0.25
+Kd 0.2 0.4 0.6
+Ke 1 0.5 0
+map_Bump -bm 1.0 normal.png
+
+newmtl metal
+Tr 0
+Pm 1
+Pr 0.3
+norm missing.png
+`
+	path := filepath.Join(dir, "test.mtl")
+	if err := os.WriteFile(path, []byte(mtl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mats, err := loadMTL(path, dir)
+	if err != nil {
+		t.Fatalf("loadMTL: %v", err)
+	}
+
+	glass := mats["glass"]
+	if glass == nil {
+		t.Fatal("glass: missing")
+	}
+	// d before Kd: the colour keeps the opacity
+	if want := (core.Color{R: 0.2, G: 0.4, B: 0.6, A: 0.25}); glass.Albedo != want {
+		t.Errorf("glass albedo: expected %v, got %v", want, glass.Albedo)
+	}
+	if !glass.Transparent {
+		t.Error("glass: d 0.25 should set Transparent")
+	}
+	if want := (core.Color{R: 1, G: 0.5, B: 0, A: 1}); glass.EmissiveColor != want {
+		t.Errorf("glass emissive: expected %v, got %v", want, glass.EmissiveColor)
+	}
+	if glass.NormalTexture == nil || glass.NormalTexture.Width != 2 {
+		t.Errorf("glass: map_Bump with options should load normal.png, got %v", glass.NormalTexture)
+	}
+	if glass.UsePBR {
+		t.Error("glass: no Pm/Pr, should stay Phong")
+	}
+
+	metal := mats["metal"]
+	if metal == nil {
+		t.Fatal("metal: missing")
+	}
+	if metal.Transparent || metal.Albedo.A != 1 {
+		t.Errorf("metal: Tr 0 is opaque, got Transparent=%v alpha=%v", metal.Transparent, metal.Albedo.A)
+	}
+	if !metal.UsePBR || metal.Metallic != 1 || metal.Roughness != 0.3 {
+		t.Errorf("metal: expected PBR metallic 1 roughness 0.3, got %v %v %v", metal.UsePBR, metal.Metallic, metal.Roughness)
+	}
+	if metal.NormalTexture != nil {
+		t.Error("metal: a missing normal map should be skipped")
+	}
+}
+
+func TestLoadMTLTextureMaps(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"diffuse.png", "spec.png", "alpha.png"} {
+		writeTestPNG(t, dir, name, color.RGBA{255, 255, 255, 255})
+	}
+	writeTestPNG(t, dir, "gloss.png", color.RGBA{200, 200, 200, 255})
+
+	mtl := `newmtl mapped
+map_Kd diffuse.png
+map_Ks spec.png
+map_Ns gloss.png
+map_d alpha.png
+Pm 1
+
+newmtl classic
+Kd 0.8 0.8 0.8
+Ks 0.5 0.5 0.5
+Ns 32
+map_Ks spec.png
+map_Ns gloss.png
+`
+	path := filepath.Join(dir, "maps.mtl")
+	if err := os.WriteFile(path, []byte(mtl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mats, err := loadMTL(path, dir)
+	if err != nil {
+		t.Fatalf("loadMTL: %v", err)
+	}
+	m := mats["mapped"]
+	if m == nil {
+		t.Fatal("mapped: missing")
+	}
+	if m.AlbedoTexture == nil || m.SpecularTexture == nil || m.OpacityTexture == nil {
+		t.Fatalf("expected albedo, specular and opacity maps, got %v %v %v",
+			m.AlbedoTexture, m.SpecularTexture, m.OpacityTexture)
+	}
+	if !m.Transparent {
+		t.Error("map_d should set Transparent")
+	}
+	// Shininess 200 → roughness 55 in green; Pm 1, given after map_Ns, in blue
+	mr := m.MetallicRoughnessTexture
+	if mr == nil {
+		t.Fatal("map_Ns: expected a metallic-roughness texture")
+	}
+	if g, b := mr.Pixels[1], mr.Pixels[2]; g != 55 || b != 255 {
+		t.Errorf("metallic-roughness pixel: expected G=55 B=255, got G=%d B=%d", g, b)
+	}
+
+	// A classic Ks/map_Ks/Ns/map_Ns material stays Phong, which samples both
+	// its specular map and its shininess map
+	classic := mats["classic"]
+	if classic == nil || classic.MetallicRoughnessTexture == nil {
+		t.Fatal("classic: expected a metallic-roughness texture from map_Ns")
+	}
+	if classic.UsePBR {
+		t.Error("classic: no Pm/Pr, should stay Phong")
+	}
+	if classic.SpecularTexture == nil || classic.Shininess != 32 {
+		t.Errorf("classic: expected map_Ks and Ns 32 kept, got %v and %v", classic.SpecularTexture, classic.Shininess)
+	}
+	if px := classic.MetallicRoughnessTexture.Pixels; px[1] != 55 || px[2] != 0 {
+		t.Errorf("classic pixel: expected G=55 B=0, got G=%d B=%d", px[1], px[2])
+	}
+}
+
+// An OBJ's MTL materials reach its meshes as the scene.Material the
+// renderer draws with; there is no separate loader material type.
+func TestLoadOBJUsesMTLMaterial(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPNG(t, dir, "albedo.png", color.RGBA{255, 0, 0, 255})
+	files := map[string]string{
+		"tri.mtl": "newmtl red\nKd 1 0 0\nKs 0.5 0.5 0.5\nNs 64\nmap_Kd albedo.png\n",
+		"tri.obj": "mtllib tri.mtl\no tri\nv 0 0 0\nv 1 0 0\nv 0 1 0\nusemtl red\nf 1 2 3\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	meshes, err := LoadOBJ(filepath.Join(dir, "tri.obj"))
+	if err != nil {
+		t.Fatalf("LoadOBJ: %v", err)
+	}
+	if len(meshes) != 1 {
+		t.Fatalf("expected 1 mesh, got %d", len(meshes))
+	}
+	m := meshes[0].Material
+	if m == nil || m.Name != "red" {
+		t.Fatalf("expected material \"red\", got %v", m)
+	}
+	if want := (core.Color{R: 1, G: 0, B: 0, A: 1}); m.Albedo != want {
+		t.Errorf("albedo: expected %v, got %v", want, m.Albedo)
+	}
+	if m.Shininess != 64 || m.Specular.R != 0.5 {
+		t.Errorf("specular: expected Ks 0.5 Ns 64, got %v %g", m.Specular, m.Shininess)
+	}
+	if m.AlbedoTexture == nil || m.AlbedoTexture.Width != 2 {
+		t.Errorf("map_Kd: expected albedo.png, got %v", m.AlbedoTexture)
+	}
+}
+
+func TestWeldSharedEdge(t *testing.T) {
+	// Two triangles of a unit quad as a soup: the diagonal's two corners are
+	// duplicated, with the second pair nudged within epsilon
+	n := reMath.Vec3{X: 0, Y: 0, Z: 1}
+	v := func(x, y float32) core.Vertex {
+		return core.Vertex{Position: reMath.Vec3{X: x, Y: y}, Normal: n, UV: reMath.Vec2{X: x, Y: y}}
+	}
+	m := CreateMeshFromData("quad", []core.Vertex{
+		v(0, 0), v(1, 0), v(1, 1),
+		v(0, 0), v(1, 1.000001), v(0, 1),
+	}, nil)
+
+	if removed := WeldVertices(m, 1e-4, 1e-3); removed != 2 {
+		t.Errorf("removed: expected 2, got %d", removed)
+	}
+	if len(m.Vertices) != 4 {
+		t.Fatalf("vertices: expected 4, got %d", len(m.Vertices))
+	}
+	want := []uint32{0, 1, 2, 0, 2, 3}
+	for i, idx := range m.Indices {
+		if i >= len(want) || idx != want[i] {
+			t.Fatalf("indices: expected %v, got %v", want, m.Indices)
+		}
+	}
+	if len(m.Indices) != len(want) || m.IndexCount != 6 {
+		t.Errorf("index count: expected 6, got %d (%d)", len(m.Indices), m.IndexCount)
+	}
+}
+
+func TestSimplifyPlane(t *testing.T) {
+	plane := CreatePlane(4, 4, 16)
+	before := len(plane.Indices) / 3
+	half := Simplify(plane, 0.5)
+
+	after := len(half.Indices) / 3
+	if after > before/2 || after < before*2/5 {
+		t.Errorf("triangles: expected about %d, got %d of %d", before/2, after, before)
+	}
+	if half.LocalAABB != plane.LocalAABB {
+		t.Errorf("bounds: expected %v, got %v", plane.LocalAABB, half.LocalAABB)
+	}
+	if len(half.Vertices) >= len(plane.Vertices) {
+		t.Errorf("vertices: expected fewer than %d, got %d", len(plane.Vertices), len(half.Vertices))
+	}
+	// Still a flat, upward-facing plane
+	for _, v := range half.Vertices {
+		if v.Position.Y != 0 || v.Normal != reMath.Vec3Up {
+			t.Fatalf("vertex left the plane: %+v", v)
+		}
+	}
+	if len(plane.Indices)/3 != before {
+		t.Errorf("source mesh was modified")
+	}
+}
+
+func TestSimplifySkinnedMesh(t *testing.T) {
+	plane := CreatePlane(4, 4, 8)
+	plane.SetSkeleton(NewSkeleton("rig", []*Node{NewNode("root")}))
+
+	out := Simplify(plane, 0.5)
+	if len(out.Indices) != len(plane.Indices) || len(out.Vertices) != len(plane.Vertices) {
+		t.Errorf("skinned mesh was simplified: %d -> %d indices", len(plane.Indices), len(out.Indices))
+	}
+	if out.Skeleton != plane.Skeleton || !out.Dynamic {
+		t.Errorf("skin settings dropped: skeleton %p, dynamic %v", out.Skeleton, out.Dynamic)
+	}
+	if len(out.BindPose) != len(out.Vertices) {
+		t.Errorf("bind pose: expected %d vertices, got %d", len(out.Vertices), len(out.BindPose))
+	}
+}
+
+func TestMakeFlatShaded(t *testing.T) {
+	// A quad folded 90° along its diagonal, sharing smooth normals across the fold
+	smooth := reMath.Vec3{X: 0, Y: 1, Z: 1}.Normalize()
+	v := func(x, y, z float32) core.Vertex {
+		return core.Vertex{Position: reMath.Vec3{X: x, Y: y, Z: z}, Normal: smooth}
+	}
+	m := CreateMeshFromData("fold", []core.Vertex{
+		v(0, 0, 0), v(1, 0, 0), v(0, 0, -1), v(1, 1, 0),
+	}, []uint32{0, 1, 2, 1, 3, 2})
+
+	MakeFlatShaded(m)
+	if len(m.Vertices) != 6 || len(m.Indices) != 6 {
+		t.Fatalf("expected 6 vertices and indices, got %d and %d", len(m.Vertices), len(m.Indices))
+	}
+	for tri := 0; tri < 2; tri++ {
+		n := m.Vertices[tri*3].Normal
+		for k := 1; k < 3; k++ {
+			if m.Vertices[tri*3+k].Normal != n {
+				t.Errorf("triangle %d: corner normals differ: %v", tri, m.Vertices[tri*3:tri*3+3])
+			}
+		}
+	}
+	n0, n1 := m.Vertices[0].Normal, m.Vertices[3].Normal
+	if n0 == n1 || n0.Dot(n1) > 0.9 {
+		t.Errorf("adjacent faces share a normal: %v, %v", n0, n1)
+	}
+	if up := (reMath.Vec3{X: 0, Y: 1, Z: 0}); n0 != up {
+		t.Errorf("bottom face: expected %v, got %v", up, n0)
+	}
+}
+
+func TestComputeTangents(t *testing.T) {
+	// CreateQuad faces +Z with U along +X and V along +Y; the extra
+	// triangle has collapsed UVs and must neither contribute nor produce NaNs
+	m := CreateQuad()
+	n := reMath.Vec3{X: 0, Y: 0, Z: 1}
+	base := uint32(len(m.Vertices))
+	for i := 0; i < 3; i++ {
+		m.Vertices = append(m.Vertices, core.Vertex{Position: reMath.Vec3{X: float32(i), Y: 2, Z: 0}, Normal: n})
+	}
+	m.Indices = append(m.Indices, base, base+1, base+2)
+
+	ComputeTangents(m)
+	near := func(a, b reMath.Vec3) bool { return a.Sub(b).Length() < 1e-5 }
+	for i, v := range m.Vertices[:4] {
+		if !near(v.Tangent, reMath.Vec3{X: 1}) || !near(v.Bitangent, reMath.Vec3{Y: 1}) {
+			t.Errorf("vertex %d: expected tangent +X and bitangent +Y, got %v and %v", i, v.Tangent, v.Bitangent)
+		}
+	}
+	for i, v := range m.Vertices[4:] {
+		if l := v.Tangent.Length(); math.IsNaN(float64(l)) || math.Abs(float64(l)-1) > 1e-5 || math.Abs(float64(v.Tangent.Dot(n))) > 1e-5 {
+			t.Errorf("degenerate vertex %d: expected a unit tangent perpendicular to the normal, got %v", i, v.Tangent)
+		}
+	}
+}
+
+// countingAnimator records the time it has been advanced by.
+type countingAnimator struct{ elapsed float32 }
+
+func (a *countingAnimator) Update(dt float32) { a.elapsed += dt }
+
+func TestSceneUpdate(t *testing.T) {
+	s := NewScene()
+	anim := &countingAnimator{}
+	s.AddAnimator(anim)
+
+	node := NewNode("Fire")
+	node.AddEmitter(NewParticleEmitter(100))
+	parent := NewNode("Parent")
+	parent.AddChild(node)
+	s.AddNode(parent)
+
+	s.Update(0.1)
+	e := node.Emitters[0]
+	if e.Count() == 0 {
+		t.Fatal("expected the attached emitter to spawn particles")
+	}
+	lives := make([]float32, e.Count())
+	for i, p := range e.Particles {
+		lives[i] = p.Life
+	}
+
+	s.Update(0.05)
+	for i := range lives {
+		if got := e.Particles[i].Life; math.Abs(float64(got-(lives[i]-0.05))) > 1e-5 {
+			t.Errorf("particle %d: expected life %v after 0.05 s, got %v", i, lives[i]-0.05, got)
+		}
+	}
+	if math.Abs(float64(anim.elapsed-0.15)) > 1e-6 {
+		t.Errorf("expected the animator advanced by 0.15 s, got %v", anim.elapsed)
+	}
+
+	s.RemoveAnimator(anim)
+	s.Update(0.1)
+	if math.Abs(float64(anim.elapsed-0.15)) > 1e-6 {
+		t.Errorf("removed animator still advanced: %v", anim.elapsed)
+	}
+}
+
+func TestNodeEmitterTracksNode(t *testing.T) {
+	parent := NewNode("Cart")
+	parent.SetPosition(reMath.Vec3{X: 10, Y: 0, Z: 0})
+	torch := NewNode("Torch")
+	torch.SetPosition(reMath.Vec3{X: 1, Y: 2, Z: 0})
+	parent.AddChild(torch)
+	fire, smoke := NewParticleEmitter(10), NewSmokeEmitter(10)
+	torch.AddEmitter(fire)
+	torch.AddEmitter(smoke)
+
+	near := func(a, b reMath.Vec3) bool { return a.Sub(b).Length() < 1e-4 }
+	parent.Update(0.01)
+	want := reMath.Vec3{X: 11, Y: 2, Z: 0}
+	if got := torch.WorldPosition(); !near(got, want) {
+		t.Fatalf("WorldPosition: expected %v, got %v", want, got)
+	}
+	for i, e := range torch.Emitters {
+		if !near(e.Position, want) {
+			t.Errorf("emitter %d: expected position %v, got %v", i, want, e.Position)
+		}
+	}
+
+	// Moving the parent carries the emitters along on the next update
+	parent.Translate(reMath.Vec3{X: 0, Y: 0, Z: -5})
+	parent.Update(0.01)
+	want = reMath.Vec3{X: 11, Y: 2, Z: -5}
+	for i, e := range torch.Emitters {
+		if !near(e.Position, want) {
+			t.Errorf("after move, emitter %d: expected position %v, got %v", i, want, e.Position)
+		}
+	}
+}
+
+func TestSaveSceneShadowFlags(t *testing.T) {
+	s := NewScene()
+	caster, glass, sign := NewNode("caster"), NewNode("glass"), NewNode("sign")
+	glass.CastShadow = false
+	sign.ReceiveShadow = false
+	for _, n := range []*Node{caster, glass, sign} {
+		s.AddNode(n)
+	}
+
+	path := filepath.Join(t.TempDir(), "scene.json")
+	if err := SaveScene(s, path); err != nil {
+		t.Fatalf("SaveScene: %v", err)
+	}
+	sd, err := LoadScene(path)
+	if err != nil {
+		t.Fatalf("LoadScene: %v", err)
+	}
+	got := map[string][2]bool{}
+	for _, n := range sd.Nodes {
+		got[n.Name] = [2]bool{n.CastShadow, n.ReceiveShadow}
+	}
+	want := map[string][2]bool{"caster": {true, true}, "glass": {false, true}, "sign": {true, false}}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("%s: expected cast/receive %v, got %v", name, w, got[name])
+		}
+	}
+}
@@ -32,6 +32,10 @@ type materialJSON struct {
 	Specular  colorJSON
 	Shininess float32
 	Unlit     bool
+
+	FlatShading   bool `json:",omitempty"`
+	NoVertexColor bool `json:",omitempty"`
+	Transparent   bool `json:",omitempty"`
 }
 
 type nodeJSON struct {
@@ -42,6 +46,12 @@ type nodeJSON struct {
 	MeshName  string // hint for re-attaching meshes; not used during load
 	Material  *materialJSON
 	Children  []nodeJSON
+
+	Instances []math.Mat4 `json:",omitempty"`
+
+	Layers          *uint32 `json:",omitempty"` // nil = LayerDefault
+	NoShadow        bool    `json:",omitempty"`
+	NoReceiveShadow bool    `json:",omitempty"`
 }
 
 type lightJSON struct {
@@ -52,6 +62,12 @@ type lightJSON struct {
 	Intensity float32
 	Range     float32
 	SpotAngle float32
+
+	SpotInnerAngle float32 `json:",omitempty"`
+	SpotOuterAngle float32 `json:",omitempty"`
+	AngularSize    float32 `json:",omitempty"`
+	Falloff        int     `json:",omitempty"`
+	Disabled       bool    `json:",omitempty"`
 }
 
 type cameraJSON struct {
@@ -212,6 +228,12 @@ func lightToJSON(l *Light) lightJSON {
 		Intensity: l.Intensity,
 		Range:     l.Range,
 		SpotAngle: l.SpotAngle,
+
+		SpotInnerAngle: l.SpotInnerAngle,
+		SpotOuterAngle: l.SpotOuterAngle,
+		AngularSize:    l.AngularSize,
+		Falloff:        l.Falloff,
+		Disabled:       l.Disabled,
 	}
 }
 
@@ -224,6 +246,12 @@ func jsonToLight(lj lightJSON) *Light {
 		Intensity: lj.Intensity,
 		Range:     lj.Range,
 		SpotAngle: lj.SpotAngle,
+
+		SpotInnerAngle: lj.SpotInnerAngle,
+		SpotOuterAngle: lj.SpotOuterAngle,
+		AngularSize:    lj.AngularSize,
+		Falloff:        lj.Falloff,
+		Disabled:       lj.Disabled,
 	}
 }
 
@@ -237,6 +265,10 @@ func matToJSON(m *Material) *materialJSON {
 		Specular:  colorToJSON(m.Specular),
 		Shininess: m.Shininess,
 		Unlit:     m.Unlit,
+
+		FlatShading:   m.FlatShading,
+		NoVertexColor: !m.UseVertexColor,
+		Transparent:   m.Transparent,
 	}
 }
 
@@ -250,15 +282,26 @@ func jsonToMat(mj *materialJSON) *Material {
 		Specular:  jsonToColor(mj.Specular),
 		Shininess: mj.Shininess,
 		Unlit:     mj.Unlit,
+
+		FlatShading:    mj.FlatShading,
+		UseVertexColor: !mj.NoVertexColor,
+		Transparent:    mj.Transparent,
 	}
 }
 
 func nodeToJSON(n *Node) nodeJSON {
 	nj := nodeJSON{
-		ID:        n.Id,
-		Name:      n.Name,
-		Transform: transformToJSON(n.Transform),
-		Visible:   n.Visible,
+		ID:              n.Id,
+		Name:            n.Name,
+		Transform:       transformToJSON(n.Transform),
+		Visible:         n.Visible,
+		Instances:       n.Instances,
+		NoShadow:        !n.CastShadow,
+		NoReceiveShadow: !n.ReceiveShadow,
+	}
+	if n.Layers != LayerDefault {
+		layers := n.Layers
+		nj.Layers = &layers
 	}
 	if n.Mesh != nil {
 		nj.MeshName = n.Mesh.Name
@@ -274,6 +317,12 @@ func jsonToNode(nj nodeJSON, parent *Node) *Node {
 	n := NewNode(nj.Name)
 	n.Transform = jsonToTransform(nj.Transform)
 	n.Visible = nj.Visible
+	n.Instances = nj.Instances
+	n.CastShadow = !nj.NoShadow
+	n.ReceiveShadow = !nj.NoReceiveShadow
+	if nj.Layers != nil {
+		n.Layers = *nj.Layers
+	}
 	n.MarkWorldMatrixDirty()
 
 	// Meshes are not serialised — the caller must re-attach them.
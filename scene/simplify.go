@@ -0,0 +1,375 @@
+package scene
+
+import (
+	"container/heap"
+
+	"render-engine/core"
+	"render-engine/math"
+)
+
+// Simplify returns a copy of m reduced to about targetRatio of its triangles
+// (0.5 keeps half) by quadric error metric edge collapse (Garland & Heckbert):
+//
+//   - Every vertex accumulates a quadric, the sum of the squared distances to
+//     the planes of the triangles around it. Collapsing an edge merges the
+//     two quadrics; the cost of a candidate position is its summed squared
+//     distance to all those planes, which stays 0 while a flat area shrinks
+//     and grows as features are cut away.
+//   - Edges are collapsed cheapest first from a priority queue, onto the
+//     cheapest of their two endpoints and their midpoint. A midpoint vertex
+//     interpolates the endpoints' attributes (normal, UVs, colour, tangents).
+//   - Vertices on an open edge — the mesh border and, because those
+//     vertices are split, UV and hard-normal seams — never move, so outlines
+//     and seams survive. Collapses that would fold a triangle over or make
+//     the surface non-manifold are skipped.
+//
+// The reduction stops early when no collapse is allowed. Triangles must share
+// vertices through the index buffer: run WeldVertices on a triangle soup
+// first. Non-triangle meshes and meshes with a Skeleton (whose BindPose must
+// stay aligned with Vertices) are copied unchanged. m itself is not modified;
+// the copy keeps its Dynamic flag and shares its Material and Skeleton.
+func Simplify(m *Mesh, targetRatio float32) *Mesh {
+	indices := m.Indices
+	if len(indices) == 0 {
+		indices = make([]uint32, len(m.Vertices))
+		for i := range indices {
+			indices[i] = uint32(i)
+		}
+	}
+	if m.DrawMode != DrawTriangles || m.Skeleton != nil || targetRatio >= 1 || len(indices) < 3 {
+		return copyMesh(m, m.Vertices, indices)
+	}
+	if targetRatio < 0 {
+		targetRatio = 0
+	}
+
+	s := newSimplifier(m.Vertices, indices)
+	s.run(int(float32(len(s.tris)) * targetRatio))
+	verts, idx := s.result()
+	return copyMesh(m, verts, idx)
+}
+
+// copyMesh builds a new mesh with m's settings and the given geometry. The
+// bind pose is copied as is, so vertices must only change for unskinned meshes.
+func copyMesh(m *Mesh, vertices []core.Vertex, indices []uint32) *Mesh {
+	out := CreateMeshFromData(m.Name, append([]core.Vertex(nil), vertices...), append([]uint32(nil), indices...))
+	out.MaterialName = m.MaterialName
+	out.Material = m.Material
+	out.DrawMode = m.DrawMode
+	out.Dynamic = m.Dynamic
+	out.Skeleton = m.Skeleton
+	out.BindPose = append([]core.Vertex(nil), m.BindPose...)
+	return out
+}
+
+// quadric is a symmetric 4×4 matrix stored as its upper triangle.
+type quadric [10]float64
+
+func planeQuadric(n math.Vec3, d float64) quadric {
+	a, b, c := float64(n.X), float64(n.Y), float64(n.Z)
+	return quadric{a * a, a * b, a * c, a * d, b * b, b * c, b * d, c * c, c * d, d * d}
+}
+
+func (q *quadric) add(o quadric) {
+	for i := range q {
+		q[i] += o[i]
+	}
+}
+
+// eval returns vᵀQv for v = (p, 1).
+func (q quadric) eval(p math.Vec3) float64 {
+	x, y, z := float64(p.X), float64(p.Y), float64(p.Z)
+	return q[0]*x*x + 2*q[1]*x*y + 2*q[2]*x*z + 2*q[3]*x +
+		q[4]*y*y + 2*q[5]*y*z + 2*q[6]*y +
+		q[7]*z*z + 2*q[8]*z + q[9]
+}
+
+// collapse is a queued edge collapse; stale entries are recognised by the
+// endpoints' versions.
+type collapse struct {
+	cost       float64
+	a, b       uint32
+	verA, verB uint32
+}
+
+type collapseQueue []collapse
+
+func (q collapseQueue) Len() int            { return len(q) }
+func (q collapseQueue) Less(i, j int) bool  { return q[i].cost < q[j].cost }
+func (q collapseQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *collapseQueue) Push(x interface{}) { *q = append(*q, x.(collapse)) }
+func (q *collapseQueue) Pop() interface{} {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
+
+type simplifier struct {
+	verts   []core.Vertex
+	quads   []quadric
+	locked  []bool // on an open edge
+	removed []bool
+	version []uint32
+	vtris   [][]int // vertex → triangles using it (may hold dead ones)
+	tris    [][3]uint32
+	dead    []bool
+	live    int
+	queue   collapseQueue
+}
+
+func newSimplifier(vertices []core.Vertex, indices []uint32) *simplifier {
+	s := &simplifier{
+		verts:   append([]core.Vertex(nil), vertices...),
+		quads:   make([]quadric, len(vertices)),
+		locked:  make([]bool, len(vertices)),
+		removed: make([]bool, len(vertices)),
+		version: make([]uint32, len(vertices)),
+		vtris:   make([][]int, len(vertices)),
+	}
+	edgeUse := make(map[[2]uint32]int)
+	var edges [][2]uint32 // first-seen order, so the result is deterministic
+	edgeKey := func(a, b uint32) [2]uint32 {
+		if a > b {
+			a, b = b, a
+		}
+		return [2]uint32{a, b}
+	}
+	for t := 0; t+2 < len(indices); t += 3 {
+		tri := [3]uint32{indices[t], indices[t+1], indices[t+2]}
+		if tri[0] == tri[1] || tri[1] == tri[2] || tri[0] == tri[2] {
+			continue
+		}
+		ti := len(s.tris)
+		s.tris = append(s.tris, tri)
+		for k := 0; k < 3; k++ {
+			s.vtris[tri[k]] = append(s.vtris[tri[k]], ti)
+			e := edgeKey(tri[k], tri[(k+1)%3])
+			if edgeUse[e] == 0 {
+				edges = append(edges, e)
+			}
+			edgeUse[e]++
+		}
+		// Plane quadric, weighted by area so slivers count for little
+		p0, p1, p2 := s.verts[tri[0]].Position, s.verts[tri[1]].Position, s.verts[tri[2]].Position
+		cr := p1.Sub(p0).Cross(p2.Sub(p0))
+		area := cr.Length()
+		if area == 0 {
+			continue
+		}
+		n := cr.Mul(1 / area)
+		q := planeQuadric(n, -float64(n.Dot(p0)))
+		for i := range q {
+			q[i] *= float64(area) * 0.5
+		}
+		for k := 0; k < 3; k++ {
+			s.quads[tri[k]].add(q)
+		}
+	}
+	s.dead = make([]bool, len(s.tris))
+	s.live = len(s.tris)
+	for e, n := range edgeUse {
+		if n == 1 {
+			s.locked[e[0]] = true
+			s.locked[e[1]] = true
+		}
+	}
+	for _, e := range edges {
+		s.push(e[0], e[1])
+	}
+	return s
+}
+
+// target picks where the collapse of edge a–b puts the surviving vertex and
+// returns its cost. ok is false when neither endpoint may move.
+func (s *simplifier) target(a, b uint32) (p math.Vec3, t float32, cost float64, ok bool) {
+	q := s.quads[a]
+	q.add(s.quads[b])
+	pa, pb := s.verts[a].Position, s.verts[b].Position
+	switch {
+	case s.locked[a] && s.locked[b]:
+		return p, 0, 0, false
+	case s.locked[a]:
+		return pa, 0, q.eval(pa), true
+	case s.locked[b]:
+		return pb, 1, q.eval(pb), true
+	}
+	p, t, cost = pa, 0, q.eval(pa)
+	if c := q.eval(pb); c < cost {
+		p, t, cost = pb, 1, c
+	}
+	if mid := pa.Add(pb).Mul(0.5); q.eval(mid) < cost {
+		p, t, cost = mid, 0.5, q.eval(mid)
+	}
+	return p, t, cost, true
+}
+
+func (s *simplifier) push(a, b uint32) {
+	if _, _, cost, ok := s.target(a, b); ok {
+		heap.Push(&s.queue, collapse{cost: cost, a: a, b: b, verA: s.version[a], verB: s.version[b]})
+	}
+}
+
+func (s *simplifier) run(targetTris int) {
+	heap.Init(&s.queue)
+	for s.live > targetTris && s.queue.Len() > 0 {
+		c := heap.Pop(&s.queue).(collapse)
+		if s.removed[c.a] || s.removed[c.b] || s.version[c.a] != c.verA || s.version[c.b] != c.verB {
+			continue
+		}
+		s.collapse(c.a, c.b)
+	}
+}
+
+// collapse merges b into a if that keeps the mesh valid.
+func (s *simplifier) collapse(a, b uint32) {
+	p, t, _, ok := s.target(a, b)
+	if !ok || !s.manifold(a, b) || s.flips(a, b, p) || s.flips(b, a, p) {
+		return
+	}
+
+	s.verts[a] = lerpVertex(s.verts[a], s.verts[b], t)
+	s.verts[a].Position = p
+	s.quads[a].add(s.quads[b])
+	s.removed[b] = true
+	for _, ti := range s.vtris[b] {
+		if s.dead[ti] {
+			continue
+		}
+		tri := &s.tris[ti]
+		if tri[0] == a || tri[1] == a || tri[2] == a {
+			s.dead[ti] = true
+			s.live--
+			continue
+		}
+		for k := range tri {
+			if tri[k] == b {
+				tri[k] = a
+			}
+		}
+		s.vtris[a] = append(s.vtris[a], ti)
+	}
+	s.vtris[b] = nil
+
+	// Only a's edges change cost: its quadric grew
+	s.version[a]++
+	for _, n := range s.neighbours(a) {
+		s.push(a, n)
+	}
+}
+
+// neighbours returns the vertices sharing a live triangle with v.
+func (s *simplifier) neighbours(v uint32) []uint32 {
+	var out []uint32
+	seen := map[uint32]bool{v: true}
+	for _, ti := range s.vtris[v] {
+		if s.dead[ti] {
+			continue
+		}
+		for _, u := range s.tris[ti] {
+			if !seen[u] {
+				seen[u] = true
+				out = append(out, u)
+			}
+		}
+	}
+	return out
+}
+
+// manifold checks the link condition: the endpoints of a–b may only share
+// the neighbours opposite the edge, or the collapse pinches the surface.
+func (s *simplifier) manifold(a, b uint32) bool {
+	shared := 0
+	for _, ti := range s.vtris[a] {
+		if s.dead[ti] {
+			continue
+		}
+		tri := s.tris[ti]
+		if tri[0] == b || tri[1] == b || tri[2] == b {
+			shared++
+		}
+	}
+	common := 0
+	nb := s.neighbours(b)
+	for _, n := range s.neighbours(a) {
+		for _, m := range nb {
+			if n == m {
+				common++
+			}
+		}
+	}
+	return common == shared
+}
+
+// flips reports whether moving v to p (collapsing edge v–other) would turn
+// over or degenerate one of v's triangles that survive the collapse.
+func (s *simplifier) flips(v, other uint32, p math.Vec3) bool {
+	for _, ti := range s.vtris[v] {
+		if s.dead[ti] {
+			continue
+		}
+		tri := s.tris[ti]
+		if tri[0] == other || tri[1] == other || tri[2] == other {
+			continue
+		}
+		var before, after [3]math.Vec3
+		for k, u := range tri {
+			before[k] = s.verts[u].Position
+			after[k] = before[k]
+			if u == v {
+				after[k] = p
+			}
+		}
+		n0 := before[1].Sub(before[0]).Cross(before[2].Sub(before[0]))
+		n1 := after[1].Sub(after[0]).Cross(after[2].Sub(after[0]))
+		if n1.LengthSqr() < 1e-12*n0.LengthSqr() || n0.Dot(n1) <= 0.2*n0.Length()*n1.Length() {
+			return true
+		}
+	}
+	return false
+}
+
+// result compacts the surviving vertices and triangles.
+func (s *simplifier) result() ([]core.Vertex, []uint32) {
+	remap := make([]int, len(s.verts))
+	for i := range remap {
+		remap[i] = -1
+	}
+	var verts []core.Vertex
+	indices := make([]uint32, 0, s.live*3)
+	for ti, tri := range s.tris {
+		if s.dead[ti] {
+			continue
+		}
+		for _, v := range tri {
+			if remap[v] < 0 {
+				remap[v] = len(verts)
+				verts = append(verts, s.verts[v])
+			}
+			indices = append(indices, uint32(remap[v]))
+		}
+	}
+	return verts, indices
+}
+
+// lerpVertex blends the interpolatable attributes of a and b; skin data
+// comes from the nearer endpoint.
+func lerpVertex(a, b core.Vertex, t float32) core.Vertex {
+	if t == 0 {
+		return a
+	}
+	if t == 1 {
+		return b
+	}
+	out := a
+	out.Position = a.Position.Lerp(b.Position, t)
+	if n := a.Normal.Lerp(b.Normal, t); n.LengthSqr() > 0 {
+		out.Normal = n.Normalize()
+	}
+	out.UV = a.UV.Lerp(b.UV, t)
+	out.UV2 = a.UV2.Lerp(b.UV2, t)
+	out.Color = lerpColor(a.Color, b.Color, t)
+	out.Tangent = a.Tangent.Lerp(b.Tangent, t)
+	out.Bitangent = a.Bitangent.Lerp(b.Bitangent, t)
+	return out
+}
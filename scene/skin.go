@@ -0,0 +1,110 @@
+package scene
+
+import (
+	"render-engine/core"
+	"render-engine/math"
+)
+
+// MaxJointInfluences is the number of joints that can move a single vertex.
+// glTF JOINTS_1/WEIGHTS_1 sets beyond the first four are ignored.
+const MaxJointInfluences = 4
+
+// Skeleton binds a skinned mesh to a set of joint nodes.  Animating the
+// joints' transforms poses every mesh that references the skeleton.
+type Skeleton struct {
+	Name   string
+	Joints []*Node // joint nodes; Vertex.Joints index into this slice
+
+	// InverseBindMatrices[i] takes a mesh-space vertex into joint i's space
+	// at bind time (the inverse of the joint's bind-pose world matrix).
+	InverseBindMatrices []math.Mat4
+
+	jointMats []math.Mat4 // scratch reused by Mesh.Update
+}
+
+// NewSkeleton creates a skeleton whose inverse bind matrices are taken from
+// the joints' current world transforms, i.e. the current pose is the bind pose.
+func NewSkeleton(name string, joints []*Node) *Skeleton {
+	s := &Skeleton{Name: name, Joints: joints}
+	s.InverseBindMatrices = make([]math.Mat4, len(joints))
+	for i, j := range joints {
+		s.InverseBindMatrices[i] = j.GetWorldMatrix().Inverse()
+	}
+	return s
+}
+
+// jointMatrices returns, per joint, the matrix taking a bind-pose vertex to
+// its posed position in the space of the mesh node whose world matrix is
+// meshWorld (the renderer applies meshWorld itself).
+func (s *Skeleton) jointMatrices(meshWorld math.Mat4) []math.Mat4 {
+	if cap(s.jointMats) < len(s.Joints) {
+		s.jointMats = make([]math.Mat4, len(s.Joints))
+	}
+	s.jointMats = s.jointMats[:len(s.Joints)]
+
+	toMesh := meshWorld.Inverse()
+	for i, j := range s.Joints {
+		ibm := math.Mat4Identity()
+		if i < len(s.InverseBindMatrices) {
+			ibm = s.InverseBindMatrices[i]
+		}
+		s.jointMats[i] = ibm.Mul(j.GetWorldMatrix()).Mul(toMesh)
+	}
+	return s.jointMats
+}
+
+// SetSkeleton makes m a skinned mesh: the current Vertices become the bind
+// pose and the mesh is flagged Dynamic for per-frame re-upload.
+func (m *Mesh) SetSkeleton(s *Skeleton) {
+	m.Skeleton = s
+	m.BindPose = append([]core.Vertex(nil), m.Vertices...)
+	m.Dynamic = true
+}
+
+// Update skins BindPose into Vertices with linear blend skinning, using the
+// skeleton's current joint pose and meshWorld (the world matrix of the node
+// drawing this mesh).  Returns false, leaving Vertices alone, for meshes
+// without a skeleton.  Callers push the result with RenderEngine.UpdateMeshVertices;
+// RenderEngine.Render does both for every visible skinned mesh.
+func (m *Mesh) Update(meshWorld math.Mat4) bool {
+	if m.Skeleton == nil || len(m.BindPose) == 0 {
+		return false
+	}
+	if len(m.Vertices) != len(m.BindPose) {
+		m.Vertices = make([]core.Vertex, len(m.BindPose))
+	}
+	mats := m.Skeleton.jointMatrices(meshWorld)
+
+	for i, src := range m.BindPose {
+		dst := src
+		var pos, nrm, tan, bit math.Vec3
+		var total float32
+		for k := 0; k < MaxJointInfluences; k++ {
+			w := src.Weights[k]
+			j := int(src.Joints[k])
+			if w == 0 || j >= len(mats) {
+				continue
+			}
+			mat := mats[j]
+			pos = pos.Add(mat.MulVec3(src.Position).Mul(w))
+			nrm = nrm.Add(transformDir(mat, src.Normal).Mul(w))
+			tan = tan.Add(transformDir(mat, src.Tangent).Mul(w))
+			bit = bit.Add(transformDir(mat, src.Bitangent).Mul(w))
+			total += w
+		}
+		if total > 0 {
+			inv := 1 / total
+			dst.Position  = pos.Mul(inv)
+			dst.Normal    = nrm.Normalize()
+			dst.Tangent   = tan.Normalize()
+			dst.Bitangent = bit.Normalize()
+		}
+		m.Vertices[i] = dst
+	}
+	return true
+}
+
+// transformDir applies the rotation/scale part of m to direction d.
+func transformDir(m math.Mat4, d math.Vec3) math.Vec3 {
+	return m.MulVec(d.ToVec4(0)).ToVec3()
+}
@@ -3,10 +3,20 @@ package scene
 import "render-engine/math"
 
 // ComputeTangents generates per-vertex tangent and bitangent vectors for a Mesh.
-// These are required for tangent-space normal mapping. The mesh must have UV
-// coordinates; triangles with a degenerate UV area are skipped.
+// These are required for tangent-space normal mapping: the tangent points
+// along +U and the bitangent along +V of the first UV set.
 //
-// Call after CreateMeshFromData, before uploading the mesh to the GPU.
+// Each triangle's tangent frame is solved from its edges and UV deltas and
+// summed into its three vertices, so shared vertices get the average of
+// their triangles; each tangent is then made perpendicular to the vertex
+// normal and normalised. Triangles with zero UV area (collapsed or
+// unmapped UVs) are skipped, and zero-area triangles add nothing. A vertex
+// left without a tangent gets an arbitrary one perpendicular to its normal,
+// so the result never contains NaNs. Normals must be set beforehand.
+//
+// Existing tangents are overwritten. Call it again after editing UVs or
+// normals, before the mesh is (re-)uploaded to the GPU. LoadGLTF calls it
+// for every mesh it returns, LoadOBJ for files with texture coordinates.
 func ComputeTangents(m *Mesh) {
 	// Zero any existing tangents/bitangents
 	for i := range m.Vertices {
@@ -29,7 +39,7 @@ func ComputeTangents(m *Mesh) {
 		dv2 := v2.UV.Y - v0.UV.Y
 
 		denom := du1*dv2 - du2*dv1
-		if denom == 0 {
+		if tangentAbs(denom) < 1e-12 {
 			return // degenerate UV triangle
 		}
 		r := 1.0 / denom
@@ -16,6 +16,9 @@ type Texture struct {
 	Height int
 	// Pixels in RGBA8 format (4 bytes per pixel, row-major, top-to-bottom).
 	Pixels []byte
+	// HDR holds linear RGB float32 pixels (3 per pixel, same layout) for
+	// high-dynamic-range images from LoadHDR; Pixels is empty for those.
+	HDR []float32
 	// GLID is the OpenGL texture object ID, set by opengl.UploadTexture.
 	GLID uint32
 }
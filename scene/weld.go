@@ -0,0 +1,123 @@
+package scene
+
+import (
+	stdmath "math"
+
+	"render-engine/core"
+	"render-engine/math"
+)
+
+// uvWeldEpsilon is how close two texture coordinates must be to weld: UV
+// seams are kept, since merging across one would smear the texture.
+const uvWeldEpsilon = 1e-5
+
+// WeldVertices merges coincident vertices of m and rebuilds its indices,
+// returning how many vertices were removed. Two vertices weld when their
+// positions are within posEpsilon, their normals within normalEpsilon and
+// their UVs, colours and skin weights match. A negative normalEpsilon ignores
+// normals instead, and the welded vertex gets the normalised sum of its
+// group's normals — this turns a triangle soup into a smooth-shaded mesh.
+//
+// Candidates are found with a spatial hash of posEpsilon-sized cells, so the
+// weld is near linear in the vertex count. A non-indexed mesh comes out
+// indexed, and triangles collapsed to a line or point are dropped. Meshes
+// with a Skeleton are left unchanged. Call before uploading the mesh to the
+// GPU (or release it first).
+func WeldVertices(m *Mesh, posEpsilon, normalEpsilon float32) int {
+	if m == nil || m.Skeleton != nil || len(m.Vertices) == 0 {
+		return 0
+	}
+	indices := m.Indices
+	if len(indices) == 0 {
+		indices = make([]uint32, len(m.Vertices))
+		for i := range indices {
+			indices[i] = uint32(i)
+		}
+	}
+
+	cell := posEpsilon
+	if cell <= 0 {
+		cell = 1e-6
+	}
+	cellOf := func(p math.Vec3) [3]int64 {
+		return [3]int64{
+			int64(stdmath.Floor(float64(p.X / cell))),
+			int64(stdmath.Floor(float64(p.Y / cell))),
+			int64(stdmath.Floor(float64(p.Z / cell))),
+		}
+	}
+
+	smooth := normalEpsilon < 0
+	out := make([]core.Vertex, 0, len(m.Vertices))
+	grid := make(map[[3]int64][]uint32) // cell → indices into out
+	remap := make([]uint32, len(m.Vertices))
+
+	for i, v := range m.Vertices {
+		c := cellOf(v.Position)
+		found := -1
+	search:
+		for dx := int64(-1); dx <= 1; dx++ {
+			for dy := int64(-1); dy <= 1; dy++ {
+				for dz := int64(-1); dz <= 1; dz++ {
+					for _, j := range grid[[3]int64{c[0] + dx, c[1] + dy, c[2] + dz}] {
+						if weldable(out[j], v, posEpsilon, normalEpsilon) {
+							found = int(j)
+							break search
+						}
+					}
+				}
+			}
+		}
+		if found < 0 {
+			found = len(out)
+			out = append(out, v)
+			grid[c] = append(grid[c], uint32(found))
+		} else if smooth {
+			out[found].Normal = out[found].Normal.Add(v.Normal)
+		}
+		remap[i] = uint32(found)
+	}
+	if smooth {
+		for i := range out {
+			if out[i].Normal.LengthSqr() > 0 {
+				out[i].Normal = out[i].Normal.Normalize()
+			}
+		}
+	}
+
+	newIndices := make([]uint32, 0, len(indices))
+	if m.DrawMode == DrawTriangles {
+		for t := 0; t+2 < len(indices); t += 3 {
+			a, b, c := remap[indices[t]], remap[indices[t+1]], remap[indices[t+2]]
+			if a == b || b == c || a == c {
+				continue
+			}
+			newIndices = append(newIndices, a, b, c)
+		}
+	} else {
+		for _, idx := range indices {
+			newIndices = append(newIndices, remap[idx])
+		}
+	}
+
+	removed := len(m.Vertices) - len(out)
+	m.Vertices = out
+	m.Indices = newIndices
+	m.IndexCount = uint32(len(newIndices))
+	m.RecomputeBounds()
+	return removed
+}
+
+// weldable reports whether b may be merged into a.
+func weldable(a, b core.Vertex, posEpsilon, normalEpsilon float32) bool {
+	if a.Position.Sub(b.Position).LengthSqr() > posEpsilon*posEpsilon {
+		return false
+	}
+	if normalEpsilon >= 0 && a.Normal.Sub(b.Normal).LengthSqr() > normalEpsilon*normalEpsilon {
+		return false
+	}
+	du, du2 := a.UV.Sub(b.UV), a.UV2.Sub(b.UV2)
+	return du.Dot(du) <= uvWeldEpsilon*uvWeldEpsilon &&
+		du2.Dot(du2) <= uvWeldEpsilon*uvWeldEpsilon &&
+		a.Color == b.Color && a.Joints == b.Joints && a.Weights == b.Weights
+}